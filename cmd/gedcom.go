@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"family-tree/internal/core/familytree"
+	"family-tree/internal/gedcom"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+const importGedcomCommand = "import-gedcom"
+
+// runImportGedcom imports a GEDCOM file straight into the configured
+// repository, without going through the web server:
+//
+//	family-tree-app import-gedcom [-quiet] family.ged
+func runImportGedcom(args []string, importer *gedcom.Importer) int {
+	flags := flag.NewFlagSet(importGedcomCommand, flag.ContinueOnError)
+	quiet := flags.Bool("quiet", false, "only print the summary")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s [-quiet] <file.ged>\n", importGedcomCommand)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer file.Close()
+
+	ctx := familytree.WithAuditSource(context.Background(), familytree.AuditSource{Actor: importGedcomCommand})
+	report, err := importer.ImportReader(ctx, file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if !*quiet {
+		writeImportEntries(os.Stdout, report)
+	}
+	fmt.Printf("imported: %d, skipped: %d, rejected: %d\n", report.Imported, report.Skipped, report.Rejected)
+	if !report.Committed {
+		fmt.Fprintln(os.Stderr, "nothing was imported since some records were rejected")
+		return 1
+	}
+	return 0
+}
+
+func writeImportEntries(output io.Writer, report *gedcom.ImportReport) {
+	writer := tabwriter.NewWriter(output, 0, 4, 2, ' ', 0)
+	for _, entry := range report.Entries {
+		description := entry.RelationType
+		if description == "" {
+			description = entry.Tag
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", entry.Status, entry.Xref, description, strings.Join(entry.People, " "), entry.Reason)
+	}
+	writer.Flush()
+}
@@ -1,9 +1,18 @@
 package main
 
 import (
+	"context"
 	"family-tree/internal/adapters/familytreerepo"
+	"family-tree/internal/adapters/jsonlaudit"
+	"family-tree/internal/adapters/memoryrepo"
 	"family-tree/internal/core/familytree"
+	"family-tree/internal/gedcom"
 	"family-tree/internal/server"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/caarlos0/env"
 	"github.com/go-chi/chi/v5"
@@ -12,17 +21,30 @@ import (
 	_ "family-tree/docs"
 )
 
+const trashPurgeActor = "trash-purge"
+
 func getServerConfig() server.ServerConfig {
 	cfg := &server.ServerConfig{}
 	if err := env.Parse(cfg); err != nil {
 		panic(err)
 	}
-	if err := env.Parse(&(cfg.GogmConfig)); err != nil {
-		panic(err)
+	if cfg.Repository == server.RepositoryNeo4j {
+		if err := env.Parse(&(cfg.GogmConfig)); err != nil {
+			panic(err)
+		}
 	}
 	if err := env.Parse(&(cfg.WebConfig)); err != nil {
 		panic(err)
 	}
+	if err := env.Parse(&(cfg.RulesConfig)); err != nil {
+		panic(err)
+	}
+	if err := env.Parse(&(cfg.TrashConfig)); err != nil {
+		panic(err)
+	}
+	if err := env.Parse(&(cfg.AuditConfig)); err != nil {
+		panic(err)
+	}
 	return *cfg
 }
 
@@ -43,34 +65,117 @@ func setupGogm(config server.GogmConfig) *gogm.Gogm {
 	return _gogm
 }
 
-func setupFamilyTreeRepo(gogm *gogm.Gogm) *familytreerepo.FamilyTreeRepo {
-	return familytreerepo.NewFamilyTreeRepo(gogm)
+func setupFamilyTreeRepo(config server.ServerConfig) familytree.FamilyTreeRepo {
+	switch config.Repository {
+	case server.RepositoryNeo4j:
+		return familytreerepo.NewFamilyTreeRepo(setupGogm(config.GogmConfig))
+	case server.RepositoryMemory:
+		return memoryrepo.NewFamilyTreeRepo()
+	default:
+		panic(fmt.Sprintf("unknown repository %q", config.Repository))
+	}
 }
 
-func setupPersonUseCase(familyTreeRepo familytree.FamilyTreeRepo) *familytree.PersonUseCase {
-	return familytree.NewPersonUseCase(familyTreeRepo)
+// setupAuditSink returns nil when the audit is off, so the use cases record
+// nothing.
+func setupAuditSink(config server.AuditConfig, familyTreeRepo familytree.FamilyTreeRepo) familytree.AuditSink {
+	switch config.Sink {
+	case server.AuditSinkRepository:
+		auditSink, ok := familyTreeRepo.(familytree.AuditSink)
+		if !ok {
+			panic("the repository can't keep audit events")
+		}
+		return auditSink
+	case server.AuditSinkJSONL:
+		auditSink, err := jsonlaudit.NewAuditSink(config.File)
+		if err != nil {
+			panic(err)
+		}
+		return auditSink
+	case server.AuditSinkNone:
+		return nil
+	default:
+		panic(fmt.Sprintf("unknown audit sink %q", config.Sink))
+	}
 }
-func setupRelationshipUseCase(familyTreeRepo familytree.FamilyTreeRepo) *familytree.RelationshipUseCase {
-	return familytree.NewRelationshipUseCase(familyTreeRepo)
+
+func setupPersonUseCase(familyTreeRepo familytree.FamilyTreeRepo, auditSink familytree.AuditSink) *familytree.PersonUseCase {
+	return familytree.NewPersonUseCase(familyTreeRepo, auditSink)
+}
+func setupRuleSeverity(severity string) familytree.RuleSeverity {
+	ruleSeverity := familytree.RuleSeverity(strings.ToUpper(strings.TrimSpace(severity)))
+	if err := ruleSeverity.Validate(); err != nil {
+		panic(err)
+	}
+	return ruleSeverity
+}
+
+func setupRelationRules(config server.RulesConfig) familytree.RelationRules {
+	return familytree.RelationRules{
+		ParentRules: []familytree.ParentRule{
+			familytree.MinParentAgeRule(config.MinParentAge, setupRuleSeverity(config.MinParentAgeSeverity)),
+			familytree.MaxParentAgeRule(config.MaxParentAge, setupRuleSeverity(config.MaxParentAgeSeverity)),
+			familytree.PosthumousBirthRule(config.PosthumousBirthMonths, setupRuleSeverity(config.PosthumousBirthSeverity)),
+		},
+		SpouseRules: []familytree.SpouseRule{
+			familytree.ContemporarySpousesRule(setupRuleSeverity(config.ContemporarySpousesSeverity)),
+		},
+		Incest: familytree.IncestRule{
+			MaxCoefficient: config.IncestMaxCoefficient,
+			MaxGenerations: config.IncestMaxGenerations,
+		},
+	}
 }
 
-func setupServer(personUseCase familytree.PersonUseCasePort, relationShipUseCase familytree.RelationshipUseCasePort, config server.WebConfig) *server.Server {
-	return server.NewServer(config, chi.NewRouter(), personUseCase, relationShipUseCase)
+func setupRelationshipUseCase(familyTreeRepo familytree.FamilyTreeRepo, rules familytree.RelationRules, auditSink familytree.AuditSink) *familytree.RelationshipUseCase {
+	return familytree.NewRelationshipUseCase(familyTreeRepo, rules, auditSink)
+}
+
+// startTrashPurge purges the expired trash every interval while the server
+// runs, a failed purge is only logged and tried again on the next one. Its
+// audit events have trashPurgeActor as their actor.
+func startTrashPurge(personUseCase familytree.PersonUseCasePort, config server.TrashConfig) {
+	if config.PurgeInterval <= 0 {
+		return
+	}
+	ctx := familytree.WithAuditSource(context.Background(), familytree.AuditSource{Actor: trashPurgeActor})
+	go func() {
+		for range time.Tick(config.PurgeInterval) {
+			purge, err := personUseCase.PurgeTrash(ctx, config.Retention)
+			if err != nil {
+				log.Printf("trash purge failed: %v", err)
+				continue
+			}
+			if purge.People > 0 || purge.Relations > 0 {
+				log.Printf("trash purge deleted %d people and %d relations", purge.People, purge.Relations)
+			}
+		}
+	}()
+}
+
+func setupServer(personUseCase familytree.PersonUseCasePort, relationShipUseCase familytree.RelationshipUseCasePort, importer *gedcom.Importer, config server.WebConfig) *server.Server {
+	return server.NewServer(config, chi.NewRouter(), personUseCase, relationShipUseCase, importer)
 }
 
 // @title Family Tree API
 // @version 1.0
 // @description Essa é uma api para gerenciar pessoas e relações de parentesco
+// @description As alterações podem informar quem as pediu no cabeçalho X-Actor, guardado nos eventos de auditoria sem nenhuma autenticação
 // @termsOfService http://swagger.io/terms/
 // @host localhost:8080
 // @BasePath /
 func main() {
 	serverConfig := getServerConfig()
-	gogm := setupGogm(serverConfig.GogmConfig)
-	familyTreeRepo := setupFamilyTreeRepo(gogm)
-	personUseCase := setupPersonUseCase(familyTreeRepo)
-	relationShipUseCase := setupRelationshipUseCase(familyTreeRepo)
-	server := setupServer(personUseCase, relationShipUseCase, serverConfig.WebConfig)
+	familyTreeRepo := setupFamilyTreeRepo(serverConfig)
+	auditSink := setupAuditSink(serverConfig.AuditConfig, familyTreeRepo)
+	personUseCase := setupPersonUseCase(familyTreeRepo, auditSink)
+	relationShipUseCase := setupRelationshipUseCase(familyTreeRepo, setupRelationRules(serverConfig.RulesConfig), auditSink)
+	importer := gedcom.NewImporter(familyTreeRepo, personUseCase, relationShipUseCase)
+	if len(os.Args) > 1 && os.Args[1] == importGedcomCommand {
+		os.Exit(runImportGedcom(os.Args[2:], importer))
+	}
+	startTrashPurge(personUseCase, serverConfig.TrashConfig)
+	server := setupServer(personUseCase, relationShipUseCase, importer, serverConfig.WebConfig)
 	server.RouteAndServe()
 
 }
@@ -17,9 +17,90 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/audit": {
+            "get": {
+                "description": "Lista os eventos de auditoria das alterações que envolveram a pessoa, dos mais recentes para os mais antigos, incluindo as relações criadas ou removidas com ela\nCada evento traz quem pediu a alteração, pelo cabeçalho X-Actor, o ID da requisição e os valores antes e depois dela\nO X-Actor não é autenticado, é o próprio cliente quem o declara, então o histórico não prova quem fez a alteração",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "audit"
+                ],
+                "summary": "Lista o histórico de alterações de uma pessoa",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID da pessoa no formato uuid (XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX)",
+                        "name": "personID",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Página que se deseja buscar onde a página 0 é a primeira página",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Tamanho da página",
+                        "name": "size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.GetAuditResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/gedcom": {
+            "post": {
+                "description": "Importa os registros INDI como pessoas e os registros FAM como relações de PARENT e SPOUSE\nTodas as relações passam pelas mesmas validações da criação manual, primeiro as de PARENT e depois as de SPOUSE\nO relatório indica para cada registro se foi importado (IMPORTED), ignorado (SKIPPED) ou recusado (REJECTED) e o motivo\nA importação é feita numa única transação, caso algum registro seja recusado nada é importado e o relatório é retornado com 422\nSomente arquivos em UTF-8 ou ASCII são suportados",
+                "consumes": [
+                    "text/plain"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "gedcom"
+                ],
+                "summary": "Importa pessoas e relações de um arquivo GEDCOM 5.5.1",
+                "parameters": [
+                    {
+                        "description": "Conteúdo do arquivo GEDCOM",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.PostGedcomImportResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/server.PostGedcomImportResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/person": {
             "get": {
-                "description": "Busca todas as pessoas salvas no banco",
+                "description": "Busca todas as pessoas salvas no banco\nCom o parâmetro q busca as pessoas pelo nome, ignorando maiúsculas e acentos, e aceita nomes que soam iguais (Soundex) ou parecidos (trigramas)\nOs resultados da busca vêm ordenados pela nota, a similaridade de trigramas entre a busca e o nome, e q não aceita sort nem cursor\nSem busca a lista é ordenada por sort, com - na frente para a ordem decrescente, e por padrão pela ordem de criação\nOs filtros valem para a lista e para a busca, bornAfter e bornBefore só trazem quem com certeza nasceu depois ou antes da data\nA lista traz em next e prev os cursores das páginas seguinte e anterior, que já levam os filtros e a ordem e substituem page\nNas páginas por cursor o total só é contado com includeTotal=true",
                 "produces": [
                     "application/json"
                 ],
@@ -28,6 +109,56 @@ const docTemplate = `{
                 ],
                 "summary": "Busca todas as pessoas salvas no banco",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Nome ou parte do nome buscado",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "name",
+                            "-name",
+                            "birthDate",
+                            "-birthDate",
+                            "createdAt",
+                            "-createdAt"
+                        ],
+                        "type": "string",
+                        "description": "Ordem da lista",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filtra quem tem ou não pais, false traz os ancestrais mais antigos",
+                        "name": "hasParents",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filtra quem não tem ou tem data de falecimento",
+                        "name": "isLiving",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Data após a qual a pessoa nasceu",
+                        "name": "bornAfter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Data antes da qual a pessoa nasceu",
+                        "name": "bornBefore",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sobrenome, ignorando maiúsculas e acentos",
+                        "name": "surname",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
                         "description": "Página que se deseja buscar onde a página 0 é a primeira página",
@@ -39,6 +170,18 @@ const docTemplate = `{
                         "description": "Tamanho da página",
                         "name": "size",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor next ou prev de uma página anterior",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Conta o total de pessoas nas páginas por cursor",
+                        "name": "includeTotal",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -47,11 +190,17 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/server.GetPeopleResponse"
                         }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/server.Error"
+                        }
                     }
                 }
             },
             "post": {
-                "description": "Cria uma pessoa dado um body com o nome desejado",
+                "description": "Cria uma pessoa dado um body com o nome desejado\nCaso o nome seja vazio ele é montado com o prenome e o sobrenome\nAs datas podem ser parciais ou aproximadas (1850, 1850-03, 1850-03-12, ABT 1850, BEF 1850, BET 1850 AND 1855) e o sexo é M, F ou U",
                 "produces": [
                     "application/json"
                 ],
@@ -61,7 +210,7 @@ const docTemplate = `{
                 "summary": "Cria uma pessoa dado um body com o nome desejado",
                 "parameters": [
                     {
-                        "description": "Nome da pessoa que deseja-se criar",
+                        "description": "Dados da pessoa que deseja-se criar",
                         "name": "request",
                         "in": "body",
                         "required": true,
@@ -80,9 +229,49 @@ const docTemplate = `{
                 }
             }
         },
+        "/person/duplicates": {
+            "get": {
+                "description": "Compara apenas as pessoas com o mesmo Soundex no primeiro e no último nome ou que têm um pai, mãe ou esposo em comum\nOs nomes precisam soar iguais ou ser parecidos, pais e esposos em comum e datas de nascimento e falecimento compatíveis aumentam a nota\nPares com sexos diferentes ou datas a mais de 2 anos de distância nunca são duplicados\nOs pares vêm ordenados pela nota, de 0 a 1, com os motivos de cada um",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "person"
+                ],
+                "summary": "Busca pares de pessoas que provavelmente são a mesma pessoa",
+                "parameters": [
+                    {
+                        "type": "number",
+                        "description": "Nota mínima dos pares, padrão 0.5",
+                        "name": "minScore",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Página que se deseja buscar onde a página 0 é a primeira página",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Tamanho da página",
+                        "name": "size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.GetDuplicatesResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/person/parent": {
             "post": {
-                "description": "Cria uma relação de parentesco entre pai e filho\nNão é permitido criação de relação incestuosa",
+                "description": "Cria uma relação de parentesco entre pai e filho\nNão é permitido criação de relação incestuosa, somente a linhagem biológica é considerada\nA filiação (parentage) pode ser BIOLOGICAL, ADOPTIVE, FOSTER, STEP ou GUARDIAN, por padrão BIOLOGICAL\nCada pessoa pode ter até dois pais ou mães de cada filiação\nAs regras cronológicas (idade mínima e máxima do pai ou mãe, nascimento após a morte do pai ou mãe) recusam a relação ou retornam avisos conforme a configuração, somente para a filiação biológica",
                 "produces": [
                     "application/json"
                 ],
@@ -103,12 +292,15 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "201": {
-                        "description": "Created"
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/server.PostCreateRelationshipResponse"
+                        }
                     }
                 }
             },
             "delete": {
-                "description": "Remove uma relação de parentesco entre pai e filho\nNão é permitido a remoção da relação se os pais do filho estiverem em uma relação de esposo e este for o único filho do casal",
+                "description": "Remove uma relação de parentesco entre pai e filho, a relação vai para a lixeira\nNão é permitido a remoção da relação se os pais do filho estiverem em uma relação de esposo e este for o único filho do casal",
                 "produces": [
                     "application/json"
                 ],
@@ -136,7 +328,7 @@ const docTemplate = `{
         },
         "/person/spouse": {
             "post": {
-                "description": "Cria uma relação de esposo entre duas pessoas\nSó é possível criar relação entre duas pessoas se elas tiverem um filho",
+                "description": "Cria uma relação de esposo entre duas pessoas\nSó é possível criar relação entre duas pessoas se elas tiverem um filho\nA regra cronológica de esposos vivos ao mesmo tempo recusa a relação ou retorna um aviso conforme a configuração\nA união pode ter data de início, data de fim e motivo do fim (DIVORCE, DEATH, ANNULMENT), sem fim ela é a união atual\nUma pessoa pode ter várias uniões terminadas mas somente uma união atual, caso um dos esposos já tenha falecido a união termina por DEATH\nOs mesmos esposos podem se casar de novo depois de uma união terminada, desde que as uniões não se sobreponham",
                 "produces": [
                     "application/json"
                 ],
@@ -157,12 +349,15 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "201": {
-                        "description": "Created"
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/server.PostCreateRelationshipResponse"
+                        }
                     }
                 }
             },
             "delete": {
-                "description": "Remove uma relação de esposo entre duas pessoas",
+                "description": "Remove uma relação de esposo entre duas pessoas, a relação vai para a lixeira\nQuando os esposos têm mais de uma união, a união é escolhida pela sua data de início em startDate",
                 "produces": [
                     "application/json"
                 ],
@@ -186,11 +381,40 @@ const docTemplate = `{
                         "description": "No Content"
                     }
                 }
+            },
+            "patch": {
+                "description": "Altera somente os campos enviados da união, como a data e o motivo do fim em um divórcio\nNão é permitido deixar a união sem fim caso um dos esposos já tenha outra união atual\nQuando os esposos têm mais de uma união, a união é escolhida pela sua data de início atual em unionStartDate\nRetorna 404 caso a relação de esposo não exista",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "relationship"
+                ],
+                "summary": "Altera o período da união entre dois esposos",
+                "parameters": [
+                    {
+                        "description": "Esposos e campos da união que deseja-se alterar",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.PatchSpouseRelationshipRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.Union"
+                        }
+                    }
+                }
             }
         },
         "/person/{personID}": {
             "get": {
-                "description": "Busca detalhes de uma pessoa pelo seu id\nRetorna 404 caso não existe",
+                "description": "Busca detalhes de uma pessoa pelo seu id\nRetorna 404 caso não existe\nPessoas unidas a outra redirecionam com 301 para a pessoa que ficou",
                 "produces": [
                     "application/json"
                 ],
@@ -213,20 +437,21 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/server.Person"
                         }
+                    },
+                    "301": {
+                        "description": "Moved Permanently"
                     }
                 }
-            }
-        },
-        "/person/{personID}/bacons/{targetID}": {
-            "get": {
-                "description": "Busca todas as pessoas salvas no banco",
+            },
+            "put": {
+                "description": "Substitui todos os campos de uma pessoa, os campos ausentes são considerados vazios\nO nome passa pelas mesmas validações da criação\nRetorna 404 caso não existe",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "person"
                 ],
-                "summary": "Busca o número de Bacon entre duas pessoas",
+                "summary": "Substitui todos os campos de uma pessoa",
                 "parameters": [
                     {
                         "type": "string",
@@ -235,36 +460,74 @@ const docTemplate = `{
                         "in": "path",
                         "required": true
                     },
+                    {
+                        "description": "Dados da pessoa",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.PutPersonRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.Person"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Sem cascade a pessoa só é removida se não tiver nenhuma relação, e nada é retornado\nCom cascade=relations a pessoa é removida junto com todas as suas relações de PARENT e SPOUSE em uma única transação, e as relações removidas são retornadas\nCom cascade=relations a pessoa não é removida se for o único filho de um casal, já que o casal precisa ter um filho em comum\nCom cascade=unions a pessoa é removida como em cascade=relations, e se for o único filho de um casal a união do casal também é removida e retornada\nA união do casal não volta ao restaurar a pessoa, ela deve ser restaurada depois da pessoa\nA pessoa e as relações removidas vão para a lixeira, de onde podem ser restauradas até o fim do período de retenção",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "person"
+                ],
+                "summary": "Remove uma pessoa",
+                "parameters": [
                     {
                         "type": "string",
-                        "description": "ID da pessoa alvo no formato uuid (XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX)",
-                        "name": "targetID",
+                        "description": "ID da pessoa no formato uuid (XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX)",
+                        "name": "personID",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "enum": [
+                            "relations",
+                            "unions"
+                        ],
+                        "type": "string",
+                        "description": "Remove também as relações da pessoa, e com unions também a união dos pais de um filho único",
+                        "name": "cascade",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/server.GetBaconsNumberResponse"
+                            "$ref": "#/definitions/server.DeletePersonResponse"
                         }
+                    },
+                    "204": {
+                        "description": "No Content"
                     }
                 }
-            }
-        },
-        "/person/{personID}/tree": {
-            "get": {
-                "description": "Busca a árvore genealógica de uma pessoa, reduzindo relações redundantes\nResultado pode ser entregue tanto de json, xml e em binário\nA relação de PARENT indica que a pessoa é pai da pessoa indicada\nA relação de SPOUSE indica que a pesoa possui uma relação de casamento com a pessoa indica\nLembrando que para reduzir redundância a relação só aparece em uma das pessoas\nNa árvore está incluso:\na) Todos os seus ancestrais\nb) Seus filhos\nc) Seus sobrinhos\nd) Relações de esposo entre ancestrais, não incluindo se estiver a relação de esposo com alguém sem relação sanguínea",
+            },
+            "patch": {
+                "description": "Altera somente os campos enviados de uma pessoa, os campos ausentes são mantidos\nO nome passa pelas mesmas validações da criação\nRetorna 404 caso não existe",
                 "produces": [
-                    "application/json",
-                    "application/xml",
-                    "application/octet-stream"
+                    "application/json"
                 ],
                 "tags": [
-                    "relationship"
+                    "person"
                 ],
-                "summary": "Busca a árvore genealógica de uma pessoa",
+                "summary": "Altera somente os campos enviados de uma pessoa",
                 "parameters": [
                     {
                         "type": "string",
@@ -272,123 +535,1508 @@ const docTemplate = `{
                         "name": "personID",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Campos que deseja-se alterar",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.PatchPersonRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/server.FamilyTree"
+                            "$ref": "#/definitions/server.Person"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "server.DeleteParentRelationshipRequest": {
-            "type": "object",
-            "properties": {
-                "childID": {
-                    "type": "string"
-                },
-                "parentID": {
-                    "type": "string"
-                }
-            }
-        },
-        "server.DeleteSpouseRelationshipRequest": {
-            "type": "object",
-            "properties": {
-                "firstSpouseID": {
-                    "type": "string"
-                },
-                "secondSpouseID": {
-                    "type": "string"
-                }
-            }
         },
-        "server.FamilyTree": {
-            "type": "object",
-            "properties": {
-                "people": {
+        "/person/{personID}/bacons/{targetID}": {
+            "get": {
+                "description": "Busca todas as pessoas salvas no banco",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "person"
+                ],
+                "summary": "Busca o número de Bacon entre duas pessoas",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID da pessoa no formato uuid (XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX)",
+                        "name": "personID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID da pessoa alvo no formato uuid (XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX)",
+                        "name": "targetID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.GetBaconsNumberResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/person/{personID}/descendants": {
+            "get": {
+                "description": "Busca os descendentes de uma pessoa até a profundidade pedida, numerados no sistema d'Aboville (1, 1.1, 1.2, 1.2.1), com os filhos ordenados pela data de nascimento\nA pessoa é a entrada 1 e cada entrada traz sua geração, contada a partir dela\nQuem descende da pessoa por mais de uma linha só é expandido na primeira entrada, as outras apontam para ela em sameAs\nCom includeSpouses as uniões de cada descendente também são trazidas",
+                "produces": [
+                    "application/json",
+                    "application/xml",
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "relationship"
+                ],
+                "summary": "Busca os descendentes de uma pessoa",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID da pessoa no formato uuid (XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX)",
+                        "name": "personID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Quantidade de gerações, padrão 10 e no máximo 20",
+                        "name": "depth",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Traz as uniões de cada descendente",
+                        "name": "includeSpouses",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.Descendants"
+                        }
+                    }
+                }
+            }
+        },
+        "/person/{personID}/inbreeding": {
+            "get": {
+                "description": "Calcula o coeficiente de consanguinidade de uma pessoa, metade da soma dos caminhos entre seus pais biológicos pelos ancestrais comuns\nPessoas sem os dois pais biológicos conhecidos têm coeficiente 0",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "relationship"
+                ],
+                "summary": "Busca o coeficiente de consanguinidade de uma pessoa",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID da pessoa no formato uuid (XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX)",
+                        "name": "personID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.GetInbreedingResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/person/{personID}/kinship/{targetPersonID}": {
+            "get": {
+                "description": "Descreve o que a pessoa alvo é da pessoa, em inglês e em português, como \"first cousin once removed\" ou \"tio-avô\"\nApenas a linhagem biológica e as uniões são seguidas, afins são buscados pelas uniões não dissolvidas\nRetorna 404 caso as pessoas não sejam parentes",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "relationship"
+                ],
+                "summary": "Busca o parentesco entre duas pessoas",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID da pessoa no formato uuid (XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX)",
+                        "name": "personID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID da pessoa alvo no formato uuid (XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX)",
+                        "name": "targetPersonID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.GetKinshipResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/person/{personID}/merge": {
+            "post": {
+                "description": "Une a pessoa duplicada à pessoa do caminho, que fica, e remove a duplicada\nOs campos vazios da pessoa que fica são preenchidos com os da duplicada\nTodas as relações de PARENT e SPOUSE da duplicada passam para a pessoa que fica com as mesmas validações da criação, as que ela já tem são ignoradas\nCaso alguma relação não possa passar, como um filho que ficaria com três pais, nada é alterado e a lista de conflitos é retornada com 409\nO ID da duplicada passa a redirecionar para a pessoa que ficou",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "person"
+                ],
+                "summary": "Une uma pessoa duplicada a outra",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID da pessoa que fica no formato uuid (XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX)",
+                        "name": "personID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Pessoa duplicada",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.PostMergePersonRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.PostMergePersonResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/server.MergeConflictResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/person/{personID}/path/{targetPersonID}": {
+            "get": {
+                "description": "Explica o número de Bacon, listando as pessoas de cada caminho e a relação seguida em cada passo\nedges[i] é o que people[i] é de people[i+1]: PARENT_OF, CHILD_OF ou SPOUSE_OF\nRetorna 404 caso não exista caminho",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "person"
+                ],
+                "summary": "Busca os caminhos mais curtos entre duas pessoas",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID da pessoa no formato uuid (XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX)",
+                        "name": "personID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID da pessoa alvo no formato uuid (XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX)",
+                        "name": "targetPersonID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Segue apenas relações de filiação biológica",
+                        "name": "bloodOnly",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Não segue relações de esposo",
+                        "name": "excludeSpouses",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Tamanho máximo dos caminhos, padrão 10 e no máximo 20",
+                        "name": "maxLength",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Quantidade de caminhos distintos, padrão 1 e no máximo 10",
+                        "name": "count",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.GetPathsResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/person/{personID}/pedigree": {
+            "get": {
+                "description": "Busca os ascendentes diretos de uma pessoa até a geração pedida, numerados no sistema Ahnentafel\nA pessoa é o número 1, o pai de n é 2n e a mãe é 2n+1, pais sem sexo conhecido seguem uma ordem estável\nApenas a linhagem biológica é seguida\nAscendentes alcançados por mais de uma linha (implexo) são marcados em collapsed e os números repetidos apontam para o menor em sameAs",
+                "produces": [
+                    "application/json",
+                    "application/xml",
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "relationship"
+                ],
+                "summary": "Busca a árvore de costados de uma pessoa",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID da pessoa no formato uuid (XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX)",
+                        "name": "personID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Quantidade de gerações, padrão 5 e no máximo 10",
+                        "name": "generations",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.Pedigree"
+                        }
+                    }
+                }
+            }
+        },
+        "/person/{personID}/relatedness/{targetPersonID}": {
+            "get": {
+                "description": "Calcula o coeficiente de parentesco de Wright somando todos os caminhos pelos ancestrais comuns, cada um valendo (1/2)^n (1 + Fa)\nTraz também o coeficiente de consanguinidade de cada pessoa e a contribuição de cada ancestral comum\nApenas a linhagem biológica é seguida, até 12 gerações acima de cada pessoa",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "relationship"
+                ],
+                "summary": "Busca o coeficiente de parentesco entre duas pessoas",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID da pessoa no formato uuid (XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX)",
+                        "name": "personID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID da pessoa alvo no formato uuid (XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX)",
+                        "name": "targetPersonID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.GetRelatednessResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/person/{personID}/tree": {
+            "get": {
+                "description": "Busca a árvore genealógica de uma pessoa, reduzindo relações redundantes\nResultado pode ser entregue tanto de json, xml e em binário\nA relação de PARENT indica que a pessoa é pai da pessoa indicada\nA relação de SPOUSE indica que a pesoa possui uma relação de casamento com a pessoa indica\nLembrando que para reduzir redundância a relação só aparece em uma das pessoas\nNa árvore está incluso:\na) Todos os seus ancestrais\nb) Seus filhos\nc) Seus sobrinhos\nd) Relações de esposo entre ancestrais, não incluindo se estiver a relação de esposo com alguém sem relação sanguínea\nAs relações de esposo trazem o período da união\nCom o header Accept text/x-gedcom a árvore é entregue como GEDCOM 5.5.1, agrupando pais, filhos e esposos em registros FAM\nCom o header Accept text/vnd.graphviz a árvore é entregue no formato DOT do Graphviz e com image/svg+xml já desenhada em SVG, com uma geração por linha",
+                "produces": [
+                    "application/json",
+                    "application/xml",
+                    "application/octet-stream",
+                    "text/x-gedcom",
+                    "text/vnd.graphviz",
+                    "image/svg+xml"
+                ],
+                "tags": [
+                    "relationship"
+                ],
+                "summary": "Busca a árvore genealógica de uma pessoa",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID da pessoa no formato uuid (XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX)",
+                        "name": "personID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.FamilyTree"
+                        }
+                    }
+                }
+            }
+        },
+        "/person/{personID}/unions": {
+            "get": {
+                "description": "Busca todas as uniões de uma pessoa, terminadas ou não, ordenadas pela data de início\nA união atual é indicada pelo campo current",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "relationship"
+                ],
+                "summary": "Busca todas as uniões de uma pessoa",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID da pessoa no formato uuid (XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX)",
+                        "name": "personID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.GetUnionsResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/trash": {
+            "get": {
+                "description": "Lista as pessoas removidas, cada uma com as relações removidas junto com ela, e as relações removidas sozinhas, das mais recentes para as mais antigas\nAs relações removidas junto com uma pessoa que já foi restaurada aparecem como relações removidas sozinhas\nTudo o que fica na lixeira por mais tempo que o período de retenção é apagado de vez",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Lista a lixeira",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.GetTrashResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/trash/parent/restore": {
+            "post": {
+                "description": "Restaura a última relação de PARENT removida entre as pessoas, com as mesmas validações da criação",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Restaura uma relação de pai ou mãe da lixeira",
+                "parameters": [
+                    {
+                        "description": "Relação que deseja-se restaurar",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.RestoreParentRelationshipRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/trash/person/{personID}/restore": {
+            "post": {
+                "description": "Restaura a pessoa junto com as relações removidas com ela, que passam pelas mesmas validações da criação, primeiro as de PARENT e depois as de SPOUSE\nAs relações com pessoas que ainda estão na lixeira continuam lá\nCaso alguma relação não possa ser restaurada, como um filho que ficaria com três pais, nada é alterado",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Restaura uma pessoa da lixeira",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID da pessoa no formato uuid (XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX)",
+                        "name": "personID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.TrashedPerson"
+                        }
+                    }
+                }
+            }
+        },
+        "/trash/spouse/restore": {
+            "post": {
+                "description": "Restaura a última relação de SPOUSE removida entre as pessoas, com a sua união e as mesmas validações da criação",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Restaura uma relação de esposo da lixeira",
+                "parameters": [
+                    {
+                        "description": "Relação que deseja-se restaurar",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.RestoreSpouseRelationshipRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "server.Ancestor": {
+            "type": "object",
+            "properties": {
+                "collapsed": {
+                    "type": "boolean"
+                },
+                "generation": {
+                    "type": "integer"
+                },
+                "number": {
+                    "type": "integer"
+                },
+                "person": {
+                    "$ref": "#/definitions/server.Person"
+                },
+                "sameAs": {
+                    "type": "integer"
+                }
+            }
+        },
+        "server.AncestorContribution": {
+            "type": "object",
+            "properties": {
+                "ancestor": {
+                    "$ref": "#/definitions/server.Person"
+                },
+                "contribution": {
+                    "type": "number"
+                },
+                "firstGenerations": {
+                    "type": "integer"
+                },
+                "inbreeding": {
+                    "type": "number"
+                },
+                "paths": {
+                    "type": "integer"
+                },
+                "secondGenerations": {
+                    "type": "integer"
+                }
+            }
+        },
+        "server.AuditEvent": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "type": "string",
+                    "example": "anonymous"
+                },
+                "after": {
+                    "$ref": "#/definitions/server.AuditState"
+                },
+                "before": {
+                    "$ref": "#/definitions/server.AuditState"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "operation": {
+                    "type": "string",
+                    "enum": [
+                        "CREATE_PERSON",
+                        "UPDATE_PERSON",
+                        "DELETE_PERSON",
+                        "MERGE_PEOPLE",
+                        "RESTORE_PERSON",
+                        "PURGE_PERSON",
+                        "CREATE_PARENT_RELATION",
+                        "DELETE_PARENT_RELATION",
+                        "RESTORE_PARENT_RELATION",
+                        "CREATE_SPOUSE_RELATION",
+                        "UPDATE_SPOUSE_RELATION",
+                        "DELETE_SPOUSE_RELATION",
+                        "RESTORE_SPOUSE_RELATION",
+                        "PURGE_RELATION"
+                    ]
+                },
+                "peopleIDs": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "requestID": {
+                    "type": "string"
+                },
+                "timestamp": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.AuditState": {
+            "type": "object",
+            "properties": {
+                "people": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.Person"
+                    }
+                },
+                "relations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.DeletedRelation"
+                    }
+                }
+            }
+        },
+        "server.DeleteParentRelationshipRequest": {
+            "type": "object",
+            "properties": {
+                "childID": {
+                    "type": "string"
+                },
+                "parentID": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.DeletePersonResponse": {
+            "type": "object",
+            "properties": {
+                "relations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.DeletedRelation"
+                    }
+                }
+            }
+        },
+        "server.DeleteSpouseRelationshipRequest": {
+            "type": "object",
+            "properties": {
+                "firstSpouseID": {
+                    "type": "string"
+                },
+                "secondSpouseID": {
+                    "type": "string"
+                },
+                "startDate": {
+                    "type": "string",
+                    "example": "1850"
+                }
+            }
+        },
+        "server.DeletedRelation": {
+            "type": "object",
+            "properties": {
+                "bottomID": {
+                    "type": "string"
+                },
+                "parentage": {
+                    "type": "string",
+                    "enum": [
+                        "BIOLOGICAL",
+                        "ADOPTIVE",
+                        "FOSTER",
+                        "STEP",
+                        "GUARDIAN"
+                    ]
+                },
+                "relation": {
+                    "type": "string",
+                    "enum": [
+                        "PARENT",
+                        "SPOUSE"
+                    ]
+                },
+                "topID": {
+                    "type": "string"
+                },
+                "union": {
+                    "$ref": "#/definitions/server.Union"
+                }
+            }
+        },
+        "server.Descendant": {
+            "type": "object",
+            "properties": {
+                "generation": {
+                    "type": "integer"
+                },
+                "number": {
+                    "type": "string"
+                },
+                "parentID": {
+                    "type": "string"
+                },
+                "parentage": {
+                    "type": "string",
+                    "enum": [
+                        "BIOLOGICAL",
+                        "ADOPTIVE",
+                        "FOSTER",
+                        "STEP",
+                        "GUARDIAN"
+                    ]
+                },
+                "person": {
+                    "$ref": "#/definitions/server.Person"
+                },
+                "sameAs": {
+                    "type": "string"
+                },
+                "spouses": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.PersonUnion"
+                    }
+                }
+            }
+        },
+        "server.Descendants": {
+            "type": "object",
+            "properties": {
+                "depth": {
+                    "type": "integer"
+                },
+                "descendants": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.Descendant"
+                    }
+                },
+                "root": {
+                    "$ref": "#/definitions/server.Person"
+                }
+            }
+        },
+        "server.DuplicatePair": {
+            "type": "object",
+            "properties": {
+                "first": {
+                    "$ref": "#/definitions/server.Person"
+                },
+                "reasons": {
+                    "type": "array",
+                    "items": {
+                        "type": "string",
+                        "enum": [
+                            "SAME_NAME",
+                            "PHONETIC_NAME",
+                            "SIMILAR_NAME",
+                            "SHARED_PARENTS",
+                            "SHARED_SPOUSE",
+                            "BIRTH_DATE",
+                            "DEATH_DATE"
+                        ]
+                    }
+                },
+                "score": {
+                    "type": "number",
+                    "example": 0.8
+                },
+                "second": {
+                    "$ref": "#/definitions/server.Person"
+                }
+            }
+        },
+        "server.Error": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.FamilyTree": {
+            "type": "object",
+            "properties": {
+                "people": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.FamilyTreeNode"
+                    }
+                }
+            }
+        },
+        "server.FamilyTreeNode": {
+            "type": "object",
+            "properties": {
+                "personID": {
+                    "$ref": "#/definitions/server.Person"
+                },
+                "relations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.FamilyTreeRelation"
+                    }
+                }
+            }
+        },
+        "server.FamilyTreeRelation": {
+            "type": "object",
+            "properties": {
+                "parentage": {
+                    "type": "string",
+                    "enum": [
+                        "BIOLOGICAL",
+                        "ADOPTIVE",
+                        "FOSTER",
+                        "STEP",
+                        "GUARDIAN"
+                    ]
+                },
+                "relation": {
+                    "type": "string"
+                },
+                "relativeID": {
+                    "type": "string"
+                },
+                "union": {
+                    "$ref": "#/definitions/server.Union"
+                }
+            }
+        },
+        "server.GedcomImportEntry": {
+            "type": "object",
+            "properties": {
+                "people": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "personID": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "relation": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "tag": {
+                    "type": "string"
+                },
+                "xref": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.GetAuditResponse": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.AuditEvent"
+                    }
+                },
+                "metadata": {
+                    "$ref": "#/definitions/server.PaginationResponseMetadata"
+                }
+            }
+        },
+        "server.GetBaconsNumberResponse": {
+            "type": "object",
+            "properties": {
+                "pathLength": {
+                    "type": "integer"
+                }
+            }
+        },
+        "server.GetDuplicatesResponse": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.DuplicatePair"
+                    }
+                },
+                "metadata": {
+                    "$ref": "#/definitions/server.PaginationResponseMetadata"
+                }
+            }
+        },
+        "server.GetInbreedingResponse": {
+            "type": "object",
+            "properties": {
+                "coefficient": {
+                    "type": "number",
+                    "example": 0.0625
+                },
+                "commonAncestors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.AncestorContribution"
+                    }
+                },
+                "generations": {
+                    "type": "integer"
+                }
+            }
+        },
+        "server.GetKinshipResponse": {
+            "type": "object",
+            "properties": {
+                "commonAncestors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.Person"
+                    }
+                },
+                "firstGenerations": {
+                    "type": "integer"
+                },
+                "former": {
+                    "type": "boolean"
+                },
+                "half": {
+                    "type": "boolean"
+                },
+                "inLaw": {
+                    "$ref": "#/definitions/server.InLaw"
+                },
+                "kinship": {
+                    "type": "string",
+                    "enum": [
+                        "SELF",
+                        "ANCESTOR",
+                        "DESCENDANT",
+                        "SIBLING",
+                        "UNCLE",
+                        "NEPHEW",
+                        "COUSIN",
+                        "SPOUSE",
+                        "IN_LAW"
+                    ]
+                },
+                "names": {
+                    "$ref": "#/definitions/server.KinshipNames"
+                },
+                "secondGenerations": {
+                    "type": "integer"
+                }
+            }
+        },
+        "server.GetPathsResponse": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.PersonPath"
+                    }
+                }
+            }
+        },
+        "server.GetPeopleResponse": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.PersonListItem"
+                    }
+                },
+                "metadata": {
+                    "$ref": "#/definitions/server.PaginationResponseMetadata"
+                }
+            }
+        },
+        "server.GetRelatednessResponse": {
+            "type": "object",
+            "properties": {
+                "coefficient": {
+                    "type": "number",
+                    "example": 0.125
+                },
+                "commonAncestors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.AncestorContribution"
+                    }
+                },
+                "firstInbreeding": {
+                    "type": "number"
+                },
+                "generations": {
+                    "type": "integer"
+                },
+                "secondInbreeding": {
+                    "type": "number"
+                }
+            }
+        },
+        "server.GetTrashResponse": {
+            "type": "object",
+            "properties": {
+                "people": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.TrashedPerson"
+                    }
+                },
+                "relations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.TrashedRelation"
+                    }
+                }
+            }
+        },
+        "server.GetUnionsResponse": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.PersonUnion"
+                    }
+                }
+            }
+        },
+        "server.InLaw": {
+            "type": "object",
+            "properties": {
+                "link": {
+                    "$ref": "#/definitions/server.Person"
+                },
+                "side": {
+                    "type": "string",
+                    "enum": [
+                        "SPOUSE_RELATIVE",
+                        "RELATIVE_SPOUSE"
+                    ]
+                }
+            }
+        },
+        "server.KinshipNames": {
+            "type": "object",
+            "properties": {
+                "en": {
+                    "type": "string",
+                    "example": "first cousin once removed"
+                },
+                "pt": {
+                    "type": "string",
+                    "example": "primo de primeiro grau, uma geração acima"
+                }
+            }
+        },
+        "server.MergeConflict": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "personID": {
+                    "type": "string"
+                },
+                "relationType": {
+                    "type": "string",
+                    "enum": [
+                        "PARENT",
+                        "SPOUSE"
+                    ]
+                }
+            }
+        },
+        "server.MergeConflictResponse": {
+            "type": "object",
+            "properties": {
+                "conflicts": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/server.FamilyTreeNode"
+                        "$ref": "#/definitions/server.MergeConflict"
                     }
+                },
+                "message": {
+                    "type": "string"
                 }
             }
         },
-        "server.FamilyTreeNode": {
+        "server.PaginationResponseMetadata": {
             "type": "object",
             "properties": {
-                "personID": {
-                    "$ref": "#/definitions/server.Person"
+                "next": {
+                    "type": "string"
                 },
-                "relations": {
+                "page": {
+                    "type": "integer"
+                },
+                "prev": {
+                    "type": "string"
+                },
+                "totalItens": {
+                    "type": "integer"
+                }
+            }
+        },
+        "server.PatchPersonRequest": {
+            "type": "object",
+            "properties": {
+                "birthDate": {
+                    "type": "string",
+                    "example": "ABT 1850"
+                },
+                "birthPlace": {
+                    "type": "string"
+                },
+                "deathDate": {
+                    "type": "string",
+                    "example": "1920-03-12"
+                },
+                "deathPlace": {
+                    "type": "string"
+                },
+                "givenName": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "sex": {
+                    "type": "string",
+                    "enum": [
+                        "M",
+                        "F",
+                        "U"
+                    ]
+                },
+                "surname": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.PatchSpouseRelationshipRequest": {
+            "type": "object",
+            "properties": {
+                "endDate": {
+                    "type": "string",
+                    "example": "ABT 1870"
+                },
+                "endReason": {
+                    "type": "string",
+                    "enum": [
+                        "DIVORCE",
+                        "DEATH",
+                        "ANNULMENT"
+                    ]
+                },
+                "firstSpouseID": {
+                    "type": "string"
+                },
+                "secondSpouseID": {
+                    "type": "string"
+                },
+                "startDate": {
+                    "type": "string",
+                    "example": "1850-06"
+                },
+                "unionStartDate": {
+                    "type": "string",
+                    "example": "1850"
+                }
+            }
+        },
+        "server.Pedigree": {
+            "type": "object",
+            "properties": {
+                "ancestors": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/server.FamilyTreeRelation"
+                        "$ref": "#/definitions/server.Ancestor"
                     }
+                },
+                "generations": {
+                    "type": "integer"
+                },
+                "root": {
+                    "$ref": "#/definitions/server.Person"
                 }
             }
         },
-        "server.FamilyTreeRelation": {
+        "server.Person": {
             "type": "object",
             "properties": {
-                "relation": {
+                "birthDate": {
+                    "type": "string",
+                    "example": "ABT 1850"
+                },
+                "birthPlace": {
                     "type": "string"
                 },
-                "relativeID": {
+                "deathDate": {
+                    "type": "string",
+                    "example": "1920-03-12"
+                },
+                "deathPlace": {
+                    "type": "string"
+                },
+                "givenName": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "sex": {
+                    "type": "string",
+                    "enum": [
+                        "M",
+                        "F",
+                        "U"
+                    ]
+                },
+                "surname": {
                     "type": "string"
                 }
             }
         },
-        "server.GetBaconsNumberResponse": {
+        "server.PersonListItem": {
             "type": "object",
             "properties": {
-                "pathLength": {
-                    "type": "integer"
+                "birthDate": {
+                    "type": "string",
+                    "example": "ABT 1850"
+                },
+                "birthPlace": {
+                    "type": "string"
+                },
+                "deathDate": {
+                    "type": "string",
+                    "example": "1920-03-12"
+                },
+                "deathPlace": {
+                    "type": "string"
+                },
+                "givenName": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "match": {
+                    "type": "string",
+                    "enum": [
+                        "SUBSTRING",
+                        "PHONETIC",
+                        "SIMILAR"
+                    ]
+                },
+                "name": {
+                    "type": "string"
+                },
+                "score": {
+                    "type": "number",
+                    "example": 0.5
+                },
+                "sex": {
+                    "type": "string",
+                    "enum": [
+                        "M",
+                        "F",
+                        "U"
+                    ]
+                },
+                "surname": {
+                    "type": "string"
                 }
             }
         },
-        "server.GetPeopleResponse": {
+        "server.PersonPath": {
             "type": "object",
             "properties": {
-                "content": {
+                "edges": {
+                    "type": "array",
+                    "items": {
+                        "type": "string",
+                        "enum": [
+                            "PARENT_OF",
+                            "CHILD_OF",
+                            "SPOUSE_OF"
+                        ]
+                    }
+                },
+                "length": {
+                    "type": "integer"
+                },
+                "people": {
                     "type": "array",
                     "items": {
                         "$ref": "#/definitions/server.Person"
                     }
+                }
+            }
+        },
+        "server.PersonUnion": {
+            "type": "object",
+            "properties": {
+                "current": {
+                    "type": "boolean"
                 },
-                "metadata": {
-                    "$ref": "#/definitions/server.PaginationResponseMetadata"
+                "spouse": {
+                    "$ref": "#/definitions/server.Person"
+                },
+                "union": {
+                    "$ref": "#/definitions/server.Union"
                 }
             }
         },
-        "server.PaginationResponseMetadata": {
+        "server.PostCreateParentRelationshipRequest": {
             "type": "object",
             "properties": {
-                "page": {
+                "childID": {
+                    "type": "string"
+                },
+                "parentID": {
+                    "type": "string"
+                },
+                "parentage": {
+                    "type": "string",
+                    "enum": [
+                        "BIOLOGICAL",
+                        "ADOPTIVE",
+                        "FOSTER",
+                        "STEP",
+                        "GUARDIAN"
+                    ]
+                }
+            }
+        },
+        "server.PostCreateRelationshipResponse": {
+            "type": "object",
+            "properties": {
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.RuleWarning"
+                    }
+                }
+            }
+        },
+        "server.PostCreateSpouseRelationshipRequest": {
+            "type": "object",
+            "properties": {
+                "endDate": {
+                    "type": "string",
+                    "example": "ABT 1870"
+                },
+                "endReason": {
+                    "type": "string",
+                    "enum": [
+                        "DIVORCE",
+                        "DEATH",
+                        "ANNULMENT"
+                    ]
+                },
+                "firstSpouseID": {
+                    "type": "string"
+                },
+                "secondSpouseID": {
+                    "type": "string"
+                },
+                "startDate": {
+                    "type": "string",
+                    "example": "1850-06"
+                }
+            }
+        },
+        "server.PostGedcomImportResponse": {
+            "type": "object",
+            "properties": {
+                "committed": {
+                    "type": "boolean"
+                },
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.GedcomImportEntry"
+                    }
+                },
+                "imported": {
                     "type": "integer"
                 },
-                "totalItens": {
+                "rejected": {
+                    "type": "integer"
+                },
+                "skipped": {
                     "type": "integer"
                 }
             }
         },
-        "server.Person": {
+        "server.PostMergePersonRequest": {
             "type": "object",
             "properties": {
-                "id": {
+                "duplicateID": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.PostMergePersonResponse": {
+            "type": "object",
+            "properties": {
+                "moved": {
+                    "type": "integer"
+                },
+                "person": {
+                    "$ref": "#/definitions/server.Person"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.RuleWarning"
+                    }
+                }
+            }
+        },
+        "server.PostPersonRequest": {
+            "type": "object",
+            "properties": {
+                "birthDate": {
+                    "type": "string",
+                    "example": "ABT 1850"
+                },
+                "birthPlace": {
+                    "type": "string"
+                },
+                "deathDate": {
+                    "type": "string",
+                    "example": "1920-03-12"
+                },
+                "deathPlace": {
+                    "type": "string"
+                },
+                "givenName": {
                     "type": "string"
                 },
                 "name": {
                     "type": "string"
+                },
+                "sex": {
+                    "type": "string",
+                    "enum": [
+                        "M",
+                        "F",
+                        "U"
+                    ]
+                },
+                "surname": {
+                    "type": "string"
                 }
             }
         },
-        "server.PostCreateParentRelationshipRequest": {
+        "server.PutPersonRequest": {
+            "type": "object",
+            "properties": {
+                "birthDate": {
+                    "type": "string",
+                    "example": "ABT 1850"
+                },
+                "birthPlace": {
+                    "type": "string"
+                },
+                "deathDate": {
+                    "type": "string",
+                    "example": "1920-03-12"
+                },
+                "deathPlace": {
+                    "type": "string"
+                },
+                "givenName": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "sex": {
+                    "type": "string",
+                    "enum": [
+                        "M",
+                        "F",
+                        "U"
+                    ]
+                },
+                "surname": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.RestoreParentRelationshipRequest": {
             "type": "object",
             "properties": {
                 "childID": {
@@ -399,7 +2047,7 @@ const docTemplate = `{
                 }
             }
         },
-        "server.PostCreateSpouseRelationshipRequest": {
+        "server.RestoreSpouseRelationshipRequest": {
             "type": "object",
             "properties": {
                 "firstSpouseID": {
@@ -410,11 +2058,86 @@ const docTemplate = `{
                 }
             }
         },
-        "server.PostPersonRequest": {
+        "server.RuleWarning": {
             "type": "object",
             "properties": {
-                "name": {
+                "message": {
+                    "type": "string"
+                },
+                "rule": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.TrashedPerson": {
+            "type": "object",
+            "properties": {
+                "deletedAt": {
+                    "type": "string"
+                },
+                "person": {
+                    "$ref": "#/definitions/server.Person"
+                },
+                "relations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.DeletedRelation"
+                    }
+                }
+            }
+        },
+        "server.TrashedRelation": {
+            "type": "object",
+            "properties": {
+                "bottomID": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "type": "string"
+                },
+                "parentage": {
+                    "type": "string",
+                    "enum": [
+                        "BIOLOGICAL",
+                        "ADOPTIVE",
+                        "FOSTER",
+                        "STEP",
+                        "GUARDIAN"
+                    ]
+                },
+                "relation": {
+                    "type": "string",
+                    "enum": [
+                        "PARENT",
+                        "SPOUSE"
+                    ]
+                },
+                "topID": {
                     "type": "string"
+                },
+                "union": {
+                    "$ref": "#/definitions/server.Union"
+                }
+            }
+        },
+        "server.Union": {
+            "type": "object",
+            "properties": {
+                "endDate": {
+                    "type": "string",
+                    "example": "ABT 1870"
+                },
+                "endReason": {
+                    "type": "string",
+                    "enum": [
+                        "DIVORCE",
+                        "DEATH",
+                        "ANNULMENT"
+                    ]
+                },
+                "startDate": {
+                    "type": "string",
+                    "example": "1850-06"
                 }
             }
         }
@@ -428,7 +2151,7 @@ var SwaggerInfo = &swag.Spec{
 	BasePath:         "/",
 	Schemes:          []string{},
 	Title:            "Family Tree API",
-	Description:      "Essa é uma api para gerenciar pessoas e relações de parentesco",
+	Description:      "Essa é uma api para gerenciar pessoas e relações de parentesco\nAs alterações podem informar quem as pediu no cabeçalho X-Actor, guardado nos eventos de auditoria sem nenhuma autenticação",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
 }
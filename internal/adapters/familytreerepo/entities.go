@@ -1,8 +1,11 @@
 package familytreerepo
 
 import (
+	"encoding/json"
 	"errors"
 	"family-tree/internal/core/familytree"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/mindstand/gogm/v2"
@@ -12,18 +15,43 @@ import (
 var (
 	ErrInvalidSessionMode  = errors.New("invalid session mode")
 	ErrInvalidSessionValue = errors.New("invalid session value")
+	ErrInvalidTx           = errors.New("invalid transaction")
 	ErrInvalidRelation     = errors.New("invalid relation type")
 	ErrInvalidQueryResult  = errors.New("invalid query result")
-	NodeConstraintMessage  = "because it still has relationships. To delete this node, you must first delete its relationships"
 )
 
+// Tx runs its queries on one transaction of a session of its own, the session
+// is closed when the Tx ends.
+type Tx struct {
+	session gogm.SessionV2
+	mode    familytree.SessionMode
+	done    bool
+	commits []func()
+}
+
 type Person struct {
 	gogm.BaseUUIDNode
 
-	Name     string    `gogm:"name=name" json:"-"`
-	Parents  []*Person `gogm:"direction=incoming;relationship=PARENT" json:"-"`
-	Children []*Person `gogm:"direction=outgoing;relationship=PARENT"`
-	Spouse   *Person   `gogm:"direction=both;relationship=SPOUSE"`
+	Name       string   `gogm:"name=name" json:"-"`
+	GivenName  string   `gogm:"name=givenName" json:"-"`
+	Surname    string   `gogm:"name=surname" json:"-"`
+	Sex        string   `gogm:"name=sex" json:"-"`
+	BirthDate  string   `gogm:"name=birthDate" json:"-"`
+	BirthPlace string   `gogm:"name=birthPlace" json:"-"`
+	DeathDate  string   `gogm:"name=deathDate" json:"-"`
+	DeathPlace string   `gogm:"name=deathPlace" json:"-"`
+	SearchName string   `gogm:"name=searchName" json:"-"`
+	Soundex    []string `gogm:"name=soundex;properties" json:"-"`
+	Trigrams   []string `gogm:"name=trigrams;properties" json:"-"`
+	BirthFrom  string   `gogm:"name=birthFrom" json:"-"`
+	BirthTo    string   `gogm:"name=birthTo" json:"-"`
+	SurnameKey string   `gogm:"name=surnameKey" json:"-"`
+	// BlockingKey groups the people compared by the duplicates scan
+	BlockingKey string    `gogm:"name=blockingKey" json:"-"`
+	CreatedAt   int64     `gogm:"name=createdAt" json:"-"`
+	Parents     []*Person `gogm:"direction=incoming;relationship=PARENT" json:"-"`
+	Children    []*Person `gogm:"direction=outgoing;relationship=PARENT"`
+	Spouses     []*Person `gogm:"direction=both;relationship=SPOUSE"`
 }
 
 func SessionMapper(sessionMode familytree.SessionMode) (neo4j.AccessMode, error) {
@@ -43,12 +71,267 @@ func PersonMapper(person *Person) (*familytree.Person, error) {
 		return nil, err
 	}
 	newPerson := &familytree.Person{
-		ID:   personUUID,
-		Name: person.Name,
+		ID:         personUUID,
+		Name:       person.Name,
+		GivenName:  person.GivenName,
+		Surname:    person.Surname,
+		Sex:        familytree.Sex(person.Sex),
+		BirthDate:  familytree.Date(person.BirthDate),
+		BirthPlace: person.BirthPlace,
+		DeathDate:  familytree.Date(person.DeathDate),
+		DeathPlace: person.DeathPlace,
 	}
 	return newPerson, nil
 }
 
+func GogmPersonMapper(person *familytree.Person) *Person {
+	searchName, soundex, trigrams := SearchKeysMapper(person.Name)
+	birthFrom, birthTo := familytree.BirthRange(person.BirthDate)
+	return &Person{
+		Name:        person.Name,
+		GivenName:   person.GivenName,
+		Surname:     person.Surname,
+		Sex:         string(person.Sex),
+		BirthDate:   string(person.BirthDate),
+		BirthPlace:  person.BirthPlace,
+		DeathDate:   string(person.DeathDate),
+		DeathPlace:  person.DeathPlace,
+		SearchName:  searchName,
+		Soundex:     soundex,
+		Trigrams:    trigrams,
+		BirthFrom:   birthFrom,
+		BirthTo:     birthTo,
+		SurnameKey:  familytree.NormalizeName(person.Surname),
+		BlockingKey: familytree.NewNameKeys(person.Name).BlockingKey(),
+		CreatedAt:   time.Now().UnixNano(),
+	}
+}
+
+// SearchKeysMapper maps a name to the properties searches filter people by,
+// the normalized name, the Soundex codes and the unique trigrams of its words.
+func SearchKeysMapper(name string) (string, []string, []string) {
+	keys := familytree.NewNameKeys(name)
+	soundex := append([]string{}, keys.Phonetics...)
+	trigrams := []string{}
+	seen := map[string]bool{}
+	for _, word := range strings.Fields(keys.Normalized) {
+		for _, trigram := range familytree.Trigrams(word) {
+			if seen[trigram] {
+				continue
+			}
+			seen[trigram] = true
+			trigrams = append(trigrams, trigram)
+		}
+	}
+	return keys.Normalized, soundex, trigrams
+}
+
+// PersonPropertiesMapper maps the properties of a Person node returned by a raw
+// query, like RETURN properties(person).
+func PersonPropertiesMapper(properties map[string]interface{}) (*familytree.Person, error) {
+	text := func(key string) string {
+		value, _ := properties[key].(string)
+		return value
+	}
+	return PersonMapper(&Person{
+		BaseUUIDNode: gogm.BaseUUIDNode{UUID: text("uuid")},
+		Name:         text("name"),
+		GivenName:    text("givenName"),
+		Surname:      text("surname"),
+		Sex:          text("sex"),
+		BirthDate:    text("birthDate"),
+		BirthPlace:   text("birthPlace"),
+		DeathDate:    text("deathDate"),
+		DeathPlace:   text("deathPlace"),
+	})
+}
+
+// UnionMapper maps the startDate, endDate and endReason properties of a SPOUSE
+// relation, relations saved before unions existed have them null.
+func UnionMapper(startDate interface{}, endDate interface{}, endReason interface{}) familytree.Union {
+	text := func(value interface{}) string {
+		textValue, _ := value.(string)
+		return textValue
+	}
+	return familytree.Union{
+		StartDate: familytree.Date(text(startDate)),
+		EndDate:   familytree.Date(text(endDate)),
+		EndReason: familytree.UnionEndReason(text(endReason)),
+	}
+}
+
+// ParentageMapper maps the parentage property of a PARENT relation, relations
+// saved before it existed have it null and are biological.
+func ParentageMapper(parentage interface{}) familytree.Parentage {
+	textValue, _ := parentage.(string)
+	if textValue == "" {
+		return familytree.ParentageBiological
+	}
+	return familytree.Parentage(textValue)
+}
+
+// TrashedRelationMapper maps a TRASHED relation, which keeps the properties of
+// the relation it replaced along with its relationType, its deletedAt and the
+// uuid of the person it was trashed with, if any.
+func TrashedRelationMapper(rawTop interface{}, rawBottom interface{}, rawRelation interface{}) (*familytree.TrashedRelation, string, error) {
+	topProperties, ok := rawTop.(map[string]interface{})
+	if !ok {
+		return nil, "", ErrInvalidQueryResult
+	}
+	bottomProperties, ok := rawBottom.(map[string]interface{})
+	if !ok {
+		return nil, "", ErrInvalidQueryResult
+	}
+	properties, ok := rawRelation.(map[string]interface{})
+	if !ok {
+		return nil, "", ErrInvalidQueryResult
+	}
+	top, err := PersonPropertiesMapper(topProperties)
+	if err != nil {
+		return nil, "", err
+	}
+	bottom, err := PersonPropertiesMapper(bottomProperties)
+	if err != nil {
+		return nil, "", err
+	}
+	relation := familytree.PersonRelation{Top: *top, Bottom: *bottom}
+	switch properties["relationType"] {
+	case familytree.RelationTypeParent.Name:
+		relation.RelationType = familytree.RelationTypeParent
+		relation.Parentage = ParentageMapper(properties["parentage"])
+	case familytree.RelationTypeSpouse.Name:
+		relation.RelationType = familytree.RelationTypeSpouse
+		relation.Union = UnionMapper(properties["startDate"], properties["endDate"], properties["endReason"])
+	default:
+		return nil, "", ErrInvalidRelation
+	}
+	deletedAt, _ := properties["deletedAt"].(int64)
+	trashedWith, _ := properties["trashedWith"].(string)
+	return &familytree.TrashedRelation{Relation: relation, DeletedAt: time.Unix(0, deletedAt)}, trashedWith, nil
+}
+
+// PathMapper maps the people of a path, as a list of node properties, and its
+// relations, as a list of [type, start node uuid] pairs.
+func PathMapper(rawPeople interface{}, rawRelations interface{}) (*familytree.Path, error) {
+	people, ok := rawPeople.([]interface{})
+	if !ok {
+		return nil, ErrInvalidQueryResult
+	}
+	relations, ok := rawRelations.([]interface{})
+	if !ok || len(relations) != len(people)-1 {
+		return nil, ErrInvalidQueryResult
+	}
+	path := &familytree.Path{
+		People: make([]familytree.Person, 0, len(people)),
+		Edges:  make([]familytree.PathEdge, 0, len(relations)),
+	}
+	for _, rawPerson := range people {
+		properties, ok := rawPerson.(map[string]interface{})
+		if !ok {
+			return nil, ErrInvalidQueryResult
+		}
+		person, err := PersonPropertiesMapper(properties)
+		if err != nil {
+			return nil, err
+		}
+		path.People = append(path.People, *person)
+	}
+	for i, rawRelation := range relations {
+		relation, ok := rawRelation.([]interface{})
+		if !ok || len(relation) != 2 {
+			return nil, ErrInvalidQueryResult
+		}
+		relationType, _ := relation[0].(string)
+		startUUID, _ := relation[1].(string)
+		switch {
+		case relationType == familytree.RelationTypeSpouse.Name:
+			path.Edges = append(path.Edges, familytree.PathEdgeSpouseOf)
+		case relationType != familytree.RelationTypeParent.Name:
+			return nil, ErrInvalidRelation
+		case startUUID == path.People[i].ID.String():
+			path.Edges = append(path.Edges, familytree.PathEdgeParentOf)
+		default:
+			path.Edges = append(path.Edges, familytree.PathEdgeChildOf)
+		}
+	}
+	return path, nil
+}
+
+func UnionProperties(union familytree.Union) map[string]interface{} {
+	return map[string]interface{}{
+		"startDate": string(union.StartDate),
+		"endDate":   string(union.EndDate),
+		"endReason": string(union.EndReason),
+	}
+}
+
+// AuditEventProperties maps the event to the properties of its AuditEvent
+// node, the states before and after the mutation are kept as JSON.
+func AuditEventProperties(event familytree.AuditEvent) (map[string]interface{}, error) {
+	before, err := json.Marshal(event.Before)
+	if err != nil {
+		return nil, err
+	}
+	after, err := json.Marshal(event.After)
+	if err != nil {
+		return nil, err
+	}
+	peopleIDs := make([]string, 0, len(event.PeopleIDs))
+	for _, personID := range event.PeopleIDs {
+		peopleIDs = append(peopleIDs, personID.String())
+	}
+	return map[string]interface{}{
+		"uuid":      event.ID.String(),
+		"timestamp": event.Timestamp.UnixNano(),
+		"actor":     event.Actor,
+		"requestID": event.RequestID,
+		"operation": string(event.Operation),
+		"peopleIDs": peopleIDs,
+		"before":    string(before),
+		"after":     string(after),
+	}, nil
+}
+
+func AuditEventMapper(rawEvent interface{}) (*familytree.AuditEvent, error) {
+	properties, ok := rawEvent.(map[string]interface{})
+	if !ok {
+		return nil, ErrInvalidQueryResult
+	}
+	text := func(key string) string {
+		value, _ := properties[key].(string)
+		return value
+	}
+	id, err := uuid.Parse(text("uuid"))
+	if err != nil {
+		return nil, err
+	}
+	timestamp, _ := properties["timestamp"].(int64)
+	rawPeopleIDs, _ := properties["peopleIDs"].([]interface{})
+	event := &familytree.AuditEvent{
+		ID:        id,
+		Timestamp: time.Unix(0, timestamp).UTC(),
+		Actor:     text("actor"),
+		RequestID: text("requestID"),
+		Operation: familytree.AuditOperation(text("operation")),
+		PeopleIDs: make([]uuid.UUID, 0, len(rawPeopleIDs)),
+	}
+	for _, rawPersonID := range rawPeopleIDs {
+		textID, _ := rawPersonID.(string)
+		personID, err := uuid.Parse(textID)
+		if err != nil {
+			return nil, err
+		}
+		event.PeopleIDs = append(event.PeopleIDs, personID)
+	}
+	if err := json.Unmarshal([]byte(text("before")), &event.Before); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(text("after")), &event.After); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
 func PeopleMapper(people ...*Person) ([]*familytree.Person, error) {
 	mappedPeople := make([]*familytree.Person, 0, len(people))
 	for _, person := range people {
@@ -119,10 +402,10 @@ func familyTreeMapperTraversal(rootPerson Person, familyTree *FamilyTree) (famil
 	}
 	familyTree.People[person.ID.String()] = *person
 	//familyTree.Relations[person.ID.String()] = []familytree.FamilyTreeRelation{}
-	if rootPerson.Spouse != nil {
-		spouse, ok := familyTree.People[rootPerson.Spouse.UUID]
+	for _, rootSpouse := range rootPerson.Spouses {
+		spouse, ok := familyTree.People[rootSpouse.UUID]
 		if !ok {
-			spouse, err = familyTreeMapperTraversal(*rootPerson.Spouse, familyTree)
+			spouse, err = familyTreeMapperTraversal(*rootSpouse, familyTree)
 			if err != nil {
 				return familytree.Person{}, err
 			}
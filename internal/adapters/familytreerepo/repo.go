@@ -6,9 +6,11 @@ import (
 	"family-tree/internal/core/familytree"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/mindstand/gogm/v2"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
 )
 
 func NewFamilyTreeRepo(gogm *gogm.Gogm) *FamilyTreeRepo {
@@ -21,7 +23,7 @@ type FamilyTreeRepo struct {
 	Gogm *gogm.Gogm
 }
 
-func (repo *FamilyTreeRepo) OpenSession(ctx context.Context, mode familytree.SessionMode) (interface{}, error) {
+func (repo *FamilyTreeRepo) Begin(ctx context.Context, mode familytree.SessionMode) (familytree.Tx, error) {
 	neo4jMode, err := SessionMapper(mode)
 	if err != nil {
 		return nil, err
@@ -30,71 +32,146 @@ func (repo *FamilyTreeRepo) OpenSession(ctx context.Context, mode familytree.Ses
 	if err != nil {
 		return nil, err
 	}
-	return session, nil
+	if err := session.Begin(ctx); err != nil {
+		session.Close()
+		return nil, err
+	}
+	return &Tx{session: session, mode: mode}, nil
 }
 
-func (repo *FamilyTreeRepo) getSessionFromContext(ctx context.Context) (gogm.SessionV2, error) {
-	genSession := ctx.Value(familytree.SessionKey)
-	session, ok := genSession.(gogm.SessionV2)
-	if !ok {
-		return nil, ErrInvalidSessionValue
+func (tx *Tx) Mode() familytree.SessionMode {
+	return tx.mode
+}
+
+func (tx *Tx) Commit(ctx context.Context) error {
+	if tx.done {
+		return familytree.ErrTxDone
+	}
+	tx.done = true
+	defer tx.session.Close()
+	if err := tx.session.Commit(ctx); err != nil {
+		return err
+	}
+	for _, hook := range tx.commits {
+		hook()
 	}
-	return session, nil
+	return nil
 }
 
-func (repo *FamilyTreeRepo) CloseSession(ctx context.Context) {
-	session, err := repo.getSessionFromContext(ctx)
-	if err != nil {
-		return
+func (tx *Tx) Rollback(ctx context.Context) error {
+	if tx.done {
+		return nil
 	}
+	tx.done = true
+	defer tx.session.Close()
+	return tx.session.Rollback(ctx)
+}
+
+func (tx *Tx) OnCommit(hook func()) {
+	tx.commits = append(tx.commits, hook)
+}
 
-	session.Close()
+func (repo *FamilyTreeRepo) getTx(tx familytree.Tx) (*Tx, error) {
+	neo4jTx, ok := tx.(*Tx)
+	if !ok {
+		return nil, ErrInvalidTx
+	}
+	if neo4jTx.done {
+		return nil, familytree.ErrTxDone
+	}
+	return neo4jTx, nil
 }
 
-func (repo *FamilyTreeRepo) getGogmPerson(ctx context.Context, id uuid.UUID) (*Person, error) {
-	session, err := repo.getSessionFromContext(ctx)
+func (repo *FamilyTreeRepo) getSession(tx familytree.Tx) (gogm.SessionV2, error) {
+	neo4jTx, err := repo.getTx(tx)
 	if err != nil {
 		return nil, err
 	}
-	person := &Person{}
+	return neo4jTx.session, nil
+}
 
-	err = session.Load(ctx, person, id.String())
+// getWriteSession refuses READ Txs, Neo4j only refuses their writes on
+// clusters.
+func (repo *FamilyTreeRepo) getWriteSession(tx familytree.Tx) (gogm.SessionV2, error) {
+	neo4jTx, err := repo.getTx(tx)
 	if err != nil {
-		if errors.Is(err, gogm.ErrNotFound) {
-			return nil, nil
-		}
 		return nil, err
 	}
-	return person, nil
+	if neo4jTx.mode != familytree.SessionWrite {
+		return nil, familytree.ErrReadOnlyTx
+	}
+	return neo4jTx.session, nil
 }
 
-func (repo *FamilyTreeRepo) GetPerson(ctx context.Context, id uuid.UUID) (*familytree.Person, error) {
-	person, err := repo.getGogmPerson(ctx, id)
+// LockPeople takes the write locks of the people by writing and removing a
+// property, always in the same order to avoid deadlocks between transactions.
+// Trashed people are locked as well, so restores wait for deletes.
+func (repo *FamilyTreeRepo) LockPeople(ctx context.Context, tx familytree.Tx, peopleIDs ...uuid.UUID) error {
+	session, err := repo.getWriteSession(tx)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	if person == nil {
-		return nil, nil
+	uuids := make([]string, 0, len(peopleIDs))
+	for _, personID := range peopleIDs {
+		uuids = append(uuids, personID.String())
+	}
+	queryRaw := `
+	MATCH (person) WHERE (person:Person OR person:TrashedPerson) AND person.uuid IN $uuids
+	WITH person ORDER BY person.uuid
+	SET person.lock = true
+	REMOVE person.lock
+	`
+	_, _, err = session.QueryRaw(ctx, queryRaw, map[string]interface{}{
+		"uuids": uuids,
+	})
+	return err
+}
+
+// IsTransientError follows the driver, deadlocks and lost connections are
+// transient, and so is a cluster leader switch.
+func (repo *FamilyTreeRepo) IsTransientError(err error) bool {
+	var neo4jErr *neo4j.Neo4jError
+	if errors.As(err, &neo4jErr) {
+		return neo4jErr.IsRetriableTransient() || neo4jErr.IsRetriableCluster()
 	}
-	parsedUUID, err := uuid.Parse(person.BaseUUIDNode.UUID)
+	var connectivityErr *neo4j.ConnectivityError
+	return errors.As(err, &connectivityErr)
+}
+
+// GetPerson matches only the Person label, so a trashed or merged id is
+// missing, and it doesn't load the relations, some of them aren't mapped to
+// gogm entities.
+func (repo *FamilyTreeRepo) GetPerson(ctx context.Context, tx familytree.Tx, id uuid.UUID) (*familytree.Person, error) {
+	session, err := repo.getSession(tx)
 	if err != nil {
 		return nil, err
 	}
-	return &familytree.Person{
-		ID:   parsedUUID,
-		Name: person.Name,
-	}, err
-
+	queryRaw := `
+	MATCH (person:Person {uuid: $uuid})
+	RETURN properties(person)
+	`
+	result, _, err := session.QueryRaw(ctx, queryRaw, map[string]interface{}{
+		"uuid": id.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, nil
+	}
+	properties, ok := result[0][0].(map[string]interface{})
+	if !ok {
+		return nil, ErrInvalidQueryResult
+	}
+	return PersonPropertiesMapper(properties)
 }
 
-func (repo *FamilyTreeRepo) SavePerson(ctx context.Context, person *familytree.Person) error {
-	session, err := repo.getSessionFromContext(ctx)
+func (repo *FamilyTreeRepo) SavePerson(ctx context.Context, tx familytree.Tx, person *familytree.Person) error {
+	session, err := repo.getWriteSession(tx)
 	if err != nil {
 		return err
 	}
-	newPerson := &Person{
-		Name: person.Name,
-	}
+	newPerson := GogmPersonMapper(person)
 
 	err = session.Save(ctx, newPerson)
 	if err != nil {
@@ -104,57 +181,141 @@ func (repo *FamilyTreeRepo) SavePerson(ctx context.Context, person *familytree.P
 	return err
 }
 
-func (repo *FamilyTreeRepo) GetParents(ctx context.Context, personID uuid.UUID) ([]*familytree.Person, error) {
-	session, err := repo.getSessionFromContext(ctx)
+func (repo *FamilyTreeRepo) UpdatePerson(ctx context.Context, tx familytree.Tx, person *familytree.Person) error {
+	session, err := repo.getWriteSession(tx)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	query := `
-		MATCH p=(person:Person {uuid:$uuid})<-[:PARENT]-(parent) 
-		return p
+	queryRaw := `
+	MATCH (person:Person {uuid: $uuid})
+	SET person.name = $name,
+		person.givenName = $givenName,
+		person.surname = $surname,
+		person.sex = $sex,
+		person.birthDate = $birthDate,
+		person.birthPlace = $birthPlace,
+		person.deathDate = $deathDate,
+		person.deathPlace = $deathPlace,
+		person.searchName = $searchName,
+		person.soundex = $soundex,
+		person.trigrams = $trigrams,
+		person.birthFrom = $birthFrom,
+		person.birthTo = $birthTo,
+		person.surnameKey = $surnameKey,
+		person.blockingKey = $blockingKey
+	RETURN count(person)
+	`
+	searchName, soundex, trigrams := SearchKeysMapper(person.Name)
+	birthFrom, birthTo := familytree.BirthRange(person.BirthDate)
+	result, _, err := session.QueryRaw(ctx, queryRaw, map[string]interface{}{
+		"uuid":        person.ID.String(),
+		"name":        person.Name,
+		"givenName":   person.GivenName,
+		"surname":     person.Surname,
+		"sex":         string(person.Sex),
+		"birthDate":   string(person.BirthDate),
+		"birthPlace":  person.BirthPlace,
+		"deathDate":   string(person.DeathDate),
+		"deathPlace":  person.DeathPlace,
+		"searchName":  searchName,
+		"soundex":     soundex,
+		"trigrams":    trigrams,
+		"birthFrom":   birthFrom,
+		"birthTo":     birthTo,
+		"surnameKey":  familytree.NormalizeName(person.Surname),
+		"blockingKey": familytree.NewNameKeys(person.Name).BlockingKey(),
+	})
+	if err != nil {
+		return err
+	}
+	if len(result) == 0 {
+		return familytree.ErrPersonNotFound
+	}
+	updatedItens, ok := result[0][0].(int64)
+	if !ok {
+		return ErrInvalidQueryResult
+	}
+	if updatedItens == 0 {
+		return familytree.ErrPersonNotFound
+	}
+	return nil
+}
 
+func (repo *FamilyTreeRepo) GetParents(ctx context.Context, tx familytree.Tx, personID uuid.UUID) ([]familytree.PersonParent, error) {
+	session, err := repo.getSession(tx)
+	if err != nil {
+		return nil, err
+	}
+	queryRaw := `
+	MATCH (:Person {uuid: $uuid})<-[relation:PARENT]-(parent:Person)
+	RETURN properties(parent), relation.parentage
 	`
-	person := &Person{}
-	err = session.Query(context.Background(), query, map[string]interface{}{"uuid": personID.String()}, person)
+	result, _, err := session.QueryRaw(ctx, queryRaw, map[string]interface{}{
+		"uuid": personID.String(),
+	})
 	if err != nil {
-		if errors.Is(err, gogm.ErrNotFound) {
-			return nil, nil
-		}
 		return nil, err
 	}
-	return PeopleMapper(person.Parents...)
+	var parents []familytree.PersonParent
+	for _, row := range result {
+		properties, ok := row[0].(map[string]interface{})
+		if !ok {
+			return nil, ErrInvalidQueryResult
+		}
+		parent, err := PersonPropertiesMapper(properties)
+		if err != nil {
+			return nil, err
+		}
+		parents = append(parents, familytree.PersonParent{
+			Parent:    *parent,
+			Parentage: ParentageMapper(row[1]),
+		})
+	}
+	return parents, nil
 }
 
-func (repo *FamilyTreeRepo) SaveRelation(ctx context.Context, relation familytree.PersonRelation) error {
-	session, err := repo.getSessionFromContext(ctx)
+func (repo *FamilyTreeRepo) SaveRelation(ctx context.Context, tx familytree.Tx, relation familytree.PersonRelation) error {
+	session, err := repo.getWriteSession(tx)
 	if err != nil {
 		return err
 	}
+	properties := ""
+	params := map[string]interface{}{
+		"top":    relation.Top.ID.String(),
+		"bottom": relation.Bottom.ID.String(),
+	}
+	switch relation.RelationType {
+	case familytree.RelationTypeParent:
+		properties = " {parentage: $parentage}"
+		params["parentage"] = string(ParentageMapper(string(relation.Parentage)))
+	case familytree.RelationTypeSpouse:
+		properties = " {startDate: $startDate, endDate: $endDate, endReason: $endReason}"
+		for key, value := range UnionProperties(relation.Union) {
+			params[key] = value
+		}
+	}
 	query := fmt.Sprintf(`
 		MATCH (a:Person),
 			  (b:Person)
 		WHERE a.uuid = $top AND b.uuid = $bottom
-		CREATE (a)-[:%s]->(b)
-	`, relation.RelationType)
-	_, _, err = session.QueryRaw(ctx, query, map[string]interface{}{
-		"top":    relation.Top.ID.String(),
-		"bottom": relation.Bottom.ID.String(),
-	})
+		CREATE (a)-[:%s%s]->(b)
+	`, relation.RelationType, properties)
+	_, _, err = session.QueryRaw(ctx, query, params)
 
 	return err
 
 }
 
-func (repo *FamilyTreeRepo) GetLowestCommonAncestor(ctx context.Context, firstPerson familytree.Person, secondPerson familytree.Person) (*familytree.Person, error) {
-	session, err := repo.getSessionFromContext(ctx)
+func (repo *FamilyTreeRepo) GetLowestCommonAncestor(ctx context.Context, tx familytree.Tx, firstPerson familytree.Person, secondPerson familytree.Person) (*familytree.Person, error) {
+	session, err := repo.getSession(tx)
 	if err != nil {
 		return nil, err
 	}
 	query := `
-		MATCH (c1:Person {uuid: $firstPerson})<-[:PARENT*0..]-(p1:Person)
-		MATCH (:Person {uuid: $secondPerson})<-[:PARENT*0..]-(p2:Person)
-		WHERE p1.uuid = p2.uuid
-		MATCH path = (c1)<-[:PARENT*0..]-(p1)
+		MATCH path = (:Person {uuid: $firstPerson})<-[:PARENT*0..]-(p1:Person)
+		WHERE all(relation IN relationships(path) WHERE coalesce(relation.parentage, $biological) = $biological)
+		MATCH secondPath = (:Person {uuid: $secondPerson})<-[:PARENT*0..]-(p1)
+		WHERE all(relation IN relationships(secondPath) WHERE coalesce(relation.parentage, $biological) = $biological)
 		RETURN p1
 		ORDER BY length(path)
 		LIMIT 1
@@ -163,6 +324,7 @@ func (repo *FamilyTreeRepo) GetLowestCommonAncestor(ctx context.Context, firstPe
 	err = session.Query(ctx, query, map[string]interface{}{
 		"firstPerson":  firstPerson.ID.String(),
 		"secondPerson": secondPerson.ID.String(),
+		"biological":   string(familytree.ParentageBiological),
 	}, ancestor)
 	if err != nil {
 		if errors.Is(err, gogm.ErrNotFound) {
@@ -173,55 +335,377 @@ func (repo *FamilyTreeRepo) GetLowestCommonAncestor(ctx context.Context, firstPe
 	return PersonMapper(ancestor)
 }
 
-func (repo *FamilyTreeRepo) GetPeople(ctx context.Context, pagination familytree.PaginationDetails) (*familytree.PeopleList, error) {
-	session, err := repo.getSessionFromContext(ctx)
+func (repo *FamilyTreeRepo) GetCommonAncestors(ctx context.Context, tx familytree.Tx, firstPerson familytree.Person, secondPerson familytree.Person) ([]familytree.CommonAncestor, error) {
+	session, err := repo.getSession(tx)
 	if err != nil {
 		return nil, err
 	}
-	countQuery := `
-		MATCH (person:Person) 
-		RETURN count(person)
+	query := `
+		MATCH firstPath = (:Person {uuid: $firstPerson})<-[:PARENT*0..]-(ancestor:Person)
+		WHERE all(relation IN relationships(firstPath) WHERE coalesce(relation.parentage, $biological) = $biological)
+		MATCH secondPath = (:Person {uuid: $secondPerson})<-[:PARENT*0..]-(ancestor)
+		WHERE all(relation IN relationships(secondPath) WHERE coalesce(relation.parentage, $biological) = $biological)
+		RETURN properties(ancestor), min(length(firstPath)), min(length(secondPath))
 	`
-	totalItens := int64(0)
-	result, _, err := session.QueryRaw(ctx, countQuery, map[string]interface{}{})
-
+	result, _, err := session.QueryRaw(ctx, query, map[string]interface{}{
+		"firstPerson":  firstPerson.ID.String(),
+		"secondPerson": secondPerson.ID.String(),
+		"biological":   string(familytree.ParentageBiological),
+	})
 	if err != nil {
 		return nil, err
 	}
-	totalItens, ok := result[0][0].(int64)
-	if !ok {
-		return nil, ErrInvalidQueryResult
+	ancestors := make([]familytree.CommonAncestor, 0, len(result))
+	for _, row := range result {
+		properties, ok := row[0].(map[string]interface{})
+		if !ok {
+			return nil, ErrInvalidQueryResult
+		}
+		firstDistance, ok := row[1].(int64)
+		if !ok {
+			return nil, ErrInvalidQueryResult
+		}
+		secondDistance, ok := row[2].(int64)
+		if !ok {
+			return nil, ErrInvalidQueryResult
+		}
+		ancestor, err := PersonPropertiesMapper(properties)
+		if err != nil {
+			return nil, err
+		}
+		ancestors = append(ancestors, familytree.CommonAncestor{
+			Ancestor:       *ancestor,
+			FirstDistance:  int(firstDistance),
+			SecondDistance: int(secondDistance),
+		})
+	}
+	return ancestors, nil
+}
+
+// peopleFilterClause is the WHERE clause of a PeopleFilter, people saved
+// before the birth range and the surname key existed are matched as unknown
+// births and by their lowered surname.
+const peopleFilterClause = `
+	($hasParents IS NULL OR $hasParents = exists( (person)<-[:PARENT]-(:Person) ))
+	AND ($isLiving IS NULL OR $isLiving = (coalesce(person.deathDate, "") = ""))
+	AND ($bornAfter = "" OR coalesce(person.birthFrom, "") > $bornAfter)
+	AND ($bornBefore = "" OR (coalesce(person.birthTo, "") <> "" AND person.birthTo < $bornBefore))
+	AND ($surname = "" OR coalesce(person.surnameKey, toLower(person.surname)) = $surname)
+`
+
+func peopleFilterParams(filter familytree.PeopleFilter) map[string]interface{} {
+	optional := func(value *bool) interface{} {
+		if value == nil {
+			return nil
+		}
+		return *value
+	}
+	return map[string]interface{}{
+		"hasParents": optional(filter.HasParents),
+		"isLiving":   optional(filter.IsLiving),
+		"bornAfter":  filter.BornAfterDay(),
+		"bornBefore": filter.BornBeforeDay(),
+		"surname":    filter.Surname,
+	}
+}
+
+// Births are sorted by their earliest day, or the latest one on BEF dates, and
+// unknown births come last on both directions.
+const (
+	unknownBirthAscending  = "~"
+	unknownBirthDescending = ""
+)
+
+// peopleSortValue is what people are sorted by before the ties, people saved
+// before createdAt existed come first by creation.
+func peopleSortValue(peopleSort familytree.PeopleSort) string {
+	switch peopleSort.Field {
+	case familytree.PeopleSortName:
+		return "person.name"
+	case familytree.PeopleSortBirthDate:
+		return `coalesce(CASE
+			WHEN coalesce(person.birthFrom, "") <> "" THEN person.birthFrom
+			WHEN coalesce(person.birthTo, "") <> "" THEN person.birthTo
+		END, $unknownBirth)`
+	default:
+		return "coalesce(person.createdAt, 0)"
+	}
+}
+
+func peopleSortParams(filter familytree.PeopleFilter, peopleSort familytree.PeopleSort) map[string]interface{} {
+	params := peopleFilterParams(filter)
+	params["unknownBirth"] = unknownBirthAscending
+	if peopleSort.Descending {
+		params["unknownBirth"] = unknownBirthDescending
+	}
+	return params
+}
+
+// peopleKeyParams sets the key the keyset clause compares people with.
+func peopleKeyParams(params map[string]interface{}, peopleSort familytree.PeopleSort, key familytree.PeopleSortKey) map[string]interface{} {
+	keyParams := map[string]interface{}{
+		"keyName": key.Name,
+		"keyID":   key.ID.String(),
+	}
+	for name, value := range params {
+		keyParams[name] = value
+	}
+	switch {
+	case peopleSort.Field == familytree.PeopleSortCreatedAt:
+		keyParams["keyValue"] = key.Number
+	case peopleSort.Field == familytree.PeopleSortBirthDate && key.Text == "":
+		keyParams["keyValue"] = params["unknownBirth"]
+	default:
+		keyParams["keyValue"] = key.Text
+	}
+	return keyParams
+}
+
+func peopleSortKeyMapper(peopleSort familytree.PeopleSort, person familytree.Person, sortValue interface{}) (*familytree.PeopleSortKey, error) {
+	key := &familytree.PeopleSortKey{Name: person.Name, ID: person.ID}
+	switch peopleSort.Field {
+	case familytree.PeopleSortCreatedAt:
+		number, ok := sortValue.(int64)
+		if !ok {
+			return nil, ErrInvalidQueryResult
+		}
+		key.Number = number
+	default:
+		text, ok := sortValue.(string)
+		if !ok {
+			return nil, ErrInvalidQueryResult
+		}
+		if text != unknownBirthAscending || peopleSort.Field != familytree.PeopleSortBirthDate {
+			key.Text = text
+		}
+	}
+	return key, nil
+}
+
+// peopleKeysetClause matches the people after $keyValue, $keyName and $keyID
+// on the sort, or before them when backward.
+func peopleKeysetClause(peopleSort familytree.PeopleSort, backward bool) string {
+	valueOperator, tieOperator := ">", ">"
+	if peopleSort.Descending != backward {
+		valueOperator = "<"
+	}
+	if backward {
+		tieOperator = "<"
+	}
+	return fmt.Sprintf(`(sortValue %[1]s $keyValue
+		OR (sortValue = $keyValue AND (person.name %[2]s $keyName
+			OR (person.name = $keyName AND person.uuid %[2]s $keyID))))`, valueOperator, tieOperator)
+}
+
+// peopleQuery lists the people on the sort order, or on the reverse order
+// when backward, ties are broken by name and then by uuid.
+func peopleQuery(peopleSort familytree.PeopleSort, keysetClause string, backward bool) string {
+	direction, tieDirection := "ASC", "ASC"
+	if peopleSort.Descending != backward {
+		direction = "DESC"
+	}
+	if backward {
+		tieDirection = "DESC"
+	}
+	// ORDER BY can't be a parameter
+	return fmt.Sprintf(`
+		MATCH (person:Person)
+		WHERE %s
+		WITH person, %s AS sortValue
+		WHERE %s
+		RETURN properties(person), sortValue
+		ORDER BY sortValue %s, person.name %s, person.uuid %s
+		SKIP $skip
+		LIMIT $limit
+	`, peopleFilterClause, peopleSortValue(peopleSort), keysetClause, direction, tieDirection, tieDirection)
+}
+
+func (repo *FamilyTreeRepo) queryPeople(ctx context.Context, tx familytree.Tx, query string, params map[string]interface{}, peopleSort familytree.PeopleSort) ([]*familytree.Person, []familytree.PeopleSortKey, error) {
+	session, err := repo.getSession(tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, _, err := session.QueryRaw(ctx, query, params)
+	if err != nil {
+		return nil, nil, err
+	}
+	people := make([]*familytree.Person, 0, len(result))
+	keys := make([]familytree.PeopleSortKey, 0, len(result))
+	for _, row := range result {
+		properties, ok := row[0].(map[string]interface{})
+		if !ok {
+			return nil, nil, ErrInvalidQueryResult
+		}
+		person, err := PersonPropertiesMapper(properties)
+		if err != nil {
+			return nil, nil, err
+		}
+		key, err := peopleSortKeyMapper(peopleSort, *person, row[1])
+		if err != nil {
+			return nil, nil, err
+		}
+		people = append(people, person)
+		keys = append(keys, *key)
+	}
+	return people, keys, nil
+}
+
+// peopleExist tells if there are people after the key on the sort, or before
+// it when backward.
+func (repo *FamilyTreeRepo) peopleExist(ctx context.Context, tx familytree.Tx, params map[string]interface{}, peopleSort familytree.PeopleSort, key familytree.PeopleSortKey, backward bool) (bool, error) {
+	keyParams := peopleKeyParams(params, peopleSort, key)
+	keyParams["skip"] = 0
+	keyParams["limit"] = 1
+	people, _, err := repo.queryPeople(ctx, tx, peopleQuery(peopleSort, peopleKeysetClause(peopleSort, backward), backward), keyParams, peopleSort)
+	return len(people) > 0, err
+}
+
+func (repo *FamilyTreeRepo) GetPeople(ctx context.Context, tx familytree.Tx, filter familytree.PeopleFilter, peopleSort familytree.PeopleSort, pagination familytree.PaginationDetails) (*familytree.PeopleList, error) {
+	session, err := repo.getSession(tx)
+	if err != nil {
+		return nil, err
 	}
 	peopleList := &familytree.PeopleList{
+		Content: []*familytree.Person{},
 		Metadata: familytree.ListMetadata{
-			TotalItens: int(totalItens),
-			Page:       pagination.Page,
+			Page: pagination.Page,
 		},
 	}
-	query := `
-		MATCH (person:Person) 
-		RETURN person
-		SKIP $skip
-		LIMIT $pagesize
-	`
-	var rawList []*Person
-	session.Query(ctx, query, map[string]interface{}{
-		"skip":     pagination.Page * pagination.PageSize,
-		"pagesize": pagination.PageSize,
-	}, &rawList)
+	params := peopleSortParams(filter, peopleSort)
+	if pagination.CountsTotal() {
+		countQuery := `
+			MATCH (person:Person)
+			WHERE ` + peopleFilterClause + `
+			RETURN count(person)
+		`
+		result, _, err := session.QueryRaw(ctx, countQuery, params)
+		if err != nil {
+			return nil, err
+		}
+		if len(result) == 0 {
+			return nil, ErrInvalidQueryResult
+		}
+		totalItens, ok := result[0][0].(int64)
+		if !ok {
+			return nil, ErrInvalidQueryResult
+		}
+		peopleList.Metadata.TotalItens = int(totalItens)
+	}
+	if pagination.PageSize <= 0 {
+		return peopleList, nil
+	}
+
+	// One more person than the page tells if there are more people ahead
+	keysetClause, backward, queryParams := "true", pagination.Before != nil, params
+	switch {
+	case pagination.After != nil:
+		keysetClause, queryParams = peopleKeysetClause(peopleSort, false), peopleKeyParams(params, peopleSort, *pagination.After)
+	case pagination.Before != nil:
+		keysetClause, queryParams = peopleKeysetClause(peopleSort, true), peopleKeyParams(params, peopleSort, *pagination.Before)
+	}
+	queryParams["skip"] = 0
+	if !pagination.IsKeyset() {
+		queryParams["skip"] = pagination.Page * pagination.PageSize
+	}
+	queryParams["limit"] = pagination.PageSize + 1
+	people, keys, err := repo.queryPeople(ctx, tx, peopleQuery(peopleSort, keysetClause, backward), queryParams, peopleSort)
 	if err != nil {
 		return nil, err
 	}
-	mappedList, err := PeopleMapper(rawList...)
+	more := len(people) > pagination.PageSize
+	if more {
+		people, keys = people[:pagination.PageSize], keys[:pagination.PageSize]
+	}
+	if backward {
+		for i, j := 0, len(people)-1; i < j; i, j = i+1, j-1 {
+			people[i], people[j] = people[j], people[i]
+			keys[i], keys[j] = keys[j], keys[i]
+		}
+	}
+	if len(people) == 0 {
+		return peopleList, nil
+	}
+	peopleList.Content = people
+	first, last := keys[0], keys[len(keys)-1]
+	hasPrev, hasNext := more, more
+	switch {
+	case backward:
+		hasNext, err = repo.peopleExist(ctx, tx, params, peopleSort, last, false)
+	case pagination.After != nil:
+		hasPrev, err = repo.peopleExist(ctx, tx, params, peopleSort, first, true)
+	default:
+		hasPrev = pagination.Page > 0
+	}
 	if err != nil {
 		return nil, err
 	}
-	peopleList.Content = mappedList
+	if hasNext {
+		peopleList.Metadata.Next = &last
+	}
+	if hasPrev {
+		peopleList.Metadata.Prev = &first
+	}
 	return peopleList, nil
 }
 
-func (repo *FamilyTreeRepo) GetFamilyTree(ctx context.Context, person familytree.Person) (*familytree.FamilyTree, error) {
-	session, err := repo.getSessionFromContext(ctx)
+// SearchPeople only fetches the people that may match the query: containing
+// it, having the codes of all its words or sharing enough trigrams with it.
+// People saved before search keys existed only match by substring of their
+// lowered name until they are updated. The fetched people are scored as the
+// memory repo does.
+func (repo *FamilyTreeRepo) SearchPeople(ctx context.Context, tx familytree.Tx, query string, filter familytree.PeopleFilter, pagination familytree.PaginationDetails) (*familytree.PeopleMatchList, error) {
+	session, err := repo.getSession(tx)
+	if err != nil {
+		return nil, err
+	}
+	searchName, soundex, trigrams := SearchKeysMapper(query)
+	queryKeys := familytree.NewNameKeys(query)
+	queryRaw := `
+	MATCH (person:Person)
+	WHERE ((person.searchName IS NULL AND $name <> "" AND toLower(person.name) CONTAINS $name)
+		OR ($searchName <> "" AND person.searchName CONTAINS $searchName)
+		OR (size($soundex) > 0 AND all(code IN $soundex WHERE code IN person.soundex))
+		OR ($minSharedTrigrams > 0 AND size([trigram IN person.trigrams WHERE trigram IN $trigrams]) >= $minSharedTrigrams))
+		AND ` + peopleFilterClause + `
+	RETURN properties(person)
+	ORDER BY coalesce(person.createdAt, 0), person.name, person.uuid
+	`
+	params := peopleFilterParams(filter)
+	params["name"] = strings.ToLower(strings.TrimSpace(query))
+	params["searchName"] = searchName
+	params["soundex"] = soundex
+	params["trigrams"] = trigrams
+	params["minSharedTrigrams"] = queryKeys.MinSharedTrigrams()
+	result, _, err := session.QueryRaw(ctx, queryRaw, params)
+	if err != nil {
+		return nil, err
+	}
+	matches := []familytree.PersonMatch{}
+	for _, row := range result {
+		properties, ok := row[0].(map[string]interface{})
+		if !ok {
+			return nil, ErrInvalidQueryResult
+		}
+		person, err := PersonPropertiesMapper(properties)
+		if err != nil {
+			return nil, err
+		}
+		score, match, ok := queryKeys.MatchName(familytree.NewNameKeys(person.Name))
+		if !ok {
+			continue
+		}
+		matches = append(matches, familytree.PersonMatch{
+			Person: *person,
+			Score:  score,
+			Match:  match,
+		})
+	}
+	familytree.SortMatches(matches)
+	return familytree.PageMatches(matches, pagination), nil
+}
+
+func (repo *FamilyTreeRepo) GetFamilyTree(ctx context.Context, tx familytree.Tx, person familytree.Person) (*familytree.FamilyTree, error) {
+	session, err := repo.getSession(tx)
 	if err != nil {
 		return nil, err
 	}
@@ -246,11 +730,127 @@ func (repo *FamilyTreeRepo) GetFamilyTree(ctx context.Context, person familytree
 	if err != nil {
 		return nil, err
 	}
-	return FamilyTreeMapper(*rootPerson)
+	tree, err := FamilyTreeMapper(*rootPerson)
+	if err != nil {
+		return nil, err
+	}
+	if err := repo.setTreeRelations(ctx, session, tree); err != nil {
+		return nil, err
+	}
+	return tree, nil
 }
 
-func (repo *FamilyTreeRepo) GetShortestPathLength(ctx context.Context, firstPerson familytree.Person, secondPerson familytree.Person) (int, bool, error) {
-	session, err := repo.getSessionFromContext(ctx)
+func (repo *FamilyTreeRepo) GetDescendants(ctx context.Context, tx familytree.Tx, person familytree.Person, depth int) ([]familytree.PersonChild, error) {
+	session, err := repo.getSession(tx)
+	if err != nil {
+		return nil, err
+	}
+	// Variable length bounds can't be parameters
+	queryRaw := fmt.Sprintf(`
+	MATCH path = (:Person {uuid: $uuid})-[:PARENT*1..%d]->(:Person)
+	WITH DISTINCT last(relationships(path)) AS relation
+	RETURN startNode(relation).uuid, properties(endNode(relation)), relation.parentage
+	`, depth)
+	result, _, err := session.QueryRaw(ctx, queryRaw, map[string]interface{}{
+		"uuid": person.ID.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	children := make([]familytree.PersonChild, 0, len(result))
+	for _, row := range result {
+		rawParentID, ok := row[0].(string)
+		if !ok {
+			return nil, ErrInvalidQueryResult
+		}
+		parentID, err := uuid.Parse(rawParentID)
+		if err != nil {
+			return nil, err
+		}
+		properties, ok := row[1].(map[string]interface{})
+		if !ok {
+			return nil, ErrInvalidQueryResult
+		}
+		child, err := PersonPropertiesMapper(properties)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, familytree.PersonChild{
+			ParentID:  parentID,
+			Child:     *child,
+			Parentage: ParentageMapper(row[2]),
+		})
+	}
+	return children, nil
+}
+
+func (repo *FamilyTreeRepo) IsDescendant(ctx context.Context, tx familytree.Tx, ancestor familytree.Person, descendant familytree.Person) (bool, error) {
+	session, err := repo.getSession(tx)
+	if err != nil {
+		return false, err
+	}
+	queryRaw := `
+	MATCH (:Person {uuid: $ancestor})-[:PARENT*1..]->(:Person {uuid: $descendant})
+	RETURN true
+	LIMIT 1
+	`
+	result, _, err := session.QueryRaw(ctx, queryRaw, map[string]interface{}{
+		"ancestor":   ancestor.ID.String(),
+		"descendant": descendant.ID.String(),
+	})
+	if err != nil {
+		return false, err
+	}
+	return len(result) > 0, nil
+}
+
+func (repo *FamilyTreeRepo) GetAncestors(ctx context.Context, tx familytree.Tx, person familytree.Person, generations int) ([]familytree.ChildParent, error) {
+	session, err := repo.getSession(tx)
+	if err != nil {
+		return nil, err
+	}
+	// Variable length bounds can't be parameters
+	queryRaw := fmt.Sprintf(`
+	MATCH path = (:Person {uuid: $uuid})<-[:PARENT*1..%d]-(:Person)
+	WHERE all(relation IN relationships(path) WHERE coalesce(relation.parentage, $biological) = $biological)
+	WITH DISTINCT last(relationships(path)) AS relation
+	RETURN endNode(relation).uuid, properties(startNode(relation))
+	`, generations)
+	result, _, err := session.QueryRaw(ctx, queryRaw, map[string]interface{}{
+		"uuid":       person.ID.String(),
+		"biological": string(familytree.ParentageBiological),
+	})
+	if err != nil {
+		return nil, err
+	}
+	parents := make([]familytree.ChildParent, 0, len(result))
+	for _, row := range result {
+		rawChildID, ok := row[0].(string)
+		if !ok {
+			return nil, ErrInvalidQueryResult
+		}
+		childID, err := uuid.Parse(rawChildID)
+		if err != nil {
+			return nil, err
+		}
+		properties, ok := row[1].(map[string]interface{})
+		if !ok {
+			return nil, ErrInvalidQueryResult
+		}
+		parent, err := PersonPropertiesMapper(properties)
+		if err != nil {
+			return nil, err
+		}
+		parents = append(parents, familytree.ChildParent{
+			ChildID: childID,
+			Parent:  *parent,
+		})
+	}
+	return parents, nil
+}
+
+func (repo *FamilyTreeRepo) GetShortestPathLength(ctx context.Context, tx familytree.Tx, firstPerson familytree.Person, secondPerson familytree.Person) (int, bool, error) {
+	session, err := repo.getSession(tx)
 	if err != nil {
 		return 0, false, err
 	}
@@ -258,7 +858,7 @@ func (repo *FamilyTreeRepo) GetShortestPathLength(ctx context.Context, firstPers
 	MATCH
 		(first:Person {uuid: $uuid_first}),
 		(second:Person {uuid: $uuid_second}),
-		p = shortestPath((first)-[*..]-(second))
+		p = shortestPath((first)-[:PARENT|SPOUSE*..]-(second))
 	RETURN length(p)
 	`
 	result, _, err := session.QueryRaw(ctx, queryRaw, map[string]interface{}{
@@ -279,8 +879,77 @@ func (repo *FamilyTreeRepo) GetShortestPathLength(ctx context.Context, firstPers
 	return int(totalItens), true, nil
 }
 
-func (repo *FamilyTreeRepo) HasCommonChild(ctx context.Context, firstPerson familytree.Person, secondPerson familytree.Person) (bool, error) {
-	session, err := repo.getSessionFromContext(ctx)
+// GetShortestPaths finds the distance between the people with shortestPath
+// first, then lists the simple paths one length at a time from it, until there
+// are enough paths or the max length is reached. Listing every path up to the
+// max length at once would expand the whole neighbourhood of the people.
+func (repo *FamilyTreeRepo) GetShortestPaths(ctx context.Context, tx familytree.Tx, firstPerson familytree.Person, secondPerson familytree.Person, filter familytree.PathFilter) ([]familytree.Path, error) {
+	session, err := repo.getSession(tx)
+	if err != nil {
+		return nil, err
+	}
+	relationTypes := familytree.RelationTypeParent.Name
+	if filter.FollowsSpouses() {
+		relationTypes += "|" + familytree.RelationTypeSpouse.Name
+	}
+	params := map[string]interface{}{
+		"uuid_first":  firstPerson.ID.String(),
+		"uuid_second": secondPerson.ID.String(),
+		"bloodOnly":   filter.BloodOnly,
+		"biological":  string(familytree.ParentageBiological),
+	}
+	const relationsFilter = `(NOT $bloodOnly OR all(relation IN relationships(path) WHERE coalesce(relation.parentage, $biological) = $biological))`
+	// Variable length bounds can't be parameters
+	distanceQuery := fmt.Sprintf(`
+	MATCH
+		(first:Person {uuid: $uuid_first}),
+		(second:Person {uuid: $uuid_second}),
+		path = shortestPath((first)-[:%s*..%d]-(second))
+	WHERE %s
+	RETURN length(path)
+	`, relationTypes, filter.MaxLength, relationsFilter)
+	result, _, err := session.QueryRaw(ctx, distanceQuery, params)
+	if err != nil {
+		return nil, err
+	}
+	paths := []familytree.Path{}
+	if len(result) == 0 {
+		return paths, nil
+	}
+	distance, ok := result[0][0].(int64)
+	if !ok {
+		return nil, ErrInvalidQueryResult
+	}
+
+	for length := int(distance); length <= filter.MaxLength && len(paths) < filter.Count; length++ {
+		params["count"] = filter.Count - len(paths)
+		pathsQuery := fmt.Sprintf(`
+		MATCH
+			(first:Person {uuid: $uuid_first}),
+			(second:Person {uuid: $uuid_second}),
+			path = (first)-[:%s*%d]-(second)
+		WHERE all(person IN nodes(path) WHERE single(other IN nodes(path) WHERE other = person))
+			AND %s
+		RETURN [person IN nodes(path) | properties(person)], [relation IN relationships(path) | [type(relation), startNode(relation).uuid]]
+		LIMIT $count
+		`, relationTypes, length, relationsFilter)
+		result, _, err := session.QueryRaw(ctx, pathsQuery, params)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range result {
+			path, err := PathMapper(row[0], row[1])
+			if err != nil {
+				return nil, err
+			}
+			paths = append(paths, *path)
+		}
+	}
+	return paths, nil
+}
+
+func (repo *FamilyTreeRepo) HasCommonChild(ctx context.Context, tx familytree.Tx, firstPerson familytree.Person, secondPerson familytree.Person) (bool, error) {
+	session, err := repo.getSession(tx)
 	if err != nil {
 		return false, err
 	}
@@ -305,8 +974,8 @@ func (repo *FamilyTreeRepo) HasCommonChild(ctx context.Context, firstPerson fami
 	return hasChild, nil
 }
 
-func (repo *FamilyTreeRepo) GetSpouse(ctx context.Context, person familytree.Person) (*familytree.Person, error) {
-	session, err := repo.getSessionFromContext(ctx)
+func (repo *FamilyTreeRepo) GetSpouse(ctx context.Context, tx familytree.Tx, person familytree.Person) (*familytree.Person, error) {
+	session, err := repo.getSession(tx)
 	if err != nil {
 		return nil, err
 	}
@@ -314,8 +983,10 @@ func (repo *FamilyTreeRepo) GetSpouse(ctx context.Context, person familytree.Per
 	query := `
 	MATCH
 	(person:Person {uuid: $uuid}),
-	(person)-[:SPOUSE]-(spouse)
+	(person)-[relation:SPOUSE]-(spouse)
+	WHERE coalesce(relation.endDate, '') = '' AND coalesce(relation.endReason, '') = ''
 	RETURN spouse
+	LIMIT 1
 	`
 	err = session.Query(ctx, query, map[string]interface{}{
 		"uuid": person.ID.String(),
@@ -334,14 +1005,130 @@ func (repo *FamilyTreeRepo) GetSpouse(ctx context.Context, person familytree.Per
 	return mappedSpouse, nil
 }
 
-func (repo *FamilyTreeRepo) GetParentMaritalChildCount(ctx context.Context, person familytree.Person) (int, error) {
-	session, err := repo.getSessionFromContext(ctx)
+func (repo *FamilyTreeRepo) GetUnions(ctx context.Context, tx familytree.Tx, person familytree.Person) ([]familytree.PersonUnion, error) {
+	session, err := repo.getSession(tx)
+	if err != nil {
+		return nil, err
+	}
+	queryRaw := `
+	MATCH (:Person {uuid: $uuid})-[relation:SPOUSE]-(spouse:Person)
+	RETURN properties(spouse), relation.startDate, relation.endDate, relation.endReason
+	`
+	result, _, err := session.QueryRaw(ctx, queryRaw, map[string]interface{}{
+		"uuid": person.ID.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	unions := make([]familytree.PersonUnion, 0, len(result))
+	for _, row := range result {
+		properties, ok := row[0].(map[string]interface{})
+		if !ok {
+			return nil, ErrInvalidQueryResult
+		}
+		spouse, err := PersonPropertiesMapper(properties)
+		if err != nil {
+			return nil, err
+		}
+		unions = append(unions, familytree.PersonUnion{
+			Spouse: *spouse,
+			Union:  UnionMapper(row[1], row[2], row[3]),
+		})
+	}
+	return unions, nil
+}
+
+func (repo *FamilyTreeRepo) UpdateUnion(ctx context.Context, tx familytree.Tx, firstPerson familytree.Person, secondPerson familytree.Person, startDate familytree.Date, union familytree.Union) (bool, error) {
+	session, err := repo.getWriteSession(tx)
+	if err != nil {
+		return false, err
+	}
+	queryRaw := `
+	MATCH (:Person {uuid: $first_uuid})-[relation:SPOUSE]-(:Person {uuid: $second_uuid})
+	WHERE coalesce(relation.startDate, '') = $current_start_date
+	SET relation.startDate = $startDate,
+		relation.endDate = $endDate,
+		relation.endReason = $endReason
+	RETURN count(relation)
+	`
+	params := UnionProperties(union)
+	params["first_uuid"] = firstPerson.ID.String()
+	params["second_uuid"] = secondPerson.ID.String()
+	params["current_start_date"] = string(startDate)
+	result, _, err := session.QueryRaw(ctx, queryRaw, params)
+	if err != nil {
+		return false, err
+	}
+	if len(result) == 0 {
+		return false, nil
+	}
+	updatedItens, ok := result[0][0].(int64)
+	if !ok {
+		return false, ErrInvalidQueryResult
+	}
+	return updatedItens > 0, nil
+}
+
+// setTreeRelations fills the union of the SPOUSE relations and the parentage
+// of the PARENT relations of the tree, gogm only maps the nodes of the
+// relations.
+func (repo *FamilyTreeRepo) setTreeRelations(ctx context.Context, session gogm.SessionV2, tree *familytree.FamilyTree) error {
+	peopleIDs := make([]string, 0, len(tree.People))
+	for _, node := range tree.People {
+		peopleIDs = append(peopleIDs, node.Person.ID.String())
+	}
+	queryRaw := `
+	MATCH (top:Person)-[relation:SPOUSE|PARENT]->(bottom:Person)
+	WHERE top.uuid IN $uuids AND bottom.uuid IN $uuids
+	RETURN top.uuid, bottom.uuid, type(relation), relation.startDate, relation.endDate, relation.endReason, relation.parentage
+	`
+	result, _, err := session.QueryRaw(ctx, queryRaw, map[string]interface{}{
+		"uuids": peopleIDs,
+	})
+	if err != nil {
+		return err
+	}
+	unions := map[[2]string]familytree.Union{}
+	parentages := map[[2]string]familytree.Parentage{}
+	for _, row := range result {
+		top, topOk := row[0].(string)
+		bottom, bottomOk := row[1].(string)
+		relationType, typeOk := row[2].(string)
+		if !topOk || !bottomOk || !typeOk {
+			return ErrInvalidQueryResult
+		}
+		switch relationType {
+		case familytree.RelationTypeSpouse.Name:
+			union := UnionMapper(row[3], row[4], row[5])
+			unions[[2]string{top, bottom}] = union
+			unions[[2]string{bottom, top}] = union
+		case familytree.RelationTypeParent.Name:
+			parentages[[2]string{top, bottom}] = ParentageMapper(row[6])
+		}
+	}
+	for i := range tree.People {
+		for j, relation := range tree.People[i].Relations {
+			key := [2]string{tree.People[i].Person.ID.String(), relation.PersonID.String()}
+			switch relation.RelationType {
+			case familytree.RelationTypeSpouse:
+				union := unions[key]
+				tree.People[i].Relations[j].Union = &union
+			case familytree.RelationTypeParent:
+				tree.People[i].Relations[j].Parentage = ParentageMapper(string(parentages[key]))
+			}
+		}
+	}
+	return nil
+}
+
+func (repo *FamilyTreeRepo) GetParentMaritalChildCount(ctx context.Context, tx familytree.Tx, person familytree.Person) (int, error) {
+	session, err := repo.getSession(tx)
 	if err != nil {
 		return 0, err
 	}
 	queryRaw := `
 	MATCH (father)-[:PARENT]->(:Person {uuid : $uuid})<-[:PARENT]-(mother)
-	MATCH (father)-[:SPOUSE]->(mother)
+	WHERE father.uuid < mother.uuid AND exists( (father)-[:SPOUSE]-(mother) )
 	MATCH (father)-[:PARENT]->(sibling:Person)<-[:PARENT]-(mother)
 	RETURN count(sibling)
 	`
@@ -369,19 +1156,23 @@ func (repo *FamilyTreeRepo) formatRelation(relationType familytree.RelationType)
 	return fmt.Sprintf(relationString, relationType)
 }
 
-func (repo *FamilyTreeRepo) DeleteRelationship(ctx context.Context, firstPerson familytree.Person, secondPerson familytree.Person, relationType familytree.RelationType) (bool, error) {
-	session, err := repo.getSessionFromContext(ctx)
+func (repo *FamilyTreeRepo) DeleteRelationship(ctx context.Context, tx familytree.Tx, firstPerson familytree.Person, secondPerson familytree.Person, relationType familytree.RelationType) (bool, error) {
+	session, err := repo.getWriteSession(tx)
 	if err != nil {
 		return false, err
 	}
 	queryRaw := fmt.Sprintf(`
 	MATCH (:Person {uuid : $first_uuid})%s(:Person {uuid : $second_uuid})
+	WITH r, startNode(r) AS top, endNode(r) AS bottom
+	CREATE (top)-[trashed:TRASHED]->(bottom)
+	SET trashed = properties(r), trashed.relationType = type(r), trashed.deletedAt = $deletedAt
 	DELETE r
 	RETURN count(r)
 	`, repo.formatRelation(relationType))
 	result, _, err := session.QueryRaw(ctx, queryRaw, map[string]interface{}{
 		"first_uuid":  firstPerson.ID.String(),
 		"second_uuid": secondPerson.ID.String(),
+		"deletedAt":   time.Now().UnixNano(),
 	})
 	if err != nil {
 		return false, err
@@ -395,23 +1186,468 @@ func (repo *FamilyTreeRepo) DeleteRelationship(ctx context.Context, firstPerson
 	}
 	return deletedItens > 0, nil
 }
-func (repo *FamilyTreeRepo) DeletePerson(ctx context.Context, person familytree.Person) error {
-	session, err := repo.getSessionFromContext(ctx)
+
+func (repo *FamilyTreeRepo) DeleteUnion(ctx context.Context, tx familytree.Tx, firstPerson familytree.Person, secondPerson familytree.Person, startDate familytree.Date) (bool, error) {
+	session, err := repo.getWriteSession(tx)
+	if err != nil {
+		return false, err
+	}
+	queryRaw := `
+	MATCH (:Person {uuid : $first_uuid})-[r:SPOUSE]-(:Person {uuid : $second_uuid})
+	WHERE coalesce(r.startDate, '') = $startDate
+	WITH r, startNode(r) AS top, endNode(r) AS bottom
+	CREATE (top)-[trashed:TRASHED]->(bottom)
+	SET trashed = properties(r), trashed.relationType = type(r), trashed.deletedAt = $deletedAt
+	DELETE r
+	RETURN count(r)
+	`
+	result, _, err := session.QueryRaw(ctx, queryRaw, map[string]interface{}{
+		"first_uuid":  firstPerson.ID.String(),
+		"second_uuid": secondPerson.ID.String(),
+		"startDate":   string(startDate),
+		"deletedAt":   time.Now().UnixNano(),
+	})
+	if err != nil {
+		return false, err
+	}
+	if len(result) == 0 {
+		return false, nil
+	}
+	deletedItens, ok := result[0][0].(int64)
+	if !ok {
+		return false, ErrInvalidSessionValue
+	}
+	return deletedItens > 0, nil
+}
+
+func (repo *FamilyTreeRepo) DeletePersonRelations(ctx context.Context, tx familytree.Tx, person familytree.Person) error {
+	session, err := repo.getWriteSession(tx)
+	if err != nil {
+		return err
+	}
+	queryRaw := `
+	MATCH (:Person {uuid : $uuid})-[relation:PARENT|SPOUSE]-()
+	WITH relation, startNode(relation) AS top, endNode(relation) AS bottom
+	CREATE (top)-[trashed:TRASHED]->(bottom)
+	SET trashed = properties(relation),
+		trashed.relationType = type(relation),
+		trashed.deletedAt = $deletedAt,
+		trashed.trashedWith = $uuid
+	DELETE relation
+	`
+	_, _, err = session.QueryRaw(ctx, queryRaw, map[string]interface{}{
+		"uuid":      person.ID.String(),
+		"deletedAt": time.Now().UnixNano(),
+	})
+	return err
+}
+
+// DeletePerson checks the relations of the person before trashing it. Trashed
+// people trade the Person label for the TrashedPerson one and trashed
+// relations are TRASHED relations keeping their type on relationType, so no
+// other query matches them.
+func (repo *FamilyTreeRepo) DeletePerson(ctx context.Context, tx familytree.Tx, person familytree.Person) error {
+	session, err := repo.getWriteSession(tx)
 	if err != nil {
 		return err
 	}
 	queryRaw := `
 	MATCH (person:Person {uuid : $uuid})
-	DELETE person
+	OPTIONAL MATCH (person)-[relation:PARENT|SPOUSE]-()
+	WITH person, count(relation) AS relations
+	FOREACH (_ IN CASE WHEN relations = 0 THEN [1] ELSE [] END |
+		REMOVE person:Person
+		SET person:TrashedPerson, person.deletedAt = $deletedAt)
+	RETURN relations
+	`
+	result, _, err := session.QueryRaw(ctx, queryRaw, map[string]interface{}{
+		"uuid":      person.ID.String(),
+		"deletedAt": time.Now().UnixNano(),
+	})
+	if err != nil {
+		return err
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	relations, ok := result[0][0].(int64)
+	if !ok {
+		return ErrInvalidSessionValue
+	}
+	if relations > 0 {
+		return familytree.ErrPersonStillHasRelations
+	}
+	return nil
+}
+
+// GetTrash keeps the relations trashed along with a person on its entry while
+// the person is in the trash.
+func (repo *FamilyTreeRepo) GetTrash(ctx context.Context, tx familytree.Tx) (*familytree.Trash, error) {
+	session, err := repo.getSession(tx)
+	if err != nil {
+		return nil, err
+	}
+	peopleQuery := `
+	MATCH (person:TrashedPerson)
+	RETURN properties(person), person.deletedAt
+	ORDER BY person.deletedAt DESC
+	`
+	result, _, err := session.QueryRaw(ctx, peopleQuery, nil)
+	if err != nil {
+		return nil, err
+	}
+	trash := &familytree.Trash{People: []familytree.TrashedPerson{}, Relations: []familytree.TrashedRelation{}}
+	entries := map[string]int{}
+	for _, row := range result {
+		properties, ok := row[0].(map[string]interface{})
+		if !ok {
+			return nil, ErrInvalidQueryResult
+		}
+		person, err := PersonPropertiesMapper(properties)
+		if err != nil {
+			return nil, err
+		}
+		deletedAt, _ := row[1].(int64)
+		entries[person.ID.String()] = len(trash.People)
+		trash.People = append(trash.People, familytree.TrashedPerson{
+			Person:    *person,
+			DeletedAt: time.Unix(0, deletedAt),
+			Relations: []familytree.PersonRelation{},
+		})
+	}
+
+	relationsQuery := `
+	MATCH (top)-[relation:TRASHED]->(bottom)
+	RETURN properties(top), properties(bottom), properties(relation)
+	ORDER BY relation.deletedAt DESC
+	`
+	result, _, err = session.QueryRaw(ctx, relationsQuery, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range result {
+		relation, trashedWith, err := TrashedRelationMapper(row[0], row[1], row[2])
+		if err != nil {
+			return nil, err
+		}
+		if entry, ok := entries[trashedWith]; ok {
+			trash.People[entry].Relations = append(trash.People[entry].Relations, relation.Relation)
+			continue
+		}
+		trash.Relations = append(trash.Relations, *relation)
+	}
+	return trash, nil
+}
+
+func (repo *FamilyTreeRepo) RestorePerson(ctx context.Context, tx familytree.Tx, personID uuid.UUID) (bool, error) {
+	session, err := repo.getWriteSession(tx)
+	if err != nil {
+		return false, err
+	}
+	queryRaw := `
+	MATCH (person:TrashedPerson {uuid : $uuid})
+	REMOVE person:TrashedPerson, person.deletedAt
+	SET person:Person
+	RETURN count(person)
+	`
+	result, _, err := session.QueryRaw(ctx, queryRaw, map[string]interface{}{
+		"uuid": personID.String(),
+	})
+	if err != nil {
+		return false, err
+	}
+	if len(result) == 0 {
+		return false, nil
+	}
+	restored, ok := result[0][0].(int64)
+	if !ok {
+		return false, ErrInvalidSessionValue
+	}
+	return restored > 0, nil
+}
+
+// RestoreRelationship recreates the relation with the type and the direction
+// it had, SPOUSE relations match in either direction.
+func (repo *FamilyTreeRepo) RestoreRelationship(ctx context.Context, tx familytree.Tx, firstPerson familytree.Person, secondPerson familytree.Person, relationType familytree.RelationType) (bool, error) {
+	session, err := repo.getWriteSession(tx)
+	if err != nil {
+		return false, err
+	}
+	// Relation types can't be parameters
+	queryRaw := fmt.Sprintf(`
+	MATCH (first:Person {uuid : $first_uuid})-[trashed:TRASHED {relationType : $relationType}]-(:Person {uuid : $second_uuid})
+	WHERE NOT $directional OR startNode(trashed) = first
+	WITH trashed, startNode(trashed) AS top, endNode(trashed) AS bottom
+	ORDER BY trashed.deletedAt DESC
+	LIMIT 1
+	CREATE (top)-[relation:%s]->(bottom)
+	SET relation = properties(trashed)
+	REMOVE relation.relationType, relation.deletedAt, relation.trashedWith
+	DELETE trashed
+	RETURN count(relation)
+	`, relationType.Name)
+	result, _, err := session.QueryRaw(ctx, queryRaw, map[string]interface{}{
+		"first_uuid":   firstPerson.ID.String(),
+		"second_uuid":  secondPerson.ID.String(),
+		"relationType": relationType.Name,
+		"directional":  relationType.Directional,
+	})
+	if err != nil {
+		return false, err
+	}
+	if len(result) == 0 {
+		return false, nil
+	}
+	restored, ok := result[0][0].(int64)
+	if !ok {
+		return false, ErrInvalidSessionValue
+	}
+	return restored > 0, nil
+}
+
+// PurgeTrash deletes the relations first, the trashed ones of the purged
+// people included, so the people have none left.
+func (repo *FamilyTreeRepo) PurgeTrash(ctx context.Context, tx familytree.Tx, before time.Time) (*familytree.TrashPurge, error) {
+	session, err := repo.getWriteSession(tx)
+	if err != nil {
+		return nil, err
+	}
+	relationsQuery := `
+	MATCH (top)-[relation:TRASHED]->(bottom)
+	WHERE relation.deletedAt < $before
+		OR (top:TrashedPerson AND top.deletedAt < $before)
+		OR (bottom:TrashedPerson AND bottom.deletedAt < $before)
+	DELETE relation
+	RETURN count(relation)
+	`
+	peopleQuery := `
+	MATCH (person:TrashedPerson)
+	WHERE person.deletedAt < $before
+	DETACH DELETE person
+	RETURN count(person)
+	`
+	purge := &familytree.TrashPurge{}
+	for _, query := range []struct {
+		queryRaw string
+		count    *int
+	}{
+		{relationsQuery, &purge.Relations},
+		{peopleQuery, &purge.People},
+	} {
+		result, _, err := session.QueryRaw(ctx, query.queryRaw, map[string]interface{}{
+			"before": before.UnixNano(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(result) == 0 {
+			continue
+		}
+		count, ok := result[0][0].(int64)
+		if !ok {
+			return nil, ErrInvalidSessionValue
+		}
+		*query.count = int(count)
+	}
+	return purge, nil
+}
+
+// SaveRedirect keeps the redirects on PersonRedirect nodes, apart from the
+// Person ones.
+func (repo *FamilyTreeRepo) SaveRedirect(ctx context.Context, tx familytree.Tx, oldID uuid.UUID, newID uuid.UUID) error {
+	session, err := repo.getWriteSession(tx)
+	if err != nil {
+		return err
+	}
+	queryRaw := `
+	OPTIONAL MATCH (previous:PersonRedirect {target : $old_uuid})
+	SET previous.target = $new_uuid
+	WITH count(previous) AS moved
+	MERGE (redirect:PersonRedirect {uuid : $old_uuid})
+	SET redirect.target = $new_uuid
 	`
 	_, _, err = session.QueryRaw(ctx, queryRaw, map[string]interface{}{
-		"uuid": person.ID.String(),
+		"old_uuid": oldID.String(),
+		"new_uuid": newID.String(),
 	})
+	return err
+}
+
+func (repo *FamilyTreeRepo) GetRedirect(ctx context.Context, tx familytree.Tx, personID uuid.UUID) (uuid.UUID, bool, error) {
+	session, err := repo.getSession(tx)
 	if err != nil {
-		if strings.Contains(err.Error(), NodeConstraintMessage) {
-			return familytree.ErrPersonStillHasRelations
+		return uuid.Nil, false, err
+	}
+	queryRaw := `
+	MATCH (redirect:PersonRedirect {uuid : $uuid})
+	RETURN redirect.target
+	`
+	result, _, err := session.QueryRaw(ctx, queryRaw, map[string]interface{}{
+		"uuid": personID.String(),
+	})
+	if err != nil {
+		return uuid.Nil, false, err
+	}
+	if len(result) == 0 {
+		return uuid.Nil, false, nil
+	}
+	target, ok := result[0][0].(string)
+	if !ok {
+		return uuid.Nil, false, ErrInvalidQueryResult
+	}
+	newID, err := uuid.Parse(target)
+	if err != nil {
+		return uuid.Nil, false, err
+	}
+	return newID, true, nil
+}
+
+// GetDuplicateCandidates groups the people by their blockingKey instead of
+// comparing every pair, people saved before it existed only meet through their
+// parents and spouses until they are updated.
+func (repo *FamilyTreeRepo) GetDuplicateCandidates(ctx context.Context, tx familytree.Tx) ([]familytree.DuplicateCandidate, error) {
+	session, err := repo.getSession(tx)
+	if err != nil {
+		return nil, err
+	}
+	queryRaw := `
+	CALL {
+		MATCH (person:Person) WHERE person.blockingKey IS NOT NULL AND person.blockingKey <> ""
+		WITH person.blockingKey AS key, collect(person) AS block
+		WHERE size(block) > 1
+		UNWIND range(0, size(block) - 2) AS i
+		UNWIND range(i + 1, size(block) - 1) AS j
+		RETURN block[i] AS first, block[j] AS second
+		UNION
+		MATCH (first:Person)<-[:PARENT]-(:Person)-[:PARENT]->(second:Person)
+		RETURN first, second
+		UNION
+		MATCH (first:Person)-[:SPOUSE]-(:Person)-[:SPOUSE]-(second:Person)
+		RETURN first, second
+	}
+	WITH first, second, coalesce(first.createdAt, 0) AS firstCreated, coalesce(second.createdAt, 0) AS secondCreated
+	WHERE first.uuid <> second.uuid
+	WITH CASE WHEN firstCreated < secondCreated OR (firstCreated = secondCreated AND first.uuid < second.uuid)
+		THEN [first, second] ELSE [second, first] END AS pair
+	WITH DISTINCT pair[0] AS first, pair[1] AS second
+	OPTIONAL MATCH (first)<-[:PARENT]-(parent:Person)-[:PARENT]->(second)
+	WITH first, second, count(DISTINCT parent) AS sharedParents
+	OPTIONAL MATCH (first)-[:SPOUSE]-(spouse:Person)-[:SPOUSE]-(second)
+	WITH first, second, sharedParents, count(DISTINCT spouse) AS sharedSpouses,
+		coalesce(first.createdAt, 0) AS firstCreated, first.uuid AS firstID,
+		coalesce(second.createdAt, 0) AS secondCreated, second.uuid AS secondID
+	RETURN properties(first), properties(second), sharedParents, sharedSpouses
+	ORDER BY firstCreated, firstID, secondCreated, secondID
+	`
+	result, _, err := session.QueryRaw(ctx, queryRaw, nil)
+	if err != nil {
+		return nil, err
+	}
+	candidates := make([]familytree.DuplicateCandidate, 0, len(result))
+	for _, row := range result {
+		firstProperties, firstOk := row[0].(map[string]interface{})
+		secondProperties, secondOk := row[1].(map[string]interface{})
+		sharedParents, parentsOk := row[2].(int64)
+		sharedSpouses, spousesOk := row[3].(int64)
+		if !firstOk || !secondOk || !parentsOk || !spousesOk {
+			return nil, ErrInvalidQueryResult
 		}
+		first, err := PersonPropertiesMapper(firstProperties)
+		if err != nil {
+			return nil, err
+		}
+		second, err := PersonPropertiesMapper(secondProperties)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, familytree.DuplicateCandidate{
+			First:         *first,
+			Second:        *second,
+			SharedParents: int(sharedParents),
+			SharedSpouses: int(sharedSpouses),
+		})
+	}
+	return candidates, nil
+}
+
+// AppendAuditEvents writes the events as AuditEvent nodes out of the graph of
+// people, so deleted and merged people keep their history.
+func (repo *FamilyTreeRepo) AppendAuditEvents(ctx context.Context, tx familytree.Tx, events ...familytree.AuditEvent) error {
+	session, err := repo.getWriteSession(tx)
+	if err != nil {
 		return err
 	}
-	return nil
+	rawEvents := make([]interface{}, 0, len(events))
+	for _, event := range events {
+		properties, err := AuditEventProperties(event)
+		if err != nil {
+			return err
+		}
+		rawEvents = append(rawEvents, properties)
+	}
+	queryRaw := `
+	UNWIND $events AS properties
+	CREATE (event:AuditEvent)
+	SET event = properties
+	`
+	_, _, err = session.QueryRaw(ctx, queryRaw, map[string]interface{}{
+		"events": rawEvents,
+	})
+	return err
+}
+
+func (repo *FamilyTreeRepo) GetAuditEvents(ctx context.Context, tx familytree.Tx, personID uuid.UUID, pagination familytree.PaginationDetails) (*familytree.AuditEventList, error) {
+	session, err := repo.getSession(tx)
+	if err != nil {
+		return nil, err
+	}
+	params := map[string]interface{}{
+		"uuid":  personID.String(),
+		"skip":  pagination.Page * pagination.PageSize,
+		"limit": pagination.PageSize,
+	}
+	countQuery := `
+	MATCH (event:AuditEvent) WHERE $uuid IN event.peopleIDs
+	RETURN count(event)
+	`
+	result, _, err := session.QueryRaw(ctx, countQuery, params)
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, ErrInvalidQueryResult
+	}
+	totalItens, ok := result[0][0].(int64)
+	if !ok {
+		return nil, ErrInvalidQueryResult
+	}
+	list := &familytree.AuditEventList{
+		Content: []familytree.AuditEvent{},
+		Metadata: familytree.ListMetadata{
+			TotalItens: int(totalItens),
+			Page:       pagination.Page,
+		},
+	}
+	if pagination.PageSize <= 0 {
+		return list, nil
+	}
+
+	queryRaw := `
+	MATCH (event:AuditEvent) WHERE $uuid IN event.peopleIDs
+	RETURN properties(event)
+	ORDER BY event.timestamp DESC
+	SKIP $skip LIMIT $limit
+	`
+	result, _, err = session.QueryRaw(ctx, queryRaw, params)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range result {
+		event, err := AuditEventMapper(row[0])
+		if err != nil {
+			return nil, err
+		}
+		list.Content = append(list.Content, *event)
+	}
+	return list, nil
 }
@@ -0,0 +1,61 @@
+package familytreerepo
+
+import (
+	"context"
+	"family-tree/internal/core/familytree"
+	"family-tree/internal/core/familytree/familytreetest"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/mindstand/gogm/v2"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// The suite needs a disposable Neo4j, every Person, TrashedPerson,
+// PersonRedirect and AuditEvent node is deleted between tests. It only runs when
+// GOGM_TEST_HOST is set, e.g.:
+//
+//	GOGM_TEST_HOST=localhost GOGM_TEST_USERNAME=neo4j GOGM_TEST_PASSWORD=sandbox go test ./...
+func setupTestGogm(t *testing.T) *gogm.Gogm {
+	host := os.Getenv("GOGM_TEST_HOST")
+	if host == "" {
+		t.Skip("GOGM_TEST_HOST isn't set, skipping Neo4j tests")
+	}
+	port, err := strconv.Atoi(os.Getenv("GOGM_TEST_PORT"))
+	if err != nil {
+		port = 7687
+	}
+	_gogm, err := gogm.New(&gogm.Config{
+		Host:          host,
+		Port:          port,
+		Username:      os.Getenv("GOGM_TEST_USERNAME"),
+		Password:      os.Getenv("GOGM_TEST_PASSWORD"),
+		PoolSize:      10,
+		IndexStrategy: gogm.IGNORE_INDEX,
+	}, gogm.UUIDPrimaryKeyStrategy, &Person{})
+	if err != nil {
+		t.Fatalf("couldn't connect to Neo4j: %v", err)
+	}
+	return _gogm
+}
+
+func cleanDatabase(t *testing.T, _gogm *gogm.Gogm) {
+	session, err := _gogm.NewSessionV2(gogm.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	if err != nil {
+		t.Fatalf("couldn't open session: %v", err)
+	}
+	defer session.Close()
+	if _, _, err := session.QueryRaw(context.Background(), "MATCH (node) WHERE node:Person OR node:TrashedPerson OR node:PersonRedirect OR node:AuditEvent DETACH DELETE node", nil); err != nil {
+		t.Fatalf("couldn't clean database: %v", err)
+	}
+}
+
+func TestFamilyTreeRepo(t *testing.T) {
+	_gogm := setupTestGogm(t)
+	cleanDatabase(t, _gogm)
+	familytreetest.RunSuite(t, func(t *testing.T) familytree.FamilyTreeRepo {
+		t.Cleanup(func() { cleanDatabase(t, _gogm) })
+		return NewFamilyTreeRepo(_gogm)
+	})
+}
@@ -0,0 +1,121 @@
+package jsonlaudit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"family-tree/internal/core/familytree"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// AuditSink appends the audit events to a local file, one JSON event per line.
+// The file can't follow the Txs of the repo, so the events are only written
+// once their Tx is committed, and the events of Txs rolled back or retried are
+// never written. A commit may still succeed without its events when the write
+// fails, the failure is only logged.
+type AuditSink struct {
+	mutex sync.Mutex
+	path  string
+	file  *os.File
+}
+
+// NewAuditSink opens the file for appending, it's created when it doesn't
+// exist.
+func NewAuditSink(path string) (*AuditSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &AuditSink{path: path, file: file}, nil
+}
+
+func (sink *AuditSink) Close() error {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+	return sink.file.Close()
+}
+
+// AppendAuditEvents writes all the events with a single write after the Tx
+// is committed, so a failure doesn't leave only some of them in the file. The
+// events aren't in the file before the commit, not even for the Tx itself.
+func (sink *AuditSink) AppendAuditEvents(ctx context.Context, tx familytree.Tx, events ...familytree.AuditEvent) error {
+	var lines []byte
+	for _, event := range events {
+		line, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		lines = append(append(lines, line...), '\n')
+	}
+	tx.OnCommit(func() {
+		if err := sink.write(lines); err != nil {
+			log.Printf("audit events lost, writing %s failed: %v", sink.path, err)
+		}
+	})
+	return nil
+}
+
+func (sink *AuditSink) write(lines []byte) error {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+	if _, err := sink.file.Write(lines); err != nil {
+		return err
+	}
+	return sink.file.Sync()
+}
+
+// GetAuditEvents scans the whole file on each call, the history of a person is
+// the events of the file naming it, from the last line up.
+func (sink *AuditSink) GetAuditEvents(ctx context.Context, tx familytree.Tx, personID uuid.UUID, pagination familytree.PaginationDetails) (*familytree.AuditEventList, error) {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+	file, err := os.Open(sink.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	history := []familytree.AuditEvent{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		event := familytree.AuditEvent{}
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, err
+		}
+		for _, eventPersonID := range event.PeopleIDs {
+			if eventPersonID == personID {
+				history = append(history, event)
+				break
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(history)-1; i < j; i, j = i+1, j-1 {
+		history[i], history[j] = history[j], history[i]
+	}
+
+	list := &familytree.AuditEventList{
+		Content: []familytree.AuditEvent{},
+		Metadata: familytree.ListMetadata{
+			TotalItens: len(history),
+			Page:       pagination.Page,
+		},
+	}
+	start := pagination.Page * pagination.PageSize
+	if start >= len(history) || pagination.PageSize <= 0 {
+		return list, nil
+	}
+	end := start + pagination.PageSize
+	if end > len(history) {
+		end = len(history)
+	}
+	list.Content = history[start:end]
+	return list, nil
+}
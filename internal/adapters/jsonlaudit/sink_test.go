@@ -0,0 +1,22 @@
+package jsonlaudit
+
+import (
+	"family-tree/internal/adapters/memoryrepo"
+	"family-tree/internal/core/familytree"
+	"family-tree/internal/core/familytree/familytreetest"
+	"path/filepath"
+	"testing"
+)
+
+func TestAuditSink(t *testing.T) {
+	familytreetest.RunAuditSuite(t, func(t *testing.T) familytree.FamilyTreeRepo {
+		return memoryrepo.NewFamilyTreeRepo()
+	}, func(t *testing.T, repo familytree.FamilyTreeRepo) familytree.AuditSink {
+		sink, err := NewAuditSink(filepath.Join(t.TempDir(), "audit.jsonl"))
+		if err != nil {
+			t.Fatalf("NewAuditSink returned error: %v", err)
+		}
+		t.Cleanup(func() { sink.Close() })
+		return sink
+	})
+}
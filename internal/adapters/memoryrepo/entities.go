@@ -0,0 +1,72 @@
+package memoryrepo
+
+import (
+	"errors"
+	"family-tree/internal/core/familytree"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidSessionMode = errors.New("invalid session mode")
+	ErrInvalidTx          = errors.New("invalid transaction")
+)
+
+// Tx of WRITE mode hold the repo mutex from their begin to their end and keep
+// how to undo each of their changes, the undos run backwards on rollback. READ
+// Txs take the mutex on each query.
+type Tx struct {
+	repo    *FamilyTreeRepo
+	mode    familytree.SessionMode
+	done    bool
+	undos   []func()
+	commits []func()
+}
+
+type Relation struct {
+	Top          uuid.UUID
+	Bottom       uuid.UUID
+	RelationType familytree.RelationType
+	Union        familytree.Union
+	Parentage    familytree.Parentage
+}
+
+// TrashedPerson Created is the creation order of the person, kept for its
+// restore.
+type TrashedPerson struct {
+	Person    familytree.Person
+	DeletedAt time.Time
+	Created   int64
+}
+
+// TrashedRelation TrashedWith is the person the relation was trashed along
+// with, uuid.Nil when it was deleted on its own.
+type TrashedRelation struct {
+	Relation
+	DeletedAt   time.Time
+	TrashedWith uuid.UUID
+}
+
+func (relation Relation) connects(firstID uuid.UUID, secondID uuid.UUID) bool {
+	if relation.Top == firstID && relation.Bottom == secondID {
+		return true
+	}
+	return !relation.RelationType.Directional && relation.Top == secondID && relation.Bottom == firstID
+}
+
+func (relation Relation) other(personID uuid.UUID) uuid.UUID {
+	if relation.Top == personID {
+		return relation.Bottom
+	}
+	return relation.Top
+}
+
+func validateSessionMode(mode familytree.SessionMode) error {
+	switch mode {
+	case familytree.SessionRead, familytree.SessionWrite:
+		return nil
+	default:
+		return ErrInvalidSessionMode
+	}
+}
@@ -0,0 +1,1344 @@
+package memoryrepo
+
+import (
+	"context"
+	"family-tree/internal/core/familytree"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func NewFamilyTreeRepo() *FamilyTreeRepo {
+	return &FamilyTreeRepo{
+		people:        make(map[uuid.UUID]familytree.Person),
+		nameKeys:      make(map[uuid.UUID]familytree.NameKeys),
+		created:       make(map[uuid.UUID]int64),
+		redirects:     make(map[uuid.UUID]uuid.UUID),
+		trashedPeople: make(map[uuid.UUID]TrashedPerson),
+	}
+}
+
+// FamilyTreeRepo keeps the whole graph in memory. People are kept in insertion
+// order and relations are stored as directed edges from Top to Bottom, exactly
+// as they are created on Neo4j, so the undirected SPOUSE edge keeps the
+// direction it was saved with. The search keys of every name are kept along
+// with the people, so searches only compare them, and so is the creation
+// order the people lists are sorted and paged by. Merged people are kept as
+// redirects from their ID to the ID they were merged into. Deleted people and
+// relations are moved to the trash. The repo is an audit sink as well, its
+// events are kept in the order they were appended.
+type FamilyTreeRepo struct {
+	mutex       sync.RWMutex
+	people      map[uuid.UUID]familytree.Person
+	peopleOrder []uuid.UUID
+	nameKeys    map[uuid.UUID]familytree.NameKeys
+	created     map[uuid.UUID]int64
+	creations   int64
+	relations   []Relation
+	redirects   map[uuid.UUID]uuid.UUID
+	// Trashed people and relations are out of the graph, so the queries don't
+	// see them
+	trashedPeople    map[uuid.UUID]TrashedPerson
+	trashedRelations []TrashedRelation
+	auditEvents      []familytree.AuditEvent
+}
+
+// Begin takes the mutex until WRITE Txs end, so they run one at a time and
+// nobody reads their changes before the commit.
+func (repo *FamilyTreeRepo) Begin(ctx context.Context, mode familytree.SessionMode) (familytree.Tx, error) {
+	if err := validateSessionMode(mode); err != nil {
+		return nil, err
+	}
+	if mode == familytree.SessionWrite {
+		repo.mutex.Lock()
+	}
+	return &Tx{repo: repo, mode: mode}, nil
+}
+
+func (tx *Tx) Mode() familytree.SessionMode {
+	return tx.mode
+}
+
+// Commit runs the hooks of the Tx once the mutex is released, so they don't
+// hold the other Txs.
+func (tx *Tx) Commit(ctx context.Context) error {
+	if tx.done {
+		return familytree.ErrTxDone
+	}
+	hooks := tx.commits
+	tx.end()
+	for _, hook := range hooks {
+		hook()
+	}
+	return nil
+}
+
+func (tx *Tx) Rollback(ctx context.Context) error {
+	if tx.done {
+		return nil
+	}
+	for i := len(tx.undos) - 1; i >= 0; i-- {
+		tx.undos[i]()
+	}
+	tx.end()
+	return nil
+}
+
+func (tx *Tx) end() {
+	tx.done = true
+	tx.undos = nil
+	tx.commits = nil
+	if tx.mode == familytree.SessionWrite {
+		tx.repo.mutex.Unlock()
+	}
+}
+
+func (tx *Tx) OnCommit(hook func()) {
+	tx.commits = append(tx.commits, hook)
+}
+
+// onRollback keeps how to undo a change.
+func (tx *Tx) onRollback(undo func()) {
+	tx.undos = append(tx.undos, undo)
+}
+
+func (repo *FamilyTreeRepo) getTx(tx familytree.Tx) (*Tx, error) {
+	memoryTx, ok := tx.(*Tx)
+	if !ok || memoryTx.repo != repo {
+		return nil, ErrInvalidTx
+	}
+	if memoryTx.done {
+		return nil, familytree.ErrTxDone
+	}
+	return memoryTx, nil
+}
+
+func (repo *FamilyTreeRepo) getWriteTx(tx familytree.Tx) (*Tx, error) {
+	memoryTx, err := repo.getTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	if memoryTx.mode != familytree.SessionWrite {
+		return nil, familytree.ErrReadOnlyTx
+	}
+	return memoryTx, nil
+}
+
+// readLock takes the mutex for a single query of a READ Tx, WRITE Txs already
+// hold it, and returns how to release it.
+func (repo *FamilyTreeRepo) readLock(memoryTx *Tx) func() {
+	if memoryTx.mode == familytree.SessionWrite {
+		return func() {}
+	}
+	repo.mutex.RLock()
+	return repo.mutex.RUnlock
+}
+
+// LockPeople has nothing to lock, WRITE Txs already hold the whole repo.
+func (repo *FamilyTreeRepo) LockPeople(ctx context.Context, tx familytree.Tx, peopleIDs ...uuid.UUID) error {
+	_, err := repo.getWriteTx(tx)
+	return err
+}
+
+// IsTransientError is always false, Txs wait for each other instead of
+// failing.
+func (repo *FamilyTreeRepo) IsTransientError(err error) bool {
+	return false
+}
+
+func (repo *FamilyTreeRepo) SavePerson(ctx context.Context, tx familytree.Tx, person *familytree.Person) error {
+	memoryTx, err := repo.getWriteTx(tx)
+	if err != nil {
+		return err
+	}
+
+	person.ID = uuid.New()
+	repo.people[person.ID] = *person
+	repo.peopleOrder = append(repo.peopleOrder, person.ID)
+	repo.nameKeys[person.ID] = familytree.NewNameKeys(person.Name)
+	repo.creations++
+	repo.created[person.ID] = repo.creations
+	personID := person.ID
+	memoryTx.onRollback(func() {
+		delete(repo.people, personID)
+		delete(repo.nameKeys, personID)
+		delete(repo.created, personID)
+		repo.peopleOrder = repo.peopleOrder[:len(repo.peopleOrder)-1]
+		repo.creations--
+	})
+	return nil
+}
+
+func (repo *FamilyTreeRepo) UpdatePerson(ctx context.Context, tx familytree.Tx, person *familytree.Person) error {
+	memoryTx, err := repo.getWriteTx(tx)
+	if err != nil {
+		return err
+	}
+
+	previous, ok := repo.people[person.ID]
+	if !ok {
+		return familytree.ErrPersonNotFound
+	}
+	previousKeys := repo.nameKeys[person.ID]
+	memoryTx.onRollback(func() {
+		repo.people[previous.ID] = previous
+		repo.nameKeys[previous.ID] = previousKeys
+	})
+	if previous.Name != person.Name {
+		repo.nameKeys[person.ID] = familytree.NewNameKeys(person.Name)
+	}
+	repo.people[person.ID] = *person
+	return nil
+}
+
+func (repo *FamilyTreeRepo) GetPerson(ctx context.Context, tx familytree.Tx, personID uuid.UUID) (*familytree.Person, error) {
+	memoryTx, err := repo.getTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	defer repo.readLock(memoryTx)()
+
+	return repo.getPerson(personID), nil
+}
+
+func (repo *FamilyTreeRepo) getPerson(personID uuid.UUID) *familytree.Person {
+	person, ok := repo.people[personID]
+	if !ok {
+		return nil
+	}
+	return &person
+}
+
+func (repo *FamilyTreeRepo) getPeople(peopleIDs ...uuid.UUID) []*familytree.Person {
+	people := make([]*familytree.Person, 0, len(peopleIDs))
+	for _, personID := range peopleIDs {
+		people = append(people, repo.getPerson(personID))
+	}
+	return people
+}
+
+func (repo *FamilyTreeRepo) SaveRelation(ctx context.Context, tx familytree.Tx, relation familytree.PersonRelation) error {
+	memoryTx, err := repo.getWriteTx(tx)
+	if err != nil {
+		return err
+	}
+
+	_, topExists := repo.people[relation.Top.ID]
+	_, bottomExists := repo.people[relation.Bottom.ID]
+	if !topExists || !bottomExists {
+		// Same as the MATCH ... CREATE query, nothing is created if one of the people is missing
+		return nil
+	}
+	newRelation := Relation{
+		Top:          relation.Top.ID,
+		Bottom:       relation.Bottom.ID,
+		RelationType: relation.RelationType,
+		Union:        relation.Union,
+	}
+	if relation.RelationType == familytree.RelationTypeParent {
+		newRelation.Parentage = relation.Parentage
+		if newRelation.Parentage == "" {
+			newRelation.Parentage = familytree.ParentageBiological
+		}
+	}
+	repo.relations = append(repo.relations, newRelation)
+	memoryTx.onRollback(func() {
+		repo.relations = repo.relations[:len(repo.relations)-1]
+	})
+	return nil
+}
+
+func (repo *FamilyTreeRepo) parentIDs(personID uuid.UUID) []uuid.UUID {
+	parents := []uuid.UUID{}
+	for _, relation := range repo.relations {
+		if relation.RelationType == familytree.RelationTypeParent && relation.Bottom == personID {
+			parents = append(parents, relation.Top)
+		}
+	}
+	return parents
+}
+
+func (repo *FamilyTreeRepo) biologicalParentIDs(personID uuid.UUID) []uuid.UUID {
+	parents := []uuid.UUID{}
+	for _, relation := range repo.relations {
+		if relation.RelationType == familytree.RelationTypeParent && relation.Bottom == personID && relation.Parentage.IsBiological() {
+			parents = append(parents, relation.Top)
+		}
+	}
+	return parents
+}
+
+func (repo *FamilyTreeRepo) childIDs(personID uuid.UUID) []uuid.UUID {
+	children := []uuid.UUID{}
+	for _, relation := range repo.relations {
+		if relation.RelationType == familytree.RelationTypeParent && relation.Top == personID {
+			children = append(children, relation.Bottom)
+		}
+	}
+	return children
+}
+
+// ancestorDistances returns every ancestor of the person, including the person
+// itself at distance 0, with the length of the shortest PARENT path to it.
+// The order slice keeps the breadth first visiting order. Parents are listed
+// by parentIDs, so the lineage may be restricted to biological parents.
+func (repo *FamilyTreeRepo) ancestorDistances(personID uuid.UUID, parentIDs func(uuid.UUID) []uuid.UUID) (map[uuid.UUID]int, []uuid.UUID) {
+	distances := map[uuid.UUID]int{personID: 0}
+	order := []uuid.UUID{personID}
+	for i := 0; i < len(order); i++ {
+		current := order[i]
+		for _, parentID := range parentIDs(current) {
+			if _, ok := distances[parentID]; ok {
+				continue
+			}
+			distances[parentID] = distances[current] + 1
+			order = append(order, parentID)
+		}
+	}
+	return distances, order
+}
+
+func (repo *FamilyTreeRepo) descendantIDs(personID uuid.UUID) map[uuid.UUID]bool {
+	descendants := map[uuid.UUID]bool{personID: true}
+	queue := []uuid.UUID{personID}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, childID := range repo.childIDs(current) {
+			if descendants[childID] {
+				continue
+			}
+			descendants[childID] = true
+			queue = append(queue, childID)
+		}
+	}
+	return descendants
+}
+
+func (repo *FamilyTreeRepo) GetParents(ctx context.Context, tx familytree.Tx, personID uuid.UUID) ([]familytree.PersonParent, error) {
+	memoryTx, err := repo.getTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	defer repo.readLock(memoryTx)()
+
+	var parents []familytree.PersonParent
+	for _, relation := range repo.relations {
+		if relation.RelationType == familytree.RelationTypeParent && relation.Bottom == personID {
+			parents = append(parents, familytree.PersonParent{
+				Parent:    *repo.getPerson(relation.Top),
+				Parentage: relation.Parentage,
+			})
+		}
+	}
+	return parents, nil
+}
+
+func (repo *FamilyTreeRepo) GetLowestCommonAncestor(ctx context.Context, tx familytree.Tx, firstPerson familytree.Person, secondPerson familytree.Person) (*familytree.Person, error) {
+	memoryTx, err := repo.getTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	defer repo.readLock(memoryTx)()
+
+	if _, ok := repo.people[firstPerson.ID]; !ok {
+		return nil, nil
+	}
+	if _, ok := repo.people[secondPerson.ID]; !ok {
+		return nil, nil
+	}
+	_, firstAncestors := repo.ancestorDistances(firstPerson.ID, repo.biologicalParentIDs)
+	secondAncestors, _ := repo.ancestorDistances(secondPerson.ID, repo.biologicalParentIDs)
+	// Breadth first order is already sorted by the distance to the first person
+	for _, ancestorID := range firstAncestors {
+		if _, ok := secondAncestors[ancestorID]; ok {
+			return repo.getPerson(ancestorID), nil
+		}
+	}
+	return nil, nil
+}
+
+func (repo *FamilyTreeRepo) GetCommonAncestors(ctx context.Context, tx familytree.Tx, firstPerson familytree.Person, secondPerson familytree.Person) ([]familytree.CommonAncestor, error) {
+	memoryTx, err := repo.getTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	defer repo.readLock(memoryTx)()
+
+	ancestors := []familytree.CommonAncestor{}
+	if _, ok := repo.people[firstPerson.ID]; !ok {
+		return ancestors, nil
+	}
+	if _, ok := repo.people[secondPerson.ID]; !ok {
+		return ancestors, nil
+	}
+	firstDistances, firstAncestors := repo.ancestorDistances(firstPerson.ID, repo.biologicalParentIDs)
+	secondDistances, _ := repo.ancestorDistances(secondPerson.ID, repo.biologicalParentIDs)
+	for _, ancestorID := range firstAncestors {
+		if secondDistance, ok := secondDistances[ancestorID]; ok {
+			ancestors = append(ancestors, familytree.CommonAncestor{
+				Ancestor:       *repo.getPerson(ancestorID),
+				FirstDistance:  firstDistances[ancestorID],
+				SecondDistance: secondDistance,
+			})
+		}
+	}
+	return ancestors, nil
+}
+
+// filteredPeople returns the ids of the people passing the filter in creation
+// order.
+func (repo *FamilyTreeRepo) filteredPeople(filter familytree.PeopleFilter) []uuid.UUID {
+	if filter == (familytree.PeopleFilter{}) {
+		return append([]uuid.UUID{}, repo.peopleOrder...)
+	}
+	children := map[uuid.UUID]bool{}
+	for _, relation := range repo.relations {
+		if relation.RelationType == familytree.RelationTypeParent {
+			children[relation.Bottom] = true
+		}
+	}
+	peopleIDs := []uuid.UUID{}
+	for _, personID := range repo.peopleOrder {
+		if filter.Matches(repo.people[personID], children[personID]) {
+			peopleIDs = append(peopleIDs, personID)
+		}
+	}
+	return peopleIDs
+}
+
+func (repo *FamilyTreeRepo) sortKey(personID uuid.UUID, peopleSort familytree.PeopleSort) familytree.PeopleSortKey {
+	person := repo.people[personID]
+	key := familytree.PeopleSortKey{Name: person.Name, ID: person.ID}
+	switch peopleSort.Field {
+	case familytree.PeopleSortCreatedAt:
+		key.Number = repo.created[personID]
+	case familytree.PeopleSortBirthDate:
+		key.Text = familytree.BirthSortKey(person.BirthDate)
+	default:
+		key.Text = person.Name
+	}
+	return key
+}
+
+// GetPeople sorts the people the same way the ORDER BY clauses used on Neo4j
+// do and pages them by offset or around a key.
+func (repo *FamilyTreeRepo) GetPeople(ctx context.Context, tx familytree.Tx, filter familytree.PeopleFilter, peopleSort familytree.PeopleSort, pagination familytree.PaginationDetails) (*familytree.PeopleList, error) {
+	memoryTx, err := repo.getTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	defer repo.readLock(memoryTx)()
+
+	peopleIDs := repo.filteredPeople(filter)
+	keys := make(map[uuid.UUID]familytree.PeopleSortKey, len(peopleIDs))
+	for _, personID := range peopleIDs {
+		keys[personID] = repo.sortKey(personID, peopleSort)
+	}
+	sort.SliceStable(peopleIDs, func(i, j int) bool {
+		return peopleSort.Compare(keys[peopleIDs[i]], keys[peopleIDs[j]]) < 0
+	})
+	peopleList := &familytree.PeopleList{
+		Content: []*familytree.Person{},
+		Metadata: familytree.ListMetadata{
+			TotalItens: len(peopleIDs),
+			Page:       pagination.Page,
+		},
+	}
+	if pagination.PageSize <= 0 {
+		return peopleList, nil
+	}
+	var start, end int
+	switch {
+	case pagination.After != nil:
+		start = sort.Search(len(peopleIDs), func(i int) bool {
+			return peopleSort.Compare(keys[peopleIDs[i]], *pagination.After) > 0
+		})
+		end = start + pagination.PageSize
+	case pagination.Before != nil:
+		end = sort.Search(len(peopleIDs), func(i int) bool {
+			return peopleSort.Compare(keys[peopleIDs[i]], *pagination.Before) >= 0
+		})
+		start = end - pagination.PageSize
+	default:
+		start = pagination.Page * pagination.PageSize
+		end = start + pagination.PageSize
+	}
+	if start < 0 {
+		start = 0
+	}
+	if end > len(peopleIDs) {
+		end = len(peopleIDs)
+	}
+	if start >= end {
+		return peopleList, nil
+	}
+	peopleList.Content = repo.getPeople(peopleIDs[start:end]...)
+	if end < len(peopleIDs) {
+		next := keys[peopleIDs[end-1]]
+		peopleList.Metadata.Next = &next
+	}
+	if start > 0 {
+		prev := keys[peopleIDs[start]]
+		peopleList.Metadata.Prev = &prev
+	}
+	return peopleList, nil
+}
+
+type nameMatch struct {
+	personID uuid.UUID
+	score    float64
+	match    familytree.NameMatch
+}
+
+func (repo *FamilyTreeRepo) SearchPeople(ctx context.Context, tx familytree.Tx, query string, filter familytree.PeopleFilter, pagination familytree.PaginationDetails) (*familytree.PeopleMatchList, error) {
+	memoryTx, err := repo.getTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	defer repo.readLock(memoryTx)()
+
+	queryKeys := familytree.NewNameKeys(query)
+	// People are only copied for the page, the matches just point to them
+	matches := []nameMatch{}
+	for _, personID := range repo.filteredPeople(filter) {
+		score, match, ok := queryKeys.MatchName(repo.nameKeys[personID])
+		if !ok {
+			continue
+		}
+		matches = append(matches, nameMatch{personID: personID, score: score, match: match})
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	matchList := &familytree.PeopleMatchList{
+		Content: []familytree.PersonMatch{},
+		Metadata: familytree.ListMetadata{
+			TotalItens: len(matches),
+			Page:       pagination.Page,
+		},
+	}
+	start := pagination.Page * pagination.PageSize
+	if start >= len(matches) || pagination.PageSize <= 0 {
+		return matchList, nil
+	}
+	end := start + pagination.PageSize
+	if end > len(matches) {
+		end = len(matches)
+	}
+	for _, match := range matches[start:end] {
+		matchList.Content = append(matchList.Content, familytree.PersonMatch{
+			Person: repo.people[match.personID],
+			Score:  match.score,
+			Match:  match.match,
+		})
+	}
+	return matchList, nil
+}
+
+// GetFamilyTree follows the same unions of the Cypher query used on Neo4j:
+// every ancestor with the PARENT relations leading to the person, the SPOUSE
+// relations between ancestors, every descendant, the siblings and the nephews.
+func (repo *FamilyTreeRepo) GetFamilyTree(ctx context.Context, tx familytree.Tx, person familytree.Person) (*familytree.FamilyTree, error) {
+	memoryTx, err := repo.getTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	defer repo.readLock(memoryTx)()
+
+	if _, ok := repo.people[person.ID]; !ok {
+		return &familytree.FamilyTree{People: []familytree.FamilyTreeNode{}}, nil
+	}
+	ancestors, _ := repo.ancestorDistances(person.ID, repo.parentIDs)
+	descendants := repo.descendantIDs(person.ID)
+	parents := map[uuid.UUID]bool{}
+	for _, parentID := range repo.parentIDs(person.ID) {
+		parents[parentID] = true
+	}
+	siblings := map[uuid.UUID]bool{}
+	for _, relation := range repo.relations {
+		if relation.RelationType == familytree.RelationTypeParent && parents[relation.Top] && relation.Bottom != person.ID {
+			siblings[relation.Bottom] = true
+		}
+	}
+	isStrictAncestor := func(personID uuid.UUID) bool {
+		distance, ok := ancestors[personID]
+		return ok && distance > 0
+	}
+
+	treePeople := map[uuid.UUID]bool{person.ID: true}
+	treeRelations := map[Relation]bool{}
+	for _, relation := range repo.relations {
+		include := false
+		switch relation.RelationType {
+		case familytree.RelationTypeParent:
+			_, bottomIsAncestor := ancestors[relation.Bottom]
+			include = bottomIsAncestor ||
+				descendants[relation.Top] ||
+				(parents[relation.Top] && relation.Bottom != person.ID) ||
+				siblings[relation.Top]
+		case familytree.RelationTypeSpouse:
+			_, topIsAncestor := ancestors[relation.Top]
+			_, bottomIsAncestor := ancestors[relation.Bottom]
+			include = (topIsAncestor && isStrictAncestor(relation.Bottom)) ||
+				(bottomIsAncestor && isStrictAncestor(relation.Top))
+		}
+		if include {
+			treeRelations[relation] = true
+			treePeople[relation.Top] = true
+			treePeople[relation.Bottom] = true
+		}
+	}
+
+	familyTree := &familytree.FamilyTree{
+		People: []familytree.FamilyTreeNode{},
+	}
+	for _, personID := range repo.peopleOrder {
+		if !treePeople[personID] {
+			continue
+		}
+		newNode := familytree.FamilyTreeNode{
+			Person: repo.people[personID],
+		}
+		for _, relation := range repo.relations {
+			if relation.Top != personID || !treeRelations[relation] {
+				continue
+			}
+			// Duplicated edges are shown only once, same as the gogm mapping
+			delete(treeRelations, relation)
+			treeRelation := familytree.FamilyTreeRelation{
+				PersonID:     relation.Bottom,
+				RelationType: relation.RelationType,
+			}
+			switch relation.RelationType {
+			case familytree.RelationTypeParent:
+				treeRelation.Parentage = relation.Parentage
+			case familytree.RelationTypeSpouse:
+				union := relation.Union
+				treeRelation.Union = &union
+			}
+			newNode.Relations = append(newNode.Relations, treeRelation)
+		}
+		familyTree.People = append(familyTree.People, newNode)
+	}
+	return familyTree, nil
+}
+
+func (repo *FamilyTreeRepo) GetDescendants(ctx context.Context, tx familytree.Tx, person familytree.Person, depth int) ([]familytree.PersonChild, error) {
+	memoryTx, err := repo.getTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	defer repo.readLock(memoryTx)()
+
+	children := []familytree.PersonChild{}
+	if _, ok := repo.people[person.ID]; !ok {
+		return children, nil
+	}
+	generations := map[uuid.UUID]int{person.ID: 0}
+	queue := []uuid.UUID{person.ID}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if generations[current] == depth {
+			continue
+		}
+		for _, relation := range repo.relations {
+			if relation.RelationType != familytree.RelationTypeParent || relation.Top != current {
+				continue
+			}
+			children = append(children, familytree.PersonChild{
+				ParentID:  current,
+				Child:     *repo.getPerson(relation.Bottom),
+				Parentage: relation.Parentage,
+			})
+			if _, ok := generations[relation.Bottom]; ok {
+				continue
+			}
+			generations[relation.Bottom] = generations[current] + 1
+			queue = append(queue, relation.Bottom)
+		}
+	}
+	return children, nil
+}
+
+func (repo *FamilyTreeRepo) IsDescendant(ctx context.Context, tx familytree.Tx, ancestor familytree.Person, descendant familytree.Person) (bool, error) {
+	memoryTx, err := repo.getTx(tx)
+	if err != nil {
+		return false, err
+	}
+	defer repo.readLock(memoryTx)()
+
+	return ancestor.ID != descendant.ID && repo.descendantIDs(ancestor.ID)[descendant.ID], nil
+}
+
+func (repo *FamilyTreeRepo) GetAncestors(ctx context.Context, tx familytree.Tx, person familytree.Person, generations int) ([]familytree.ChildParent, error) {
+	memoryTx, err := repo.getTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	defer repo.readLock(memoryTx)()
+
+	parents := []familytree.ChildParent{}
+	if _, ok := repo.people[person.ID]; !ok {
+		return parents, nil
+	}
+	distances, order := repo.ancestorDistances(person.ID, repo.biologicalParentIDs)
+	for _, childID := range order {
+		if distances[childID] >= generations {
+			continue
+		}
+		for _, parentID := range repo.biologicalParentIDs(childID) {
+			parents = append(parents, familytree.ChildParent{
+				ChildID: childID,
+				Parent:  *repo.getPerson(parentID),
+			})
+		}
+	}
+	return parents, nil
+}
+
+func (repo *FamilyTreeRepo) GetShortestPathLength(ctx context.Context, tx familytree.Tx, firstPerson familytree.Person, secondPerson familytree.Person) (int, bool, error) {
+	memoryTx, err := repo.getTx(tx)
+	if err != nil {
+		return 0, false, err
+	}
+	defer repo.readLock(memoryTx)()
+
+	if _, ok := repo.people[firstPerson.ID]; !ok {
+		return 0, false, nil
+	}
+	if _, ok := repo.people[secondPerson.ID]; !ok {
+		return 0, false, nil
+	}
+	distances := map[uuid.UUID]int{firstPerson.ID: 0}
+	queue := []uuid.UUID{firstPerson.ID}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current == secondPerson.ID {
+			return distances[current], true, nil
+		}
+		for _, relation := range repo.relations {
+			if relation.Top != current && relation.Bottom != current {
+				continue
+			}
+			next := relation.other(current)
+			if _, ok := distances[next]; ok {
+				continue
+			}
+			distances[next] = distances[current] + 1
+			queue = append(queue, next)
+		}
+	}
+	return 0, false, nil
+}
+
+type pathNeighbour struct {
+	personID uuid.UUID
+	edge     familytree.PathEdge
+}
+
+// pathNeighbours lists the people linked to the person by the relations the
+// filter follows, with what the person is of each of them.
+func (repo *FamilyTreeRepo) pathNeighbours(personID uuid.UUID, filter familytree.PathFilter) []pathNeighbour {
+	neighbours := []pathNeighbour{}
+	for _, relation := range repo.relations {
+		if relation.Top != personID && relation.Bottom != personID {
+			continue
+		}
+		neighbour := pathNeighbour{personID: relation.other(personID)}
+		switch {
+		case relation.RelationType == familytree.RelationTypeSpouse:
+			if !filter.FollowsSpouses() {
+				continue
+			}
+			neighbour.edge = familytree.PathEdgeSpouseOf
+		case !filter.Follows(relation.Parentage):
+			continue
+		case relation.Top == personID:
+			neighbour.edge = familytree.PathEdgeParentOf
+		default:
+			neighbour.edge = familytree.PathEdgeChildOf
+		}
+		neighbours = append(neighbours, neighbour)
+	}
+	return neighbours
+}
+
+// GetShortestPaths walks the partial paths breadth first, so they come out
+// sorted by length. Partial paths that can't reach the second person within
+// the max length are dropped using the distances from the second person.
+func (repo *FamilyTreeRepo) GetShortestPaths(ctx context.Context, tx familytree.Tx, firstPerson familytree.Person, secondPerson familytree.Person, filter familytree.PathFilter) ([]familytree.Path, error) {
+	memoryTx, err := repo.getTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	defer repo.readLock(memoryTx)()
+
+	paths := []familytree.Path{}
+	if _, ok := repo.people[firstPerson.ID]; !ok {
+		return paths, nil
+	}
+	if _, ok := repo.people[secondPerson.ID]; !ok {
+		return paths, nil
+	}
+	distances := map[uuid.UUID]int{secondPerson.ID: 0}
+	queue := []uuid.UUID{secondPerson.ID}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, neighbour := range repo.pathNeighbours(current, filter) {
+			if _, ok := distances[neighbour.personID]; ok {
+				continue
+			}
+			distances[neighbour.personID] = distances[current] + 1
+			queue = append(queue, neighbour.personID)
+		}
+	}
+	if distance, ok := distances[firstPerson.ID]; !ok || distance > filter.MaxLength {
+		return paths, nil
+	}
+
+	type partialPath struct {
+		personIDs []uuid.UUID
+		edges     []familytree.PathEdge
+	}
+	partialPaths := []partialPath{{personIDs: []uuid.UUID{firstPerson.ID}, edges: []familytree.PathEdge{}}}
+	for len(partialPaths) > 0 && len(paths) < filter.Count {
+		current := partialPaths[0]
+		partialPaths = partialPaths[1:]
+		last := current.personIDs[len(current.personIDs)-1]
+		if last == secondPerson.ID {
+			path := familytree.Path{People: make([]familytree.Person, 0, len(current.personIDs)), Edges: current.edges}
+			for _, person := range repo.getPeople(current.personIDs...) {
+				path.People = append(path.People, *person)
+			}
+			paths = append(paths, path)
+			continue
+		}
+		visited := map[uuid.UUID]bool{}
+		for _, personID := range current.personIDs {
+			visited[personID] = true
+		}
+		for _, neighbour := range repo.pathNeighbours(last, filter) {
+			distance, ok := distances[neighbour.personID]
+			if !ok || visited[neighbour.personID] || len(current.edges)+1+distance > filter.MaxLength {
+				continue
+			}
+			partialPaths = append(partialPaths, partialPath{
+				personIDs: append(append([]uuid.UUID{}, current.personIDs...), neighbour.personID),
+				edges:     append(append([]familytree.PathEdge{}, current.edges...), neighbour.edge),
+			})
+		}
+	}
+	return paths, nil
+}
+
+func (repo *FamilyTreeRepo) HasCommonChild(ctx context.Context, tx familytree.Tx, firstPerson familytree.Person, secondPerson familytree.Person) (bool, error) {
+	memoryTx, err := repo.getTx(tx)
+	if err != nil {
+		return false, err
+	}
+	defer repo.readLock(memoryTx)()
+
+	return repo.commonChildCount(firstPerson.ID, secondPerson.ID) > 0, nil
+}
+
+func (repo *FamilyTreeRepo) commonChildCount(firstPersonID uuid.UUID, secondPersonID uuid.UUID) int {
+	count := 0
+	for i, firstRelation := range repo.relations {
+		if firstRelation.RelationType != familytree.RelationTypeParent || firstRelation.Top != firstPersonID {
+			continue
+		}
+		for j, secondRelation := range repo.relations {
+			if i == j || secondRelation.RelationType != familytree.RelationTypeParent {
+				continue
+			}
+			if secondRelation.Top == secondPersonID && secondRelation.Bottom == firstRelation.Bottom {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+func (repo *FamilyTreeRepo) GetSpouse(ctx context.Context, tx familytree.Tx, person familytree.Person) (*familytree.Person, error) {
+	memoryTx, err := repo.getTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	defer repo.readLock(memoryTx)()
+
+	for _, relation := range repo.relations {
+		if relation.RelationType != familytree.RelationTypeSpouse || !relation.Union.Ongoing() {
+			continue
+		}
+		if relation.Top == person.ID || relation.Bottom == person.ID {
+			return repo.getPerson(relation.other(person.ID)), nil
+		}
+	}
+	return nil, nil
+}
+
+func (repo *FamilyTreeRepo) GetUnions(ctx context.Context, tx familytree.Tx, person familytree.Person) ([]familytree.PersonUnion, error) {
+	memoryTx, err := repo.getTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	defer repo.readLock(memoryTx)()
+
+	unions := []familytree.PersonUnion{}
+	for _, relation := range repo.relations {
+		if relation.RelationType != familytree.RelationTypeSpouse {
+			continue
+		}
+		if relation.Top == person.ID || relation.Bottom == person.ID {
+			unions = append(unions, familytree.PersonUnion{
+				Spouse: *repo.getPerson(relation.other(person.ID)),
+				Union:  relation.Union,
+			})
+		}
+	}
+	return unions, nil
+}
+
+func (repo *FamilyTreeRepo) UpdateUnion(ctx context.Context, tx familytree.Tx, firstPerson familytree.Person, secondPerson familytree.Person, startDate familytree.Date, union familytree.Union) (bool, error) {
+	memoryTx, err := repo.getWriteTx(tx)
+	if err != nil {
+		return false, err
+	}
+
+	updated := false
+	for i, relation := range repo.relations {
+		if relation.RelationType == familytree.RelationTypeSpouse && relation.connects(firstPerson.ID, secondPerson.ID) && relation.Union.StartDate == startDate {
+			index, previous := i, relation.Union
+			memoryTx.onRollback(func() {
+				repo.relations[index].Union = previous
+			})
+			repo.relations[i].Union = union
+			updated = true
+		}
+	}
+	return updated, nil
+}
+
+func (repo *FamilyTreeRepo) GetParentMaritalChildCount(ctx context.Context, tx familytree.Tx, person familytree.Person) (int, error) {
+	memoryTx, err := repo.getTx(tx)
+	if err != nil {
+		return 0, err
+	}
+	defer repo.readLock(memoryTx)()
+
+	// Each married couple of parents is counted once, even when they married
+	// again
+	parentIDs := repo.parentIDs(person.ID)
+	count := 0
+	for i, father := range parentIDs {
+		for _, mother := range parentIDs[i+1:] {
+			for _, relation := range repo.relations {
+				if relation.RelationType == familytree.RelationTypeSpouse && relation.connects(father, mother) {
+					count += repo.commonChildCount(father, mother)
+					break
+				}
+			}
+		}
+	}
+	return count, nil
+}
+
+func (repo *FamilyTreeRepo) DeleteRelationship(ctx context.Context, tx familytree.Tx, firstPerson familytree.Person, secondPerson familytree.Person, relationType familytree.RelationType) (bool, error) {
+	memoryTx, err := repo.getWriteTx(tx)
+	if err != nil {
+		return false, err
+	}
+
+	deleted := repo.trashRelations(memoryTx, uuid.Nil, func(relation Relation) bool {
+		return relation.RelationType == relationType && relation.connects(firstPerson.ID, secondPerson.ID)
+	})
+	return deleted > 0, nil
+}
+
+func (repo *FamilyTreeRepo) DeleteUnion(ctx context.Context, tx familytree.Tx, firstPerson familytree.Person, secondPerson familytree.Person, startDate familytree.Date) (bool, error) {
+	memoryTx, err := repo.getWriteTx(tx)
+	if err != nil {
+		return false, err
+	}
+
+	deleted := repo.trashRelations(memoryTx, uuid.Nil, func(relation Relation) bool {
+		return relation.RelationType == familytree.RelationTypeSpouse && relation.connects(firstPerson.ID, secondPerson.ID) && relation.Union.StartDate == startDate
+	})
+	return deleted > 0, nil
+}
+
+func (repo *FamilyTreeRepo) DeletePersonRelations(ctx context.Context, tx familytree.Tx, person familytree.Person) error {
+	memoryTx, err := repo.getWriteTx(tx)
+	if err != nil {
+		return err
+	}
+
+	repo.trashRelations(memoryTx, person.ID, func(relation Relation) bool {
+		return relation.Top == person.ID || relation.Bottom == person.ID
+	})
+	return nil
+}
+
+// trashRelations moves the matching relations to the trash, trashed along
+// with the person trashedWith unless it's uuid.Nil, and counts them.
+func (repo *FamilyTreeRepo) trashRelations(memoryTx *Tx, trashedWith uuid.UUID, matches func(Relation) bool) int {
+	remaining := make([]Relation, 0, len(repo.relations))
+	trashed := append([]TrashedRelation{}, repo.trashedRelations...)
+	deletedAt := time.Now()
+	for _, relation := range repo.relations {
+		if matches(relation) {
+			trashed = append(trashed, TrashedRelation{Relation: relation, DeletedAt: deletedAt, TrashedWith: trashedWith})
+			continue
+		}
+		remaining = append(remaining, relation)
+	}
+	// remaining and trashed are new slices, the previous ones are kept as they
+	// were
+	previous, previousTrashed := repo.relations, repo.trashedRelations
+	memoryTx.onRollback(func() {
+		repo.relations, repo.trashedRelations = previous, previousTrashed
+	})
+	deleted := len(trashed) - len(repo.trashedRelations)
+	repo.relations, repo.trashedRelations = remaining, trashed
+	return deleted
+}
+
+func (repo *FamilyTreeRepo) DeletePerson(ctx context.Context, tx familytree.Tx, person familytree.Person) error {
+	memoryTx, err := repo.getWriteTx(tx)
+	if err != nil {
+		return err
+	}
+
+	previous, ok := repo.people[person.ID]
+	if !ok {
+		return nil
+	}
+	for _, relation := range repo.relations {
+		if relation.Top == person.ID || relation.Bottom == person.ID {
+			return familytree.ErrPersonStillHasRelations
+		}
+	}
+	previousKeys, previousCreated := repo.nameKeys[person.ID], repo.created[person.ID]
+	delete(repo.people, person.ID)
+	delete(repo.nameKeys, person.ID)
+	delete(repo.created, person.ID)
+	for i, personID := range repo.peopleOrder {
+		if personID == person.ID {
+			repo.peopleOrder = append(repo.peopleOrder[:i], repo.peopleOrder[i+1:]...)
+			memoryTx.onRollback(func() {
+				repo.peopleOrder = append(repo.peopleOrder[:i], append([]uuid.UUID{previous.ID}, repo.peopleOrder[i:]...)...)
+			})
+			break
+		}
+	}
+	repo.trashedPeople[previous.ID] = TrashedPerson{Person: previous, DeletedAt: time.Now(), Created: previousCreated}
+	memoryTx.onRollback(func() {
+		delete(repo.trashedPeople, previous.ID)
+		repo.people[previous.ID] = previous
+		repo.nameKeys[previous.ID] = previousKeys
+		repo.created[previous.ID] = previousCreated
+	})
+	return nil
+}
+
+// GetTrash keeps the relations trashed along with a person on its entry while
+// the person is in the trash.
+func (repo *FamilyTreeRepo) GetTrash(ctx context.Context, tx familytree.Tx) (*familytree.Trash, error) {
+	memoryTx, err := repo.getTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	defer repo.readLock(memoryTx)()
+
+	person := func(personID uuid.UUID) familytree.Person {
+		if trashed, ok := repo.trashedPeople[personID]; ok {
+			return trashed.Person
+		}
+		return repo.people[personID]
+	}
+	trash := &familytree.Trash{People: []familytree.TrashedPerson{}, Relations: []familytree.TrashedRelation{}}
+	entries := map[uuid.UUID]int{}
+	for _, trashed := range repo.trashedPeople {
+		entries[trashed.Person.ID] = len(trash.People)
+		trash.People = append(trash.People, familytree.TrashedPerson{
+			Person:    trashed.Person,
+			DeletedAt: trashed.DeletedAt,
+			Relations: []familytree.PersonRelation{},
+		})
+	}
+	// The relations are trashed in order, so the latest ones are the last
+	for i := len(repo.trashedRelations) - 1; i >= 0; i-- {
+		trashed := repo.trashedRelations[i]
+		relation := familytree.PersonRelation{
+			Top:          person(trashed.Top),
+			Bottom:       person(trashed.Bottom),
+			RelationType: trashed.RelationType,
+			Union:        trashed.Union,
+			Parentage:    trashed.Parentage,
+		}
+		if entry, ok := entries[trashed.TrashedWith]; ok {
+			trash.People[entry].Relations = append(trash.People[entry].Relations, relation)
+			continue
+		}
+		trash.Relations = append(trash.Relations, familytree.TrashedRelation{Relation: relation, DeletedAt: trashed.DeletedAt})
+	}
+	sort.SliceStable(trash.People, func(i, j int) bool {
+		return trash.People[i].DeletedAt.After(trash.People[j].DeletedAt)
+	})
+	return trash, nil
+}
+
+// RestorePerson puts the person back on its place of the creation order.
+func (repo *FamilyTreeRepo) RestorePerson(ctx context.Context, tx familytree.Tx, personID uuid.UUID) (bool, error) {
+	memoryTx, err := repo.getWriteTx(tx)
+	if err != nil {
+		return false, err
+	}
+
+	trashed, ok := repo.trashedPeople[personID]
+	if !ok {
+		return false, nil
+	}
+	delete(repo.trashedPeople, personID)
+	repo.people[personID] = trashed.Person
+	repo.nameKeys[personID] = familytree.NewNameKeys(trashed.Person.Name)
+	repo.created[personID] = trashed.Created
+	position := sort.Search(len(repo.peopleOrder), func(i int) bool {
+		return repo.created[repo.peopleOrder[i]] > trashed.Created
+	})
+	previousOrder := repo.peopleOrder
+	repo.peopleOrder = append(append(append([]uuid.UUID{}, previousOrder[:position]...), personID), previousOrder[position:]...)
+	memoryTx.onRollback(func() {
+		repo.trashedPeople[personID] = trashed
+		delete(repo.people, personID)
+		delete(repo.nameKeys, personID)
+		delete(repo.created, personID)
+		repo.peopleOrder = previousOrder
+	})
+	return true, nil
+}
+
+func (repo *FamilyTreeRepo) RestoreRelationship(ctx context.Context, tx familytree.Tx, firstPerson familytree.Person, secondPerson familytree.Person, relationType familytree.RelationType) (bool, error) {
+	memoryTx, err := repo.getWriteTx(tx)
+	if err != nil {
+		return false, err
+	}
+
+	_, firstFound := repo.people[firstPerson.ID]
+	_, secondFound := repo.people[secondPerson.ID]
+	if !firstFound || !secondFound {
+		return false, nil
+	}
+	for i := len(repo.trashedRelations) - 1; i >= 0; i-- {
+		trashed := repo.trashedRelations[i]
+		if trashed.RelationType != relationType || !trashed.connects(firstPerson.ID, secondPerson.ID) {
+			continue
+		}
+		previous, previousTrashed := repo.relations, repo.trashedRelations
+		repo.relations = append(append([]Relation{}, previous...), trashed.Relation)
+		repo.trashedRelations = append(append([]TrashedRelation{}, previousTrashed[:i]...), previousTrashed[i+1:]...)
+		memoryTx.onRollback(func() {
+			repo.relations, repo.trashedRelations = previous, previousTrashed
+		})
+		return true, nil
+	}
+	return false, nil
+}
+
+func (repo *FamilyTreeRepo) PurgeTrash(ctx context.Context, tx familytree.Tx, before time.Time) (*familytree.TrashPurge, error) {
+	memoryTx, err := repo.getWriteTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	purge := &familytree.TrashPurge{}
+	previousPeople := make(map[uuid.UUID]TrashedPerson, len(repo.trashedPeople))
+	purged := map[uuid.UUID]bool{}
+	for personID, trashed := range repo.trashedPeople {
+		previousPeople[personID] = trashed
+		if trashed.DeletedAt.Before(before) {
+			purged[personID] = true
+			delete(repo.trashedPeople, personID)
+			purge.People++
+		}
+	}
+	remaining := make([]TrashedRelation, 0, len(repo.trashedRelations))
+	for _, trashed := range repo.trashedRelations {
+		if trashed.DeletedAt.Before(before) || purged[trashed.Top] || purged[trashed.Bottom] {
+			purge.Relations++
+			continue
+		}
+		remaining = append(remaining, trashed)
+	}
+	previousRelations := repo.trashedRelations
+	repo.trashedRelations = remaining
+	memoryTx.onRollback(func() {
+		repo.trashedPeople, repo.trashedRelations = previousPeople, previousRelations
+	})
+	return purge, nil
+}
+
+func (repo *FamilyTreeRepo) SaveRedirect(ctx context.Context, tx familytree.Tx, oldID uuid.UUID, newID uuid.UUID) error {
+	memoryTx, err := repo.getWriteTx(tx)
+	if err != nil {
+		return err
+	}
+
+	previous := make(map[uuid.UUID]uuid.UUID, len(repo.redirects))
+	for fromID, toID := range repo.redirects {
+		previous[fromID] = toID
+		if toID == oldID {
+			repo.redirects[fromID] = newID
+		}
+	}
+	repo.redirects[oldID] = newID
+	memoryTx.onRollback(func() {
+		repo.redirects = previous
+	})
+	return nil
+}
+
+func (repo *FamilyTreeRepo) GetRedirect(ctx context.Context, tx familytree.Tx, personID uuid.UUID) (uuid.UUID, bool, error) {
+	memoryTx, err := repo.getTx(tx)
+	if err != nil {
+		return uuid.Nil, false, err
+	}
+	defer repo.readLock(memoryTx)()
+
+	newID, ok := repo.redirects[personID]
+	return newID, ok, nil
+}
+
+// GetDuplicateCandidates groups the people by the blocking key of their names,
+// by parent and by spouse, only people of the same group are paired. The pairs
+// follow the creation order of the people.
+func (repo *FamilyTreeRepo) GetDuplicateCandidates(ctx context.Context, tx familytree.Tx) ([]familytree.DuplicateCandidate, error) {
+	memoryTx, err := repo.getTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	defer repo.readLock(memoryTx)()
+
+	groups := map[string][]uuid.UUID{}
+	for _, personID := range repo.peopleOrder {
+		if key := repo.nameKeys[personID].BlockingKey(); key != "" {
+			groups["name "+key] = append(groups["name "+key], personID)
+		}
+	}
+	parents := map[uuid.UUID]map[uuid.UUID]bool{}
+	spouses := map[uuid.UUID]map[uuid.UUID]bool{}
+	addRelative := func(relatives map[uuid.UUID]map[uuid.UUID]bool, personID uuid.UUID, relativeID uuid.UUID) {
+		if relatives[personID] == nil {
+			relatives[personID] = map[uuid.UUID]bool{}
+		}
+		relatives[personID][relativeID] = true
+	}
+	for _, relation := range repo.relations {
+		switch relation.RelationType {
+		case familytree.RelationTypeParent:
+			addRelative(parents, relation.Bottom, relation.Top)
+			groups["parent "+relation.Top.String()] = append(groups["parent "+relation.Top.String()], relation.Bottom)
+		case familytree.RelationTypeSpouse:
+			addRelative(spouses, relation.Top, relation.Bottom)
+			addRelative(spouses, relation.Bottom, relation.Top)
+			groups["spouse "+relation.Top.String()] = append(groups["spouse "+relation.Top.String()], relation.Bottom)
+			groups["spouse "+relation.Bottom.String()] = append(groups["spouse "+relation.Bottom.String()], relation.Top)
+		}
+	}
+	shared := func(relatives map[uuid.UUID]map[uuid.UUID]bool, firstID uuid.UUID, secondID uuid.UUID) int {
+		count := 0
+		for relativeID := range relatives[firstID] {
+			if relatives[secondID][relativeID] {
+				count++
+			}
+		}
+		return count
+	}
+
+	pairs := map[[2]uuid.UUID]bool{}
+	for _, group := range groups {
+		for i, firstID := range group {
+			for _, secondID := range group[i+1:] {
+				pair := [2]uuid.UUID{firstID, secondID}
+				if repo.created[secondID] < repo.created[firstID] {
+					pair = [2]uuid.UUID{secondID, firstID}
+				}
+				pairs[pair] = true
+			}
+		}
+	}
+	candidates := make([]familytree.DuplicateCandidate, 0, len(pairs))
+	for pair := range pairs {
+		candidates = append(candidates, familytree.DuplicateCandidate{
+			First:         repo.people[pair[0]],
+			Second:        repo.people[pair[1]],
+			SharedParents: shared(parents, pair[0], pair[1]),
+			SharedSpouses: shared(spouses, pair[0], pair[1]),
+		})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		first, second := candidates[i], candidates[j]
+		if first.First.ID != second.First.ID {
+			return repo.created[first.First.ID] < repo.created[second.First.ID]
+		}
+		return repo.created[first.Second.ID] < repo.created[second.Second.ID]
+	})
+	return candidates, nil
+}
+
+func (repo *FamilyTreeRepo) AppendAuditEvents(ctx context.Context, tx familytree.Tx, events ...familytree.AuditEvent) error {
+	memoryTx, err := repo.getWriteTx(tx)
+	if err != nil {
+		return err
+	}
+
+	previous := len(repo.auditEvents)
+	repo.auditEvents = append(repo.auditEvents, events...)
+	memoryTx.onRollback(func() {
+		repo.auditEvents = repo.auditEvents[:previous]
+	})
+	return nil
+}
+
+func (repo *FamilyTreeRepo) GetAuditEvents(ctx context.Context, tx familytree.Tx, personID uuid.UUID, pagination familytree.PaginationDetails) (*familytree.AuditEventList, error) {
+	memoryTx, err := repo.getTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	defer repo.readLock(memoryTx)()
+
+	history := []familytree.AuditEvent{}
+	for i := len(repo.auditEvents) - 1; i >= 0; i-- {
+		event := repo.auditEvents[i]
+		for _, eventPersonID := range event.PeopleIDs {
+			if eventPersonID == personID {
+				history = append(history, event)
+				break
+			}
+		}
+	}
+	list := &familytree.AuditEventList{
+		Content: []familytree.AuditEvent{},
+		Metadata: familytree.ListMetadata{
+			TotalItens: len(history),
+			Page:       pagination.Page,
+		},
+	}
+	start := pagination.Page * pagination.PageSize
+	if start >= len(history) || pagination.PageSize <= 0 {
+		return list, nil
+	}
+	end := start + pagination.PageSize
+	if end > len(history) {
+		end = len(history)
+	}
+	list.Content = history[start:end]
+	return list, nil
+}
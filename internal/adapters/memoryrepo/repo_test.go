@@ -0,0 +1,13 @@
+package memoryrepo
+
+import (
+	"family-tree/internal/core/familytree"
+	"family-tree/internal/core/familytree/familytreetest"
+	"testing"
+)
+
+func TestFamilyTreeRepo(t *testing.T) {
+	familytreetest.RunSuite(t, func(t *testing.T) familytree.FamilyTreeRepo {
+		return NewFamilyTreeRepo()
+	})
+}
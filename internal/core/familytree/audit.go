@@ -0,0 +1,115 @@
+package familytree
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditOperation is the mutation an audit event records.
+type AuditOperation string
+
+const (
+	AuditOperationCreatePerson          = AuditOperation("CREATE_PERSON")
+	AuditOperationUpdatePerson          = AuditOperation("UPDATE_PERSON")
+	AuditOperationDeletePerson          = AuditOperation("DELETE_PERSON")
+	AuditOperationMergePeople           = AuditOperation("MERGE_PEOPLE")
+	AuditOperationRestorePerson         = AuditOperation("RESTORE_PERSON")
+	AuditOperationPurgePerson           = AuditOperation("PURGE_PERSON")
+	AuditOperationCreateParentRelation  = AuditOperation("CREATE_PARENT_RELATION")
+	AuditOperationDeleteParentRelation  = AuditOperation("DELETE_PARENT_RELATION")
+	AuditOperationRestoreParentRelation = AuditOperation("RESTORE_PARENT_RELATION")
+	AuditOperationCreateSpouseRelation  = AuditOperation("CREATE_SPOUSE_RELATION")
+	AuditOperationUpdateSpouseRelation  = AuditOperation("UPDATE_SPOUSE_RELATION")
+	AuditOperationDeleteSpouseRelation  = AuditOperation("DELETE_SPOUSE_RELATION")
+	AuditOperationRestoreSpouseRelation = AuditOperation("RESTORE_SPOUSE_RELATION")
+	AuditOperationPurgeRelation         = AuditOperation("PURGE_RELATION")
+)
+
+// AuditState holds the people and relations a mutation changed, as they were
+// before or after it.
+type AuditState struct {
+	People    []Person
+	Relations []PersonRelation
+}
+
+// AuditEvent records a mutation along with who asked for it. PeopleIDs are
+// the people with the event in their history, the ones changed and both ends
+// of the relations changed.
+type AuditEvent struct {
+	ID        uuid.UUID
+	Timestamp time.Time
+	Actor     string
+	RequestID string
+	Operation AuditOperation
+	PeopleIDs []uuid.UUID
+	Before    AuditState
+	After     AuditState
+}
+
+type AuditEventList struct {
+	Content  []AuditEvent
+	Metadata ListMetadata
+}
+
+// AuditSource is who asked for the mutations, the use cases read it from the
+// context to fill their audit events.
+type AuditSource struct {
+	Actor     string
+	RequestID string
+}
+
+type auditSourceContextKey struct{}
+
+func WithAuditSource(ctx context.Context, source AuditSource) context.Context {
+	return context.WithValue(ctx, auditSourceContextKey{}, source)
+}
+
+func auditSourceFrom(ctx context.Context) AuditSource {
+	source, _ := ctx.Value(auditSourceContextKey{}).(AuditSource)
+	return source
+}
+
+// auditPeopleIDs returns the people of the states and of their relations,
+// each one once.
+func auditPeopleIDs(states ...AuditState) []uuid.UUID {
+	peopleIDs := []uuid.UUID{}
+	seen := map[uuid.UUID]bool{}
+	add := func(personID uuid.UUID) {
+		if !seen[personID] {
+			seen[personID] = true
+			peopleIDs = append(peopleIDs, personID)
+		}
+	}
+	for _, state := range states {
+		for _, person := range state.People {
+			add(person.ID)
+		}
+		for _, relation := range state.Relations {
+			add(relation.Top.ID)
+			add(relation.Bottom.ID)
+		}
+	}
+	return peopleIDs
+}
+
+// recordAudit appends the event of a mutation to the sink on the Tx of the
+// mutation, stamped with the source of the context. Nothing is recorded
+// without a sink.
+func recordAudit(ctx context.Context, auditSink AuditSink, tx Tx, operation AuditOperation, before AuditState, after AuditState) error {
+	if auditSink == nil {
+		return nil
+	}
+	source := auditSourceFrom(ctx)
+	return auditSink.AppendAuditEvents(ctx, tx, AuditEvent{
+		ID:        uuid.New(),
+		Timestamp: time.Now().UTC(),
+		Actor:     source.Actor,
+		RequestID: source.RequestID,
+		Operation: operation,
+		PeopleIDs: auditPeopleIDs(before, after),
+		Before:    before,
+		After:     after,
+	})
+}
@@ -0,0 +1,282 @@
+package familytree
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Date is a genealogical date kept on its canonical text form. It may be
+// partial ("1850", "1850-03", "1850-03-12") and carry a GEDCOM qualifier
+// ("ABT 1850", "BEF 1850-03", "BET 1850 AND 1855"). The empty Date is an
+// unknown date.
+type Date string
+
+type DateQualifier string
+
+const (
+	DateExact      = DateQualifier("")
+	DateAbout      = DateQualifier("ABT")
+	DateCalculated = DateQualifier("CAL")
+	DateEstimated  = DateQualifier("EST")
+	DateBefore     = DateQualifier("BEF")
+	DateAfter      = DateQualifier("AFT")
+	DateBetween    = DateQualifier("BET")
+	dateBetweenAnd = "AND"
+)
+
+var dateMonths = []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}
+
+// DatePoint is a calendar date where Month and Day are 0 when unknown.
+type DatePoint struct {
+	Year  int
+	Month int
+	Day   int
+}
+
+func (point DatePoint) String() string {
+	switch {
+	case point.Month == 0:
+		return fmt.Sprintf("%04d", point.Year)
+	case point.Day == 0:
+		return fmt.Sprintf("%04d-%02d", point.Year, point.Month)
+	default:
+		return fmt.Sprintf("%04d-%02d-%02d", point.Year, point.Month, point.Day)
+	}
+}
+
+// Gedcom writes the point as a GEDCOM date, like "12 MAR 1850".
+func (point DatePoint) Gedcom() string {
+	switch {
+	case point.Month == 0:
+		return fmt.Sprint(point.Year)
+	case point.Day == 0:
+		return fmt.Sprintf("%s %d", dateMonths[point.Month-1], point.Year)
+	default:
+		return fmt.Sprintf("%d %s %d", point.Day, dateMonths[point.Month-1], point.Year)
+	}
+}
+
+// First returns the first day the point may refer to.
+func (point DatePoint) First() time.Time {
+	month, day := point.Month, point.Day
+	if month == 0 {
+		month = 1
+	}
+	if day == 0 {
+		day = 1
+	}
+	return time.Date(point.Year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
+
+// Last returns the last day the point may refer to.
+func (point DatePoint) Last() time.Time {
+	switch {
+	case point.Month == 0:
+		return time.Date(point.Year, time.December, 31, 0, 0, 0, 0, time.UTC)
+	case point.Day == 0:
+		return time.Date(point.Year, time.Month(point.Month)+1, 0, 0, 0, 0, 0, time.UTC)
+	default:
+		return point.First()
+	}
+}
+
+type ParsedDate struct {
+	Qualifier DateQualifier
+	From      DatePoint
+	// To is only set for BET ... AND ... dates
+	To DatePoint
+}
+
+func (date ParsedDate) String() string {
+	switch date.Qualifier {
+	case DateExact:
+		return date.From.String()
+	case DateBetween:
+		return fmt.Sprintf("%s %s %s %s", DateBetween, date.From, dateBetweenAnd, date.To)
+	default:
+		return fmt.Sprintf("%s %s", date.Qualifier, date.From)
+	}
+}
+
+func (date ParsedDate) Gedcom() string {
+	switch date.Qualifier {
+	case DateExact:
+		return date.From.Gedcom()
+	case DateBetween:
+		return fmt.Sprintf("%s %s %s %s", DateBetween, date.From.Gedcom(), dateBetweenAnd, date.To.Gedcom())
+	default:
+		return fmt.Sprintf("%s %s", date.Qualifier, date.From.Gedcom())
+	}
+}
+
+func invalidDate(value string) error {
+	return fmt.Errorf("%w: %q", ErrInvalidDate, value)
+}
+
+func parseDateNumber(value string, min int, max int) (int, bool) {
+	number, err := strconv.Atoi(value)
+	if err != nil || number < min || number > max {
+		return 0, false
+	}
+	return number, true
+}
+
+func validDay(year int, month int, day int) bool {
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC).Day() == day
+}
+
+// parseDatePoint accepts the ISO forms "YYYY", "YYYY-MM" and "YYYY-MM-DD" and
+// the GEDCOM forms "YYYY", "MON YYYY" and "DD MON YYYY".
+func parseDatePoint(value string) (DatePoint, bool) {
+	point := DatePoint{}
+	ok := true
+	if fields := strings.Fields(value); len(fields) > 1 {
+		yearIndex := len(fields) - 1
+		if point.Year, ok = parseDateNumber(fields[yearIndex], 1, 9999); !ok || len(fields) > 3 {
+			return DatePoint{}, false
+		}
+		point.Month = 0
+		for i, month := range dateMonths {
+			if fields[yearIndex-1] == month {
+				point.Month = i + 1
+			}
+		}
+		if point.Month == 0 {
+			return DatePoint{}, false
+		}
+		if len(fields) == 3 {
+			if point.Day, ok = parseDateNumber(fields[0], 1, 31); !ok {
+				return DatePoint{}, false
+			}
+		}
+	} else {
+		parts := strings.Split(value, "-")
+		if len(parts) > 3 || len(parts[0]) != 4 {
+			return DatePoint{}, false
+		}
+		if point.Year, ok = parseDateNumber(parts[0], 1, 9999); !ok {
+			return DatePoint{}, false
+		}
+		if len(parts) > 1 {
+			if point.Month, ok = parseDateNumber(parts[1], 1, 12); !ok || len(parts[1]) != 2 {
+				return DatePoint{}, false
+			}
+		}
+		if len(parts) > 2 {
+			if point.Day, ok = parseDateNumber(parts[2], 1, 31); !ok || len(parts[2]) != 2 {
+				return DatePoint{}, false
+			}
+		}
+	}
+	if point.Day != 0 && !validDay(point.Year, point.Month, point.Day) {
+		return DatePoint{}, false
+	}
+	return point, true
+}
+
+// Parse reads the date in any of the accepted forms. It's case insensitive and
+// the ISO and GEDCOM forms may be mixed, like "ABT MAR 1850".
+func (date Date) Parse() (ParsedDate, error) {
+	value := strings.ToUpper(strings.Join(strings.Fields(string(date)), " "))
+	if value == "" {
+		return ParsedDate{}, invalidDate(string(date))
+	}
+	parsed := ParsedDate{}
+	fields := strings.SplitN(value, " ", 2)
+	switch qualifier := DateQualifier(fields[0]); qualifier {
+	case DateAbout, DateCalculated, DateEstimated, DateBefore, DateAfter, DateBetween:
+		if len(fields) < 2 {
+			return ParsedDate{}, invalidDate(string(date))
+		}
+		parsed.Qualifier = qualifier
+		value = fields[1]
+	}
+	if parsed.Qualifier == DateBetween {
+		points := strings.SplitN(value, " "+dateBetweenAnd+" ", 2)
+		if len(points) != 2 {
+			return ParsedDate{}, invalidDate(string(date))
+		}
+		from, fromOk := parseDatePoint(points[0])
+		to, toOk := parseDatePoint(points[1])
+		if !fromOk || !toOk || to.Last().Before(from.First()) {
+			return ParsedDate{}, invalidDate(string(date))
+		}
+		parsed.From, parsed.To = from, to
+		return parsed, nil
+	}
+	point, ok := parseDatePoint(value)
+	if !ok {
+		return ParsedDate{}, invalidDate(string(date))
+	}
+	parsed.From = point
+	return parsed, nil
+}
+
+func (date Date) IsZero() bool {
+	return strings.TrimSpace(string(date)) == ""
+}
+
+// Normalize returns the date on its canonical form, or ErrInvalidDate.
+func (date Date) Normalize() (Date, error) {
+	if date.IsZero() {
+		return "", nil
+	}
+	parsed, err := date.Parse()
+	if err != nil {
+		return "", err
+	}
+	return Date(parsed.String()), nil
+}
+
+type Sex string
+
+const (
+	SexUnknown = Sex("")
+	SexMale    = Sex("M")
+	SexFemale  = Sex("F")
+	// SexUndetermined is the GEDCOM U, the sex is known to be undetermined
+	SexUndetermined = Sex("U")
+)
+
+// Normalize accepts the GEDCOM letters on any case and returns them on upper
+// case, or ErrInvalidSex.
+func (sex Sex) Normalize() (Sex, error) {
+	normalized := Sex(strings.ToUpper(strings.TrimSpace(string(sex))))
+	switch normalized {
+	case SexUnknown, SexMale, SexFemale, SexUndetermined:
+		return normalized, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidSex, string(sex))
+	}
+}
+
+// ApproximateDateYears is how much ABT, CAL and EST dates are widened on both
+// sides when comparing dates.
+const ApproximateDateYears = 5
+
+// Bounds returns the earliest and latest days the date may refer to. A zero
+// time means that side is open, like on BEF and AFT dates, and both are zero
+// for unknown or invalid dates.
+func (date Date) Bounds() (time.Time, time.Time) {
+	if date.IsZero() {
+		return time.Time{}, time.Time{}
+	}
+	parsed, err := date.Parse()
+	if err != nil {
+		return time.Time{}, time.Time{}
+	}
+	switch parsed.Qualifier {
+	case DateBefore:
+		return time.Time{}, parsed.From.Last()
+	case DateAfter:
+		return parsed.From.First(), time.Time{}
+	case DateBetween:
+		return parsed.From.First(), parsed.To.Last()
+	case DateAbout, DateCalculated, DateEstimated:
+		return parsed.From.First().AddDate(-ApproximateDateYears, 0, 0), parsed.From.Last().AddDate(ApproximateDateYears, 0, 0)
+	default:
+		return parsed.From.First(), parsed.From.Last()
+	}
+}
@@ -0,0 +1,97 @@
+package familytree
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DescendantsDefaultDepth = 10
+	DescendantsMaxDepth     = 20
+)
+
+// PersonChild is a PARENT relation seen from the parent.
+type PersonChild struct {
+	ParentID  uuid.UUID
+	Child     Person
+	Parentage Parentage
+}
+
+// Descendant is an entry of a descendants report. Number is the d'Aboville
+// number, 1 for the root person, 1.2 for its second child and 1.2.1 for the
+// first child of that one, children sorted by birth date. People descending
+// from the root through more than one line, as on cousin marriages, are only
+// expanded on their first entry, the others point to it on SameAs.
+type Descendant struct {
+	Number     string
+	Generation int
+	Person     Person
+	// ParentID and Parentage are empty on the root person
+	ParentID  *uuid.UUID
+	Parentage Parentage
+	SameAs    string
+	Spouses   []PersonUnion
+}
+
+type Descendants struct {
+	Root        Person
+	Depth       int
+	Descendants []Descendant
+}
+
+// numberDescendants lists the root and its descendants, up to the depth, on
+// d'Aboville order.
+func numberDescendants(root Person, depth int, children []PersonChild) *Descendants {
+	childrenByParent := map[uuid.UUID][]PersonChild{}
+	for _, child := range children {
+		childrenByParent[child.ParentID] = append(childrenByParent[child.ParentID], child)
+	}
+	for _, parentChildren := range childrenByParent {
+		sort.SliceStable(parentChildren, func(i, j int) bool {
+			first, _ := parentChildren[i].Child.BirthDate.Bounds()
+			second, _ := parentChildren[j].Child.BirthDate.Bounds()
+			// Children with unknown birth come last
+			switch {
+			case first.IsZero() != second.IsZero():
+				return !first.IsZero()
+			case !first.Equal(second):
+				return first.Before(second)
+			}
+			return parentChildren[i].Child.Name < parentChildren[j].Child.Name
+		})
+	}
+
+	descendants := &Descendants{
+		Root:        root,
+		Depth:       depth,
+		Descendants: []Descendant{},
+	}
+	numbers := map[uuid.UUID]string{}
+	var visit func(descendant Descendant)
+	visit = func(descendant Descendant) {
+		if number, ok := numbers[descendant.Person.ID]; ok {
+			descendant.SameAs = number
+			descendants.Descendants = append(descendants.Descendants, descendant)
+			return
+		}
+		numbers[descendant.Person.ID] = descendant.Number
+		descendants.Descendants = append(descendants.Descendants, descendant)
+		if descendant.Generation == depth {
+			return
+		}
+		parentID := descendant.Person.ID
+		for i, child := range childrenByParent[parentID] {
+			visit(Descendant{
+				Number:     fmt.Sprintf("%s.%d", descendant.Number, i+1),
+				Generation: descendant.Generation + 1,
+				Person:     child.Child,
+				ParentID:   &parentID,
+				Parentage:  child.Parentage,
+			})
+		}
+	}
+	visit(Descendant{Number: "1", Person: root})
+	return descendants
+}
@@ -0,0 +1,184 @@
+package familytree
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// DuplicatesMinNameSimilarity is the trigram similarity the names of a pair
+	// need when they don't sound alike.
+	DuplicatesMinNameSimilarity = 0.5
+	DuplicatesDefaultMinScore   = 0.5
+	// DuplicatesDateToleranceYears is how far apart the dates of a pair may be
+	// before they are told to be different people, sources often disagree on a
+	// year or two.
+	DuplicatesDateToleranceYears = 2
+)
+
+// DuplicateReason is why a pair is likely the same person.
+type DuplicateReason string
+
+const (
+	DuplicateReasonSameName      = DuplicateReason("SAME_NAME")
+	DuplicateReasonPhoneticName  = DuplicateReason("PHONETIC_NAME")
+	DuplicateReasonSimilarName   = DuplicateReason("SIMILAR_NAME")
+	DuplicateReasonSharedParents = DuplicateReason("SHARED_PARENTS")
+	DuplicateReasonSharedSpouse  = DuplicateReason("SHARED_SPOUSE")
+	DuplicateReasonBirthDate     = DuplicateReason("BIRTH_DATE")
+	DuplicateReasonDeathDate     = DuplicateReason("DEATH_DATE")
+)
+
+// DuplicateCandidate is a pair of people sharing a blocking key or a relative,
+// with how many parents and spouses they share.
+type DuplicateCandidate struct {
+	First         Person
+	Second        Person
+	SharedParents int
+	SharedSpouses int
+}
+
+// DuplicatePair Score goes from 0 to 1, the reasons are the evidences that
+// added to it.
+type DuplicatePair struct {
+	First   Person
+	Second  Person
+	Score   float64
+	Reasons []DuplicateReason
+}
+
+type DuplicateList struct {
+	Content  []DuplicatePair
+	Metadata ListMetadata
+}
+
+// BlockingKey is the Soundex code of the first and the last words of the name,
+// only people with the same key are compared, so middle names and spelling
+// variants still meet. Names without latin letters have no key.
+func (keys NameKeys) BlockingKey() string {
+	if len(keys.Phonetics) == 0 {
+		return ""
+	}
+	first, last := keys.Phonetics[0], keys.Phonetics[len(keys.Phonetics)-1]
+	if len(keys.Phonetics) == 1 {
+		return first
+	}
+	return first + " " + last
+}
+
+type dateComparison int
+
+const (
+	datesUnknown dateComparison = iota
+	datesMatch
+	datesClose
+	datesDiffer
+)
+
+// compareDates tells if the dates may be the same day, counting their
+// uncertainty, or are surely more than DuplicatesDateToleranceYears apart.
+func compareDates(first Date, second Date) dateComparison {
+	firstEarliest, firstLatest := first.Bounds()
+	secondEarliest, secondLatest := second.Bounds()
+	if (firstEarliest.IsZero() && firstLatest.IsZero()) || (secondEarliest.IsZero() && secondLatest.IsZero()) {
+		return datesUnknown
+	}
+	before := func(latest time.Time, earliest time.Time, years int) bool {
+		return !latest.IsZero() && !earliest.IsZero() && latest.AddDate(years, 0, 0).Before(earliest)
+	}
+	switch {
+	case before(firstLatest, secondEarliest, DuplicatesDateToleranceYears), before(secondLatest, firstEarliest, DuplicatesDateToleranceYears):
+		return datesDiffer
+	case before(firstLatest, secondEarliest, 0), before(secondLatest, firstEarliest, 0):
+		return datesClose
+	default:
+		return datesMatch
+	}
+}
+
+// ScoreDuplicate weighs the evidences that the candidates are the same person.
+// Names must sound alike or be similar, and pairs with known different sexes
+// or dates surely apart are never duplicates, it returns false for those.
+func ScoreDuplicate(candidate DuplicateCandidate) (DuplicatePair, bool) {
+	pair := DuplicatePair{First: candidate.First, Second: candidate.Second, Reasons: []DuplicateReason{}}
+	firstKeys, secondKeys := NewNameKeys(candidate.First.Name), NewNameKeys(candidate.Second.Name)
+	similarity := firstKeys.Similarity(secondKeys)
+	phonetic := firstKeys.soundsLike(secondKeys) || secondKeys.soundsLike(firstKeys)
+	switch {
+	case firstKeys.Normalized == secondKeys.Normalized:
+		pair.Score = 0.6
+		pair.Reasons = append(pair.Reasons, DuplicateReasonSameName)
+	case phonetic:
+		pair.Score = 0.3 + 0.3*similarity
+		pair.Reasons = append(pair.Reasons, DuplicateReasonPhoneticName)
+	case similarity >= DuplicatesMinNameSimilarity:
+		pair.Score = 0.5 * similarity
+		pair.Reasons = append(pair.Reasons, DuplicateReasonSimilarName)
+	default:
+		return pair, false
+	}
+	first, second := candidate.First, candidate.Second
+	if (first.Sex == SexMale && second.Sex == SexFemale) || (first.Sex == SexFemale && second.Sex == SexMale) {
+		return pair, false
+	}
+	for _, dates := range []struct {
+		reason DuplicateReason
+		weight float64
+		first  Date
+		second Date
+	}{
+		{DuplicateReasonBirthDate, 0.2, first.BirthDate, second.BirthDate},
+		{DuplicateReasonDeathDate, 0.1, first.DeathDate, second.DeathDate},
+	} {
+		switch compareDates(dates.first, dates.second) {
+		case datesDiffer:
+			return pair, false
+		case datesMatch:
+			pair.Score += dates.weight
+			pair.Reasons = append(pair.Reasons, dates.reason)
+		}
+	}
+	if candidate.SharedParents > 0 {
+		pair.Score += 0.15 * float64(candidate.SharedParents)
+		pair.Reasons = append(pair.Reasons, DuplicateReasonSharedParents)
+	}
+	if candidate.SharedSpouses > 0 {
+		pair.Score += 0.2
+		pair.Reasons = append(pair.Reasons, DuplicateReasonSharedSpouse)
+	}
+	pair.Score = math.Min(math.Round(pair.Score*100)/100, 1)
+	return pair, true
+}
+
+// RankDuplicates scores the candidates and returns the page of the pairs
+// reaching minScore, from the likeliest one.
+func RankDuplicates(candidates []DuplicateCandidate, minScore float64, pagination PaginationDetails) *DuplicateList {
+	pairs := []DuplicatePair{}
+	for _, candidate := range candidates {
+		pair, ok := ScoreDuplicate(candidate)
+		if ok && pair.Score >= minScore {
+			pairs = append(pairs, pair)
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return pairs[i].Score > pairs[j].Score
+	})
+	list := &DuplicateList{
+		Content: []DuplicatePair{},
+		Metadata: ListMetadata{
+			TotalItens: len(pairs),
+			Page:       pagination.Page,
+		},
+	}
+	start := pagination.Page * pagination.PageSize
+	if start >= len(pairs) || pagination.PageSize <= 0 {
+		return list
+	}
+	end := start + pagination.PageSize
+	if end > len(pairs) {
+		end = len(pairs)
+	}
+	list.Content = pairs[start:end]
+	return list
+}
@@ -0,0 +1,167 @@
+package familytree
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBlockingKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{name: "John Smith", expected: "J500 S530"},
+		{name: "John Paul Smith", expected: "J500 S530"},
+		{name: "Jon Smyth", expected: "J500 S530"},
+		{name: "Madonna", expected: "M350"},
+		{name: "李", expected: ""},
+		{name: "", expected: ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if key := NewNameKeys(test.name).BlockingKey(); key != test.expected {
+				t.Errorf("BlockingKey returned %q, expected %q", key, test.expected)
+			}
+		})
+	}
+}
+
+func TestCompareDates(t *testing.T) {
+	tests := []struct {
+		first    Date
+		second   Date
+		expected dateComparison
+	}{
+		{first: "1850", second: "1850-03-12", expected: datesMatch},
+		{first: "1850", second: "1852", expected: datesClose},
+		{first: "1853", second: "1850", expected: datesDiffer},
+		{first: "ABT 1850", second: "1854", expected: datesMatch},
+		{first: "BEF 1850", second: "1900", expected: datesDiffer},
+		{first: "AFT 1900", second: "1850", expected: datesDiffer},
+		{first: "AFT 1900", second: "1950", expected: datesMatch},
+		{first: "", second: "1850", expected: datesUnknown},
+		{first: "1850", second: "someday", expected: datesUnknown},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%s %s", test.first, test.second), func(t *testing.T) {
+			if comparison := compareDates(test.first, test.second); comparison != test.expected {
+				t.Errorf("compareDates returned %d, expected %d", comparison, test.expected)
+			}
+		})
+	}
+}
+
+func TestScoreDuplicate(t *testing.T) {
+	tests := []struct {
+		name      string
+		candidate DuplicateCandidate
+		score     float64
+		reasons   []DuplicateReason
+		ok        bool
+	}{
+		{
+			name:      "same name",
+			candidate: DuplicateCandidate{First: Person{Name: "John Smith"}, Second: Person{Name: "john  smith"}},
+			score:     0.6,
+			reasons:   []DuplicateReason{DuplicateReasonSameName},
+			ok:        true,
+		},
+		{
+			name:      "same name and dates",
+			candidate: DuplicateCandidate{First: Person{Name: "John Smith", BirthDate: "1850", DeathDate: "1920"}, Second: Person{Name: "John Smith", BirthDate: "1850-03-12", DeathDate: "ABT 1920"}},
+			score:     0.9,
+			reasons:   []DuplicateReason{DuplicateReasonSameName, DuplicateReasonBirthDate, DuplicateReasonDeathDate},
+			ok:        true,
+		},
+		{
+			name:      "close birth dates",
+			candidate: DuplicateCandidate{First: Person{Name: "John Smith", BirthDate: "1850"}, Second: Person{Name: "John Smith", BirthDate: "1852"}},
+			score:     0.6,
+			reasons:   []DuplicateReason{DuplicateReasonSameName},
+			ok:        true,
+		},
+		{
+			name:      "phonetic name and shared parents",
+			candidate: DuplicateCandidate{First: Person{Name: "Jon Smyth"}, Second: Person{Name: "John Smith"}, SharedParents: 2},
+			score:     0.69,
+			reasons:   []DuplicateReason{DuplicateReasonPhoneticName, DuplicateReasonSharedParents},
+			ok:        true,
+		},
+		{
+			name:      "similar name and shared spouse",
+			candidate: DuplicateCandidate{First: Person{Name: "Mariana Silva"}, Second: Person{Name: "Maria Silva"}, SharedSpouses: 1},
+			score:     0.57,
+			reasons:   []DuplicateReason{DuplicateReasonSimilarName, DuplicateReasonSharedSpouse},
+			ok:        true,
+		},
+		{
+			name:      "score up to 1",
+			candidate: DuplicateCandidate{First: Person{Name: "John Smith", Sex: SexMale, BirthDate: "1850", DeathDate: "1920"}, Second: Person{Name: "John Smith", BirthDate: "1850", DeathDate: "1920"}, SharedParents: 2, SharedSpouses: 1},
+			score:     1,
+			reasons:   []DuplicateReason{DuplicateReasonSameName, DuplicateReasonBirthDate, DuplicateReasonDeathDate, DuplicateReasonSharedParents, DuplicateReasonSharedSpouse},
+			ok:        true,
+		},
+		{
+			name:      "different names",
+			candidate: DuplicateCandidate{First: Person{Name: "John Smith"}, Second: Person{Name: "Mary Jones"}, SharedParents: 2},
+			ok:        false,
+		},
+		{
+			name:      "different sexes",
+			candidate: DuplicateCandidate{First: Person{Name: "Sam Smith", Sex: SexMale}, Second: Person{Name: "Sam Smith", Sex: SexFemale}},
+			ok:        false,
+		},
+		{
+			name:      "birth dates apart",
+			candidate: DuplicateCandidate{First: Person{Name: "John Smith", BirthDate: "1850"}, Second: Person{Name: "John Smith", BirthDate: "1860"}},
+			ok:        false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pair, ok := ScoreDuplicate(test.candidate)
+			if ok != test.ok {
+				t.Fatalf("ScoreDuplicate returned %+v, %t, expected %t", pair, ok, test.ok)
+			}
+			if !ok {
+				return
+			}
+			if pair.Score != test.score || fmt.Sprint(pair.Reasons) != fmt.Sprint(test.reasons) {
+				t.Errorf("ScoreDuplicate returned %g %v, expected %g %v", pair.Score, pair.Reasons, test.score, test.reasons)
+			}
+		})
+	}
+}
+
+func TestRankDuplicates(t *testing.T) {
+	candidates := []DuplicateCandidate{
+		{First: Person{Name: "Jon Smyth"}, Second: Person{Name: "John Smith"}},
+		{First: Person{Name: "Mary Jones"}, Second: Person{Name: "Mary Jones"}},
+		{First: Person{Name: "John Smith"}, Second: Person{Name: "Mary Jones"}},
+		{First: Person{Name: "Ann Lee"}, Second: Person{Name: "Ann Lee"}, SharedSpouses: 1},
+	}
+	tests := []struct {
+		name       string
+		minScore   float64
+		pagination PaginationDetails
+		expected   []string
+		total      int
+	}{
+		{name: "every pair", minScore: 0, pagination: PaginationDetails{PageSize: 10}, expected: []string{"Ann Lee", "Mary Jones", "Jon Smyth"}, total: 3},
+		{name: "above the minimum score", minScore: DuplicatesDefaultMinScore, pagination: PaginationDetails{PageSize: 10}, expected: []string{"Ann Lee", "Mary Jones"}, total: 2},
+		{name: "second page", minScore: 0, pagination: PaginationDetails{Page: 1, PageSize: 2}, expected: []string{"Jon Smyth"}, total: 3},
+		{name: "after the last page", minScore: 0, pagination: PaginationDetails{Page: 2, PageSize: 2}, expected: []string{}, total: 3},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			list := RankDuplicates(candidates, test.minScore, test.pagination)
+			names := []string{}
+			for _, pair := range list.Content {
+				names = append(names, pair.First.Name)
+			}
+			if fmt.Sprint(names) != fmt.Sprint(test.expected) || list.Metadata.TotalItens != test.total {
+				t.Errorf("RankDuplicates returned %q of %d, expected %q of %d", names, list.Metadata.TotalItens, test.expected, test.total)
+			}
+		})
+	}
+}
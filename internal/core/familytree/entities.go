@@ -3,6 +3,7 @@ package familytree
 import (
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/google/uuid"
 )
@@ -16,14 +17,13 @@ func (rel RelationType) String() string {
 	return rel.Name
 }
 
+// SessionMode is the mode of a Tx.
 type SessionMode string
-type ContextKey string
 
 const (
 	MaxParents               = 2
 	SessionRead              = SessionMode("READ")
 	SessionWrite             = SessionMode("WRITE")
-	SessionKey               = ContextKey("family_tree_session")
 	GetPeopleMaxPageSize     = 50
 	GetPeopleDefaultPageSize = 10
 	GetPeopleDefaultPage     = 0
@@ -44,36 +44,229 @@ var (
 	ErrOnlyChildFromSpouseCouple = errors.New("can't delete only child relation of spouse coupe")
 	ErrRelationNotFound          = errors.New("relation not found")
 	ErrPersonStillHasRelations   = errors.New("person still has relations")
+	ErrInvalidDate               = errors.New("invalid date")
+	ErrInvalidSex                = errors.New("invalid sex")
+	ErrInvalidUnionEndReason     = errors.New("invalid union end reason")
+	ErrUnionEndsBeforeStart      = errors.New("union ends before it starts")
+	ErrAmbiguousUnion            = errors.New("spouses have several unions, the start date must be given")
+	ErrInvalidParentage          = errors.New("invalid parentage")
+	ErrSameMergeID               = errors.New("can't merge a person into itself")
+	ErrMergeConflict             = errors.New("merge conflicts with the relations of the survivor")
+	ErrMergedSelfRelation        = errors.New("merged person would be related to itself")
 )
 
+// PaginationDetails After and Before replace Page on people lists, the page
+// starts right after or ends right before the person with the key. The total
+// of those pages is only counted on IncludeTotal.
 type PaginationDetails struct {
-	Page     int
-	PageSize int
+	Page         int
+	PageSize     int
+	After        *PeopleSortKey
+	Before       *PeopleSortKey
+	IncludeTotal bool
 }
 
+// ListMetadata Next and Prev are set on people lists when there are people
+// after or before the page, as the keys of its last and first people.
 type ListMetadata struct {
 	Page       int
 	TotalItens int
+	Next       *PeopleSortKey
+	Prev       *PeopleSortKey
 }
+
+func (pagination PaginationDetails) IsKeyset() bool {
+	return pagination.After != nil || pagination.Before != nil
+}
+
+// CountsTotal tells if the total of itens must be counted, offset pages
+// always count it.
+func (pagination PaginationDetails) CountsTotal() bool {
+	return !pagination.IsKeyset() || pagination.IncludeTotal
+}
+
 type PeopleList struct {
 	Content  []*Person
 	Metadata ListMetadata
 }
 
+// Person attributes other than ID and Name are optional, people saved before
+// they existed have them empty.
 type Person struct {
-	ID   uuid.UUID
-	Name string
+	ID         uuid.UUID
+	Name       string
+	GivenName  string
+	Surname    string
+	Sex        Sex
+	BirthDate  Date
+	BirthPlace string
+	DeathDate  Date
+	DeathPlace string
+}
+
+// PersonUpdate holds the fields to change on a person, nil fields are kept as
+// they are and empty values clear the optional attributes.
+type PersonUpdate struct {
+	Name       *string
+	GivenName  *string
+	Surname    *string
+	Sex        *Sex
+	BirthDate  *Date
+	BirthPlace *string
+	DeathDate  *Date
+	DeathPlace *string
+}
+
+func (update PersonUpdate) Apply(person *Person) {
+	if update.Name != nil {
+		person.Name = *update.Name
+	}
+	if update.GivenName != nil {
+		person.GivenName = *update.GivenName
+	}
+	if update.Surname != nil {
+		person.Surname = *update.Surname
+	}
+	if update.Sex != nil {
+		person.Sex = *update.Sex
+	}
+	if update.BirthDate != nil {
+		person.BirthDate = *update.BirthDate
+	}
+	if update.BirthPlace != nil {
+		person.BirthPlace = *update.BirthPlace
+	}
+	if update.DeathDate != nil {
+		person.DeathDate = *update.DeathDate
+	}
+	if update.DeathPlace != nil {
+		person.DeathPlace = *update.DeathPlace
+	}
+}
+
+type UnionEndReason string
+
+const (
+	UnionEndDivorce   = UnionEndReason("DIVORCE")
+	UnionEndDeath     = UnionEndReason("DEATH")
+	UnionEndAnnulment = UnionEndReason("ANNULMENT")
+)
+
+func (reason UnionEndReason) Normalize() (UnionEndReason, error) {
+	normalized := UnionEndReason(strings.ToUpper(strings.TrimSpace(string(reason))))
+	switch normalized {
+	case "", UnionEndDivorce, UnionEndDeath, UnionEndAnnulment:
+		return normalized, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidUnionEndReason, string(reason))
+	}
+}
+
+// Union is the period of a SPOUSE relation. SPOUSE relations saved before
+// unions existed have every field empty and are ongoing. The same spouses may
+// have several unions, as long as they don't overlap, so the start date tells
+// their unions apart.
+type Union struct {
+	StartDate Date
+	EndDate   Date
+	EndReason UnionEndReason
+}
+
+// Ongoing tells if the union has not ended, a person can only have one ongoing
+// union.
+func (union Union) Ongoing() bool {
+	return union.EndDate.IsZero() && union.EndReason == ""
+}
+
+// Overlaps tells if the unions may have been at the same time, they're only
+// apart when one of them surely ended before the other started. Unions with
+// an unknown end or start overlap every other one.
+func (union Union) Overlaps(other Union) bool {
+	return !union.endsBefore(other) && !other.endsBefore(union)
+}
+
+func (union Union) endsBefore(other Union) bool {
+	_, end := union.EndDate.Bounds()
+	start, _ := other.StartDate.Bounds()
+	return !end.IsZero() && !start.IsZero() && end.Before(start)
+}
+
+// UnionUpdate holds the fields to change on a union, as PersonUpdate does.
+type UnionUpdate struct {
+	StartDate *Date
+	EndDate   *Date
+	EndReason *UnionEndReason
+}
+
+func (update UnionUpdate) Apply(union *Union) {
+	if update.StartDate != nil {
+		union.StartDate = *update.StartDate
+	}
+	if update.EndDate != nil {
+		union.EndDate = *update.EndDate
+	}
+	if update.EndReason != nil {
+		union.EndReason = *update.EndReason
+	}
+}
+
+type PersonUnion struct {
+	Spouse Person
+	Union  Union
+}
+
+// Parentage qualifies a PARENT relation. PARENT relations saved before it
+// existed have no parentage and are biological.
+type Parentage string
+
+const (
+	ParentageBiological = Parentage("BIOLOGICAL")
+	ParentageAdoptive   = Parentage("ADOPTIVE")
+	ParentageFoster     = Parentage("FOSTER")
+	ParentageStep       = Parentage("STEP")
+	ParentageGuardian   = Parentage("GUARDIAN")
+)
+
+// Normalize returns the parentage on upper case, or ErrInvalidParentage. The
+// empty parentage is biological.
+func (parentage Parentage) Normalize() (Parentage, error) {
+	normalized := Parentage(strings.ToUpper(strings.TrimSpace(string(parentage))))
+	switch normalized {
+	case "":
+		return ParentageBiological, nil
+	case ParentageBiological, ParentageAdoptive, ParentageFoster, ParentageStep, ParentageGuardian:
+		return normalized, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidParentage, string(parentage))
+	}
+}
+
+func (parentage Parentage) IsBiological() bool {
+	return parentage == "" || parentage == ParentageBiological
+}
+
+type PersonParent struct {
+	Parent    Person
+	Parentage Parentage
 }
 
+// PersonRelation Union is only used by SPOUSE relations and Parentage by
+// PARENT relations.
 type PersonRelation struct {
 	Top          Person
 	Bottom       Person
 	RelationType RelationType
+	Union        Union
+	Parentage    Parentage
 }
 
+// FamilyTreeRelation Union is only set on SPOUSE relations and Parentage on
+// PARENT relations.
 type FamilyTreeRelation struct {
 	PersonID     uuid.UUID
 	RelationType RelationType
+	Union        *Union
+	Parentage    Parentage
 }
 type FamilyTreeNode struct {
 	Person    Person
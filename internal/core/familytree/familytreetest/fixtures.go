@@ -0,0 +1,262 @@
+package familytreetest
+
+import (
+	"context"
+	"family-tree/internal/core/familytree"
+	"testing"
+)
+
+// RepoFactory must return an empty repository every time it's called, the
+// suite builds a new fixture on each subtest.
+type RepoFactory func(t *testing.T) familytree.FamilyTreeRepo
+
+// Fixture builds the people and relations on a WRITE Tx, tests running other
+// Txs or use cases call Commit first, the memory repo runs one WRITE Tx at a
+// time.
+type Fixture struct {
+	Repo        familytree.FamilyTreeRepo
+	Ctx         context.Context
+	Tx          familytree.Tx
+	People      map[string]familytree.Person
+	personNames map[string]string
+}
+
+func beginTx(t *testing.T, repo familytree.FamilyTreeRepo, mode familytree.SessionMode) familytree.Tx {
+	t.Helper()
+	tx, err := repo.Begin(context.Background(), mode)
+	if err != nil {
+		t.Fatalf("Begin(%s) returned error: %v", mode, err)
+	}
+	t.Cleanup(func() {
+		tx.Rollback(context.Background())
+	})
+	return tx
+}
+
+func NewFixture(t *testing.T, newRepo RepoFactory) *Fixture {
+	t.Helper()
+	repo := newRepo(t)
+	return &Fixture{
+		Repo:        repo,
+		Ctx:         context.Background(),
+		Tx:          beginTx(t, repo, familytree.SessionWrite),
+		People:      map[string]familytree.Person{},
+		personNames: map[string]string{},
+	}
+}
+
+// Commit commits the fixture Tx and replaces it with a READ one, so the fixture
+// can only be read afterwards.
+func (fixture *Fixture) Commit(t *testing.T) {
+	t.Helper()
+	if err := fixture.Tx.Commit(fixture.Ctx); err != nil {
+		t.Fatalf("Commit returned error: %v", err)
+	}
+	fixture.Tx = beginTx(t, fixture.Repo, familytree.SessionRead)
+}
+
+func (fixture *Fixture) AddPeople(t *testing.T, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		person := &familytree.Person{Name: name}
+		if err := fixture.Repo.SavePerson(fixture.Ctx, fixture.Tx, person); err != nil {
+			t.Fatalf("SavePerson(%s) returned error: %v", name, err)
+		}
+		fixture.People[name] = *person
+		fixture.personNames[person.ID.String()] = name
+	}
+}
+
+// AddDetailedPeople saves the people with their attributes, they are named
+// after their Name.
+func (fixture *Fixture) AddDetailedPeople(t *testing.T, people ...familytree.Person) {
+	t.Helper()
+	for _, person := range people {
+		person := person
+		if err := fixture.Repo.SavePerson(fixture.Ctx, fixture.Tx, &person); err != nil {
+			t.Fatalf("SavePerson(%s) returned error: %v", person.Name, err)
+		}
+		fixture.People[person.Name] = person
+		fixture.personNames[person.ID.String()] = person.Name
+	}
+}
+
+func (fixture *Fixture) AddParent(t *testing.T, parent string, children ...string) {
+	t.Helper()
+	for _, child := range children {
+		fixture.addRelation(t, parent, child, familytree.RelationTypeParent)
+	}
+}
+
+// AddParentage saves PARENT relations with the given parentage, AddParent
+// saves them without one, like the relations saved before it existed.
+func (fixture *Fixture) AddParentage(t *testing.T, parent string, parentage familytree.Parentage, children ...string) {
+	t.Helper()
+	for _, child := range children {
+		fixture.saveRelation(t, familytree.PersonRelation{
+			Top:          fixture.Person(t, parent),
+			Bottom:       fixture.Person(t, child),
+			RelationType: familytree.RelationTypeParent,
+			Parentage:    parentage,
+		})
+	}
+}
+
+func (fixture *Fixture) AddSpouse(t *testing.T, firstSpouse string, secondSpouse string) {
+	t.Helper()
+	fixture.addRelation(t, firstSpouse, secondSpouse, familytree.RelationTypeSpouse)
+}
+
+// AddUnion saves a SPOUSE relation with the given period.
+func (fixture *Fixture) AddUnion(t *testing.T, firstSpouse string, secondSpouse string, union familytree.Union) {
+	t.Helper()
+	fixture.saveRelation(t, familytree.PersonRelation{
+		Top:          fixture.Person(t, firstSpouse),
+		Bottom:       fixture.Person(t, secondSpouse),
+		RelationType: familytree.RelationTypeSpouse,
+		Union:        union,
+	})
+}
+
+func (fixture *Fixture) addRelation(t *testing.T, top string, bottom string, relationType familytree.RelationType) {
+	t.Helper()
+	fixture.saveRelation(t, familytree.PersonRelation{
+		Top:          fixture.Person(t, top),
+		Bottom:       fixture.Person(t, bottom),
+		RelationType: relationType,
+	})
+}
+
+func (fixture *Fixture) saveRelation(t *testing.T, relation familytree.PersonRelation) {
+	t.Helper()
+	if err := fixture.Repo.SaveRelation(fixture.Ctx, fixture.Tx, relation); err != nil {
+		t.Fatalf("SaveRelation(%s, %s, %s) returned error: %v", fixture.Name(&relation.Top), fixture.Name(&relation.Bottom), relation.RelationType, err)
+	}
+}
+
+func (fixture *Fixture) Person(t *testing.T, name string) familytree.Person {
+	t.Helper()
+	person, ok := fixture.People[name]
+	if !ok {
+		t.Fatalf("person %s is not part of the fixture", name)
+	}
+	return person
+}
+
+// Name returns the fixture name of a saved person, unknown ids are returned
+// as they are so failures still show something useful.
+func (fixture *Fixture) Name(person *familytree.Person) string {
+	if person == nil {
+		return "<nil>"
+	}
+	name, ok := fixture.personNames[person.ID.String()]
+	if !ok {
+		return person.ID.String()
+	}
+	return name
+}
+
+// NewGrandparentsFixture builds three generations around Child:
+//
+//	Grandpa = Grandma          Mother's side has no parents
+//	    |-------------|
+//	  Father = Mother        Uncle
+//	    |---------|            |
+//	  Child     Sibling      Cousin
+//	    |          |
+//	Grandchild  Nephew
+//
+// Child and Partner are the parents of Grandchild and are married. Partner has
+// no blood relation with Child.
+func NewGrandparentsFixture(t *testing.T, newRepo RepoFactory) *Fixture {
+	t.Helper()
+	fixture := NewFixture(t, newRepo)
+	fixture.AddPeople(t, "Grandpa", "Grandma", "Father", "Mother", "Uncle", "Child", "Sibling", "Cousin", "Partner", "Grandchild", "Nephew", "Stranger")
+	fixture.AddParent(t, "Grandpa", "Father", "Uncle")
+	fixture.AddParent(t, "Grandma", "Father", "Uncle")
+	fixture.AddSpouse(t, "Grandpa", "Grandma")
+	fixture.AddParent(t, "Father", "Child", "Sibling")
+	fixture.AddParent(t, "Mother", "Child", "Sibling")
+	fixture.AddSpouse(t, "Father", "Mother")
+	fixture.AddParent(t, "Uncle", "Cousin")
+	fixture.AddParent(t, "Child", "Grandchild")
+	fixture.AddParent(t, "Partner", "Grandchild")
+	fixture.AddSpouse(t, "Child", "Partner")
+	fixture.AddParent(t, "Sibling", "Nephew")
+	return fixture
+}
+
+// NewHalfSiblingsFixture builds Father with two children from different
+// mothers. Father is married to SecondWife only.
+//
+//	FirstWife   Father = SecondWife
+//	    |--------|   |-------|
+//	 OlderHalf        YoungerHalf
+func NewHalfSiblingsFixture(t *testing.T, newRepo RepoFactory) *Fixture {
+	t.Helper()
+	fixture := NewFixture(t, newRepo)
+	fixture.AddPeople(t, "Father", "FirstWife", "SecondWife", "OlderHalf", "YoungerHalf")
+	fixture.AddParent(t, "Father", "OlderHalf", "YoungerHalf")
+	fixture.AddParent(t, "FirstWife", "OlderHalf")
+	fixture.AddParent(t, "SecondWife", "YoungerHalf")
+	fixture.AddSpouse(t, "Father", "SecondWife")
+	return fixture
+}
+
+// NewRemarriedFixture builds Husband that was married to FirstWife, had
+// FirstChild, divorced and then married SecondWife, with whom he had
+// SecondChild and ThirdChild. The first SPOUSE relation is saved and then
+// deleted, the same way the API would do it.
+func NewRemarriedFixture(t *testing.T, newRepo RepoFactory) *Fixture {
+	t.Helper()
+	fixture := NewFixture(t, newRepo)
+	fixture.AddPeople(t, "Husband", "FirstWife", "SecondWife", "FirstChild", "SecondChild", "ThirdChild")
+	fixture.AddParent(t, "Husband", "FirstChild", "SecondChild", "ThirdChild")
+	fixture.AddParent(t, "FirstWife", "FirstChild")
+	fixture.AddParent(t, "SecondWife", "SecondChild", "ThirdChild")
+	fixture.AddSpouse(t, "Husband", "FirstWife")
+	ok, err := fixture.Repo.DeleteRelationship(fixture.Ctx, fixture.Tx, fixture.Person(t, "Husband"), fixture.Person(t, "FirstWife"), familytree.RelationTypeSpouse)
+	if err != nil || !ok {
+		t.Fatalf("DeleteRelationship(Husband, FirstWife) returned %v, %v", ok, err)
+	}
+	fixture.AddSpouse(t, "Husband", "SecondWife")
+	return fixture
+}
+
+// NewDivorcedFixture builds Husband that married FirstWife in 1900, had
+// FirstChild and divorced in 1910, then married SecondWife in 1912, with whom
+// he had SecondChild. Both unions are kept, only the second is ongoing.
+func NewDivorcedFixture(t *testing.T, newRepo RepoFactory) *Fixture {
+	t.Helper()
+	fixture := NewFixture(t, newRepo)
+	fixture.AddPeople(t, "Husband", "FirstWife", "SecondWife", "FirstChild", "SecondChild")
+	fixture.AddParent(t, "Husband", "FirstChild", "SecondChild")
+	fixture.AddParent(t, "FirstWife", "FirstChild")
+	fixture.AddParent(t, "SecondWife", "SecondChild")
+	fixture.AddUnion(t, "Husband", "FirstWife", familytree.Union{StartDate: "1900", EndDate: "1910", EndReason: familytree.UnionEndDivorce})
+	fixture.AddUnion(t, "Husband", "SecondWife", familytree.Union{StartDate: "1912"})
+	return fixture
+}
+
+// NewAdoptionFixture builds Adoptee, the biological child of BirthFather and
+// BirthMother adopted by AdoptiveFather and AdoptiveMother, who also have
+// AdoptiveSibling. StepMother is married to BirthFather and is Adoptee's step
+// parent.
+//
+//	BirthFather = StepMother   AdoptiveFather = AdoptiveMother
+//	BirthMother     |                |----------|
+//	    |-----------|            AdoptiveSibling
+//	 Adoptee <- adopted by AdoptiveFather and AdoptiveMother
+func NewAdoptionFixture(t *testing.T, newRepo RepoFactory) *Fixture {
+	t.Helper()
+	fixture := NewFixture(t, newRepo)
+	fixture.AddPeople(t, "BirthFather", "BirthMother", "StepMother", "AdoptiveFather", "AdoptiveMother", "Adoptee", "AdoptiveSibling")
+	fixture.AddParentage(t, "BirthFather", familytree.ParentageBiological, "Adoptee")
+	fixture.AddParent(t, "BirthMother", "Adoptee")
+	fixture.AddParentage(t, "StepMother", familytree.ParentageStep, "Adoptee")
+	fixture.AddParentage(t, "AdoptiveFather", familytree.ParentageAdoptive, "Adoptee")
+	fixture.AddParentage(t, "AdoptiveMother", familytree.ParentageAdoptive, "Adoptee")
+	fixture.AddParent(t, "AdoptiveFather", "AdoptiveSibling")
+	fixture.AddParent(t, "AdoptiveMother", "AdoptiveSibling")
+	return fixture
+}
@@ -0,0 +1,2255 @@
+// Package familytreetest holds the behaviour every familytree.FamilyTreeRepo
+// adapter must follow. Adapters plug into it from their own tests:
+//
+//	func TestFamilyTreeRepo(t *testing.T) {
+//		familytreetest.RunSuite(t, func(t *testing.T) familytree.FamilyTreeRepo {
+//			return NewFamilyTreeRepo()
+//		})
+//	}
+package familytreetest
+
+import (
+	"context"
+	"errors"
+	"family-tree/internal/core/familytree"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func RunSuite(t *testing.T, newRepo RepoFactory) {
+	t.Run("Session", func(t *testing.T) { testSession(t, newRepo) })
+	t.Run("SavePerson", func(t *testing.T) { testSavePerson(t, newRepo) })
+	t.Run("UpdatePerson", func(t *testing.T) { testUpdatePerson(t, newRepo) })
+	t.Run("GetParents", func(t *testing.T) { testGetParents(t, newRepo) })
+	t.Run("GetLowestCommonAncestor", func(t *testing.T) { testGetLowestCommonAncestor(t, newRepo) })
+	t.Run("GetCommonAncestors", func(t *testing.T) { testGetCommonAncestors(t, newRepo) })
+	t.Run("Parentage", func(t *testing.T) { testParentage(t, newRepo) })
+	t.Run("ParentageCycles", func(t *testing.T) { testParentageCycles(t, newRepo) })
+	t.Run("GetPeople", func(t *testing.T) { testGetPeople(t, newRepo) })
+	t.Run("SortPeople", func(t *testing.T) { testSortPeople(t, newRepo) })
+	t.Run("FilterPeople", func(t *testing.T) { testFilterPeople(t, newRepo) })
+	t.Run("PeopleKeyset", func(t *testing.T) { testPeopleKeyset(t, newRepo) })
+	t.Run("SearchPeople", func(t *testing.T) { testSearchPeople(t, newRepo) })
+	t.Run("GetFamilyTree", func(t *testing.T) { testGetFamilyTree(t, newRepo) })
+	t.Run("GetDescendants", func(t *testing.T) { testGetDescendants(t, newRepo) })
+	t.Run("GetAncestors", func(t *testing.T) { testGetAncestors(t, newRepo) })
+	t.Run("GetShortestPathLength", func(t *testing.T) { testGetShortestPathLength(t, newRepo) })
+	t.Run("GetShortestPaths", func(t *testing.T) { testGetShortestPaths(t, newRepo) })
+	t.Run("HasCommonChild", func(t *testing.T) { testHasCommonChild(t, newRepo) })
+	t.Run("GetSpouse", func(t *testing.T) { testGetSpouse(t, newRepo) })
+	t.Run("Unions", func(t *testing.T) { testUnions(t, newRepo) })
+	t.Run("UpdateSpouseRelation", func(t *testing.T) { testUpdateSpouseRelation(t, newRepo) })
+	t.Run("Remarriage", func(t *testing.T) { testRemarriage(t, newRepo) })
+	t.Run("GetParentMaritalChildCount", func(t *testing.T) { testGetParentMaritalChildCount(t, newRepo) })
+	t.Run("DeleteRelationship", func(t *testing.T) { testDeleteRelationship(t, newRepo) })
+	t.Run("DeletePerson", func(t *testing.T) { testDeletePerson(t, newRepo) })
+	t.Run("DeletePersonCascade", func(t *testing.T) { testDeletePersonCascade(t, newRepo) })
+	t.Run("Transactions", func(t *testing.T) { testTransactions(t, newRepo) })
+	t.Run("ConcurrentRelations", func(t *testing.T) { testConcurrentRelations(t, newRepo) })
+	t.Run("Redirects", func(t *testing.T) { testRedirects(t, newRepo) })
+	t.Run("MergePeople", func(t *testing.T) { testMergePeople(t, newRepo) })
+	t.Run("Duplicates", func(t *testing.T) { testDuplicates(t, newRepo) })
+	t.Run("Trash", func(t *testing.T) { testTrash(t, newRepo) })
+	t.Run("RestoreFromTrash", func(t *testing.T) { testRestoreFromTrash(t, newRepo) })
+	t.Run("Audit", func(t *testing.T) { testAudit(t, newRepo, repoAuditSink) })
+	t.Run("AuditRollback", func(t *testing.T) { testAuditRollback(t, newRepo) })
+	t.Run("AuditCommit", func(t *testing.T) { testAuditCommit(t, newRepo, repoAuditSink) })
+}
+
+// AuditSinkFactory returns the sink the use cases on the repo record their
+// events on.
+type AuditSinkFactory func(t *testing.T, repo familytree.FamilyTreeRepo) familytree.AuditSink
+
+// RunAuditSuite runs the audit tests of an audit sink out of the repo, on the
+// repos of newRepo.
+func RunAuditSuite(t *testing.T, newRepo RepoFactory, newSink AuditSinkFactory) {
+	t.Run("Audit", func(t *testing.T) { testAudit(t, newRepo, newSink) })
+	t.Run("AuditCommit", func(t *testing.T) { testAuditCommit(t, newRepo, newSink) })
+}
+
+// repoAuditSink is the repo itself, repos that aren't audit sinks skip the
+// audit tests.
+func repoAuditSink(t *testing.T, repo familytree.FamilyTreeRepo) familytree.AuditSink {
+	t.Helper()
+	auditSink, ok := repo.(familytree.AuditSink)
+	if !ok {
+		t.Skip("the repo isn't an audit sink")
+	}
+	return auditSink
+}
+
+func testSession(t *testing.T, newRepo RepoFactory) {
+	repo := newRepo(t)
+	ctx := context.Background()
+	if _, err := repo.Begin(ctx, familytree.SessionMode("INVALID")); err == nil {
+		t.Errorf("Begin(INVALID) returned no error")
+	}
+	if _, err := repo.GetPerson(ctx, nil, uuid.New()); err == nil {
+		t.Errorf("GetPerson without a Tx returned no error")
+	}
+	if _, err := repo.GetPerson(ctx, beginTx(t, newRepo(t), familytree.SessionRead), uuid.New()); err == nil {
+		t.Errorf("GetPerson with a Tx of another repo returned no error")
+	}
+	readTx := beginTx(t, repo, familytree.SessionRead)
+	if readTx.Mode() != familytree.SessionRead {
+		t.Errorf("Mode of a READ Tx returned %s", readTx.Mode())
+	}
+	if err := repo.SavePerson(ctx, readTx, &familytree.Person{Name: "Person"}); !errors.Is(err, familytree.ErrReadOnlyTx) {
+		t.Errorf("SavePerson on a READ Tx returned %v, expected %v", err, familytree.ErrReadOnlyTx)
+	}
+	if err := readTx.Commit(ctx); err != nil {
+		t.Fatalf("Commit of a READ Tx returned error: %v", err)
+	}
+	if _, err := repo.GetPerson(ctx, readTx, uuid.New()); !errors.Is(err, familytree.ErrTxDone) {
+		t.Errorf("GetPerson after the commit returned %v, expected %v", err, familytree.ErrTxDone)
+	}
+	if err := readTx.Commit(ctx); !errors.Is(err, familytree.ErrTxDone) {
+		t.Errorf("Commit of a committed Tx returned %v, expected %v", err, familytree.ErrTxDone)
+	}
+	if err := readTx.Rollback(ctx); err != nil {
+		t.Errorf("Rollback of a committed Tx returned error: %v", err)
+	}
+}
+
+func testSavePerson(t *testing.T, newRepo RepoFactory) {
+	fixture := NewFixture(t, newRepo)
+	fixture.AddPeople(t, "First", "Second")
+	first := fixture.Person(t, "First")
+	second := fixture.Person(t, "Second")
+	if first.ID == uuid.Nil || second.ID == uuid.Nil {
+		t.Fatalf("SavePerson didn't set the person ID")
+	}
+	if first.ID == second.ID {
+		t.Fatalf("SavePerson returned the same ID for two people")
+	}
+
+	found, err := fixture.Repo.GetPerson(fixture.Ctx, fixture.Tx, first.ID)
+	if err != nil {
+		t.Fatalf("GetPerson returned error: %v", err)
+	}
+	if found == nil || !reflect.DeepEqual(*found, first) {
+		t.Errorf("GetPerson returned %+v, expected %+v", found, first)
+	}
+
+	notFound, err := fixture.Repo.GetPerson(fixture.Ctx, fixture.Tx, uuid.New())
+	if err != nil {
+		t.Errorf("GetPerson of an unknown person returned error %v, expected nil", err)
+	}
+	if notFound != nil {
+		t.Errorf("GetPerson of an unknown person returned %+v, expected nil", notFound)
+	}
+
+	detailed := &familytree.Person{
+		Name:       "Ada Lovelace",
+		GivenName:  "Ada",
+		Surname:    "Lovelace",
+		Sex:        familytree.SexFemale,
+		BirthDate:  "1815-12-10",
+		BirthPlace: "London",
+		DeathDate:  "ABT 1852",
+		DeathPlace: "Marylebone",
+	}
+	if err := fixture.Repo.SavePerson(fixture.Ctx, fixture.Tx, detailed); err != nil {
+		t.Fatalf("SavePerson with every attribute returned error: %v", err)
+	}
+	found, err = fixture.Repo.GetPerson(fixture.Ctx, fixture.Tx, detailed.ID)
+	if err != nil {
+		t.Fatalf("GetPerson returned error: %v", err)
+	}
+	if found == nil || !reflect.DeepEqual(*found, *detailed) {
+		t.Errorf("GetPerson returned %+v, expected %+v", found, *detailed)
+	}
+}
+
+func testUpdatePerson(t *testing.T, newRepo RepoFactory) {
+	fixture := NewGrandparentsFixture(t, newRepo)
+	father := fixture.Person(t, "Father")
+	father.Name = "Renamed Father"
+	father.Sex = familytree.SexMale
+	father.BirthDate = "BET 1950 AND 1955"
+	father.DeathPlace = "Lisbon"
+	if err := fixture.Repo.UpdatePerson(fixture.Ctx, fixture.Tx, &father); err != nil {
+		t.Fatalf("UpdatePerson returned error: %v", err)
+	}
+	found, err := fixture.Repo.GetPerson(fixture.Ctx, fixture.Tx, father.ID)
+	if err != nil || found == nil {
+		t.Fatalf("GetPerson after update returned %v, %v", found, err)
+	}
+	if !reflect.DeepEqual(*found, father) {
+		t.Errorf("GetPerson after update returned %+v, expected %+v", *found, father)
+	}
+	// Relations are kept
+	parents, err := fixture.Repo.GetParents(fixture.Ctx, fixture.Tx, fixture.Person(t, "Child").ID)
+	if err != nil {
+		t.Fatalf("GetParents(Child) returned error: %v", err)
+	}
+	if len(parents) != 2 {
+		t.Errorf("GetParents(Child) after updating Father returned %d parents, expected 2", len(parents))
+	}
+
+	err = fixture.Repo.UpdatePerson(fixture.Ctx, fixture.Tx, &familytree.Person{ID: uuid.New(), Name: "Nobody"})
+	if !errors.Is(err, familytree.ErrPersonNotFound) {
+		t.Errorf("UpdatePerson of an unknown person returned %v, expected %v", err, familytree.ErrPersonNotFound)
+	}
+}
+
+func testGetParents(t *testing.T, newRepo RepoFactory) {
+	fixture := NewGrandparentsFixture(t, newRepo)
+	cases := []struct {
+		person   string
+		expected []string
+	}{
+		{"Child", []string{"Father", "Mother"}},
+		{"Cousin", []string{"Uncle"}},
+		{"Grandpa", []string{}},
+	}
+	for _, testCase := range cases {
+		parents, err := fixture.Repo.GetParents(fixture.Ctx, fixture.Tx, fixture.Person(t, testCase.person).ID)
+		if err != nil {
+			t.Errorf("GetParents(%s) returned error: %v", testCase.person, err)
+			continue
+		}
+		assertNames(t, fmt.Sprintf("GetParents(%s)", testCase.person), fixture.parentNames(parents), testCase.expected)
+	}
+
+	parents, err := fixture.Repo.GetParents(fixture.Ctx, fixture.Tx, uuid.New())
+	if err != nil || len(parents) != 0 {
+		t.Errorf("GetParents of an unknown person returned %v, %v, expected no parents and no error", parents, err)
+	}
+}
+
+func testGetLowestCommonAncestor(t *testing.T, newRepo RepoFactory) {
+	fixture := NewGrandparentsFixture(t, newRepo)
+	halfSiblings := NewHalfSiblingsFixture(t, newRepo)
+	cases := []struct {
+		fixture       *Fixture
+		first, second string
+		// Ancestors at the same distance are all valid answers
+		expected []string
+	}{
+		{fixture, "Child", "Cousin", []string{"Grandpa", "Grandma"}},
+		{fixture, "Child", "Sibling", []string{"Father", "Mother"}},
+		{fixture, "Nephew", "Cousin", []string{"Grandpa", "Grandma"}},
+		{fixture, "Father", "Child", []string{"Father"}},
+		{fixture, "Child", "Father", []string{"Father"}},
+		{fixture, "Child", "Child", []string{"Child"}},
+		{fixture, "Child", "Partner", nil},
+		{fixture, "Child", "Stranger", nil},
+		{halfSiblings, "OlderHalf", "YoungerHalf", []string{"Father"}},
+	}
+	for _, testCase := range cases {
+		name := fmt.Sprintf("GetLowestCommonAncestor(%s, %s)", testCase.first, testCase.second)
+		ancestor, err := testCase.fixture.Repo.GetLowestCommonAncestor(testCase.fixture.Ctx, testCase.fixture.Tx, testCase.fixture.Person(t, testCase.first), testCase.fixture.Person(t, testCase.second))
+		if err != nil {
+			t.Errorf("%s returned error: %v", name, err)
+			continue
+		}
+		if testCase.expected == nil {
+			if ancestor != nil {
+				t.Errorf("%s returned %s, expected nil", name, testCase.fixture.Name(ancestor))
+			}
+			continue
+		}
+		if !contains(testCase.expected, testCase.fixture.Name(ancestor)) {
+			t.Errorf("%s returned %s, expected one of %v", name, testCase.fixture.Name(ancestor), testCase.expected)
+		}
+	}
+}
+
+func testGetCommonAncestors(t *testing.T, newRepo RepoFactory) {
+	fixture := NewGrandparentsFixture(t, newRepo)
+	adoption := NewAdoptionFixture(t, newRepo)
+	cases := []struct {
+		fixture       *Fixture
+		first, second string
+		expected      []string
+	}{
+		{fixture, "Child", "Cousin", []string{"Grandma 2 2", "Grandpa 2 2"}},
+		{fixture, "Child", "Sibling", []string{"Father 1 1", "Grandma 2 2", "Grandpa 2 2", "Mother 1 1"}},
+		{fixture, "Nephew", "Uncle", []string{"Grandma 3 1", "Grandpa 3 1"}},
+		{fixture, "Grandchild", "Father", []string{"Father 2 0", "Grandma 3 1", "Grandpa 3 1"}},
+		{fixture, "Child", "Child", []string{"Child 0 0", "Father 1 1", "Grandma 2 2", "Grandpa 2 2", "Mother 1 1"}},
+		{fixture, "Child", "Partner", []string{}},
+		{fixture, "Child", "Stranger", []string{}},
+		{adoption, "Adoptee", "AdoptiveSibling", []string{}},
+	}
+	for _, testCase := range cases {
+		ancestors, err := testCase.fixture.Repo.GetCommonAncestors(testCase.fixture.Ctx, testCase.fixture.Tx, testCase.fixture.Person(t, testCase.first), testCase.fixture.Person(t, testCase.second))
+		if err != nil {
+			t.Errorf("GetCommonAncestors(%s, %s) returned error: %v", testCase.first, testCase.second, err)
+			continue
+		}
+		found := make([]string, 0, len(ancestors))
+		for _, ancestor := range ancestors {
+			found = append(found, fmt.Sprintf("%s %d %d", testCase.fixture.Name(&ancestor.Ancestor), ancestor.FirstDistance, ancestor.SecondDistance))
+		}
+		sort.Strings(found)
+		if !reflect.DeepEqual(found, testCase.expected) {
+			t.Errorf("GetCommonAncestors(%s, %s) returned %v, expected %v", testCase.first, testCase.second, found, testCase.expected)
+		}
+	}
+}
+
+func testParentage(t *testing.T, newRepo RepoFactory) {
+	fixture := NewAdoptionFixture(t, newRepo)
+	parents, err := fixture.Repo.GetParents(fixture.Ctx, fixture.Tx, fixture.Person(t, "Adoptee").ID)
+	if err != nil {
+		t.Fatalf("GetParents(Adoptee) returned error: %v", err)
+	}
+	found := map[string]familytree.Parentage{}
+	for _, parent := range parents {
+		found[fixture.Name(&parent.Parent)] = parent.Parentage
+	}
+	// Relations saved without parentage are biological
+	expected := map[string]familytree.Parentage{
+		"BirthFather":    familytree.ParentageBiological,
+		"BirthMother":    familytree.ParentageBiological,
+		"StepMother":     familytree.ParentageStep,
+		"AdoptiveFather": familytree.ParentageAdoptive,
+		"AdoptiveMother": familytree.ParentageAdoptive,
+	}
+	if !reflect.DeepEqual(found, expected) {
+		t.Errorf("GetParents(Adoptee) returned %v, expected %v", found, expected)
+	}
+
+	// Only biological lineage makes people relatives
+	ancestorCases := []struct {
+		first, second string
+		expected      string
+	}{
+		{"BirthFather", "Adoptee", "BirthFather"},
+		{"AdoptiveFather", "Adoptee", "<nil>"},
+		{"StepMother", "Adoptee", "<nil>"},
+		{"Adoptee", "AdoptiveSibling", "<nil>"},
+	}
+	for _, testCase := range ancestorCases {
+		ancestor, err := fixture.Repo.GetLowestCommonAncestor(fixture.Ctx, fixture.Tx, fixture.Person(t, testCase.first), fixture.Person(t, testCase.second))
+		if err != nil {
+			t.Errorf("GetLowestCommonAncestor(%s, %s) returned error: %v", testCase.first, testCase.second, err)
+			continue
+		}
+		if name := fixture.Name(ancestor); name != testCase.expected {
+			t.Errorf("GetLowestCommonAncestor(%s, %s) returned %s, expected %s", testCase.first, testCase.second, name, testCase.expected)
+		}
+	}
+
+	tree, err := fixture.Repo.GetFamilyTree(fixture.Ctx, fixture.Tx, fixture.Person(t, "Adoptee"))
+	if err != nil {
+		t.Fatalf("GetFamilyTree(Adoptee) returned error: %v", err)
+	}
+	treeParentages := map[string]familytree.Parentage{}
+	for _, node := range tree.People {
+		for _, relation := range node.Relations {
+			if relation.RelationType == familytree.RelationTypeParent && relation.PersonID == fixture.Person(t, "Adoptee").ID {
+				treeParentages[fixture.Name(&node.Person)] = relation.Parentage
+			}
+		}
+	}
+	if !reflect.DeepEqual(treeParentages, expected) {
+		t.Errorf("GetFamilyTree(Adoptee) returned parentages %v, expected %v", treeParentages, expected)
+	}
+}
+
+// testParentageCycles follows every parentage, so Adoptee can't become the
+// parent of the people who adopted or raised it, of any parentage.
+func testParentageCycles(t *testing.T, newRepo RepoFactory) {
+	fixture := NewAdoptionFixture(t, newRepo)
+	descendantCases := []struct {
+		ancestor, descendant string
+		expected             bool
+	}{
+		{"BirthFather", "Adoptee", true},
+		{"AdoptiveFather", "Adoptee", true},
+		{"StepMother", "Adoptee", true},
+		{"Adoptee", "AdoptiveFather", false},
+		{"Adoptee", "Adoptee", false},
+		{"AdoptiveSibling", "Adoptee", false},
+	}
+	for _, testCase := range descendantCases {
+		isDescendant, err := fixture.Repo.IsDescendant(fixture.Ctx, fixture.Tx, fixture.Person(t, testCase.ancestor), fixture.Person(t, testCase.descendant))
+		if err != nil || isDescendant != testCase.expected {
+			t.Errorf("IsDescendant(%s, %s) returned %v, %v, expected %v", testCase.ancestor, testCase.descendant, isDescendant, err, testCase.expected)
+		}
+	}
+
+	fixture.Commit(t)
+	useCase := familytree.NewRelationshipUseCase(fixture.Repo, familytree.DefaultRelationRules(), nil)
+	adoptee := fixture.Person(t, "Adoptee")
+	for _, name := range []string{"AdoptiveFather", "StepMother"} {
+		for _, parentage := range []familytree.Parentage{familytree.ParentageBiological, familytree.ParentageAdoptive, familytree.ParentageFoster} {
+			_, err := useCase.CreateParentRelation(fixture.Ctx, adoptee.ID, fixture.Person(t, name).ID, parentage)
+			if !errors.Is(err, familytree.ErrIncestuousRelation) {
+				t.Errorf("CreateParentRelation(Adoptee, %s, %s) returned %v, expected %v", name, parentage, err, familytree.ErrIncestuousRelation)
+			}
+		}
+	}
+	// Relatives who aren't descendants may still raise each other
+	if _, err := useCase.CreateParentRelation(fixture.Ctx, fixture.Person(t, "AdoptiveSibling").ID, adoptee.ID, familytree.ParentageFoster); err != nil {
+		t.Errorf("CreateParentRelation(AdoptiveSibling, Adoptee, FOSTER) returned error: %v", err)
+	}
+}
+
+func testGetPeople(t *testing.T, newRepo RepoFactory) {
+	fixture := NewGrandparentsFixture(t, newRepo)
+	total := len(fixture.People)
+	pageSize := 5
+	seen := map[uuid.UUID]bool{}
+	for page := 0; page*pageSize < total+pageSize; page++ {
+		list, err := fixture.Repo.GetPeople(fixture.Ctx, fixture.Tx, familytree.PeopleFilter{}, familytree.PeopleSort{Field: familytree.PeopleSortCreatedAt}, familytree.PaginationDetails{Page: page, PageSize: pageSize})
+		if err != nil {
+			t.Fatalf("GetPeople(page %d) returned error: %v", page, err)
+		}
+		if list.Metadata.TotalItens != total || list.Metadata.Page != page {
+			t.Errorf("GetPeople(page %d) returned metadata %+v, expected page %d and %d itens", page, list.Metadata, page, total)
+		}
+		expectedSize := total - page*pageSize
+		if expectedSize > pageSize {
+			expectedSize = pageSize
+		}
+		if expectedSize < 0 {
+			expectedSize = 0
+		}
+		if len(list.Content) != expectedSize {
+			t.Errorf("GetPeople(page %d) returned %d people, expected %d", page, len(list.Content), expectedSize)
+		}
+		for _, person := range list.Content {
+			if seen[person.ID] {
+				t.Errorf("GetPeople returned %s in more than one page", fixture.Name(person))
+			}
+			seen[person.ID] = true
+		}
+	}
+	if len(seen) != total {
+		t.Errorf("GetPeople returned %d distinct people across pages, expected %d", len(seen), total)
+	}
+}
+
+// newDatedFixture builds people saved on this order with births and deaths
+// around three generations:
+//
+//	Zé Root = Maria Wife      Beto Unknown   Carla Living
+//	       |
+//	    João Son
+//	       |
+//	 Pedro Grandson
+//
+// Maria Wife was born ABT 1805, whose earliest day is the birth of Zé Root.
+func newDatedFixture(t *testing.T, newRepo RepoFactory) *Fixture {
+	fixture := NewFixture(t, newRepo)
+	fixture.AddDetailedPeople(t,
+		familytree.Person{Name: "Zé Root", Surname: "Gonçalves", BirthDate: "1800", DeathDate: "1870"},
+		familytree.Person{Name: "Maria Wife", Surname: "Silva", BirthDate: "ABT 1805", DeathDate: "1880"},
+		familytree.Person{Name: "João Son", Surname: "Gonçalves", BirthDate: "1830-05-02", DeathDate: "BEF 1900"},
+		familytree.Person{Name: "Pedro Grandson", Surname: "GONCALVES", BirthDate: "BET 1860 AND 1865"},
+		familytree.Person{Name: "Beto Unknown"},
+		familytree.Person{Name: "Carla Living", Surname: "Silva", BirthDate: "1990-01-01"},
+	)
+	fixture.AddParent(t, "Zé Root", "João Son")
+	fixture.AddParent(t, "Maria Wife", "João Son")
+	fixture.AddParent(t, "João Son", "Pedro Grandson")
+	return fixture
+}
+
+func (fixture *Fixture) listNames(t *testing.T, filter familytree.PeopleFilter, peopleSort familytree.PeopleSort, pagination familytree.PaginationDetails) []string {
+	t.Helper()
+	if err := filter.Normalize(); err != nil {
+		t.Fatalf("Normalize(%+v) returned error: %v", filter, err)
+	}
+	list, err := fixture.Repo.GetPeople(fixture.Ctx, fixture.Tx, filter, peopleSort, pagination)
+	if err != nil {
+		t.Fatalf("GetPeople(%+v, %+v) returned error: %v", filter, peopleSort, err)
+	}
+	names := []string{}
+	for _, person := range list.Content {
+		names = append(names, fixture.Name(person))
+	}
+	return names
+}
+
+func testSortPeople(t *testing.T, newRepo RepoFactory) {
+	fixture := newDatedFixture(t, newRepo)
+	cases := []struct {
+		sort  string
+		names []string
+	}{
+		{sort: "", names: []string{"Zé Root", "Maria Wife", "João Son", "Pedro Grandson", "Beto Unknown", "Carla Living"}},
+		{sort: "-createdAt", names: []string{"Carla Living", "Beto Unknown", "Pedro Grandson", "João Son", "Maria Wife", "Zé Root"}},
+		{sort: "name", names: []string{"Beto Unknown", "Carla Living", "João Son", "Maria Wife", "Pedro Grandson", "Zé Root"}},
+		{sort: "-name", names: []string{"Zé Root", "Pedro Grandson", "Maria Wife", "João Son", "Carla Living", "Beto Unknown"}},
+		// Ties are broken by name and unknown births come last
+		{sort: "birthDate", names: []string{"Maria Wife", "Zé Root", "João Son", "Pedro Grandson", "Carla Living", "Beto Unknown"}},
+		{sort: "-birthDate", names: []string{"Carla Living", "Pedro Grandson", "João Son", "Maria Wife", "Zé Root", "Beto Unknown"}},
+	}
+	for _, c := range cases {
+		peopleSort, err := familytree.ParsePeopleSort(c.sort)
+		if err != nil {
+			t.Fatalf("ParsePeopleSort(%s) returned error: %v", c.sort, err)
+		}
+		names := fixture.listNames(t, familytree.PeopleFilter{}, peopleSort, familytree.PaginationDetails{PageSize: 10})
+		assertOrderedNames(t, "GetPeople(sort "+c.sort+")", names, c.names)
+
+		// Pages are slices of the same order
+		paged := []string{}
+		for page := 0; page < 3; page++ {
+			paged = append(paged, fixture.listNames(t, familytree.PeopleFilter{}, peopleSort, familytree.PaginationDetails{Page: page, PageSize: 4})...)
+		}
+		assertOrderedNames(t, "GetPeople(sort "+c.sort+", pages of 4)", paged, c.names)
+	}
+	if _, err := familytree.ParsePeopleSort("-age"); !errors.Is(err, familytree.ErrInvalidPeopleSort) {
+		t.Errorf("ParsePeopleSort(-age) returned %v, expected %v", err, familytree.ErrInvalidPeopleSort)
+	}
+}
+
+func testPeopleKeyset(t *testing.T, newRepo RepoFactory) {
+	fixture := newDatedFixture(t, newRepo)
+	for _, sortValue := range []string{"", "-createdAt", "name", "-name", "birthDate", "-birthDate"} {
+		peopleSort, err := familytree.ParsePeopleSort(sortValue)
+		if err != nil {
+			t.Fatalf("ParsePeopleSort(%s) returned error: %v", sortValue, err)
+		}
+		expected := fixture.listNames(t, familytree.PeopleFilter{}, peopleSort, familytree.PaginationDetails{PageSize: 10})
+
+		// Forward pages follow Next until there is none
+		forward, backward := []string{}, []string{}
+		pagination := familytree.PaginationDetails{PageSize: 4}
+		var last *familytree.PeopleSortKey
+		for pages := 0; pages < 4; pages++ {
+			list, err := fixture.Repo.GetPeople(fixture.Ctx, fixture.Tx, familytree.PeopleFilter{}, peopleSort, pagination)
+			if err != nil {
+				t.Fatalf("GetPeople(sort %s, after %+v) returned error: %v", sortValue, pagination.After, err)
+			}
+			if (list.Metadata.Prev != nil) != (pages > 0) {
+				t.Errorf("GetPeople(sort %s) page %d returned prev %+v", sortValue, pages, list.Metadata.Prev)
+			}
+			for _, person := range list.Content {
+				forward = append(forward, fixture.Name(person))
+			}
+			if list.Metadata.Next == nil {
+				last = list.Metadata.Prev
+				break
+			}
+			pagination = familytree.PaginationDetails{PageSize: 4, After: list.Metadata.Next}
+		}
+		assertOrderedNames(t, "GetPeople(sort "+sortValue+", forward pages of 4)", forward, expected)
+
+		// Backward pages follow Prev from the last page
+		pagination = familytree.PaginationDetails{PageSize: 3, Before: last}
+		for pages := 0; pages < 4 && last != nil; pages++ {
+			list, err := fixture.Repo.GetPeople(fixture.Ctx, fixture.Tx, familytree.PeopleFilter{}, peopleSort, pagination)
+			if err != nil {
+				t.Fatalf("GetPeople(sort %s, before %+v) returned error: %v", sortValue, pagination.Before, err)
+			}
+			if list.Metadata.Next == nil {
+				t.Errorf("GetPeople(sort %s, before %+v) returned no next", sortValue, pagination.Before)
+			}
+			names := []string{}
+			for _, person := range list.Content {
+				names = append(names, fixture.Name(person))
+			}
+			backward = append(names, backward...)
+			if list.Metadata.Prev == nil {
+				break
+			}
+			pagination = familytree.PaginationDetails{PageSize: 3, Before: list.Metadata.Prev}
+		}
+		assertOrderedNames(t, "GetPeople(sort "+sortValue+", backward pages of 3)", backward, expected[:4])
+	}
+
+	// People saved between pages don't move the next page
+	first, err := fixture.Repo.GetPeople(fixture.Ctx, fixture.Tx, familytree.PeopleFilter{}, familytree.PeopleSort{Field: familytree.PeopleSortName}, familytree.PaginationDetails{PageSize: 3})
+	if err != nil {
+		t.Fatalf("GetPeople(name) returned error: %v", err)
+	}
+	fixture.AddPeople(t, "Abel Early", "Xavier Late")
+	second, err := fixture.Repo.GetPeople(fixture.Ctx, fixture.Tx, familytree.PeopleFilter{}, familytree.PeopleSort{Field: familytree.PeopleSortName}, familytree.PaginationDetails{PageSize: 3, After: first.Metadata.Next})
+	if err != nil {
+		t.Fatalf("GetPeople(name, after %+v) returned error: %v", first.Metadata.Next, err)
+	}
+	names := []string{}
+	for _, person := range second.Content {
+		names = append(names, fixture.Name(person))
+	}
+	assertOrderedNames(t, "GetPeople(name, after inserting)", names, []string{"Maria Wife", "Pedro Grandson", "Xavier Late"})
+}
+
+func testFilterPeople(t *testing.T, newRepo RepoFactory) {
+	fixture := newDatedFixture(t, newRepo)
+	yes, no := true, false
+	cases := []struct {
+		name   string
+		filter familytree.PeopleFilter
+		names  []string
+	}{
+		{name: "no parents", filter: familytree.PeopleFilter{HasParents: &no}, names: []string{"Zé Root", "Maria Wife", "Beto Unknown", "Carla Living"}},
+		{name: "parents", filter: familytree.PeopleFilter{HasParents: &yes}, names: []string{"João Son", "Pedro Grandson"}},
+		{name: "living", filter: familytree.PeopleFilter{IsLiving: &yes}, names: []string{"Pedro Grandson", "Beto Unknown", "Carla Living"}},
+		{name: "dead", filter: familytree.PeopleFilter{IsLiving: &no}, names: []string{"Zé Root", "Maria Wife", "João Son"}},
+		{name: "born after 1850", filter: familytree.PeopleFilter{BornAfter: "1850"}, names: []string{"Pedro Grandson", "Carla Living"}},
+		{name: "born before 1830", filter: familytree.PeopleFilter{BornBefore: "1830"}, names: []string{"Zé Root", "Maria Wife"}},
+		// Maria Wife may have been born on 1800
+		{name: "born after ABT 1800", filter: familytree.PeopleFilter{BornAfter: "ABT 1800"}, names: []string{"João Son", "Pedro Grandson", "Carla Living"}},
+		{name: "surname", filter: familytree.PeopleFilter{Surname: "goncalves"}, names: []string{"Zé Root", "João Son", "Pedro Grandson"}},
+		{name: "living Silva", filter: familytree.PeopleFilter{Surname: "SILVA", IsLiving: &yes}, names: []string{"Carla Living"}},
+	}
+	for _, c := range cases {
+		names := fixture.listNames(t, c.filter, familytree.PeopleSort{Field: familytree.PeopleSortCreatedAt}, familytree.PaginationDetails{PageSize: 10})
+		assertOrderedNames(t, "GetPeople("+c.name+")", names, c.names)
+	}
+
+	list, err := fixture.Repo.GetPeople(fixture.Ctx, fixture.Tx, familytree.PeopleFilter{HasParents: &no}, familytree.PeopleSort{Field: familytree.PeopleSortName}, familytree.PaginationDetails{Page: 1, PageSize: 3})
+	if err != nil {
+		t.Fatalf("GetPeople(no parents, page 1) returned error: %v", err)
+	}
+	if list.Metadata.TotalItens != 4 || len(list.Content) != 1 || fixture.Name(list.Content[0]) != "Zé Root" {
+		t.Errorf("GetPeople(no parents, page 1) returned %d itens and %d people, expected Zé Root of 4 itens", list.Metadata.TotalItens, len(list.Content))
+	}
+
+	matchList, err := fixture.Repo.SearchPeople(fixture.Ctx, fixture.Tx, "son", familytree.PeopleFilter{IsLiving: &no}, familytree.PaginationDetails{PageSize: 10})
+	if err != nil {
+		t.Fatalf("SearchPeople(son, dead) returned error: %v", err)
+	}
+	if len(matchList.Content) != 1 || fixture.Name(&matchList.Content[0].Person) != "João Son" {
+		t.Errorf("SearchPeople(son, dead) returned %d people, expected João Son", len(matchList.Content))
+	}
+}
+
+func testSearchPeople(t *testing.T, newRepo RepoFactory) {
+	fixture := NewFixture(t, newRepo)
+	fixture.AddPeople(t, "João da Silva", "Maria Smith", "Mary Smyth", "Pedro Gonçalves", "Zeca Tatu")
+	cases := []struct {
+		query   string
+		names   []string
+		matches []familytree.NameMatch
+	}{
+		{query: "joao", names: []string{"João da Silva"}, matches: []familytree.NameMatch{familytree.NameMatchSubstring}},
+		{query: "DA SILVA", names: []string{"João da Silva"}, matches: []familytree.NameMatch{familytree.NameMatchSubstring}},
+		{
+			// The substring match has the most trigrams in common
+			query:   "smith",
+			names:   []string{"Maria Smith", "Mary Smyth"},
+			matches: []familytree.NameMatch{familytree.NameMatchSubstring, familytree.NameMatchPhonetic},
+		},
+		{query: "Gonsalves", names: []string{"Pedro Gonçalves"}, matches: []familytree.NameMatch{familytree.NameMatchPhonetic}},
+		{query: "Xeca Tatu", names: []string{"Zeca Tatu"}, matches: []familytree.NameMatch{familytree.NameMatchSimilar}},
+		{query: "Nobody", names: []string{}, matches: []familytree.NameMatch{}},
+	}
+	for _, c := range cases {
+		list, err := fixture.Repo.SearchPeople(fixture.Ctx, fixture.Tx, c.query, familytree.PeopleFilter{}, familytree.PaginationDetails{PageSize: 10})
+		if err != nil {
+			t.Fatalf("SearchPeople(%s) returned error: %v", c.query, err)
+		}
+		names := []string{}
+		matches := []familytree.NameMatch{}
+		for i, match := range list.Content {
+			names = append(names, fixture.Name(&match.Person))
+			matches = append(matches, match.Match)
+			if match.Score < 0 || match.Score > 1 || (i > 0 && match.Score > list.Content[i-1].Score) {
+				t.Errorf("SearchPeople(%s) returned score %f for %s out of order", c.query, match.Score, fixture.Name(&match.Person))
+			}
+		}
+		if !reflect.DeepEqual(names, c.names) || !reflect.DeepEqual(matches, c.matches) {
+			t.Errorf("SearchPeople(%s) returned %v matching %v, expected %v matching %v", c.query, names, matches, c.names, c.matches)
+		}
+		if list.Metadata.TotalItens != len(c.names) {
+			t.Errorf("SearchPeople(%s) returned %d itens, expected %d", c.query, list.Metadata.TotalItens, len(c.names))
+		}
+	}
+
+	list, err := fixture.Repo.SearchPeople(fixture.Ctx, fixture.Tx, "smith", familytree.PeopleFilter{}, familytree.PaginationDetails{Page: 1, PageSize: 1})
+	if err != nil {
+		t.Fatalf("SearchPeople(smith, page 1) returned error: %v", err)
+	}
+	if len(list.Content) != 1 || fixture.Name(&list.Content[0].Person) != "Mary Smyth" || list.Metadata.TotalItens != 2 {
+		t.Errorf("SearchPeople(smith, page 1) returned %+v, expected Mary Smyth of 2 itens", list)
+	}
+
+	// Search keys follow the name
+	renamed := fixture.Person(t, "Zeca Tatu")
+	renamed.Name = "Zeca Pagodinho"
+	if err := fixture.Repo.UpdatePerson(fixture.Ctx, fixture.Tx, &renamed); err != nil {
+		t.Fatalf("UpdatePerson returned error: %v", err)
+	}
+	list, err = fixture.Repo.SearchPeople(fixture.Ctx, fixture.Tx, "tatu", familytree.PeopleFilter{}, familytree.PaginationDetails{PageSize: 10})
+	if err != nil {
+		t.Fatalf("SearchPeople(tatu) returned error: %v", err)
+	}
+	if len(list.Content) != 0 {
+		t.Errorf("SearchPeople(tatu) after renaming returned %d people, expected none", len(list.Content))
+	}
+}
+
+func testGetFamilyTree(t *testing.T, newRepo RepoFactory) {
+	fixture := NewGrandparentsFixture(t, newRepo)
+	halfSiblings := NewHalfSiblingsFixture(t, newRepo)
+	cases := []struct {
+		fixture   *Fixture
+		person    string
+		people    []string
+		relations []string
+	}{
+		{
+			// Uncle and Cousin aren't in the tree, neither is Partner since the
+			// SPOUSE relation is only shown between ancestors
+			fixture: fixture,
+			person:  "Child",
+			people:  []string{"Child", "Father", "Mother", "Grandpa", "Grandma", "Grandchild", "Sibling", "Nephew"},
+			relations: []string{
+				"Grandpa PARENT Father", "Grandma PARENT Father", "Grandma SPOUSE Grandpa",
+				"Father PARENT Child", "Mother PARENT Child", "Father SPOUSE Mother",
+				"Father PARENT Sibling", "Mother PARENT Sibling",
+				"Sibling PARENT Nephew", "Child PARENT Grandchild",
+			},
+		},
+		{
+			fixture: fixture,
+			person:  "Cousin",
+			people:  []string{"Cousin", "Uncle", "Grandpa", "Grandma"},
+			relations: []string{
+				"Grandpa PARENT Uncle", "Grandma PARENT Uncle", "Grandma SPOUSE Grandpa", "Uncle PARENT Cousin",
+			},
+		},
+		{
+			fixture:   fixture,
+			person:    "Stranger",
+			people:    []string{"Stranger"},
+			relations: []string{},
+		},
+		{
+			// SecondWife is married to an ancestor but isn't an ancestor herself
+			fixture: halfSiblings,
+			person:  "OlderHalf",
+			people:  []string{"OlderHalf", "Father", "FirstWife", "YoungerHalf"},
+			relations: []string{
+				"Father PARENT OlderHalf", "FirstWife PARENT OlderHalf", "Father PARENT YoungerHalf",
+			},
+		},
+	}
+	for _, testCase := range cases {
+		name := fmt.Sprintf("GetFamilyTree(%s)", testCase.person)
+		tree, err := testCase.fixture.Repo.GetFamilyTree(testCase.fixture.Ctx, testCase.fixture.Tx, testCase.fixture.Person(t, testCase.person))
+		if err != nil {
+			t.Errorf("%s returned error: %v", name, err)
+			continue
+		}
+		if tree == nil {
+			t.Errorf("%s returned nil", name)
+			continue
+		}
+		people := []string{}
+		relations := []string{}
+		for _, node := range tree.People {
+			personName := testCase.fixture.Name(&node.Person)
+			if contains(people, personName) {
+				t.Errorf("%s returned %s more than once", name, personName)
+			}
+			people = append(people, personName)
+			for _, relation := range node.Relations {
+				relations = append(relations, testCase.fixture.relationName(node.Person.ID, relation))
+			}
+		}
+		assertNames(t, name+" people", people, testCase.people)
+		// Every relation must be listed exactly once, on only one of the people
+		assertNames(t, name+" relations", relations, testCase.relations)
+	}
+}
+
+func testGetDescendants(t *testing.T, newRepo RepoFactory) {
+	fixture := NewGrandparentsFixture(t, newRepo)
+	adoption := NewAdoptionFixture(t, newRepo)
+	cases := []struct {
+		fixture  *Fixture
+		person   string
+		depth    int
+		expected []string
+	}{
+		{fixture, "Grandpa", 1, []string{"Grandpa>Father", "Grandpa>Uncle"}},
+		{fixture, "Grandpa", 2, []string{"Father>Child", "Father>Sibling", "Grandpa>Father", "Grandpa>Uncle", "Uncle>Cousin"}},
+		{fixture, "Father", 10, []string{"Child>Grandchild", "Father>Child", "Father>Sibling", "Sibling>Nephew"}},
+		{fixture, "Stranger", 10, []string{}},
+		{adoption, "AdoptiveFather", 10, []string{"AdoptiveFather>Adoptee ADOPTIVE", "AdoptiveFather>AdoptiveSibling BIOLOGICAL"}},
+	}
+	for _, testCase := range cases {
+		children, err := testCase.fixture.Repo.GetDescendants(testCase.fixture.Ctx, testCase.fixture.Tx, testCase.fixture.Person(t, testCase.person), testCase.depth)
+		if err != nil {
+			t.Errorf("GetDescendants(%s, %d) returned error: %v", testCase.person, testCase.depth, err)
+			continue
+		}
+		found := make([]string, 0, len(children))
+		for _, child := range children {
+			parent := testCase.fixture.Name(&familytree.Person{ID: child.ParentID})
+			name := fmt.Sprintf("%s>%s", parent, testCase.fixture.Name(&child.Child))
+			if testCase.fixture == adoption {
+				name += " " + string(child.Parentage)
+			}
+			found = append(found, name)
+		}
+		sort.Strings(found)
+		if !reflect.DeepEqual(found, testCase.expected) {
+			t.Errorf("GetDescendants(%s, %d) returned %v, expected %v", testCase.person, testCase.depth, found, testCase.expected)
+		}
+	}
+}
+
+func testGetAncestors(t *testing.T, newRepo RepoFactory) {
+	fixture := NewGrandparentsFixture(t, newRepo)
+	adoption := NewAdoptionFixture(t, newRepo)
+	cases := []struct {
+		fixture     *Fixture
+		person      string
+		generations int
+		expected    []string
+	}{
+		{fixture, "Grandchild", 1, []string{"Grandchild<Child", "Grandchild<Partner"}},
+		{fixture, "Child", 1, []string{"Child<Father", "Child<Mother"}},
+		{fixture, "Child", 2, []string{"Child<Father", "Child<Mother", "Father<Grandma", "Father<Grandpa"}},
+		{fixture, "Grandpa", 10, []string{}},
+		// Only the biological lineage is followed
+		{adoption, "Adoptee", 10, []string{"Adoptee<BirthFather", "Adoptee<BirthMother"}},
+	}
+	for _, testCase := range cases {
+		parents, err := testCase.fixture.Repo.GetAncestors(testCase.fixture.Ctx, testCase.fixture.Tx, testCase.fixture.Person(t, testCase.person), testCase.generations)
+		if err != nil {
+			t.Errorf("GetAncestors(%s, %d) returned error: %v", testCase.person, testCase.generations, err)
+			continue
+		}
+		found := make([]string, 0, len(parents))
+		for _, parent := range parents {
+			child := testCase.fixture.Name(&familytree.Person{ID: parent.ChildID})
+			found = append(found, fmt.Sprintf("%s<%s", child, testCase.fixture.Name(&parent.Parent)))
+		}
+		sort.Strings(found)
+		if !reflect.DeepEqual(found, testCase.expected) {
+			t.Errorf("GetAncestors(%s, %d) returned %v, expected %v", testCase.person, testCase.generations, found, testCase.expected)
+		}
+	}
+}
+
+func testGetShortestPathLength(t *testing.T, newRepo RepoFactory) {
+	fixture := NewGrandparentsFixture(t, newRepo)
+	cases := []struct {
+		first, second string
+		length        int
+		found         bool
+	}{
+		{"Child", "Father", 1, true},
+		{"Child", "Partner", 1, true},
+		{"Father", "Mother", 1, true},
+		{"Child", "Cousin", 4, true},
+		{"Cousin", "Nephew", 5, true},
+		{"Partner", "Uncle", 4, true},
+		{"Child", "Stranger", 0, false},
+	}
+	for _, testCase := range cases {
+		length, found, err := fixture.Repo.GetShortestPathLength(fixture.Ctx, fixture.Tx, fixture.Person(t, testCase.first), fixture.Person(t, testCase.second))
+		if err != nil {
+			t.Errorf("GetShortestPathLength(%s, %s) returned error: %v", testCase.first, testCase.second, err)
+			continue
+		}
+		if length != testCase.length || found != testCase.found {
+			t.Errorf("GetShortestPathLength(%s, %s) returned %d, %v, expected %d, %v", testCase.first, testCase.second, length, found, testCase.length, testCase.found)
+		}
+	}
+}
+
+func testGetShortestPaths(t *testing.T, newRepo RepoFactory) {
+	fixture := NewGrandparentsFixture(t, newRepo)
+	adoption := NewAdoptionFixture(t, newRepo)
+	cases := []struct {
+		fixture       *Fixture
+		first, second string
+		filter        familytree.PathFilter
+		// Paths of the same length may come in any order
+		expected []string
+	}{
+		{fixture, "Child", "Cousin", familytree.PathFilter{MaxLength: 10, Count: 2}, []string{
+			"Child CHILD_OF Father CHILD_OF Grandma PARENT_OF Uncle PARENT_OF Cousin",
+			"Child CHILD_OF Father CHILD_OF Grandpa PARENT_OF Uncle PARENT_OF Cousin",
+		}},
+		{fixture, "Child", "Cousin", familytree.PathFilter{MaxLength: 3, Count: 2}, []string{}},
+		{fixture, "Child", "Partner", familytree.PathFilter{MaxLength: 10, Count: 1}, []string{
+			"Child SPOUSE_OF Partner",
+		}},
+		{fixture, "Child", "Partner", familytree.PathFilter{ExcludeSpouses: true, MaxLength: 10, Count: 1}, []string{
+			"Child PARENT_OF Grandchild CHILD_OF Partner",
+		}},
+		{fixture, "Child", "Stranger", familytree.PathFilter{MaxLength: 10, Count: 1}, []string{}},
+		{adoption, "Adoptee", "AdoptiveFather", familytree.PathFilter{MaxLength: 10, Count: 1}, []string{
+			"Adoptee CHILD_OF AdoptiveFather",
+		}},
+		{adoption, "Adoptee", "AdoptiveFather", familytree.PathFilter{BloodOnly: true, MaxLength: 10, Count: 1}, []string{}},
+	}
+	for _, testCase := range cases {
+		paths, err := testCase.fixture.Repo.GetShortestPaths(testCase.fixture.Ctx, testCase.fixture.Tx, testCase.fixture.Person(t, testCase.first), testCase.fixture.Person(t, testCase.second), testCase.filter)
+		if err != nil {
+			t.Errorf("GetShortestPaths(%s, %s, %+v) returned error: %v", testCase.first, testCase.second, testCase.filter, err)
+			continue
+		}
+		found := make([]string, 0, len(paths))
+		for _, path := range paths {
+			found = append(found, testCase.fixture.pathName(path))
+		}
+		sort.Strings(found)
+		if !reflect.DeepEqual(found, testCase.expected) {
+			t.Errorf("GetShortestPaths(%s, %s, %+v) returned %v, expected %v", testCase.first, testCase.second, testCase.filter, found, testCase.expected)
+		}
+	}
+}
+
+func testHasCommonChild(t *testing.T, newRepo RepoFactory) {
+	fixture := NewGrandparentsFixture(t, newRepo)
+	remarried := NewRemarriedFixture(t, newRepo)
+	cases := []struct {
+		fixture       *Fixture
+		first, second string
+		expected      bool
+	}{
+		{fixture, "Father", "Mother", true},
+		{fixture, "Mother", "Father", true},
+		{fixture, "Child", "Partner", true},
+		{fixture, "Father", "Uncle", false},
+		{fixture, "Grandpa", "Father", false},
+		{fixture, "Child", "Stranger", false},
+		// Having a child together doesn't depend on being married
+		{remarried, "Husband", "FirstWife", true},
+		{remarried, "FirstWife", "SecondWife", false},
+	}
+	for _, testCase := range cases {
+		hasChild, err := testCase.fixture.Repo.HasCommonChild(testCase.fixture.Ctx, testCase.fixture.Tx, testCase.fixture.Person(t, testCase.first), testCase.fixture.Person(t, testCase.second))
+		if err != nil {
+			t.Errorf("HasCommonChild(%s, %s) returned error: %v", testCase.first, testCase.second, err)
+			continue
+		}
+		if hasChild != testCase.expected {
+			t.Errorf("HasCommonChild(%s, %s) returned %v, expected %v", testCase.first, testCase.second, hasChild, testCase.expected)
+		}
+	}
+}
+
+func testGetSpouse(t *testing.T, newRepo RepoFactory) {
+	fixture := NewGrandparentsFixture(t, newRepo)
+	remarried := NewRemarriedFixture(t, newRepo)
+	cases := []struct {
+		fixture  *Fixture
+		person   string
+		expected string
+	}{
+		{fixture, "Father", "Mother"},
+		// SPOUSE is saved from the first to the second spouse but is read on both ways
+		{fixture, "Mother", "Father"},
+		{fixture, "Partner", "Child"},
+		{fixture, "Uncle", "<nil>"},
+		{remarried, "Husband", "SecondWife"},
+		{remarried, "FirstWife", "<nil>"},
+	}
+	for _, testCase := range cases {
+		spouse, err := testCase.fixture.Repo.GetSpouse(testCase.fixture.Ctx, testCase.fixture.Tx, testCase.fixture.Person(t, testCase.person))
+		if err != nil {
+			t.Errorf("GetSpouse(%s) returned error: %v", testCase.person, err)
+			continue
+		}
+		if name := testCase.fixture.Name(spouse); name != testCase.expected {
+			t.Errorf("GetSpouse(%s) returned %s, expected %s", testCase.person, name, testCase.expected)
+		}
+	}
+}
+
+func testUnions(t *testing.T, newRepo RepoFactory) {
+	fixture := NewDivorcedFixture(t, newRepo)
+	firstUnion := familytree.Union{StartDate: "1900", EndDate: "1910", EndReason: familytree.UnionEndDivorce}
+	secondUnion := familytree.Union{StartDate: "1912"}
+
+	spouseCases := []struct {
+		person   string
+		expected string
+	}{
+		{"Husband", "SecondWife"},
+		{"SecondWife", "Husband"},
+		{"FirstWife", "<nil>"},
+	}
+	for _, testCase := range spouseCases {
+		spouse, err := fixture.Repo.GetSpouse(fixture.Ctx, fixture.Tx, fixture.Person(t, testCase.person))
+		if err != nil {
+			t.Fatalf("GetSpouse(%s) returned error: %v", testCase.person, err)
+		}
+		if name := fixture.Name(spouse); name != testCase.expected {
+			t.Errorf("GetSpouse(%s) returned %s, expected %s", testCase.person, name, testCase.expected)
+		}
+	}
+
+	unionCases := []struct {
+		person   string
+		expected map[string]familytree.Union
+	}{
+		{"Husband", map[string]familytree.Union{"FirstWife": firstUnion, "SecondWife": secondUnion}},
+		{"FirstWife", map[string]familytree.Union{"Husband": firstUnion}},
+		{"FirstChild", map[string]familytree.Union{}},
+	}
+	for _, testCase := range unionCases {
+		unions, err := fixture.Repo.GetUnions(fixture.Ctx, fixture.Tx, fixture.Person(t, testCase.person))
+		if err != nil {
+			t.Fatalf("GetUnions(%s) returned error: %v", testCase.person, err)
+		}
+		found := map[string]familytree.Union{}
+		for _, union := range unions {
+			spouse := union.Spouse
+			found[fixture.Name(&spouse)] = union.Union
+		}
+		if !reflect.DeepEqual(found, testCase.expected) {
+			t.Errorf("GetUnions(%s) returned %v, expected %v", testCase.person, found, testCase.expected)
+		}
+	}
+
+	tree, err := fixture.Repo.GetFamilyTree(fixture.Ctx, fixture.Tx, fixture.Person(t, "FirstChild"))
+	if err != nil {
+		t.Fatalf("GetFamilyTree(FirstChild) returned error: %v", err)
+	}
+	treeUnions := 0
+	for _, node := range tree.People {
+		for _, relation := range node.Relations {
+			if relation.RelationType != familytree.RelationTypeSpouse {
+				continue
+			}
+			treeUnions++
+			if relation.Union == nil || *relation.Union != firstUnion {
+				t.Errorf("GetFamilyTree(FirstChild) SPOUSE relation has union %v, expected %v", relation.Union, firstUnion)
+			}
+		}
+	}
+	if treeUnions != 1 {
+		t.Errorf("GetFamilyTree(FirstChild) returned %d SPOUSE relations, expected 1", treeUnions)
+	}
+
+	endedUnion := familytree.Union{StartDate: "1912", EndDate: "1930", EndReason: familytree.UnionEndDeath}
+	ok, err := fixture.Repo.UpdateUnion(fixture.Ctx, fixture.Tx, fixture.Person(t, "SecondWife"), fixture.Person(t, "Husband"), "1912", endedUnion)
+	if err != nil || !ok {
+		t.Fatalf("UpdateUnion(SecondWife, Husband) returned %v, %v, expected true", ok, err)
+	}
+	spouse, err := fixture.Repo.GetSpouse(fixture.Ctx, fixture.Tx, fixture.Person(t, "Husband"))
+	if err != nil || spouse != nil {
+		t.Errorf("GetSpouse(Husband) after ending the union returned %s, %v, expected no spouse", fixture.Name(spouse), err)
+	}
+	unions, err := fixture.Repo.GetUnions(fixture.Ctx, fixture.Tx, fixture.Person(t, "SecondWife"))
+	if err != nil || len(unions) != 1 || unions[0].Union != endedUnion {
+		t.Errorf("GetUnions(SecondWife) after update returned %v, %v, expected %v", unions, err, endedUnion)
+	}
+
+	ok, err = fixture.Repo.UpdateUnion(fixture.Ctx, fixture.Tx, fixture.Person(t, "FirstChild"), fixture.Person(t, "SecondChild"), "", endedUnion)
+	if err != nil || ok {
+		t.Errorf("UpdateUnion without SPOUSE relation returned %v, %v, expected false", ok, err)
+	}
+	ok, err = fixture.Repo.UpdateUnion(fixture.Ctx, fixture.Tx, fixture.Person(t, "Husband"), fixture.Person(t, "FirstWife"), "1912", endedUnion)
+	if err != nil || ok {
+		t.Errorf("UpdateUnion(Husband, FirstWife) of a union starting on another date returned %v, %v, expected false", ok, err)
+	}
+}
+
+// testUpdateSpouseRelation updates each union of Husband through the use case,
+// whatever order the repo lists them in the other one is kept as it is.
+func testUpdateSpouseRelation(t *testing.T, newRepo RepoFactory) {
+	fixture := NewDivorcedFixture(t, newRepo)
+	fixture.Commit(t)
+	useCase := familytree.NewRelationshipUseCase(fixture.Repo, familytree.DefaultRelationRules(), nil)
+	husband := fixture.Person(t, "Husband")
+	firstUnion := familytree.Union{StartDate: "1899", EndDate: "1910", EndReason: familytree.UnionEndDivorce}
+	secondUnion := familytree.Union{StartDate: "1913"}
+
+	cases := []struct {
+		spouse    string
+		startDate familytree.Date
+		expected  map[string]familytree.Union
+	}{
+		{"FirstWife", "1899", map[string]familytree.Union{"FirstWife": firstUnion, "SecondWife": {StartDate: "1912"}}},
+		{"SecondWife", "1913", map[string]familytree.Union{"FirstWife": firstUnion, "SecondWife": secondUnion}},
+	}
+	for _, testCase := range cases {
+		startDate := testCase.startDate
+		union, err := useCase.UpdateSpouseRelation(fixture.Ctx, husband.ID, fixture.Person(t, testCase.spouse).ID, nil, familytree.UnionUpdate{StartDate: &startDate})
+		if err != nil {
+			t.Fatalf("UpdateSpouseRelation(Husband, %s) returned error: %v", testCase.spouse, err)
+		}
+		if *union != testCase.expected[testCase.spouse] {
+			t.Errorf("UpdateSpouseRelation(Husband, %s) returned %+v, expected %+v", testCase.spouse, *union, testCase.expected[testCase.spouse])
+		}
+		unions, err := useCase.GetUnions(fixture.Ctx, husband.ID)
+		if err != nil {
+			t.Fatalf("GetUnions(Husband) returned error: %v", err)
+		}
+		found := map[string]familytree.Union{}
+		for _, personUnion := range unions {
+			spouse := personUnion.Spouse
+			found[fixture.Name(&spouse)] = personUnion.Union
+		}
+		if !reflect.DeepEqual(found, testCase.expected) {
+			t.Errorf("GetUnions(Husband) after updating %s returned %v, expected %v", testCase.spouse, found, testCase.expected)
+		}
+	}
+}
+
+// testRemarriage marries Husband and FirstWife again in 1920, after Husband
+// divorced SecondWife, their unions are then told apart by the start date.
+func testRemarriage(t *testing.T, newRepo RepoFactory) {
+	fixture := NewDivorcedFixture(t, newRepo)
+	fixture.Commit(t)
+	useCase := familytree.NewRelationshipUseCase(fixture.Repo, familytree.DefaultRelationRules(), nil)
+	husband, firstWife := fixture.Person(t, "Husband"), fixture.Person(t, "FirstWife")
+	date := func(value familytree.Date) *familytree.Date { return &value }
+	divorce := familytree.UnionEndDivorce
+	assertUnions := func(name string, expected []string) {
+		t.Helper()
+		unions, err := useCase.GetUnions(fixture.Ctx, husband.ID)
+		if err != nil {
+			t.Fatalf("GetUnions(Husband) %s returned error: %v", name, err)
+		}
+		found := []string{}
+		for _, personUnion := range unions {
+			spouse := personUnion.Spouse
+			found = append(found, fmt.Sprintf("%s %s-%s", fixture.Name(&spouse), personUnion.Union.StartDate, personUnion.Union.EndDate))
+		}
+		assertNames(t, "GetUnions(Husband) "+name, found, expected)
+	}
+
+	if _, err := useCase.UpdateSpouseRelation(fixture.Ctx, husband.ID, fixture.Person(t, "SecondWife").ID, nil, familytree.UnionUpdate{EndDate: date("1915"), EndReason: &divorce}); err != nil {
+		t.Fatalf("UpdateSpouseRelation(Husband, SecondWife) returned error: %v", err)
+	}
+	if _, err := useCase.CreateSpouseRelation(fixture.Ctx, husband.ID, firstWife.ID, familytree.Union{StartDate: "1905"}); !errors.Is(err, familytree.ErrDuplicateRelation) {
+		t.Errorf("CreateSpouseRelation(Husband, FirstWife) overlapping their first union returned %v, expected %v", err, familytree.ErrDuplicateRelation)
+	}
+	if _, err := useCase.CreateSpouseRelation(fixture.Ctx, firstWife.ID, husband.ID, familytree.Union{StartDate: "1920"}); err != nil {
+		t.Fatalf("CreateSpouseRelation(FirstWife, Husband) after their divorce returned error: %v", err)
+	}
+	assertUnions("after the remarriage", []string{"FirstWife 1900-1910", "FirstWife 1920-", "SecondWife 1912-1915"})
+	count, err := fixture.Repo.GetParentMaritalChildCount(fixture.Ctx, fixture.Tx, fixture.Person(t, "FirstChild"))
+	if err != nil || count != 1 {
+		t.Errorf("GetParentMaritalChildCount(FirstChild) of parents married twice returned %d, %v, expected 1", count, err)
+	}
+
+	if _, err := useCase.UpdateSpouseRelation(fixture.Ctx, husband.ID, firstWife.ID, nil, familytree.UnionUpdate{EndDate: date("1930")}); !errors.Is(err, familytree.ErrAmbiguousUnion) {
+		t.Errorf("UpdateSpouseRelation(Husband, FirstWife) without a start date returned %v, expected %v", err, familytree.ErrAmbiguousUnion)
+	}
+	union, err := useCase.UpdateSpouseRelation(fixture.Ctx, husband.ID, firstWife.ID, date("1920"), familytree.UnionUpdate{EndDate: date("1930"), EndReason: &divorce})
+	if err != nil {
+		t.Fatalf("UpdateSpouseRelation(Husband, FirstWife, 1920) returned error: %v", err)
+	}
+	if expected := (familytree.Union{StartDate: "1920", EndDate: "1930", EndReason: divorce}); *union != expected {
+		t.Errorf("UpdateSpouseRelation(Husband, FirstWife, 1920) returned %+v, expected %+v", *union, expected)
+	}
+	if _, err := useCase.UpdateSpouseRelation(fixture.Ctx, husband.ID, firstWife.ID, date("1920"), familytree.UnionUpdate{StartDate: date("1908")}); !errors.Is(err, familytree.ErrDuplicateRelation) {
+		t.Errorf("UpdateSpouseRelation(Husband, FirstWife, 1920) overlapping their first union returned %v, expected %v", err, familytree.ErrDuplicateRelation)
+	}
+	if _, err := useCase.UpdateSpouseRelation(fixture.Ctx, husband.ID, firstWife.ID, date("1950"), familytree.UnionUpdate{EndDate: date("1960")}); !errors.Is(err, familytree.ErrRelationNotFound) {
+		t.Errorf("UpdateSpouseRelation(Husband, FirstWife, 1950) returned %v, expected %v", err, familytree.ErrRelationNotFound)
+	}
+	assertUnions("after the updates", []string{"FirstWife 1900-1910", "FirstWife 1920-1930", "SecondWife 1912-1915"})
+
+	if err := useCase.DeleteSpouseRelation(fixture.Ctx, husband.ID, firstWife.ID, nil); !errors.Is(err, familytree.ErrAmbiguousUnion) {
+		t.Errorf("DeleteSpouseRelation(Husband, FirstWife) without a start date returned %v, expected %v", err, familytree.ErrAmbiguousUnion)
+	}
+	if err := useCase.DeleteSpouseRelation(fixture.Ctx, husband.ID, firstWife.ID, date("1900")); err != nil {
+		t.Fatalf("DeleteSpouseRelation(Husband, FirstWife, 1900) returned error: %v", err)
+	}
+	assertUnions("after the delete", []string{"FirstWife 1920-1930", "SecondWife 1912-1915"})
+}
+
+func testGetParentMaritalChildCount(t *testing.T, newRepo RepoFactory) {
+	fixture := NewGrandparentsFixture(t, newRepo)
+	halfSiblings := NewHalfSiblingsFixture(t, newRepo)
+	remarried := NewRemarriedFixture(t, newRepo)
+	cases := []struct {
+		fixture  *Fixture
+		person   string
+		expected int
+	}{
+		{fixture, "Child", 2},
+		{fixture, "Father", 2},
+		{fixture, "Grandchild", 1},
+		{fixture, "Cousin", 0},
+		{fixture, "Grandpa", 0},
+		{halfSiblings, "OlderHalf", 0},
+		{halfSiblings, "YoungerHalf", 1},
+		{remarried, "FirstChild", 0},
+		{remarried, "SecondChild", 2},
+	}
+	for _, testCase := range cases {
+		count, err := testCase.fixture.Repo.GetParentMaritalChildCount(testCase.fixture.Ctx, testCase.fixture.Tx, testCase.fixture.Person(t, testCase.person))
+		if err != nil {
+			t.Errorf("GetParentMaritalChildCount(%s) returned error: %v", testCase.person, err)
+			continue
+		}
+		if count != testCase.expected {
+			t.Errorf("GetParentMaritalChildCount(%s) returned %d, expected %d", testCase.person, count, testCase.expected)
+		}
+	}
+}
+
+func testDeleteRelationship(t *testing.T, newRepo RepoFactory) {
+	fixture := NewGrandparentsFixture(t, newRepo)
+	cases := []struct {
+		first, second string
+		relationType  familytree.RelationType
+		expected      bool
+	}{
+		// PARENT is directional, it can only be deleted from parent to child
+		{"Child", "Father", familytree.RelationTypeParent, false},
+		{"Father", "Child", familytree.RelationTypeParent, true},
+		{"Father", "Child", familytree.RelationTypeParent, false},
+		// SPOUSE isn't, it can be deleted from any of the spouses
+		{"Mother", "Father", familytree.RelationTypeSpouse, true},
+		{"Father", "Mother", familytree.RelationTypeSpouse, false},
+		{"Uncle", "Stranger", familytree.RelationTypeSpouse, false},
+		{"Grandpa", "Uncle", familytree.RelationTypeSpouse, false},
+	}
+	for _, testCase := range cases {
+		deleted, err := fixture.Repo.DeleteRelationship(fixture.Ctx, fixture.Tx, fixture.Person(t, testCase.first), fixture.Person(t, testCase.second), testCase.relationType)
+		if err != nil {
+			t.Errorf("DeleteRelationship(%s, %s, %s) returned error: %v", testCase.first, testCase.second, testCase.relationType, err)
+			continue
+		}
+		if deleted != testCase.expected {
+			t.Errorf("DeleteRelationship(%s, %s, %s) returned %v, expected %v", testCase.first, testCase.second, testCase.relationType, deleted, testCase.expected)
+		}
+	}
+
+	parents, err := fixture.Repo.GetParents(fixture.Ctx, fixture.Tx, fixture.Person(t, "Child").ID)
+	if err != nil {
+		t.Fatalf("GetParents(Child) returned error: %v", err)
+	}
+	assertNames(t, "GetParents(Child) after delete", fixture.parentNames(parents), []string{"Mother"})
+	spouse, err := fixture.Repo.GetSpouse(fixture.Ctx, fixture.Tx, fixture.Person(t, "Father"))
+	if err != nil || spouse != nil {
+		t.Errorf("GetSpouse(Father) after delete returned %s, %v, expected no spouse", fixture.Name(spouse), err)
+	}
+	uncleParents, err := fixture.Repo.GetParents(fixture.Ctx, fixture.Tx, fixture.Person(t, "Uncle").ID)
+	if err != nil {
+		t.Fatalf("GetParents(Uncle) returned error: %v", err)
+	}
+	assertNames(t, "GetParents(Uncle) after deleting another relation type", fixture.parentNames(uncleParents), []string{"Grandpa", "Grandma"})
+}
+
+func testDeletePerson(t *testing.T, newRepo RepoFactory) {
+	fixture := NewGrandparentsFixture(t, newRepo)
+	err := fixture.Repo.DeletePerson(fixture.Ctx, fixture.Tx, fixture.Person(t, "Father"))
+	if !errors.Is(err, familytree.ErrPersonStillHasRelations) {
+		t.Errorf("DeletePerson(Father) returned %v, expected %v", err, familytree.ErrPersonStillHasRelations)
+	}
+	father, err := fixture.Repo.GetPerson(fixture.Ctx, fixture.Tx, fixture.Person(t, "Father").ID)
+	if err != nil || father == nil {
+		t.Errorf("GetPerson(Father) after a refused delete returned %v, %v", father, err)
+	}
+
+	if err := fixture.Repo.DeletePerson(fixture.Ctx, fixture.Tx, fixture.Person(t, "Stranger")); err != nil {
+		t.Errorf("DeletePerson(Stranger) returned error: %v", err)
+	}
+	stranger, err := fixture.Repo.GetPerson(fixture.Ctx, fixture.Tx, fixture.Person(t, "Stranger").ID)
+	if err != nil || stranger != nil {
+		t.Errorf("GetPerson(Stranger) after delete returned %v, %v, expected nil", stranger, err)
+	}
+	list, err := fixture.Repo.GetPeople(fixture.Ctx, fixture.Tx, familytree.PeopleFilter{}, familytree.PeopleSort{Field: familytree.PeopleSortCreatedAt}, familytree.PaginationDetails{Page: 0, PageSize: familytree.GetPeopleMaxPageSize})
+	if err != nil {
+		t.Fatalf("GetPeople returned error: %v", err)
+	}
+	if list.Metadata.TotalItens != len(fixture.People)-1 {
+		t.Errorf("GetPeople after delete returned %d itens, expected %d", list.Metadata.TotalItens, len(fixture.People)-1)
+	}
+}
+
+func testDeletePersonCascade(t *testing.T, newRepo RepoFactory) {
+	fixture := NewGrandparentsFixture(t, newRepo)
+	fixture.Commit(t)
+	useCase := familytree.NewPersonUseCase(fixture.Repo, nil)
+	deleteCascade := func(name string) ([]string, error) {
+		t.Helper()
+		relations, err := useCase.DeletePersonCascade(fixture.Ctx, fixture.Person(t, name).ID, false)
+		return fixture.personRelationNames(relations), err
+	}
+
+	if _, err := useCase.DeletePersonCascade(fixture.Ctx, uuid.New(), false); !errors.Is(err, familytree.ErrPersonNotFound) {
+		t.Errorf("DeletePersonCascade of a missing person returned %v, expected %v", err, familytree.ErrPersonNotFound)
+	}
+	// Child and Partner are married and Grandchild is their only child
+	if _, err := deleteCascade("Grandchild"); !errors.Is(err, familytree.ErrOnlyChildFromSpouseCouple) {
+		t.Errorf("DeletePersonCascade(Grandchild) returned %v, expected %v", err, familytree.ErrOnlyChildFromSpouseCouple)
+	}
+	parents, err := fixture.Repo.GetParents(fixture.Ctx, fixture.Tx, fixture.Person(t, "Grandchild").ID)
+	if err != nil {
+		t.Fatalf("GetParents(Grandchild) returned error: %v", err)
+	}
+	assertNames(t, "GetParents(Grandchild) after a refused delete", fixture.parentNames(parents), []string{"Child", "Partner"})
+
+	deleted, err := deleteCascade("Cousin")
+	if err != nil {
+		t.Fatalf("DeletePersonCascade(Cousin) returned error: %v", err)
+	}
+	assertNames(t, "DeletePersonCascade(Cousin)", deleted, []string{"Uncle PARENT Cousin"})
+	// Child has Sibling as a brother, and the union with Partner goes with it
+	deleted, err = deleteCascade("Child")
+	if err != nil {
+		t.Fatalf("DeletePersonCascade(Child) returned error: %v", err)
+	}
+	assertNames(t, "DeletePersonCascade(Child)", deleted, []string{"Father PARENT Child", "Mother PARENT Child", "Child PARENT Grandchild", "Child SPOUSE Partner"})
+
+	for _, name := range []string{"Cousin", "Child"} {
+		if person, err := fixture.Repo.GetPerson(fixture.Ctx, fixture.Tx, fixture.Person(t, name).ID); err != nil || person != nil {
+			t.Errorf("GetPerson(%s) after delete returned %v, %v, expected nil", name, person, err)
+		}
+	}
+	parents, err = fixture.Repo.GetParents(fixture.Ctx, fixture.Tx, fixture.Person(t, "Grandchild").ID)
+	if err != nil {
+		t.Fatalf("GetParents(Grandchild) returned error: %v", err)
+	}
+	assertNames(t, "GetParents(Grandchild) after delete", fixture.parentNames(parents), []string{"Partner"})
+	unions, err := fixture.Repo.GetUnions(fixture.Ctx, fixture.Tx, fixture.Person(t, "Partner"))
+	if err != nil {
+		t.Fatalf("GetUnions(Partner) returned error: %v", err)
+	}
+	if len(unions) != 0 {
+		t.Errorf("GetUnions(Partner) after delete returned %d unions, expected none", len(unions))
+	}
+
+	// Deleting the union of the parents lets the only child of a couple go
+	fixture = NewGrandparentsFixture(t, newRepo)
+	fixture.Commit(t)
+	useCase = familytree.NewPersonUseCase(fixture.Repo, nil)
+	relations, err := useCase.DeletePersonCascade(fixture.Ctx, fixture.Person(t, "Grandchild").ID, true)
+	if err != nil {
+		t.Fatalf("DeletePersonCascade(Grandchild) with the parents union returned error: %v", err)
+	}
+	deleted = []string{}
+	for _, relation := range relations {
+		if relation.RelationType == familytree.RelationTypeSpouse {
+			deleted = append(deleted, "SPOUSE "+fixture.pairName(relation.Top, relation.Bottom))
+			continue
+		}
+		deleted = append(deleted, fixture.personRelationNames([]familytree.PersonRelation{relation})...)
+	}
+	assertNames(t, "DeletePersonCascade(Grandchild) with the parents union", deleted, []string{"SPOUSE Child & Partner", "Child PARENT Grandchild", "Partner PARENT Grandchild"})
+	for _, name := range []string{"Child", "Partner"} {
+		unions, err := fixture.Repo.GetUnions(fixture.Ctx, fixture.Tx, fixture.Person(t, name))
+		if err != nil {
+			t.Fatalf("GetUnions(%s) returned error: %v", name, err)
+		}
+		if len(unions) != 0 {
+			t.Errorf("GetUnions(%s) after delete returned %d unions, expected none", name, len(unions))
+		}
+	}
+	// Only the union of a couple losing its only child is deleted
+	relations, err = useCase.DeletePersonCascade(fixture.Ctx, fixture.Person(t, "Sibling").ID, true)
+	if err != nil {
+		t.Fatalf("DeletePersonCascade(Sibling) with the parents union returned error: %v", err)
+	}
+	assertNames(t, "DeletePersonCascade(Sibling) with the parents union", fixture.personRelationNames(relations), []string{"Father PARENT Sibling", "Mother PARENT Sibling", "Sibling PARENT Nephew"})
+}
+
+// testTransactions changes the repo on another Tx, so the fixture Tx is only
+// used once that one has ended.
+func testTransactions(t *testing.T, newRepo RepoFactory) {
+	fixture := NewGrandparentsFixture(t, newRepo)
+	fixture.Commit(t)
+	father, mother, child := fixture.Person(t, "Father"), fixture.Person(t, "Mother"), fixture.Person(t, "Child")
+	stranger, nephew := fixture.Person(t, "Stranger"), fixture.Person(t, "Nephew")
+	ctx := fixture.Ctx
+	changeAll := func(tx familytree.Tx) *familytree.Person {
+		t.Helper()
+		if err := fixture.Repo.LockPeople(ctx, tx, father.ID, child.ID); err != nil {
+			t.Fatalf("LockPeople returned error: %v", err)
+		}
+		newPerson := &familytree.Person{Name: "Transient"}
+		if err := fixture.Repo.SavePerson(ctx, tx, newPerson); err != nil {
+			t.Fatalf("SavePerson returned error: %v", err)
+		}
+		renamed := father
+		renamed.Name = "Renamed Father"
+		if err := fixture.Repo.UpdatePerson(ctx, tx, &renamed); err != nil {
+			t.Fatalf("UpdatePerson returned error: %v", err)
+		}
+		if err := fixture.Repo.SaveRelation(ctx, tx, familytree.PersonRelation{Top: *newPerson, Bottom: nephew, RelationType: familytree.RelationTypeParent}); err != nil {
+			t.Fatalf("SaveRelation returned error: %v", err)
+		}
+		if _, err := fixture.Repo.UpdateUnion(ctx, tx, father, mother, "", familytree.Union{EndDate: "2000", EndReason: familytree.UnionEndDivorce}); err != nil {
+			t.Fatalf("UpdateUnion returned error: %v", err)
+		}
+		if _, err := fixture.Repo.DeleteRelationship(ctx, tx, father, child, familytree.RelationTypeParent); err != nil {
+			t.Fatalf("DeleteRelationship returned error: %v", err)
+		}
+		if err := fixture.Repo.DeletePerson(ctx, tx, stranger); err != nil {
+			t.Fatalf("DeletePerson returned error: %v", err)
+		}
+		// The Tx reads its own changes
+		parents, err := fixture.Repo.GetParents(ctx, tx, child.ID)
+		if err != nil {
+			t.Fatalf("GetParents(Child) returned error: %v", err)
+		}
+		assertNames(t, "GetParents(Child) inside the Tx", fixture.parentNames(parents), []string{"Mother"})
+		return newPerson
+	}
+
+	tx := beginTx(t, fixture.Repo, familytree.SessionWrite)
+	newPerson := changeAll(tx)
+	if err := tx.Rollback(ctx); err != nil {
+		t.Fatalf("Rollback returned error: %v", err)
+	}
+	if err := tx.Commit(ctx); !errors.Is(err, familytree.ErrTxDone) {
+		t.Errorf("Commit after the rollback returned %v, expected %v", err, familytree.ErrTxDone)
+	}
+	if found, err := fixture.Repo.GetPerson(fixture.Ctx, fixture.Tx, newPerson.ID); err != nil || found != nil {
+		t.Errorf("GetPerson of a rolled back person returned %v, %v, expected nil", found, err)
+	}
+	if found, err := fixture.Repo.GetPerson(fixture.Ctx, fixture.Tx, father.ID); err != nil || found == nil || found.Name != "Father" {
+		t.Errorf("GetPerson(Father) after the rollback returned %v, %v, expected the old name", found, err)
+	}
+	if found, err := fixture.Repo.GetPerson(fixture.Ctx, fixture.Tx, stranger.ID); err != nil || found == nil {
+		t.Errorf("GetPerson(Stranger) after the rollback returned %v, %v, expected the person", found, err)
+	}
+	parents, err := fixture.Repo.GetParents(fixture.Ctx, fixture.Tx, child.ID)
+	if err != nil {
+		t.Fatalf("GetParents(Child) returned error: %v", err)
+	}
+	assertNames(t, "GetParents(Child) after the rollback", fixture.parentNames(parents), []string{"Father", "Mother"})
+	spouse, err := fixture.Repo.GetSpouse(fixture.Ctx, fixture.Tx, father)
+	if err != nil || spouse == nil || spouse.ID != mother.ID {
+		t.Errorf("GetSpouse(Father) after the rollback returned %s, %v, expected Mother", fixture.Name(spouse), err)
+	}
+	nephewParents, err := fixture.Repo.GetParents(fixture.Ctx, fixture.Tx, nephew.ID)
+	if err != nil {
+		t.Fatalf("GetParents(Nephew) returned error: %v", err)
+	}
+	assertNames(t, "GetParents(Nephew) after the rollback", fixture.parentNames(nephewParents), []string{"Sibling"})
+	list, err := fixture.Repo.GetPeople(fixture.Ctx, fixture.Tx, familytree.PeopleFilter{}, familytree.PeopleSort{Field: familytree.PeopleSortCreatedAt}, familytree.PaginationDetails{Page: 0, PageSize: familytree.GetPeopleMaxPageSize})
+	if err != nil {
+		t.Fatalf("GetPeople returned error: %v", err)
+	}
+	if list.Metadata.TotalItens != len(fixture.People) {
+		t.Errorf("GetPeople after the rollback returned %d itens, expected %d", list.Metadata.TotalItens, len(fixture.People))
+	}
+
+	tx = beginTx(t, fixture.Repo, familytree.SessionWrite)
+	newPerson = changeAll(tx)
+	if err := tx.Commit(ctx); err != nil {
+		t.Fatalf("Commit returned error: %v", err)
+	}
+	if found, err := fixture.Repo.GetPerson(fixture.Ctx, fixture.Tx, newPerson.ID); err != nil || found == nil {
+		t.Errorf("GetPerson of a committed person returned %v, %v", found, err)
+	}
+	parents, err = fixture.Repo.GetParents(fixture.Ctx, fixture.Tx, child.ID)
+	if err != nil {
+		t.Fatalf("GetParents(Child) returned error: %v", err)
+	}
+	assertNames(t, "GetParents(Child) after the commit", fixture.parentNames(parents), []string{"Mother"})
+
+	// Hooks only run once their Tx is committed
+	hooks := []string{}
+	tx = beginTx(t, fixture.Repo, familytree.SessionWrite)
+	tx.OnCommit(func() { hooks = append(hooks, "rolled back") })
+	if err := tx.Rollback(ctx); err != nil {
+		t.Fatalf("Rollback returned error: %v", err)
+	}
+	tx = beginTx(t, fixture.Repo, familytree.SessionWrite)
+	tx.OnCommit(func() { hooks = append(hooks, "committed") })
+	assertOrderedNames(t, "OnCommit hooks before the commit", hooks, []string{})
+	if err := tx.Commit(ctx); err != nil {
+		t.Fatalf("Commit returned error: %v", err)
+	}
+	assertOrderedNames(t, "OnCommit hooks after the commit", hooks, []string{"committed"})
+
+	// RunInTx only commits the work that succeeds
+	errStop := errors.New("stop")
+	rolledBack := &familytree.Person{Name: "Rolled Back"}
+	err = familytree.RunInTx(ctx, fixture.Repo, familytree.SessionWrite, func(ctx context.Context, tx familytree.Tx) error {
+		if err := fixture.Repo.SavePerson(ctx, tx, rolledBack); err != nil {
+			return err
+		}
+		return errStop
+	})
+	if !errors.Is(err, errStop) {
+		t.Fatalf("RunInTx returned %v, expected %v", err, errStop)
+	}
+	if found, err := fixture.Repo.GetPerson(fixture.Ctx, fixture.Tx, rolledBack.ID); err != nil || found != nil {
+		t.Errorf("GetPerson of a person saved by a failed RunInTx returned %v, %v, expected nil", found, err)
+	}
+	committed := &familytree.Person{Name: "Committed"}
+	err = familytree.RunInTx(ctx, fixture.Repo, familytree.SessionWrite, func(ctx context.Context, tx familytree.Tx) error {
+		return fixture.Repo.SavePerson(ctx, tx, committed)
+	})
+	if err != nil {
+		t.Fatalf("RunInTx returned error: %v", err)
+	}
+	if found, err := fixture.Repo.GetPerson(fixture.Ctx, fixture.Tx, committed.ID); err != nil || found == nil {
+		t.Errorf("GetPerson of a person saved by RunInTx returned %v, %v", found, err)
+	}
+
+	// Use cases run by RunInTx join its Tx and are rolled back with it
+	useCase := familytree.NewPersonUseCase(fixture.Repo, nil)
+	joined := &familytree.Person{Name: "Joined"}
+	err = familytree.RunInTx(ctx, fixture.Repo, familytree.SessionWrite, func(ctx context.Context, tx familytree.Tx) error {
+		if err := useCase.CreatePerson(ctx, joined); err != nil {
+			return err
+		}
+		if found, err := fixture.Repo.GetPerson(ctx, tx, joined.ID); err != nil || found == nil {
+			t.Errorf("GetPerson of a person created by a joined use case returned %v, %v", found, err)
+		}
+		return errStop
+	})
+	if !errors.Is(err, errStop) {
+		t.Fatalf("RunInTx returned %v, expected %v", err, errStop)
+	}
+	if found, err := fixture.Repo.GetPerson(fixture.Ctx, fixture.Tx, joined.ID); err != nil || found != nil {
+		t.Errorf("GetPerson of a person created by a rolled back use case returned %v, %v, expected nil", found, err)
+	}
+	err = familytree.RunInTx(ctx, fixture.Repo, familytree.SessionRead, func(ctx context.Context, tx familytree.Tx) error {
+		return useCase.CreatePerson(ctx, &familytree.Person{Name: "Read Only"})
+	})
+	if !errors.Is(err, familytree.ErrReadOnlyTx) {
+		t.Errorf("CreatePerson inside a READ RunInTx returned %v, expected %v", err, familytree.ErrReadOnlyTx)
+	}
+}
+
+// slowChecksRepo takes a while to read parents and unions, so concurrent use
+// cases that don't isolate their checks from their changes surely interleave.
+type slowChecksRepo struct {
+	familytree.FamilyTreeRepo
+}
+
+func (repo slowChecksRepo) GetParents(ctx context.Context, tx familytree.Tx, personID uuid.UUID) ([]familytree.PersonParent, error) {
+	parents, err := repo.FamilyTreeRepo.GetParents(ctx, tx, personID)
+	time.Sleep(10 * time.Millisecond)
+	return parents, err
+}
+
+func (repo slowChecksRepo) GetUnions(ctx context.Context, tx familytree.Tx, person familytree.Person) ([]familytree.PersonUnion, error) {
+	unions, err := repo.FamilyTreeRepo.GetUnions(ctx, tx, person)
+	time.Sleep(10 * time.Millisecond)
+	return unions, err
+}
+
+// testConcurrentRelations runs the use cases at the same time, their checks
+// and changes must not interleave.
+func testConcurrentRelations(t *testing.T, newRepo RepoFactory) {
+	fixture := NewFixture(t, newRepo)
+	candidates := []string{"First", "Second", "Third", "Fourth", "Fifth", "Sixth"}
+	fixture.AddPeople(t, append([]string{"Child", "Person"}, candidates...)...)
+	for _, candidate := range candidates {
+		// Every candidate may marry Person, they have a child together
+		fixture.AddPeople(t, candidate+" Child")
+		fixture.AddParent(t, "Person", candidate+" Child")
+		fixture.AddParent(t, candidate, candidate+" Child")
+	}
+	fixture.Commit(t)
+	useCase := familytree.NewRelationshipUseCase(slowChecksRepo{fixture.Repo}, familytree.DefaultRelationRules(), nil)
+
+	run := func(work func(candidate familytree.Person) error) (int, []error) {
+		var wait sync.WaitGroup
+		var mutex sync.Mutex
+		created, errs := 0, []error{}
+		for _, candidate := range candidates {
+			wait.Add(1)
+			go func(candidate familytree.Person) {
+				defer wait.Done()
+				err := work(candidate)
+				mutex.Lock()
+				defer mutex.Unlock()
+				if err == nil {
+					created++
+				} else {
+					errs = append(errs, err)
+				}
+			}(fixture.Person(t, candidate))
+		}
+		wait.Wait()
+		return created, errs
+	}
+
+	child := fixture.Person(t, "Child")
+	created, errs := run(func(candidate familytree.Person) error {
+		_, err := useCase.CreateParentRelation(context.Background(), candidate.ID, child.ID, familytree.ParentageBiological)
+		return err
+	})
+	if created != familytree.MaxParents {
+		t.Errorf("Concurrent CreateParentRelation created %d relations, expected %d", created, familytree.MaxParents)
+	}
+	for _, err := range errs {
+		if !errors.Is(err, familytree.ErrMaxParents) {
+			t.Errorf("Concurrent CreateParentRelation returned %v, expected %v", err, familytree.ErrMaxParents)
+		}
+	}
+	parents, err := fixture.Repo.GetParents(fixture.Ctx, fixture.Tx, child.ID)
+	if err != nil {
+		t.Fatalf("GetParents(Child) returned error: %v", err)
+	}
+	if len(parents) != familytree.MaxParents {
+		t.Errorf("GetParents(Child) after concurrent requests returned %d parents, expected %d", len(parents), familytree.MaxParents)
+	}
+
+	person := fixture.Person(t, "Person")
+	created, errs = run(func(candidate familytree.Person) error {
+		_, err := useCase.CreateSpouseRelation(context.Background(), person.ID, candidate.ID, familytree.Union{})
+		return err
+	})
+	if created != 1 {
+		t.Errorf("Concurrent CreateSpouseRelation created %d unions, expected 1", created)
+	}
+	for _, err := range errs {
+		if !errors.Is(err, familytree.ErrHasSpouseAlready) {
+			t.Errorf("Concurrent CreateSpouseRelation returned %v, expected %v", err, familytree.ErrHasSpouseAlready)
+		}
+	}
+	unions, err := fixture.Repo.GetUnions(fixture.Ctx, fixture.Tx, person)
+	if err != nil {
+		t.Fatalf("GetUnions(Person) returned error: %v", err)
+	}
+	if len(unions) != 1 {
+		t.Errorf("GetUnions(Person) after concurrent requests returned %d unions, expected 1", len(unions))
+	}
+}
+
+func testRedirects(t *testing.T, newRepo RepoFactory) {
+	fixture := NewFixture(t, newRepo)
+	fixture.AddPeople(t, "First", "Second", "Third")
+	first, second, third := fixture.Person(t, "First"), fixture.Person(t, "Second"), fixture.Person(t, "Third")
+	assertRedirect := func(name string, expected *familytree.Person) {
+		t.Helper()
+		redirect, ok, err := fixture.Repo.GetRedirect(fixture.Ctx, fixture.Tx, fixture.Person(t, name).ID)
+		if err != nil {
+			t.Fatalf("GetRedirect(%s) returned error: %v", name, err)
+		}
+		if expected == nil {
+			if ok {
+				t.Errorf("GetRedirect(%s) returned %s, expected no redirect", name, redirect)
+			}
+			return
+		}
+		if !ok || redirect != expected.ID {
+			t.Errorf("GetRedirect(%s) returned %s, %v, expected %s", name, redirect, ok, fixture.Name(expected))
+		}
+	}
+	assertRedirect("First", nil)
+
+	if err := fixture.Repo.SaveRedirect(fixture.Ctx, fixture.Tx, first.ID, second.ID); err != nil {
+		t.Fatalf("SaveRedirect(First, Second) returned error: %v", err)
+	}
+	assertRedirect("First", &second)
+	assertRedirect("Second", nil)
+	// Redirects to a merged person follow it
+	if err := fixture.Repo.SaveRedirect(fixture.Ctx, fixture.Tx, second.ID, third.ID); err != nil {
+		t.Fatalf("SaveRedirect(Second, Third) returned error: %v", err)
+	}
+	assertRedirect("First", &third)
+	assertRedirect("Second", &third)
+	fixture.Commit(t)
+
+	tx := beginTx(t, fixture.Repo, familytree.SessionWrite)
+	if err := fixture.Repo.SaveRedirect(fixture.Ctx, tx, third.ID, first.ID); err != nil {
+		t.Fatalf("SaveRedirect(Third, First) returned error: %v", err)
+	}
+	if err := tx.Rollback(fixture.Ctx); err != nil {
+		t.Fatalf("Rollback returned error: %v", err)
+	}
+	assertRedirect("First", &third)
+	assertRedirect("Third", nil)
+}
+
+// testMergePeople merges duplicates of the grandparents fixture:
+//
+//	  Father            Stranger   Other
+//	    |                   |--------|
+//	Duplicate = Partner         Copy
+//	    |----------|
+//	 SecondGrandchild
+//
+// Duplicate is Child with a birth place, it shares Father and Partner with
+// Child. Copy is Cousin with two other parents.
+func testMergePeople(t *testing.T, newRepo RepoFactory) {
+	fixture := NewGrandparentsFixture(t, newRepo)
+	fixture.AddDetailedPeople(t, familytree.Person{Name: "Duplicate", BirthPlace: "Porto"})
+	fixture.AddPeople(t, "SecondGrandchild", "Other", "Copy")
+	fixture.AddParent(t, "Father", "Duplicate")
+	fixture.AddParent(t, "Duplicate", "SecondGrandchild")
+	fixture.AddParent(t, "Partner", "SecondGrandchild")
+	fixture.AddSpouse(t, "Duplicate", "Partner")
+	fixture.AddParent(t, "Stranger", "Copy")
+	fixture.AddParent(t, "Other", "Copy")
+	fixture.Commit(t)
+	useCase := familytree.NewRelationshipUseCase(fixture.Repo, familytree.DefaultRelationRules(), nil)
+	ctx := fixture.Ctx
+	child, duplicate := fixture.Person(t, "Child"), fixture.Person(t, "Duplicate")
+
+	if _, err := useCase.MergePeople(ctx, child.ID, child.ID); !errors.Is(err, familytree.ErrSameMergeID) {
+		t.Errorf("MergePeople(Child, Child) returned %v, expected %v", err, familytree.ErrSameMergeID)
+	}
+	if _, err := useCase.MergePeople(ctx, child.ID, uuid.New()); !errors.Is(err, familytree.ErrPersonNotFound) {
+		t.Errorf("MergePeople of a missing duplicate returned %v, expected %v", err, familytree.ErrPersonNotFound)
+	}
+
+	conflictTestCases := []struct {
+		survivor  string
+		duplicate string
+		expected  []error
+	}{
+		{survivor: "Cousin", duplicate: "Copy", expected: []error{familytree.ErrMaxParents}},
+		{survivor: "Father", duplicate: "Child", expected: []error{familytree.ErrMergedSelfRelation}},
+	}
+	for _, testCase := range conflictTestCases {
+		_, err := useCase.MergePeople(ctx, fixture.Person(t, testCase.survivor).ID, fixture.Person(t, testCase.duplicate).ID)
+		var mergeErr *familytree.MergeConflictError
+		if !errors.As(err, &mergeErr) || !errors.Is(err, familytree.ErrMergeConflict) {
+			t.Errorf("MergePeople(%s, %s) returned %v, expected a merge conflict", testCase.survivor, testCase.duplicate, err)
+			continue
+		}
+		for _, expected := range testCase.expected {
+			found := false
+			for _, conflict := range mergeErr.Conflicts {
+				found = found || errors.Is(conflict.Err, expected)
+			}
+			if !found {
+				t.Errorf("MergePeople(%s, %s) conflicts %v don't have %v", testCase.survivor, testCase.duplicate, mergeErr.Conflicts, expected)
+			}
+		}
+		// Refused merges change nothing
+		if found, err := fixture.Repo.GetPerson(ctx, fixture.Tx, fixture.Person(t, testCase.duplicate).ID); err != nil || found == nil {
+			t.Errorf("GetPerson(%s) after a refused merge returned %v, %v", testCase.duplicate, found, err)
+		}
+	}
+	copyParents, err := fixture.Repo.GetParents(ctx, fixture.Tx, fixture.Person(t, "Copy").ID)
+	if err != nil {
+		t.Fatalf("GetParents(Copy) returned error: %v", err)
+	}
+	assertNames(t, "GetParents(Copy) after a refused merge", fixture.parentNames(copyParents), []string{"Stranger", "Other"})
+
+	result, err := useCase.MergePeople(ctx, child.ID, duplicate.ID)
+	if err != nil {
+		t.Fatalf("MergePeople(Child, Duplicate) returned error: %v", err)
+	}
+	if result.Moved != 1 {
+		t.Errorf("MergePeople(Child, Duplicate) moved %d relations, expected 1", result.Moved)
+	}
+	if result.Person.ID != child.ID || result.Person.Name != "Child" || result.Person.BirthPlace != "Porto" {
+		t.Errorf("MergePeople(Child, Duplicate) returned %+v, expected Child born in Porto", result.Person)
+	}
+	if found, err := fixture.Repo.GetPerson(ctx, fixture.Tx, duplicate.ID); err != nil || found != nil {
+		t.Errorf("GetPerson(Duplicate) after the merge returned %v, %v, expected nil", found, err)
+	}
+	if redirect, ok, err := fixture.Repo.GetRedirect(ctx, fixture.Tx, duplicate.ID); err != nil || !ok || redirect != child.ID {
+		t.Errorf("GetRedirect(Duplicate) returned %s, %v, %v, expected Child", redirect, ok, err)
+	}
+	parents, err := fixture.Repo.GetParents(ctx, fixture.Tx, fixture.Person(t, "SecondGrandchild").ID)
+	if err != nil {
+		t.Fatalf("GetParents(SecondGrandchild) returned error: %v", err)
+	}
+	assertNames(t, "GetParents(SecondGrandchild) after the merge", fixture.parentNames(parents), []string{"Child", "Partner"})
+	childParents, err := fixture.Repo.GetParents(ctx, fixture.Tx, child.ID)
+	if err != nil {
+		t.Fatalf("GetParents(Child) returned error: %v", err)
+	}
+	assertNames(t, "GetParents(Child) after the merge", fixture.parentNames(childParents), []string{"Father", "Mother"})
+	unions, err := fixture.Repo.GetUnions(ctx, fixture.Tx, child)
+	if err != nil {
+		t.Fatalf("GetUnions(Child) returned error: %v", err)
+	}
+	if len(unions) != 1 || unions[0].Spouse.ID != fixture.Person(t, "Partner").ID {
+		t.Errorf("GetUnions(Child) after the merge returned %d unions, expected Partner only", len(unions))
+	}
+}
+
+func (fixture *Fixture) parentNames(parents []familytree.PersonParent) []string {
+	names := make([]string, 0, len(parents))
+	for _, parent := range parents {
+		names = append(names, fixture.Name(&parent.Parent))
+	}
+	return names
+}
+
+// relationName writes the relation as "Top TYPE Bottom". SPOUSE isn't
+// directional so both names are sorted, the adapters may list it on any of
+// the spouses.
+func (fixture *Fixture) pathName(path familytree.Path) string {
+	name := ""
+	for i, person := range path.People {
+		if i > 0 {
+			name += fmt.Sprintf(" %s ", path.Edges[i-1])
+		}
+		name += fixture.Name(&person)
+	}
+	return name
+}
+
+func (fixture *Fixture) relationName(personID uuid.UUID, relation familytree.FamilyTreeRelation) string {
+	first := fixture.Name(&familytree.Person{ID: personID})
+	second := fixture.Name(&familytree.Person{ID: relation.PersonID})
+	if !relation.RelationType.Directional && second < first {
+		first, second = second, first
+	}
+	return fmt.Sprintf("%s %s %s", first, relation.RelationType, second)
+}
+
+// testDuplicates blocks people by the Soundex of their names and by their
+// relatives:
+//
+//	     Father                 Zeca
+//	   |--------|           |---------|
+//	Alice Smith  Bruno Smith  Maria Pereira  Mária Pereira
+//
+// John Smith, Jon Smith and John Smyth share the blocking key, but John Smyth
+// was born 50 years later. Paulo Costa and Paula Costa have different sexes.
+func testDuplicates(t *testing.T, newRepo RepoFactory) {
+	fixture := NewFixture(t, newRepo)
+	fixture.AddDetailedPeople(t,
+		familytree.Person{Name: "John Smith", BirthDate: "1900"},
+		familytree.Person{Name: "Jon Smith", BirthDate: "1900"},
+		familytree.Person{Name: "John Smyth", BirthDate: "1950"},
+		familytree.Person{Name: "Maria Pereira", BirthDate: "1920"},
+		familytree.Person{Name: "Mária Pereira", BirthDate: "ABT 1920"},
+		familytree.Person{Name: "Paulo Costa", Sex: familytree.SexMale},
+		familytree.Person{Name: "Paula Costa", Sex: familytree.SexFemale},
+	)
+	fixture.AddPeople(t, "Father", "Alice Smith", "Bruno Smith", "Zeca")
+	fixture.AddParent(t, "Father", "Alice Smith", "Bruno Smith")
+	fixture.AddSpouse(t, "Zeca", "Maria Pereira")
+	fixture.AddSpouse(t, "Zeca", "Mária Pereira")
+
+	candidates, err := fixture.Repo.GetDuplicateCandidates(fixture.Ctx, fixture.Tx)
+	if err != nil {
+		t.Fatalf("GetDuplicateCandidates returned error: %v", err)
+	}
+	got := []string{}
+	for _, candidate := range candidates {
+		got = append(got, fmt.Sprintf("%s parents %d spouses %d", fixture.pairName(candidate.First, candidate.Second), candidate.SharedParents, candidate.SharedSpouses))
+	}
+	assertNames(t, "GetDuplicateCandidates", got, []string{
+		"John Smith & Jon Smith parents 0 spouses 0",
+		"John Smith & John Smyth parents 0 spouses 0",
+		"John Smyth & Jon Smith parents 0 spouses 0",
+		"Maria Pereira & Mária Pereira parents 0 spouses 1",
+		"Paula Costa & Paulo Costa parents 0 spouses 0",
+		"Alice Smith & Bruno Smith parents 1 spouses 0",
+	})
+
+	fixture.Commit(t)
+	useCase := familytree.NewPersonUseCase(fixture.Repo, nil)
+	testCases := []struct {
+		minScore   float64
+		pagination familytree.PaginationDetails
+		expected   []string
+		total      int
+	}{
+		{minScore: 0, pagination: familytree.PaginationDetails{PageSize: 10}, expected: []string{"Maria Pereira & Mária Pereira", "John Smith & Jon Smith"}, total: 2},
+		{minScore: 0.9, pagination: familytree.PaginationDetails{PageSize: 10}, expected: []string{"Maria Pereira & Mária Pereira"}, total: 1},
+		{minScore: 0, pagination: familytree.PaginationDetails{Page: 1, PageSize: 1}, expected: []string{"John Smith & Jon Smith"}, total: 2},
+	}
+	for _, testCase := range testCases {
+		duplicates, err := useCase.GetDuplicates(fixture.Ctx, testCase.minScore, testCase.pagination)
+		if err != nil {
+			t.Fatalf("GetDuplicates(%v, %+v) returned error: %v", testCase.minScore, testCase.pagination, err)
+		}
+		pairs := []string{}
+		for _, pair := range duplicates.Content {
+			pairs = append(pairs, fixture.pairName(pair.First, pair.Second))
+		}
+		assertOrderedNames(t, fmt.Sprintf("GetDuplicates(%v, %+v)", testCase.minScore, testCase.pagination), pairs, testCase.expected)
+		if duplicates.Metadata.TotalItens != testCase.total {
+			t.Errorf("GetDuplicates(%v, %+v) returned %d pairs in total, expected %d", testCase.minScore, testCase.pagination, duplicates.Metadata.TotalItens, testCase.total)
+		}
+	}
+	duplicates, err := useCase.GetDuplicates(fixture.Ctx, 0.9, familytree.PaginationDetails{PageSize: 10})
+	if err != nil || len(duplicates.Content) != 1 {
+		t.Fatalf("GetDuplicates(0.9) returned %v, %v", duplicates, err)
+	}
+	pair := duplicates.Content[0]
+	if pair.Score != 1 || !reflect.DeepEqual(pair.Reasons, []familytree.DuplicateReason{familytree.DuplicateReasonSameName, familytree.DuplicateReasonBirthDate, familytree.DuplicateReasonSharedSpouse}) {
+		t.Errorf("GetDuplicates(0.9) returned score %v with reasons %v", pair.Score, pair.Reasons)
+	}
+}
+
+func testTrash(t *testing.T, newRepo RepoFactory) {
+	fixture := NewGrandparentsFixture(t, newRepo)
+	uncle, cousin := fixture.Person(t, "Uncle"), fixture.Person(t, "Cousin")
+	sibling, nephew := fixture.Person(t, "Sibling"), fixture.Person(t, "Nephew")
+	assertTrash := func(name string, expectedPeople []string, expectedRelations []string) {
+		t.Helper()
+		trash, err := fixture.Repo.GetTrash(fixture.Ctx, fixture.Tx)
+		if err != nil {
+			t.Fatalf("GetTrash %s returned error: %v", name, err)
+		}
+		people := []string{}
+		for _, trashed := range trash.People {
+			relations := fixture.personRelationNames(trashed.Relations)
+			sort.Strings(relations)
+			people = append(people, fmt.Sprintf("%s %v", fixture.Name(&trashed.Person), relations))
+		}
+		relations := []string{}
+		for _, trashed := range trash.Relations {
+			relations = append(relations, fixture.personRelationNames([]familytree.PersonRelation{trashed.Relation})...)
+		}
+		assertNames(t, "GetTrash people "+name, people, expectedPeople)
+		assertNames(t, "GetTrash relations "+name, relations, expectedRelations)
+	}
+	assertTrash("of a new tree", []string{}, []string{})
+
+	if ok, err := fixture.Repo.DeleteRelationship(fixture.Ctx, fixture.Tx, uncle, cousin, familytree.RelationTypeParent); err != nil || !ok {
+		t.Fatalf("DeleteRelationship(Uncle, Cousin) returned %v, %v", ok, err)
+	}
+	if err := fixture.Repo.DeletePersonRelations(fixture.Ctx, fixture.Tx, nephew); err != nil {
+		t.Fatalf("DeletePersonRelations(Nephew) returned error: %v", err)
+	}
+	if err := fixture.Repo.DeletePerson(fixture.Ctx, fixture.Tx, nephew); err != nil {
+		t.Fatalf("DeletePerson(Nephew) returned error: %v", err)
+	}
+	assertTrash("after deletes", []string{"Nephew [Sibling PARENT Nephew]"}, []string{"Uncle PARENT Cousin"})
+
+	// Trashed people and relations are hidden from the other queries
+	if person, err := fixture.Repo.GetPerson(fixture.Ctx, fixture.Tx, nephew.ID); err != nil || person != nil {
+		t.Errorf("GetPerson(Nephew) in the trash returned %v, %v, expected nil", person, err)
+	}
+	for _, live := range []familytree.Person{uncle, cousin, sibling} {
+		if person, err := fixture.Repo.GetPerson(fixture.Ctx, fixture.Tx, live.ID); err != nil || person == nil {
+			t.Errorf("GetPerson(%s) with a trashed relation returned %v, %v, expected the person", live.Name, person, err)
+		}
+	}
+	parents, err := fixture.Repo.GetParents(fixture.Ctx, fixture.Tx, cousin.ID)
+	if err != nil || len(parents) != 0 {
+		t.Errorf("GetParents(Cousin) without the trashed relation returned %v, %v, expected none", fixture.parentNames(parents), err)
+	}
+	list, err := fixture.Repo.GetPeople(fixture.Ctx, fixture.Tx, familytree.PeopleFilter{}, familytree.PeopleSort{Field: familytree.PeopleSortCreatedAt}, familytree.PaginationDetails{PageSize: familytree.GetPeopleMaxPageSize})
+	if err != nil {
+		t.Fatalf("GetPeople returned error: %v", err)
+	}
+	if list.Metadata.TotalItens != len(fixture.People)-1 {
+		t.Errorf("GetPeople with Nephew in the trash returned %d itens, expected %d", list.Metadata.TotalItens, len(fixture.People)-1)
+	}
+	if length, found, err := fixture.Repo.GetShortestPathLength(fixture.Ctx, fixture.Tx, uncle, cousin); err != nil || found {
+		t.Errorf("GetShortestPathLength(Uncle, Cousin) through the trash returned %d, %v, %v, expected no path", length, found, err)
+	}
+
+	if ok, err := fixture.Repo.RestorePerson(fixture.Ctx, fixture.Tx, nephew.ID); err != nil || !ok {
+		t.Fatalf("RestorePerson(Nephew) returned %v, %v", ok, err)
+	}
+	if ok, err := fixture.Repo.RestorePerson(fixture.Ctx, fixture.Tx, nephew.ID); err != nil || ok {
+		t.Errorf("RestorePerson(Nephew) out of the trash returned %v, %v, expected false", ok, err)
+	}
+	// The relations of a restored person are left on their own in the trash
+	assertTrash("after RestorePerson", []string{}, []string{"Sibling PARENT Nephew", "Uncle PARENT Cousin"})
+	if ok, err := fixture.Repo.RestoreRelationship(fixture.Ctx, fixture.Tx, sibling, nephew, familytree.RelationTypeParent); err != nil || !ok {
+		t.Fatalf("RestoreRelationship(Sibling, Nephew) returned %v, %v", ok, err)
+	}
+	parents, err = fixture.Repo.GetParents(fixture.Ctx, fixture.Tx, nephew.ID)
+	if err != nil {
+		t.Fatalf("GetParents(Nephew) returned error: %v", err)
+	}
+	assertNames(t, "GetParents(Nephew) after the restore", fixture.parentNames(parents), []string{"Sibling"})
+
+	purge, err := fixture.Repo.PurgeTrash(fixture.Ctx, fixture.Tx, time.Now().Add(-time.Hour))
+	if err != nil || *purge != (familytree.TrashPurge{}) {
+		t.Errorf("PurgeTrash of an hour ago returned %+v, %v, expected nothing purged", purge, err)
+	}
+	purge, err = fixture.Repo.PurgeTrash(fixture.Ctx, fixture.Tx, time.Now().Add(time.Hour))
+	if err != nil || *purge != (familytree.TrashPurge{Relations: 1}) {
+		t.Errorf("PurgeTrash returned %+v, %v, expected the relation purged", purge, err)
+	}
+	assertTrash("after PurgeTrash", []string{}, []string{})
+	if ok, err := fixture.Repo.RestoreRelationship(fixture.Ctx, fixture.Tx, uncle, cousin, familytree.RelationTypeParent); err != nil || ok {
+		t.Errorf("RestoreRelationship(Uncle, Cousin) after the purge returned %v, %v, expected false", ok, err)
+	}
+}
+
+// testRestoreFromTrash restores Child, deleted with its relations, through
+// the use cases. The union with Partner needs Grandchild back first.
+func testRestoreFromTrash(t *testing.T, newRepo RepoFactory) {
+	fixture := NewGrandparentsFixture(t, newRepo)
+	fixture.Commit(t)
+	personUseCase := familytree.NewPersonUseCase(fixture.Repo, nil)
+	relationshipUseCase := familytree.NewRelationshipUseCase(fixture.Repo, familytree.DefaultRelationRules(), nil)
+	ctx := fixture.Ctx
+	child, partner, grandchild := fixture.Person(t, "Child"), fixture.Person(t, "Partner"), fixture.Person(t, "Grandchild")
+
+	if _, err := personUseCase.DeletePersonCascade(ctx, child.ID, false); err != nil {
+		t.Fatalf("DeletePersonCascade(Child) returned error: %v", err)
+	}
+	if err := relationshipUseCase.DeleteParentRelation(ctx, partner.ID, grandchild.ID); err != nil {
+		t.Fatalf("DeleteParentRelation(Partner, Grandchild) returned error: %v", err)
+	}
+	if _, err := relationshipUseCase.RestorePerson(ctx, uuid.New()); !errors.Is(err, familytree.ErrPersonNotFound) {
+		t.Errorf("RestorePerson of a missing person returned %v, expected %v", err, familytree.ErrPersonNotFound)
+	}
+	if err := relationshipUseCase.RestoreSpouseRelation(ctx, fixture.Person(t, "Father").ID, fixture.Person(t, "Mother").ID); !errors.Is(err, familytree.ErrRelationNotFound) {
+		t.Errorf("RestoreSpouseRelation(Father, Mother) out of the trash returned %v, expected %v", err, familytree.ErrRelationNotFound)
+	}
+	if _, err := relationshipUseCase.RestorePerson(ctx, child.ID); !errors.Is(err, familytree.ErrCoupleHasNoChild) {
+		t.Errorf("RestorePerson(Child) without Partner as a parent of Grandchild returned %v, expected %v", err, familytree.ErrCoupleHasNoChild)
+	}
+	if person, err := fixture.Repo.GetPerson(ctx, fixture.Tx, child.ID); err != nil || person != nil {
+		t.Errorf("GetPerson(Child) after a refused restore returned %v, %v, expected nil", person, err)
+	}
+
+	if err := relationshipUseCase.RestoreParentRelation(ctx, partner.ID, grandchild.ID); err != nil {
+		t.Fatalf("RestoreParentRelation(Partner, Grandchild) returned error: %v", err)
+	}
+	restored, err := relationshipUseCase.RestorePerson(ctx, child.ID)
+	if err != nil {
+		t.Fatalf("RestorePerson(Child) returned error: %v", err)
+	}
+	assertNames(t, "RestorePerson(Child)", fixture.personRelationNames(restored.Relations), []string{"Father PARENT Child", "Mother PARENT Child", "Child PARENT Grandchild", "Child SPOUSE Partner"})
+	parents, err := fixture.Repo.GetParents(ctx, fixture.Tx, grandchild.ID)
+	if err != nil {
+		t.Fatalf("GetParents(Grandchild) returned error: %v", err)
+	}
+	assertNames(t, "GetParents(Grandchild) after the restore", fixture.parentNames(parents), []string{"Child", "Partner"})
+	unions, err := fixture.Repo.GetUnions(ctx, fixture.Tx, child)
+	if err != nil || len(unions) != 1 || unions[0].Spouse.ID != partner.ID {
+		t.Errorf("GetUnions(Child) after the restore returned %v, %v, expected Partner only", unions, err)
+	}
+
+	if err := personUseCase.DeletePerson(ctx, fixture.Person(t, "Stranger").ID); err != nil {
+		t.Fatalf("DeletePerson(Stranger) returned error: %v", err)
+	}
+	purge, err := personUseCase.PurgeTrash(ctx, time.Nanosecond)
+	if err != nil || *purge != (familytree.TrashPurge{People: 1}) {
+		t.Errorf("PurgeTrash returned %+v, %v, expected Stranger purged", purge, err)
+	}
+	trash, err := personUseCase.GetTrash(ctx)
+	if err != nil || len(trash.People) != 0 || len(trash.Relations) != 0 {
+		t.Errorf("GetTrash after the purge returned %+v, %v, expected nothing", trash, err)
+	}
+}
+
+// testAudit runs the mutations of Newborn, a child of Uncle, through the use
+// cases and pages through the histories they leave.
+func testAudit(t *testing.T, newRepo RepoFactory, newSink AuditSinkFactory) {
+	fixture := NewGrandparentsFixture(t, newRepo)
+	fixture.Commit(t)
+	auditSink := newSink(t, fixture.Repo)
+	personUseCase := familytree.NewPersonUseCase(fixture.Repo, auditSink)
+	relationshipUseCase := familytree.NewRelationshipUseCase(fixture.Repo, familytree.DefaultRelationRules(), auditSink)
+	ctx := familytree.WithAuditSource(fixture.Ctx, familytree.AuditSource{Actor: "tester", RequestID: "request-1"})
+	uncle, grandchild := fixture.Person(t, "Uncle"), fixture.Person(t, "Grandchild")
+
+	newborn := &familytree.Person{Name: "Newborn"}
+	if err := personUseCase.CreatePerson(ctx, newborn); err != nil {
+		t.Fatalf("CreatePerson(Newborn) returned error: %v", err)
+	}
+	if _, err := relationshipUseCase.CreateParentRelation(ctx, uncle.ID, newborn.ID, familytree.ParentageAdoptive); err != nil {
+		t.Fatalf("CreateParentRelation(Uncle, Newborn) returned error: %v", err)
+	}
+	renamed := "Renamed"
+	if _, err := personUseCase.UpdatePerson(ctx, newborn.ID, familytree.PersonUpdate{Name: &renamed}); err != nil {
+		t.Fatalf("UpdatePerson(Newborn) returned error: %v", err)
+	}
+	if err := relationshipUseCase.DeleteParentRelation(ctx, uncle.ID, newborn.ID); err != nil {
+		t.Fatalf("DeleteParentRelation(Uncle, Newborn) returned error: %v", err)
+	}
+	if err := personUseCase.DeletePerson(ctx, newborn.ID); err != nil {
+		t.Fatalf("DeletePerson(Newborn) returned error: %v", err)
+	}
+	if _, err := personUseCase.DeletePersonCascade(ctx, grandchild.ID, false); !errors.Is(err, familytree.ErrOnlyChildFromSpouseCouple) {
+		t.Fatalf("DeletePersonCascade(Grandchild) returned %v, expected %v", err, familytree.ErrOnlyChildFromSpouseCouple)
+	}
+
+	operations := func(events []familytree.AuditEvent) []string {
+		names := []string{}
+		for _, event := range events {
+			names = append(names, string(event.Operation))
+		}
+		return names
+	}
+	history, err := personUseCase.GetAuditEvents(fixture.Ctx, newborn.ID, familytree.PaginationDetails{Page: 0, PageSize: 10})
+	if err != nil {
+		t.Fatalf("GetAuditEvents(Newborn) returned error: %v", err)
+	}
+	assertOrderedNames(t, "GetAuditEvents(Newborn)", operations(history.Content), []string{"DELETE_PERSON", "DELETE_PARENT_RELATION", "UPDATE_PERSON", "CREATE_PARENT_RELATION", "CREATE_PERSON"})
+	if history.Metadata.TotalItens != 5 {
+		t.Errorf("GetAuditEvents(Newborn) returned %d total itens, expected 5", history.Metadata.TotalItens)
+	}
+	for _, event := range history.Content {
+		if event.ID == uuid.Nil || event.Timestamp.IsZero() || event.Actor != "tester" || event.RequestID != "request-1" {
+			t.Errorf("GetAuditEvents(Newborn) returned %s event with ID %s, timestamp %v, actor %q and request ID %q", event.Operation, event.ID, event.Timestamp, event.Actor, event.RequestID)
+		}
+	}
+	update := history.Content[2]
+	if len(update.Before.People) != 1 || update.Before.People[0].Name != "Newborn" || len(update.After.People) != 1 || update.After.People[0].Name != "Renamed" {
+		t.Errorf("UPDATE_PERSON event changed %+v into %+v, expected Newborn renamed", update.Before, update.After)
+	}
+	deleted := history.Content[1]
+	if len(deleted.Before.Relations) != 1 || len(deleted.After.Relations) != 0 {
+		t.Fatalf("DELETE_PARENT_RELATION event changed %+v into %+v, expected one relation deleted", deleted.Before, deleted.After)
+	}
+	relation := deleted.Before.Relations[0]
+	if relation.Top.ID != uncle.ID || relation.Bottom.Name != "Renamed" || relation.RelationType != familytree.RelationTypeParent || relation.Parentage != familytree.ParentageAdoptive {
+		t.Errorf("DELETE_PARENT_RELATION event deleted %+v, expected the ADOPTIVE relation of Uncle and Renamed", relation)
+	}
+
+	page, err := personUseCase.GetAuditEvents(fixture.Ctx, newborn.ID, familytree.PaginationDetails{Page: 1, PageSize: 2})
+	if err != nil {
+		t.Fatalf("GetAuditEvents(Newborn) page 1 returned error: %v", err)
+	}
+	assertOrderedNames(t, "GetAuditEvents(Newborn) page 1", operations(page.Content), []string{"UPDATE_PERSON", "CREATE_PARENT_RELATION"})
+	if page.Metadata.TotalItens != 5 || page.Metadata.Page != 1 {
+		t.Errorf("GetAuditEvents(Newborn) page 1 returned metadata %+v, expected page 1 of 5 itens", page.Metadata)
+	}
+	history, err = personUseCase.GetAuditEvents(fixture.Ctx, uncle.ID, familytree.PaginationDetails{Page: 0, PageSize: 10})
+	if err != nil {
+		t.Fatalf("GetAuditEvents(Uncle) returned error: %v", err)
+	}
+	assertOrderedNames(t, "GetAuditEvents(Uncle)", operations(history.Content), []string{"DELETE_PARENT_RELATION", "CREATE_PARENT_RELATION"})
+	history, err = personUseCase.GetAuditEvents(fixture.Ctx, grandchild.ID, familytree.PaginationDetails{Page: 0, PageSize: 10})
+	if err != nil || len(history.Content) != 0 {
+		t.Errorf("GetAuditEvents(Grandchild) after a refused delete returned %+v, %v, expected no events", history, err)
+	}
+
+	// Newborn and its relation with Uncle are purged with an event each
+	if _, err := personUseCase.PurgeTrash(ctx, time.Nanosecond); err != nil {
+		t.Fatalf("PurgeTrash returned error: %v", err)
+	}
+	history, err = personUseCase.GetAuditEvents(fixture.Ctx, newborn.ID, familytree.PaginationDetails{Page: 0, PageSize: 2})
+	if err != nil {
+		t.Fatalf("GetAuditEvents(Newborn) after the purge returned error: %v", err)
+	}
+	assertOrderedNames(t, "GetAuditEvents(Newborn) after the purge", operations(history.Content), []string{"PURGE_RELATION", "PURGE_PERSON"})
+	purged := history.Content[1]
+	if len(purged.Before.People) != 1 || purged.Before.People[0].ID != newborn.ID || len(purged.After.People) != 0 || purged.Actor != "tester" {
+		t.Errorf("PURGE_PERSON event changed %+v into %+v by %q, expected Newborn purged by tester", purged.Before, purged.After, purged.Actor)
+	}
+	history, err = personUseCase.GetAuditEvents(fixture.Ctx, uncle.ID, familytree.PaginationDetails{Page: 0, PageSize: 10})
+	if err != nil {
+		t.Fatalf("GetAuditEvents(Uncle) after the purge returned error: %v", err)
+	}
+	assertOrderedNames(t, "GetAuditEvents(Uncle) after the purge", operations(history.Content), []string{"PURGE_RELATION", "DELETE_PARENT_RELATION", "CREATE_PARENT_RELATION"})
+}
+
+// testAuditRollback checks that the events a repo keeps are rolled back along
+// with their mutations.
+func testAuditRollback(t *testing.T, newRepo RepoFactory) {
+	fixture := NewFixture(t, newRepo)
+	fixture.Commit(t)
+	auditSink := repoAuditSink(t, fixture.Repo)
+	useCase := familytree.NewPersonUseCase(fixture.Repo, auditSink)
+
+	rolledBack := &familytree.Person{Name: "Rolled Back"}
+	errStop := errors.New("stop")
+	err := familytree.RunInTx(fixture.Ctx, fixture.Repo, familytree.SessionWrite, func(ctx context.Context, tx familytree.Tx) error {
+		if err := fixture.Repo.SavePerson(ctx, tx, rolledBack); err != nil {
+			return err
+		}
+		err := auditSink.AppendAuditEvents(ctx, tx, familytree.AuditEvent{
+			ID:        uuid.New(),
+			Timestamp: time.Now().UTC(),
+			Operation: familytree.AuditOperationCreatePerson,
+			PeopleIDs: []uuid.UUID{rolledBack.ID},
+			After:     familytree.AuditState{People: []familytree.Person{*rolledBack}},
+		})
+		if err != nil {
+			return err
+		}
+		history, err := auditSink.GetAuditEvents(ctx, tx, rolledBack.ID, familytree.PaginationDetails{Page: 0, PageSize: 10})
+		if err != nil || len(history.Content) != 1 {
+			t.Errorf("GetAuditEvents of a person created on the Tx returned %+v, %v, expected its CREATE_PERSON event", history, err)
+		}
+		return errStop
+	})
+	if !errors.Is(err, errStop) {
+		t.Fatalf("RunInTx returned %v, expected %v", err, errStop)
+	}
+	history, err := useCase.GetAuditEvents(fixture.Ctx, rolledBack.ID, familytree.PaginationDetails{Page: 0, PageSize: 10})
+	if err != nil || len(history.Content) != 0 || history.Metadata.TotalItens != 0 {
+		t.Errorf("GetAuditEvents of a person created by a rolled back Tx returned %+v, %v, expected no events", history, err)
+	}
+}
+
+// testAuditCommit checks that a sink only keeps the events of committed Txs,
+// the events of a work retried or rolled back are never seen.
+func testAuditCommit(t *testing.T, newRepo RepoFactory, newSink AuditSinkFactory) {
+	fixture := NewFixture(t, newRepo)
+	fixture.Commit(t)
+	auditSink := newSink(t, fixture.Repo)
+	useCase := familytree.NewPersonUseCase(fixture.Repo, auditSink)
+	appendCreated := func(ctx context.Context, tx familytree.Tx, person *familytree.Person) error {
+		if err := fixture.Repo.SavePerson(ctx, tx, person); err != nil {
+			return err
+		}
+		return auditSink.AppendAuditEvents(ctx, tx, familytree.AuditEvent{
+			ID:        uuid.New(),
+			Timestamp: time.Now().UTC(),
+			Operation: familytree.AuditOperationCreatePerson,
+			PeopleIDs: []uuid.UUID{person.ID},
+			After:     familytree.AuditState{People: []familytree.Person{*person}},
+		})
+	}
+
+	rolledBack := &familytree.Person{Name: "Rolled Back"}
+	errStop := errors.New("stop")
+	err := familytree.RunInTx(fixture.Ctx, fixture.Repo, familytree.SessionWrite, func(ctx context.Context, tx familytree.Tx) error {
+		if err := appendCreated(ctx, tx, rolledBack); err != nil {
+			return err
+		}
+		return errStop
+	})
+	if !errors.Is(err, errStop) {
+		t.Fatalf("RunInTx returned %v, expected %v", err, errStop)
+	}
+	committed := &familytree.Person{Name: "Committed"}
+	err = familytree.RunInTx(fixture.Ctx, fixture.Repo, familytree.SessionWrite, func(ctx context.Context, tx familytree.Tx) error {
+		return appendCreated(ctx, tx, committed)
+	})
+	if err != nil {
+		t.Fatalf("RunInTx returned error: %v", err)
+	}
+
+	for _, test := range []struct {
+		person *familytree.Person
+		events int
+	}{
+		{rolledBack, 0},
+		{committed, 1},
+	} {
+		history, err := useCase.GetAuditEvents(fixture.Ctx, test.person.ID, familytree.PaginationDetails{Page: 0, PageSize: 10})
+		if err != nil || len(history.Content) != test.events || history.Metadata.TotalItens != test.events {
+			t.Errorf("GetAuditEvents(%s) returned %+v, %v, expected %d events", test.person.Name, history, err, test.events)
+		}
+	}
+}
+
+// personRelationNames names the relations as "Top TYPE Bottom".
+func (fixture *Fixture) personRelationNames(relations []familytree.PersonRelation) []string {
+	names := []string{}
+	for _, relation := range relations {
+		names = append(names, fmt.Sprintf("%s %s %s", fixture.Name(&relation.Top), relation.RelationType.Name, fixture.Name(&relation.Bottom)))
+	}
+	return names
+}
+
+// pairName names the pair in alphabetical order, whatever order the repo
+// returned it in.
+func (fixture *Fixture) pairName(first familytree.Person, second familytree.Person) string {
+	names := []string{fixture.Name(&first), fixture.Name(&second)}
+	sort.Strings(names)
+	return strings.Join(names, " & ")
+}
+
+func assertOrderedNames(t *testing.T, name string, got []string, expected []string) {
+	t.Helper()
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("%s returned %v, expected %v", name, got, expected)
+	}
+}
+
+func assertNames(t *testing.T, name string, got []string, expected []string) {
+	t.Helper()
+	sortedGot := append([]string{}, got...)
+	sortedExpected := append([]string{}, expected...)
+	sort.Strings(sortedGot)
+	sort.Strings(sortedExpected)
+	if !reflect.DeepEqual(sortedGot, sortedExpected) {
+		t.Errorf("%s returned %v, expected %v", name, sortedGot, sortedExpected)
+	}
+}
+
+func contains(names []string, name string) bool {
+	for _, current := range names {
+		if current == name {
+			return true
+		}
+	}
+	return false
+}
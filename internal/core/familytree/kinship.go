@@ -0,0 +1,159 @@
+package familytree
+
+import (
+	"errors"
+	"sort"
+)
+
+var ErrNotRelated = errors.New("people aren't related")
+
+type KinshipType string
+
+const (
+	KinshipSelf       = KinshipType("SELF")
+	KinshipAncestor   = KinshipType("ANCESTOR")
+	KinshipDescendant = KinshipType("DESCENDANT")
+	KinshipSibling    = KinshipType("SIBLING")
+	// KinshipUncle is the sibling of an ancestor, like an aunt or a grand-uncle
+	KinshipUncle = KinshipType("UNCLE")
+	// KinshipNephew is the descendant of a sibling, like a niece or a grand-nephew
+	KinshipNephew = KinshipType("NEPHEW")
+	KinshipCousin = KinshipType("COUSIN")
+	KinshipSpouse = KinshipType("SPOUSE")
+	KinshipInLaw  = KinshipType("IN_LAW")
+)
+
+type Language string
+
+const (
+	LanguageEnglish    = Language("en")
+	LanguagePortuguese = Language("pt")
+)
+
+var Languages = []Language{LanguageEnglish, LanguagePortuguese}
+
+// CommonAncestor is a biological ancestor of two people, the people
+// themselves included, with the number of generations between it and each of
+// them.
+type CommonAncestor struct {
+	Ancestor       Person
+	FirstDistance  int
+	SecondDistance int
+}
+
+type InLawSide string
+
+const (
+	// InLawSpouseRelative is a blood relative of the spouse, like a
+	// father-in-law or a stepchild
+	InLawSpouseRelative = InLawSide("SPOUSE_RELATIVE")
+	// InLawRelativeSpouse is the spouse of a blood relative, like a
+	// daughter-in-law or a stepmother
+	InLawRelativeSpouse = InLawSide("RELATIVE_SPOUSE")
+)
+
+// InLaw links the people of an IN_LAW kinship. On SPOUSE_RELATIVE kinships Link
+// is the spouse of the first person, on RELATIVE_SPOUSE kinships it's the
+// relative of the first person married to the second.
+type InLaw struct {
+	Side InLawSide
+	Link Person
+}
+
+// Kinship is what the second person is to the first one. FirstGenerations and
+// SecondGenerations are the generations between each person and the closest
+// common ancestors, on IN_LAW kinships they are counted from the Link instead
+// of the person it's married to. Only biological lineage is followed.
+type Kinship struct {
+	Type              KinshipType
+	FirstGenerations  int
+	SecondGenerations int
+	// Half is set when the people only share one of the closest ancestors
+	Half            bool
+	CommonAncestors []Person
+	// Former is set on SPOUSE kinships ended by divorce or annulment
+	Former bool
+	InLaw  *InLaw
+	Names  map[Language]string
+}
+
+// closestKinship builds the blood kinship from the common ancestors at the
+// fewest generations from both people, it returns nil when there are none.
+func closestKinship(ancestors []CommonAncestor) *Kinship {
+	if len(ancestors) == 0 {
+		return nil
+	}
+	sort.SliceStable(ancestors, func(i, j int) bool {
+		first := ancestors[i].FirstDistance + ancestors[i].SecondDistance
+		second := ancestors[j].FirstDistance + ancestors[j].SecondDistance
+		if first != second {
+			return first < second
+		}
+		if ancestors[i].FirstDistance != ancestors[j].FirstDistance {
+			return ancestors[i].FirstDistance < ancestors[j].FirstDistance
+		}
+		return ancestors[i].Ancestor.Name < ancestors[j].Ancestor.Name
+	})
+	kinship := &Kinship{
+		FirstGenerations:  ancestors[0].FirstDistance,
+		SecondGenerations: ancestors[0].SecondDistance,
+		CommonAncestors:   []Person{},
+	}
+	for _, ancestor := range ancestors {
+		if ancestor.FirstDistance == kinship.FirstGenerations && ancestor.SecondDistance == kinship.SecondGenerations {
+			kinship.CommonAncestors = append(kinship.CommonAncestors, ancestor.Ancestor)
+		}
+	}
+	kinship.Type = bloodKinshipType(kinship.FirstGenerations, kinship.SecondGenerations)
+	// Siblings and their descendants usually share a couple, people missing
+	// from the tree make full relatives look like half relatives
+	collateral := kinship.FirstGenerations > 0 && kinship.SecondGenerations > 0
+	kinship.Half = collateral && len(kinship.CommonAncestors) == 1
+	return kinship
+}
+
+// formerUnion tells if the union was dissolved, unions ended by death still
+// make in-laws.
+func formerUnion(union Union) bool {
+	return union.EndReason == UnionEndDivorce || union.EndReason == UnionEndAnnulment
+}
+
+func bloodKinshipType(firstGenerations int, secondGenerations int) KinshipType {
+	switch {
+	case firstGenerations == 0 && secondGenerations == 0:
+		return KinshipSelf
+	case secondGenerations == 0:
+		return KinshipAncestor
+	case firstGenerations == 0:
+		return KinshipDescendant
+	case firstGenerations == 1 && secondGenerations == 1:
+		return KinshipSibling
+	case secondGenerations == 1:
+		return KinshipUncle
+	case firstGenerations == 1:
+		return KinshipNephew
+	default:
+		return KinshipCousin
+	}
+}
+
+// closer tells if the kinship is between nearer generations than the other,
+// a nil kinship is never closer.
+func (kinship *Kinship) closer(other *Kinship) bool {
+	if kinship == nil {
+		return false
+	}
+	if other == nil {
+		return true
+	}
+	return kinship.FirstGenerations+kinship.SecondGenerations < other.FirstGenerations+other.SecondGenerations
+}
+
+// setNames names the kinship on every language, second is the person the
+// kinship describes.
+func (kinship *Kinship) setNames(second Person) {
+	kinship.Names = map[Language]string{}
+	for _, language := range Languages {
+		kinship.Names[language] = kinshipName(kinshipLanguages[language], *kinship, second.Sex)
+	}
+}
@@ -0,0 +1,313 @@
+package familytree
+
+import (
+	"fmt"
+	"strings"
+)
+
+// kinshipTerms names kinships on a language. Generations are counted as on
+// Kinship and sex is the sex of the person being named.
+type kinshipTerms interface {
+	blood(firstGenerations int, secondGenerations int, half bool, sex Sex) string
+	spouse(sex Sex, former bool) string
+	// spouseRelative names a blood relative of the spouse, generations are
+	// counted from the spouse
+	spouseRelative(spouseSex Sex, firstGenerations int, secondGenerations int, half bool, sex Sex) string
+	// relativeSpouse names the spouse of a blood relative, generations are
+	// counted to the relative
+	relativeSpouse(relativeSex Sex, firstGenerations int, secondGenerations int, half bool, sex Sex) string
+}
+
+var kinshipLanguages = map[Language]kinshipTerms{
+	LanguageEnglish:    englishKinship{},
+	LanguagePortuguese: portugueseKinship{},
+}
+
+func kinshipName(terms kinshipTerms, kinship Kinship, sex Sex) string {
+	switch kinship.Type {
+	case KinshipSpouse:
+		return terms.spouse(sex, kinship.Former)
+	case KinshipInLaw:
+		if kinship.InLaw.Side == InLawSpouseRelative {
+			return terms.spouseRelative(kinship.InLaw.Link.Sex, kinship.FirstGenerations, kinship.SecondGenerations, kinship.Half, sex)
+		}
+		return terms.relativeSpouse(kinship.InLaw.Link.Sex, kinship.FirstGenerations, kinship.SecondGenerations, kinship.Half, sex)
+	default:
+		return terms.blood(kinship.FirstGenerations, kinship.SecondGenerations, kinship.Half, sex)
+	}
+}
+
+func gendered(sex Sex, male string, female string, neutral string) string {
+	switch sex {
+	case SexMale:
+		return male
+	case SexFemale:
+		return female
+	default:
+		return neutral
+	}
+}
+
+type englishKinship struct{}
+
+var englishOrdinals = []string{"first", "second", "third", "fourth", "fifth", "sixth", "seventh", "eighth", "ninth", "tenth"}
+
+func englishOrdinalNumber(number int) string {
+	suffix := "th"
+	if number%100 < 11 || number%100 > 13 {
+		switch number % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return fmt.Sprintf("%d%s", number, suffix)
+}
+
+func englishOrdinal(number int) string {
+	if number <= len(englishOrdinals) {
+		return englishOrdinals[number-1]
+	}
+	return englishOrdinalNumber(number)
+}
+
+// englishGreats writes "great-" once for each generation up to two, like
+// "great-great-", and then counts them, like "3rd great-".
+func englishGreats(greats int) string {
+	if greats <= 2 {
+		return strings.Repeat("great-", greats)
+	}
+	return englishOrdinalNumber(greats) + " great-"
+}
+
+func englishRemoved(removed int) string {
+	switch removed {
+	case 0:
+		return ""
+	case 1:
+		return " once removed"
+	case 2:
+		return " twice removed"
+	case 3:
+		return " thrice removed"
+	default:
+		return fmt.Sprintf(" %d times removed", removed)
+	}
+}
+
+func (englishKinship) blood(firstGenerations int, secondGenerations int, half bool, sex Sex) string {
+	halfPrefix := ""
+	if half {
+		halfPrefix = "half-"
+	}
+	switch bloodKinshipType(firstGenerations, secondGenerations) {
+	case KinshipSelf:
+		return "self"
+	case KinshipAncestor:
+		if firstGenerations == 1 {
+			return gendered(sex, "father", "mother", "parent")
+		}
+		return englishGreats(firstGenerations-2) + gendered(sex, "grandfather", "grandmother", "grandparent")
+	case KinshipDescendant:
+		if secondGenerations == 1 {
+			return gendered(sex, "son", "daughter", "child")
+		}
+		return englishGreats(secondGenerations-2) + gendered(sex, "grandson", "granddaughter", "grandchild")
+	case KinshipSibling:
+		return halfPrefix + gendered(sex, "brother", "sister", "sibling")
+	case KinshipUncle:
+		uncle := gendered(sex, "uncle", "aunt", "uncle or aunt")
+		if firstGenerations == 2 {
+			return halfPrefix + uncle
+		}
+		return halfPrefix + englishGreats(firstGenerations-3) + "grand-" + uncle
+	case KinshipNephew:
+		nephew := gendered(sex, "nephew", "niece", "nephew or niece")
+		if secondGenerations == 2 {
+			return halfPrefix + nephew
+		}
+		return halfPrefix + englishGreats(secondGenerations-3) + "grand-" + nephew
+	default:
+		degree, removed := cousinDegree(firstGenerations, secondGenerations)
+		cousin := englishOrdinal(degree) + " cousin" + englishRemoved(removed)
+		if half {
+			return "half " + cousin
+		}
+		return cousin
+	}
+}
+
+func (englishKinship) spouse(sex Sex, former bool) string {
+	if former {
+		return gendered(sex, "ex-husband", "ex-wife", "former spouse")
+	}
+	return gendered(sex, "husband", "wife", "spouse")
+}
+
+func (terms englishKinship) spouseRelative(spouseSex Sex, firstGenerations int, secondGenerations int, half bool, sex Sex) string {
+	switch {
+	case firstGenerations == 1 && secondGenerations == 0:
+		return gendered(sex, "father-in-law", "mother-in-law", "parent-in-law")
+	case firstGenerations == 1 && secondGenerations == 1:
+		return gendered(sex, "brother-in-law", "sister-in-law", "sibling-in-law")
+	case firstGenerations == 0 && secondGenerations == 1:
+		return gendered(sex, "stepson", "stepdaughter", "stepchild")
+	default:
+		return gendered(spouseSex, "husband's ", "wife's ", "spouse's ") + terms.blood(firstGenerations, secondGenerations, half, sex)
+	}
+}
+
+func (terms englishKinship) relativeSpouse(relativeSex Sex, firstGenerations int, secondGenerations int, half bool, sex Sex) string {
+	switch {
+	case firstGenerations == 1 && secondGenerations == 0:
+		return gendered(sex, "stepfather", "stepmother", "step-parent")
+	case firstGenerations == 1 && secondGenerations == 1:
+		return gendered(sex, "brother-in-law", "sister-in-law", "sibling-in-law")
+	case firstGenerations == 0 && secondGenerations == 1:
+		return gendered(sex, "son-in-law", "daughter-in-law", "child-in-law")
+	default:
+		return terms.blood(firstGenerations, secondGenerations, half, relativeSex) + "'s " + terms.spouse(sex, false)
+	}
+}
+
+type portugueseKinship struct{}
+
+var (
+	portugueseOrdinals    = []string{"primeiro", "segundo", "terceiro", "quarto", "quinto", "sexto", "sétimo", "oitavo", "nono", "décimo"}
+	portugueseCounts      = []string{"uma", "duas", "três", "quatro", "cinco", "seis", "sete", "oito", "nove", "dez"}
+	portugueseAncestors   = []string{"avô", "bisavô", "trisavô", "tetravô", "pentavô", "hexavô"}
+	portugueseDescendants = []string{"neto", "bisneto", "trineto", "tetraneto", "pentaneto", "hexaneto"}
+)
+
+func portugueseOrdinal(number int) string {
+	if number <= len(portugueseOrdinals) {
+		return portugueseOrdinals[number-1]
+	}
+	return fmt.Sprintf("%dº", number)
+}
+
+// portugueseAncestor names the ancestor generations above the grandparents,
+// starting at 0 for avô.
+func portugueseAncestor(generation int, sex Sex) string {
+	if generation >= len(portugueseAncestors) {
+		return fmt.Sprintf("ascendente de %dª geração", generation+2)
+	}
+	male := portugueseAncestors[generation]
+	stem := strings.TrimSuffix(male, "ô")
+	return gendered(sex, male, stem+"ó", male+"(ó)")
+}
+
+// portugueseDescendant names the descendant generations below the children,
+// starting at 0 for neto.
+func portugueseDescendant(generation int, sex Sex) string {
+	if generation >= len(portugueseDescendants) {
+		return fmt.Sprintf("descendente de %dª geração", generation+2)
+	}
+	male := portugueseDescendants[generation]
+	return gendered(sex, male, strings.TrimSuffix(male, "o")+"a", male+"(a)")
+}
+
+func portugueseRemoved(firstGenerations int, secondGenerations int) string {
+	removed := firstGenerations - secondGenerations
+	direction := "acima"
+	if removed < 0 {
+		removed, direction = -removed, "abaixo"
+	}
+	if removed == 0 {
+		return ""
+	}
+	count := fmt.Sprint(removed)
+	if removed <= len(portugueseCounts) {
+		count = portugueseCounts[removed-1]
+	}
+	generations := "gerações"
+	if removed == 1 {
+		generations = "geração"
+	}
+	return fmt.Sprintf(", %s %s %s", count, generations, direction)
+}
+
+func (portugueseKinship) blood(firstGenerations int, secondGenerations int, half bool, sex Sex) string {
+	halfPrefix := ""
+	if half {
+		halfPrefix = gendered(sex, "meio-", "meia-", "meio(a)-")
+	}
+	switch bloodKinshipType(firstGenerations, secondGenerations) {
+	case KinshipSelf:
+		return "a própria pessoa"
+	case KinshipAncestor:
+		if firstGenerations == 1 {
+			return gendered(sex, "pai", "mãe", "pai ou mãe")
+		}
+		return portugueseAncestor(firstGenerations-2, sex)
+	case KinshipDescendant:
+		if secondGenerations == 1 {
+			return gendered(sex, "filho", "filha", "filho(a)")
+		}
+		return portugueseDescendant(secondGenerations-2, sex)
+	case KinshipSibling:
+		return halfPrefix + gendered(sex, "irmão", "irmã", "irmão(ã)")
+	case KinshipUncle:
+		uncle := gendered(sex, "tio", "tia", "tio(a)")
+		if firstGenerations == 2 {
+			return halfPrefix + uncle
+		}
+		return halfPrefix + uncle + "-" + portugueseAncestor(firstGenerations-3, sex)
+	case KinshipNephew:
+		nephew := gendered(sex, "sobrinho", "sobrinha", "sobrinho(a)")
+		if secondGenerations == 2 {
+			return halfPrefix + nephew
+		}
+		return halfPrefix + nephew + "-" + portugueseDescendant(secondGenerations-3, sex)
+	default:
+		degree, _ := cousinDegree(firstGenerations, secondGenerations)
+		cousin := gendered(sex, "primo", "prima", "primo(a)")
+		return fmt.Sprintf("%s%s de %s grau%s", halfPrefix, cousin, portugueseOrdinal(degree), portugueseRemoved(firstGenerations, secondGenerations))
+	}
+}
+
+func (portugueseKinship) spouse(sex Sex, former bool) string {
+	if former {
+		return gendered(sex, "ex-marido", "ex-esposa", "ex-cônjuge")
+	}
+	return gendered(sex, "marido", "esposa", "cônjuge")
+}
+
+func (terms portugueseKinship) spouseRelative(spouseSex Sex, firstGenerations int, secondGenerations int, half bool, sex Sex) string {
+	switch {
+	case firstGenerations == 1 && secondGenerations == 0:
+		return gendered(sex, "sogro", "sogra", "sogro(a)")
+	case firstGenerations == 1 && secondGenerations == 1:
+		return gendered(sex, "cunhado", "cunhada", "cunhado(a)")
+	case firstGenerations == 0 && secondGenerations == 1:
+		return gendered(sex, "enteado", "enteada", "enteado(a)")
+	default:
+		return terms.blood(firstGenerations, secondGenerations, half, sex) + gendered(spouseSex, " do marido", " da esposa", " do cônjuge")
+	}
+}
+
+func (terms portugueseKinship) relativeSpouse(relativeSex Sex, firstGenerations int, secondGenerations int, half bool, sex Sex) string {
+	switch {
+	case firstGenerations == 1 && secondGenerations == 0:
+		return gendered(sex, "padrasto", "madrasta", "padrasto ou madrasta")
+	case firstGenerations == 1 && secondGenerations == 1:
+		return gendered(sex, "cunhado", "cunhada", "cunhado(a)")
+	case firstGenerations == 0 && secondGenerations == 1:
+		return gendered(sex, "genro", "nora", "genro ou nora")
+	default:
+		return terms.spouse(sex, false) + gendered(relativeSex, " do ", " da ", " de ") + terms.blood(firstGenerations, secondGenerations, half, relativeSex)
+	}
+}
+
+// cousinDegree returns the cousin degree, 1 for first cousins, and how many
+// generations the cousins are removed.
+func cousinDegree(firstGenerations int, secondGenerations int) (int, int) {
+	degree, removed := firstGenerations, secondGenerations-firstGenerations
+	if secondGenerations < firstGenerations {
+		degree, removed = secondGenerations, firstGenerations-secondGenerations
+	}
+	return degree - 1, removed
+}
@@ -0,0 +1,151 @@
+package familytree
+
+import (
+	"testing"
+)
+
+func TestKinshipNames(t *testing.T) {
+	male, female := Person{Sex: SexMale}, Person{Sex: SexFemale}
+	tests := []struct {
+		name       string
+		kinship    Kinship
+		sex        Sex
+		english    string
+		portuguese string
+	}{
+		{name: "self", kinship: Kinship{Type: KinshipSelf}, sex: SexMale, english: "self", portuguese: "a própria pessoa"},
+		{name: "father", kinship: Kinship{Type: KinshipAncestor, FirstGenerations: 1}, sex: SexMale, english: "father", portuguese: "pai"},
+		{name: "parent", kinship: Kinship{Type: KinshipAncestor, FirstGenerations: 1}, sex: SexUnknown, english: "parent", portuguese: "pai ou mãe"},
+		{name: "great-grandmother", kinship: Kinship{Type: KinshipAncestor, FirstGenerations: 3}, sex: SexFemale, english: "great-grandmother", portuguese: "bisavó"},
+		{name: "3rd great-grandmother", kinship: Kinship{Type: KinshipAncestor, FirstGenerations: 5}, sex: SexFemale, english: "3rd great-grandmother", portuguese: "tetravó"},
+		{name: "far ancestor", kinship: Kinship{Type: KinshipAncestor, FirstGenerations: 9}, sex: SexMale, english: "7th great-grandfather", portuguese: "ascendente de 9ª geração"},
+		{name: "daughter", kinship: Kinship{Type: KinshipDescendant, SecondGenerations: 1}, sex: SexFemale, english: "daughter", portuguese: "filha"},
+		{name: "grandchild", kinship: Kinship{Type: KinshipDescendant, SecondGenerations: 2}, sex: SexUnknown, english: "grandchild", portuguese: "neto(a)"},
+		{name: "great-great-grandson", kinship: Kinship{Type: KinshipDescendant, SecondGenerations: 4}, sex: SexMale, english: "great-great-grandson", portuguese: "trineto"},
+		{name: "brother", kinship: Kinship{Type: KinshipSibling, FirstGenerations: 1, SecondGenerations: 1}, sex: SexMale, english: "brother", portuguese: "irmão"},
+		{name: "half-sister", kinship: Kinship{Type: KinshipSibling, FirstGenerations: 1, SecondGenerations: 1, Half: true}, sex: SexFemale, english: "half-sister", portuguese: "meia-irmã"},
+		{name: "aunt", kinship: Kinship{Type: KinshipUncle, FirstGenerations: 2, SecondGenerations: 1}, sex: SexFemale, english: "aunt", portuguese: "tia"},
+		{name: "grand-uncle", kinship: Kinship{Type: KinshipUncle, FirstGenerations: 3, SecondGenerations: 1}, sex: SexMale, english: "grand-uncle", portuguese: "tio-avô"},
+		{name: "half-niece", kinship: Kinship{Type: KinshipNephew, FirstGenerations: 1, SecondGenerations: 2, Half: true}, sex: SexFemale, english: "half-niece", portuguese: "meia-sobrinha"},
+		{name: "great-grand-nephew", kinship: Kinship{Type: KinshipNephew, FirstGenerations: 1, SecondGenerations: 4}, sex: SexMale, english: "great-grand-nephew", portuguese: "sobrinho-bisneto"},
+		{name: "first cousin", kinship: Kinship{Type: KinshipCousin, FirstGenerations: 2, SecondGenerations: 2}, sex: SexMale, english: "first cousin", portuguese: "primo de primeiro grau"},
+		{name: "half first cousin once removed", kinship: Kinship{Type: KinshipCousin, FirstGenerations: 2, SecondGenerations: 3, Half: true}, sex: SexFemale, english: "half first cousin once removed", portuguese: "meia-prima de primeiro grau, uma geração abaixo"},
+		{name: "first cousin twice removed", kinship: Kinship{Type: KinshipCousin, FirstGenerations: 4, SecondGenerations: 2}, sex: SexUnknown, english: "first cousin twice removed", portuguese: "primo(a) de primeiro grau, duas gerações acima"},
+		{name: "third cousin", kinship: Kinship{Type: KinshipCousin, FirstGenerations: 4, SecondGenerations: 4}, sex: SexMale, english: "third cousin", portuguese: "primo de terceiro grau"},
+		{name: "wife", kinship: Kinship{Type: KinshipSpouse}, sex: SexFemale, english: "wife", portuguese: "esposa"},
+		{name: "ex-husband", kinship: Kinship{Type: KinshipSpouse, Former: true}, sex: SexMale, english: "ex-husband", portuguese: "ex-marido"},
+		{name: "father-in-law", kinship: Kinship{Type: KinshipInLaw, FirstGenerations: 1, InLaw: &InLaw{Side: InLawSpouseRelative, Link: female}}, sex: SexMale, english: "father-in-law", portuguese: "sogro"},
+		{name: "stepdaughter", kinship: Kinship{Type: KinshipInLaw, SecondGenerations: 1, InLaw: &InLaw{Side: InLawSpouseRelative, Link: male}}, sex: SexFemale, english: "stepdaughter", portuguese: "enteada"},
+		{name: "wife's grandmother", kinship: Kinship{Type: KinshipInLaw, FirstGenerations: 2, InLaw: &InLaw{Side: InLawSpouseRelative, Link: female}}, sex: SexFemale, english: "wife's grandmother", portuguese: "avó da esposa"},
+		{name: "stepmother", kinship: Kinship{Type: KinshipInLaw, FirstGenerations: 1, InLaw: &InLaw{Side: InLawRelativeSpouse, Link: male}}, sex: SexFemale, english: "stepmother", portuguese: "madrasta"},
+		{name: "son-in-law", kinship: Kinship{Type: KinshipInLaw, SecondGenerations: 1, InLaw: &InLaw{Side: InLawRelativeSpouse, Link: female}}, sex: SexMale, english: "son-in-law", portuguese: "genro"},
+		{name: "sister-in-law", kinship: Kinship{Type: KinshipInLaw, FirstGenerations: 1, SecondGenerations: 1, InLaw: &InLaw{Side: InLawRelativeSpouse, Link: male}}, sex: SexFemale, english: "sister-in-law", portuguese: "cunhada"},
+		{name: "first cousin's wife", kinship: Kinship{Type: KinshipInLaw, FirstGenerations: 2, SecondGenerations: 2, InLaw: &InLaw{Side: InLawRelativeSpouse, Link: male}}, sex: SexFemale, english: "first cousin's wife", portuguese: "esposa do primo de primeiro grau"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			kinship := test.kinship
+			kinship.setNames(Person{Sex: test.sex})
+			if kinship.Names[LanguageEnglish] != test.english {
+				t.Errorf("the English name is %q, expected %q", kinship.Names[LanguageEnglish], test.english)
+			}
+			if kinship.Names[LanguagePortuguese] != test.portuguese {
+				t.Errorf("the Portuguese name is %q, expected %q", kinship.Names[LanguagePortuguese], test.portuguese)
+			}
+		})
+	}
+}
+
+func TestEnglishOrdinalNumber(t *testing.T) {
+	tests := []struct {
+		number   int
+		expected string
+	}{
+		{number: 1, expected: "1st"},
+		{number: 2, expected: "2nd"},
+		{number: 3, expected: "3rd"},
+		{number: 4, expected: "4th"},
+		{number: 11, expected: "11th"},
+		{number: 12, expected: "12th"},
+		{number: 13, expected: "13th"},
+		{number: 21, expected: "21st"},
+		{number: 112, expected: "112th"},
+		{number: 122, expected: "122nd"},
+	}
+	for _, test := range tests {
+		t.Run(test.expected, func(t *testing.T) {
+			if ordinal := englishOrdinalNumber(test.number); ordinal != test.expected {
+				t.Errorf("englishOrdinalNumber(%d) returned %q, expected %q", test.number, ordinal, test.expected)
+			}
+		})
+	}
+}
+
+func TestClosestKinship(t *testing.T) {
+	grandpa, grandma, father, mother := Person{Name: "Grandpa"}, Person{Name: "Grandma"}, Person{Name: "Father"}, Person{Name: "Mother"}
+	tests := []struct {
+		name              string
+		ancestors         []CommonAncestor
+		kinshipType       KinshipType
+		firstGenerations  int
+		secondGenerations int
+		half              bool
+		commonAncestors   int
+	}{
+		{
+			name:              "self",
+			ancestors:         []CommonAncestor{{Ancestor: father}},
+			kinshipType:       KinshipSelf,
+			firstGenerations:  0,
+			secondGenerations: 0,
+			commonAncestors:   1,
+		},
+		{
+			name:              "full siblings",
+			ancestors:         []CommonAncestor{{Ancestor: grandpa, FirstDistance: 2, SecondDistance: 2}, {Ancestor: father, FirstDistance: 1, SecondDistance: 1}, {Ancestor: mother, FirstDistance: 1, SecondDistance: 1}},
+			kinshipType:       KinshipSibling,
+			firstGenerations:  1,
+			secondGenerations: 1,
+			commonAncestors:   2,
+		},
+		{
+			name:              "half siblings",
+			ancestors:         []CommonAncestor{{Ancestor: mother, FirstDistance: 1, SecondDistance: 1}},
+			kinshipType:       KinshipSibling,
+			firstGenerations:  1,
+			secondGenerations: 1,
+			half:              true,
+			commonAncestors:   1,
+		},
+		{
+			name:              "grandparent through both grandparents",
+			ancestors:         []CommonAncestor{{Ancestor: grandma, FirstDistance: 2}, {Ancestor: grandpa, FirstDistance: 3, SecondDistance: 1}},
+			kinshipType:       KinshipAncestor,
+			firstGenerations:  2,
+			secondGenerations: 0,
+			commonAncestors:   1,
+		},
+		{
+			name:              "first cousins",
+			ancestors:         []CommonAncestor{{Ancestor: grandpa, FirstDistance: 2, SecondDistance: 2}, {Ancestor: grandma, FirstDistance: 2, SecondDistance: 2}},
+			kinshipType:       KinshipCousin,
+			firstGenerations:  2,
+			secondGenerations: 2,
+			commonAncestors:   2,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			kinship := closestKinship(test.ancestors)
+			if kinship == nil {
+				t.Fatalf("closestKinship returned nil")
+			}
+			if kinship.Type != test.kinshipType || kinship.FirstGenerations != test.firstGenerations || kinship.SecondGenerations != test.secondGenerations || kinship.Half != test.half || len(kinship.CommonAncestors) != test.commonAncestors {
+				t.Errorf("closestKinship returned %+v, expected %s %d/%d half %t with %d common ancestors", kinship, test.kinshipType, test.firstGenerations, test.secondGenerations, test.half, test.commonAncestors)
+			}
+		})
+	}
+	if kinship := closestKinship(nil); kinship != nil {
+		t.Errorf("closestKinship without common ancestors returned %+v, expected nil", kinship)
+	}
+}
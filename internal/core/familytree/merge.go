@@ -0,0 +1,64 @@
+package familytree
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MergeConflict is a relation of the duplicate that can't be moved to the
+// survivor, Err is the error of the validation that refused it.
+type MergeConflict struct {
+	RelationType RelationType
+	Person       Person
+	Err          error
+}
+
+// MergeConflictError lists every conflict of a refused merge, it unwraps to
+// ErrMergeConflict.
+type MergeConflictError struct {
+	Conflicts []MergeConflict
+}
+
+func (mergeErr *MergeConflictError) Error() string {
+	reasons := make([]string, 0, len(mergeErr.Conflicts))
+	for _, conflict := range mergeErr.Conflicts {
+		reasons = append(reasons, fmt.Sprintf("%s %s: %v", conflict.RelationType, conflict.Person.ID, conflict.Err))
+	}
+	return fmt.Sprintf("%v: %s", ErrMergeConflict, strings.Join(reasons, "; "))
+}
+
+func (mergeErr *MergeConflictError) Unwrap() error {
+	return ErrMergeConflict
+}
+
+// MergeResult is the survivor after the merge with the warnings of the
+// relation rules on the moved relations. Moved counts the relations moved to
+// the survivor, the ones the survivor already had are dropped.
+type MergeResult struct {
+	Person   Person
+	Warnings []RuleViolation
+	Moved    int
+}
+
+// mergeAttributes fills the empty attributes of the survivor with the ones of
+// the duplicate, the attributes of the survivor are kept otherwise.
+func mergeAttributes(survivor *Person, duplicate Person) {
+	fill := func(attribute *string, value string) {
+		if *attribute == "" {
+			*attribute = value
+		}
+	}
+	fill(&survivor.GivenName, duplicate.GivenName)
+	fill(&survivor.Surname, duplicate.Surname)
+	fill(&survivor.BirthPlace, duplicate.BirthPlace)
+	fill(&survivor.DeathPlace, duplicate.DeathPlace)
+	if survivor.Sex == SexUnknown {
+		survivor.Sex = duplicate.Sex
+	}
+	if survivor.BirthDate.IsZero() {
+		survivor.BirthDate = duplicate.BirthDate
+	}
+	if survivor.DeathDate.IsZero() {
+		survivor.DeathDate = duplicate.DeathDate
+	}
+}
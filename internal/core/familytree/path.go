@@ -0,0 +1,49 @@
+package familytree
+
+const (
+	PathDefaultMaxLength = 10
+	PathMaxLength        = 20
+	PathDefaultCount     = 1
+	PathMaxCount         = 10
+)
+
+// PathEdge is what a person of a path is of the next one.
+type PathEdge string
+
+const (
+	PathEdgeParentOf = PathEdge("PARENT_OF")
+	PathEdgeChildOf  = PathEdge("CHILD_OF")
+	PathEdgeSpouseOf = PathEdge("SPOUSE_OF")
+)
+
+// PathFilter restricts the relations a path may follow. BloodOnly follows only
+// biological PARENT relations, ExcludeSpouses drops the SPOUSE relations but
+// keeps every parentage. Count is how many of the shortest paths are wanted.
+type PathFilter struct {
+	BloodOnly      bool
+	ExcludeSpouses bool
+	MaxLength      int
+	Count          int
+}
+
+// FollowsSpouses tells if the path may go through SPOUSE relations.
+func (filter PathFilter) FollowsSpouses() bool {
+	return !filter.BloodOnly && !filter.ExcludeSpouses
+}
+
+// Follows tells if the path may go through a PARENT relation of the
+// parentage.
+func (filter PathFilter) Follows(parentage Parentage) bool {
+	return !filter.BloodOnly || parentage.IsBiological()
+}
+
+// Path links two people without repeating anyone, People[i] is Edges[i] of
+// People[i+1].
+type Path struct {
+	People []Person
+	Edges  []PathEdge
+}
+
+func (path Path) Length() int {
+	return len(path.Edges)
+}
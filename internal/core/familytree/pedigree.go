@@ -0,0 +1,98 @@
+package familytree
+
+import (
+	"github.com/google/uuid"
+)
+
+const (
+	PedigreeDefaultGenerations = 5
+	PedigreeMaxGenerations     = 10
+)
+
+// ChildParent is a PARENT relation seen from the child.
+type ChildParent struct {
+	ChildID uuid.UUID
+	Parent  Person
+}
+
+// Ancestor is an entry of a pedigree. Number is the Ahnentafel number, 1 for
+// the root person, 2n for the father and 2n+1 for the mother of the entry n.
+// On pedigree collapse the same person takes several numbers, every one of
+// them is Collapsed and all but the lowest point to it on SameAs.
+type Ancestor struct {
+	Number     int
+	Generation int
+	Person     Person
+	SameAs     int
+	Collapsed  bool
+}
+
+type Pedigree struct {
+	Root        Person
+	Generations int
+	Ancestors   []Ancestor
+}
+
+// orderParents places the parents on the Ahnentafel slots, father first and
+// mother second. Parents of unknown or equal sex keep a stable order by ID.
+func orderParents(parents []Person) []Person {
+	if len(parents) != 2 {
+		return parents
+	}
+	first, second := parents[0], parents[1]
+	switch {
+	case first.Sex == second.Sex:
+		if second.ID.String() < first.ID.String() {
+			first, second = second, first
+		}
+	case second.Sex == SexMale, first.Sex == SexFemale:
+		first, second = second, first
+	}
+	return []Person{first, second}
+}
+
+// numberAncestors lists the root and its ancestors, up to the generations, on
+// Ahnentafel order.
+func numberAncestors(root Person, generations int, parents []ChildParent) *Pedigree {
+	parentsByChild := map[uuid.UUID][]Person{}
+	for _, parent := range parents {
+		parentsByChild[parent.ChildID] = append(parentsByChild[parent.ChildID], parent.Parent)
+	}
+
+	pedigree := &Pedigree{
+		Root:        root,
+		Generations: generations,
+		Ancestors:   []Ancestor{{Number: 1, Person: root}},
+	}
+	numbers := map[uuid.UUID][]int{root.ID: {1}}
+	// Entries are appended generation by generation, so they stay sorted
+	for i := 0; i < len(pedigree.Ancestors); i++ {
+		child := pedigree.Ancestors[i]
+		if child.Generation == generations {
+			continue
+		}
+		for j, parent := range orderParents(parentsByChild[child.Person.ID]) {
+			number := child.Number * 2
+			if j == 1 || (len(parentsByChild[child.Person.ID]) == 1 && parent.Sex == SexFemale) {
+				number++
+			}
+			numbers[parent.ID] = append(numbers[parent.ID], number)
+			pedigree.Ancestors = append(pedigree.Ancestors, Ancestor{
+				Number:     number,
+				Generation: child.Generation + 1,
+				Person:     parent,
+			})
+		}
+	}
+	for i, ancestor := range pedigree.Ancestors {
+		personNumbers := numbers[ancestor.Person.ID]
+		if len(personNumbers) == 1 {
+			continue
+		}
+		pedigree.Ancestors[i].Collapsed = true
+		if personNumbers[0] != ancestor.Number {
+			pedigree.Ancestors[i].SameAs = personNumbers[0]
+		}
+	}
+	return pedigree
+}
@@ -0,0 +1,177 @@
+package familytree
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidPeopleSort = errors.New("invalid people sort")
+
+// dayLayout writes days so that comparing them as strings compares the days.
+const dayLayout = "2006-01-02"
+
+type PeopleSortField string
+
+const (
+	PeopleSortName      = PeopleSortField("name")
+	PeopleSortBirthDate = PeopleSortField("birthDate")
+	PeopleSortCreatedAt = PeopleSortField("createdAt")
+)
+
+// PeopleSort orders people lists. Ties are broken by name and then by ID, so
+// pages stay the same between calls, and people of unknown birth come last on
+// both directions of birthDate.
+type PeopleSort struct {
+	Field      PeopleSortField
+	Descending bool
+}
+
+// PeopleSortKey is where a person stands on a PeopleSort. Text is the name
+// on name sorts and the birth sort key, empty when unknown, on birthDate
+// sorts. Number is the creation order on createdAt sorts.
+type PeopleSortKey struct {
+	Text   string
+	Number int64
+	Name   string
+	ID     uuid.UUID
+}
+
+// Compare returns a negative number when the first key comes before the
+// second one on the sort, a positive one when it comes after them and 0 for
+// the same person.
+func (peopleSort PeopleSort) Compare(first PeopleSortKey, second PeopleSortKey) int {
+	direction := 1
+	if peopleSort.Descending {
+		direction = -1
+	}
+	switch peopleSort.Field {
+	case PeopleSortCreatedAt:
+		if first.Number != second.Number {
+			if first.Number < second.Number {
+				return -direction
+			}
+			return direction
+		}
+	case PeopleSortBirthDate:
+		if (first.Text == "") != (second.Text == "") {
+			if second.Text == "" {
+				return -1
+			}
+			return 1
+		}
+		if first.Text != second.Text {
+			return strings.Compare(first.Text, second.Text) * direction
+		}
+	default:
+		if first.Text != second.Text {
+			return strings.Compare(first.Text, second.Text) * direction
+		}
+	}
+	if first.Name != second.Name {
+		return strings.Compare(first.Name, second.Name)
+	}
+	return strings.Compare(first.ID.String(), second.ID.String())
+}
+
+// ParsePeopleSort reads a field optionally prefixed by - for the descending
+// order, like -createdAt. An empty value sorts by creation.
+func ParsePeopleSort(value string) (PeopleSort, error) {
+	if value == "" {
+		return PeopleSort{Field: PeopleSortCreatedAt}, nil
+	}
+	peopleSort := PeopleSort{
+		Field:      PeopleSortField(strings.TrimPrefix(value, "-")),
+		Descending: strings.HasPrefix(value, "-"),
+	}
+	switch peopleSort.Field {
+	case PeopleSortName, PeopleSortBirthDate, PeopleSortCreatedAt:
+		return peopleSort, nil
+	default:
+		return PeopleSort{}, fmt.Errorf("%w: %q", ErrInvalidPeopleSort, value)
+	}
+}
+
+// PeopleFilter empty fields don't filter. IsLiving matches people without a
+// death date. BornAfter and BornBefore only match people certainly born after
+// or before the date, a birth known as ABT 1850 isn't after 1850. Surname is
+// compared ignoring case and accents.
+type PeopleFilter struct {
+	HasParents *bool
+	IsLiving   *bool
+	BornAfter  Date
+	BornBefore Date
+	Surname    string
+}
+
+func (filter *PeopleFilter) Normalize() error {
+	var err error
+	if filter.BornAfter, err = filter.BornAfter.Normalize(); err != nil {
+		return err
+	}
+	if filter.BornBefore, err = filter.BornBefore.Normalize(); err != nil {
+		return err
+	}
+	filter.Surname = NormalizeName(filter.Surname)
+	return nil
+}
+
+// BirthRange returns the earliest and latest days of the birth formatted as
+// dates, empty when that side is open or the birth is unknown.
+func BirthRange(birthDate Date) (string, string) {
+	from, to := birthDate.Bounds()
+	return formatDay(from), formatDay(to)
+}
+
+// BirthSortKey is the day people are sorted by on birthDate, the earliest day
+// of the birth or the latest one when it's only known as BEF.
+func BirthSortKey(birthDate Date) string {
+	from, to := BirthRange(birthDate)
+	if from == "" {
+		return to
+	}
+	return from
+}
+
+func formatDay(day time.Time) string {
+	if day.IsZero() {
+		return ""
+	}
+	return day.Format(dayLayout)
+}
+
+// BornAfterDay is the day births must start after, empty when not filtered.
+func (filter PeopleFilter) BornAfterDay() string {
+	_, last := filter.BornAfter.Bounds()
+	return formatDay(last)
+}
+
+// BornBeforeDay is the day births must end before, empty when not filtered.
+func (filter PeopleFilter) BornBeforeDay() string {
+	first, _ := filter.BornBefore.Bounds()
+	return formatDay(first)
+}
+
+// Matches tells if the person passes the filter, the repos know if the person
+// has parents.
+func (filter PeopleFilter) Matches(person Person, hasParents bool) bool {
+	if filter.HasParents != nil && *filter.HasParents != hasParents {
+		return false
+	}
+	if filter.IsLiving != nil && *filter.IsLiving != person.DeathDate.IsZero() {
+		return false
+	}
+	if !filter.BornAfter.IsZero() || !filter.BornBefore.IsZero() {
+		from, to := BirthRange(person.BirthDate)
+		if after := filter.BornAfterDay(); after != "" && (from == "" || from <= after) {
+			return false
+		}
+		if before := filter.BornBeforeDay(); before != "" && (to == "" || to >= before) {
+			return false
+		}
+	}
+	return filter.Surname == "" || NormalizeName(person.Surname) == filter.Surname
+}
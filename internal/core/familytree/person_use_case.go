@@ -3,45 +3,106 @@ package familytree
 import (
 	"context"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+// PersonUseCase records an audit event of each mutation on the auditSink,
+// nothing is recorded when it's nil.
 type PersonUseCase struct {
 	familyTreeRepo FamilyTreeRepo
+	auditSink      AuditSink
 }
 
-func NewPersonUseCase(familyTreeRepo FamilyTreeRepo) *PersonUseCase {
+func NewPersonUseCase(familyTreeRepo FamilyTreeRepo, auditSink AuditSink) *PersonUseCase {
 
 	return &PersonUseCase{
 		familyTreeRepo: familyTreeRepo,
+		auditSink:      auditSink,
 	}
 }
 
-func (useCase *PersonUseCase) openSession(ctx context.Context, sessionMode SessionMode) (context.Context, error) {
-	session, err := useCase.familyTreeRepo.OpenSession(ctx, sessionMode)
-	if err != nil {
-		return ctx, err
+func (useCase *PersonUseCase) CreatePerson(ctx context.Context, person *Person) error {
+	if person == nil {
+		return ErrCreateNilPerson
 	}
-	return context.WithValue(ctx, SessionKey, session), nil
+	if err := useCase.normalizePerson(person); err != nil {
+		return err
+	}
+	return RunInTx(ctx, useCase.familyTreeRepo, SessionWrite, func(ctx context.Context, tx Tx) error {
+		return useCase.createPerson(ctx, tx, person)
+	})
 }
 
-func (useCase *PersonUseCase) CreatePerson(ctx context.Context, person *Person) error {
-	newCtx, err := useCase.openSession(ctx, SessionWrite)
-	if err != nil {
+func (useCase *PersonUseCase) createPerson(ctx context.Context, tx Tx, person *Person) error {
+	if err := useCase.familyTreeRepo.SavePerson(ctx, tx, person); err != nil {
 		return err
 	}
-	ctx = newCtx
-	defer useCase.familyTreeRepo.CloseSession(ctx)
-	if person == nil {
-		return ErrCreateNilPerson
-	}
+	return recordAudit(ctx, useCase.auditSink, tx, AuditOperationCreatePerson, AuditState{}, AuditState{People: []Person{*person}})
+}
+
+// normalizePerson trims the person text fields and writes the dates and sex on
+// their canonical form. A missing name is built from the given name and
+// surname.
+func (useCase *PersonUseCase) normalizePerson(person *Person) error {
+	person.GivenName = strings.TrimSpace(person.GivenName)
+	person.Surname = strings.TrimSpace(person.Surname)
+	person.BirthPlace = strings.TrimSpace(person.BirthPlace)
+	person.DeathPlace = strings.TrimSpace(person.DeathPlace)
 	trimmedName := strings.TrimSpace(person.Name)
+	if trimmedName == "" {
+		trimmedName = strings.TrimSpace(person.GivenName + " " + person.Surname)
+	}
 	if trimmedName == "" {
 		return ErrEmptyPersonName
 	}
 	person.Name = trimmedName
-	return useCase.familyTreeRepo.SavePerson(ctx, person)
+
+	sex, err := person.Sex.Normalize()
+	if err != nil {
+		return err
+	}
+	person.Sex = sex
+	if person.BirthDate, err = person.BirthDate.Normalize(); err != nil {
+		return err
+	}
+	if person.DeathDate, err = person.DeathDate.Normalize(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (useCase *PersonUseCase) UpdatePerson(ctx context.Context, personID uuid.UUID, update PersonUpdate) (*Person, error) {
+	return runInTx(ctx, useCase.familyTreeRepo, SessionWrite, func(ctx context.Context, tx Tx) (*Person, error) {
+		return useCase.updatePerson(ctx, tx, personID, update)
+	})
+}
+
+func (useCase *PersonUseCase) updatePerson(ctx context.Context, tx Tx, personID uuid.UUID, update PersonUpdate) (*Person, error) {
+	if err := useCase.familyTreeRepo.LockPeople(ctx, tx, personID); err != nil {
+		return nil, err
+	}
+	person, err := useCase.familyTreeRepo.GetPerson(ctx, tx, personID)
+	if err != nil {
+		return nil, err
+	}
+	if person == nil {
+		return nil, ErrPersonNotFound
+	}
+	before := *person
+	update.Apply(person)
+	if err := useCase.normalizePerson(person); err != nil {
+		return nil, err
+	}
+	if err := useCase.familyTreeRepo.UpdatePerson(ctx, tx, person); err != nil {
+		return nil, err
+	}
+	err = recordAudit(ctx, useCase.auditSink, tx, AuditOperationUpdatePerson, AuditState{People: []Person{before}}, AuditState{People: []Person{*person}})
+	if err != nil {
+		return nil, err
+	}
+	return person, nil
 }
 
 func (useCase *PersonUseCase) paginationValidate(pagination *PaginationDetails) {
@@ -56,37 +117,64 @@ func (useCase *PersonUseCase) paginationValidate(pagination *PaginationDetails)
 	}
 }
 
-func (useCase *PersonUseCase) GetPeople(ctx context.Context, pagination PaginationDetails) (*PeopleList, error) {
-	newCtx, err := useCase.openSession(ctx, SessionRead)
-	if err != nil {
+func (useCase *PersonUseCase) GetPeople(ctx context.Context, filter PeopleFilter, sort PeopleSort, pagination PaginationDetails) (*PeopleList, error) {
+	if err := filter.Normalize(); err != nil {
 		return nil, err
 	}
-	ctx = newCtx
-	defer useCase.familyTreeRepo.CloseSession(ctx)
 	useCase.paginationValidate(&pagination)
-	return useCase.familyTreeRepo.GetPeople(ctx, pagination)
-
+	return runInTx(ctx, useCase.familyTreeRepo, SessionRead, func(ctx context.Context, tx Tx) (*PeopleList, error) {
+		return useCase.familyTreeRepo.GetPeople(ctx, tx, filter, sort, pagination)
+	})
 }
 
-func (useCase *PersonUseCase) GetPerson(ctx context.Context, personID uuid.UUID) (*Person, error) {
-	newCtx, err := useCase.openSession(ctx, SessionRead)
-	if err != nil {
+func (useCase *PersonUseCase) SearchPeople(ctx context.Context, query string, filter PeopleFilter, pagination PaginationDetails) (*PeopleMatchList, error) {
+	if err := filter.Normalize(); err != nil {
 		return nil, err
 	}
-	ctx = newCtx
-	defer useCase.familyTreeRepo.CloseSession(ctx)
-	return useCase.familyTreeRepo.GetPerson(ctx, personID)
+	useCase.paginationValidate(&pagination)
+	return runInTx(ctx, useCase.familyTreeRepo, SessionRead, func(ctx context.Context, tx Tx) (*PeopleMatchList, error) {
+		return useCase.familyTreeRepo.SearchPeople(ctx, tx, query, filter, pagination)
+	})
+}
+
+// GetDuplicates ranks the pairs of people that are likely the same person,
+// only the candidates the repo blocks together are scored.
+func (useCase *PersonUseCase) GetDuplicates(ctx context.Context, minScore float64, pagination PaginationDetails) (*DuplicateList, error) {
+	if minScore <= 0 {
+		minScore = DuplicatesDefaultMinScore
+	}
+	useCase.paginationValidate(&pagination)
+	return runInTx(ctx, useCase.familyTreeRepo, SessionRead, func(ctx context.Context, tx Tx) (*DuplicateList, error) {
+		candidates, err := useCase.familyTreeRepo.GetDuplicateCandidates(ctx, tx)
+		if err != nil {
+			return nil, err
+		}
+		return RankDuplicates(candidates, minScore, pagination), nil
+	})
+}
+
+func (useCase *PersonUseCase) GetPerson(ctx context.Context, personID uuid.UUID) (*Person, error) {
+	return runInTx(ctx, useCase.familyTreeRepo, SessionRead, func(ctx context.Context, tx Tx) (*Person, error) {
+		return useCase.familyTreeRepo.GetPerson(ctx, tx, personID)
+	})
 }
 
 func (useCase *PersonUseCase) GetBaconsNumber(ctx context.Context, firstPersonID uuid.UUID, secondPersonID uuid.UUID) (int, bool, error) {
-	newCtx, err := useCase.openSession(ctx, SessionRead)
+	var baconsNumber int
+	var found bool
+	err := RunInTx(ctx, useCase.familyTreeRepo, SessionRead, func(ctx context.Context, tx Tx) error {
+		var err error
+		baconsNumber, found, err = useCase.getBaconsNumber(ctx, tx, firstPersonID, secondPersonID)
+		return err
+	})
 	if err != nil {
 		return 0, false, err
 	}
-	ctx = newCtx
-	defer useCase.familyTreeRepo.CloseSession(ctx)
+	return baconsNumber, found, nil
+}
 
-	firstPerson, err := useCase.familyTreeRepo.GetPerson(ctx, firstPersonID)
+func (useCase *PersonUseCase) getBaconsNumber(ctx context.Context, tx Tx, firstPersonID uuid.UUID, secondPersonID uuid.UUID) (int, bool, error) {
+	firstPerson, err := useCase.familyTreeRepo.GetPerson(ctx, tx, firstPersonID)
 	if err != nil {
 		return 0, false, err
 	}
@@ -94,7 +182,7 @@ func (useCase *PersonUseCase) GetBaconsNumber(ctx context.Context, firstPersonID
 		return 0, false, ErrPersonNotFound
 	}
 
-	secondPerson, err := useCase.familyTreeRepo.GetPerson(ctx, secondPersonID)
+	secondPerson, err := useCase.familyTreeRepo.GetPerson(ctx, tx, secondPersonID)
 	if err != nil {
 		return 0, false, err
 	}
@@ -105,24 +193,262 @@ func (useCase *PersonUseCase) GetBaconsNumber(ctx context.Context, firstPersonID
 		return 0, true, nil
 	}
 
-	return useCase.familyTreeRepo.GetShortestPathLength(ctx, *firstPerson, *secondPerson)
+	return useCase.familyTreeRepo.GetShortestPathLength(ctx, tx, *firstPerson, *secondPerson)
 }
 
-func (useCase *PersonUseCase) DeletePerson(ctx context.Context, personID uuid.UUID) error {
-	newCtx, err := useCase.openSession(ctx, SessionWrite)
-	if err != nil {
+func (useCase *PersonUseCase) pathFilterValidate(filter *PathFilter) {
+	if filter.MaxLength <= 0 {
+		filter.MaxLength = PathDefaultMaxLength
+	}
+	if filter.MaxLength > PathMaxLength {
+		filter.MaxLength = PathMaxLength
+	}
+	if filter.Count <= 0 {
+		filter.Count = PathDefaultCount
+	}
+	if filter.Count > PathMaxCount {
+		filter.Count = PathMaxCount
+	}
+}
+
+// GetPaths explains the Bacon's number, it returns the shortest paths between
+// the people with the edge followed at each step.
+func (useCase *PersonUseCase) GetPaths(ctx context.Context, firstPersonID uuid.UUID, secondPersonID uuid.UUID, filter PathFilter) ([]Path, error) {
+	return runInTx(ctx, useCase.familyTreeRepo, SessionRead, func(ctx context.Context, tx Tx) ([]Path, error) {
+		firstPerson, err := useCase.familyTreeRepo.GetPerson(ctx, tx, firstPersonID)
+		if err != nil {
+			return nil, err
+		}
+		if firstPerson == nil {
+			return nil, ErrPersonNotFound
+		}
+
+		secondPerson, err := useCase.familyTreeRepo.GetPerson(ctx, tx, secondPersonID)
+		if err != nil {
+			return nil, err
+		}
+		if secondPerson == nil {
+			return nil, ErrPersonNotFound
+		}
+		if firstPerson.ID == secondPerson.ID {
+			return []Path{{People: []Person{*firstPerson}, Edges: []PathEdge{}}}, nil
+		}
+
+		useCase.pathFilterValidate(&filter)
+		return useCase.familyTreeRepo.GetShortestPaths(ctx, tx, *firstPerson, *secondPerson, filter)
+	})
+}
+
+// GetRedirect returns the person a merged person was merged into, people that
+// weren't merged have no redirect.
+func (useCase *PersonUseCase) GetRedirect(ctx context.Context, personID uuid.UUID) (uuid.UUID, bool, error) {
+	var redirect uuid.UUID
+	var found bool
+	err := RunInTx(ctx, useCase.familyTreeRepo, SessionRead, func(ctx context.Context, tx Tx) error {
+		var err error
+		redirect, found, err = useCase.familyTreeRepo.GetRedirect(ctx, tx, personID)
 		return err
+	})
+	if err != nil {
+		return uuid.Nil, false, err
 	}
-	ctx = newCtx
-	defer useCase.familyTreeRepo.CloseSession(ctx)
+	return redirect, found, nil
+}
 
-	person, err := useCase.familyTreeRepo.GetPerson(ctx, personID)
+func (useCase *PersonUseCase) DeletePerson(ctx context.Context, personID uuid.UUID) error {
+	return RunInTx(ctx, useCase.familyTreeRepo, SessionWrite, func(ctx context.Context, tx Tx) error {
+		return useCase.deletePerson(ctx, tx, personID)
+	})
+}
+
+func (useCase *PersonUseCase) deletePerson(ctx context.Context, tx Tx, personID uuid.UUID) error {
+	if err := useCase.familyTreeRepo.LockPeople(ctx, tx, personID); err != nil {
+		return err
+	}
+	person, err := useCase.familyTreeRepo.GetPerson(ctx, tx, personID)
 	if err != nil {
 		return err
 	}
 	if person == nil {
 		return ErrPersonNotFound
 	}
-	return useCase.familyTreeRepo.DeletePerson(ctx, *person)
+	if err := useCase.familyTreeRepo.DeletePerson(ctx, tx, *person); err != nil {
+		return err
+	}
+	return recordAudit(ctx, useCase.auditSink, tx, AuditOperationDeletePerson, AuditState{People: []Person{*person}}, AuditState{})
+}
+
+// DeletePersonCascade moves the person to the trash along with all of its
+// relations and returns them. Married parents must keep a child in common, so it
+// fails with ErrOnlyChildFromSpouseCouple when the person is the only one of
+// its parents, unless deleteParentsUnion is set, then the union of the parents
+// goes to the trash as well. The children of the person may lose it, its
+// unions with their other parents are deleted as well.
+func (useCase *PersonUseCase) DeletePersonCascade(ctx context.Context, personID uuid.UUID, deleteParentsUnion bool) ([]PersonRelation, error) {
+	return runInTx(ctx, useCase.familyTreeRepo, SessionWrite, func(ctx context.Context, tx Tx) ([]PersonRelation, error) {
+		return useCase.deletePersonCascade(ctx, tx, personID, deleteParentsUnion)
+	})
+}
+
+func (useCase *PersonUseCase) deletePersonCascade(ctx context.Context, tx Tx, personID uuid.UUID, deleteParentsUnion bool) ([]PersonRelation, error) {
+	if err := useCase.familyTreeRepo.LockPeople(ctx, tx, personID); err != nil {
+		return nil, err
+	}
+	person, err := useCase.familyTreeRepo.GetPerson(ctx, tx, personID)
+	if err != nil {
+		return nil, err
+	}
+	if person == nil {
+		return nil, ErrPersonNotFound
+	}
+	parents, err := useCase.familyTreeRepo.GetParents(ctx, tx, person.ID)
+	if err != nil {
+		return nil, err
+	}
+	children, err := useCase.familyTreeRepo.GetDescendants(ctx, tx, *person, 1)
+	if err != nil {
+		return nil, err
+	}
+	// The parents and children are locked as well, so the siblings of the
+	// person can't lose its parents meanwhile, nor the children their other
+	// parents
+	relativeIDs := make([]uuid.UUID, 0, len(parents)+len(children))
+	for _, parent := range parents {
+		relativeIDs = append(relativeIDs, parent.Parent.ID)
+	}
+	for _, child := range children {
+		relativeIDs = append(relativeIDs, child.Child.ID)
+	}
+	if err := useCase.familyTreeRepo.LockPeople(ctx, tx, relativeIDs...); err != nil {
+		return nil, err
+	}
+	count, err := useCase.familyTreeRepo.GetParentMaritalChildCount(ctx, tx, *person)
+	if err != nil {
+		return nil, err
+	}
+	if count == 1 && !deleteParentsUnion {
+		return nil, ErrOnlyChildFromSpouseCouple
+	}
+	unions, err := useCase.familyTreeRepo.GetUnions(ctx, tx, *person)
+	if err != nil {
+		return nil, err
+	}
+
+	relations := make([]PersonRelation, 0, len(parents)+len(children)+len(unions)+1)
+	if count == 1 {
+		parentsUnions, err := useCase.deleteParentsUnion(ctx, tx, parents)
+		if err != nil {
+			return nil, err
+		}
+		relations = append(relations, parentsUnions...)
+	}
+	for _, parent := range parents {
+		relations = append(relations, PersonRelation{Top: parent.Parent, Bottom: *person, RelationType: RelationTypeParent, Parentage: parent.Parentage})
+	}
+	for _, child := range children {
+		relations = append(relations, PersonRelation{Top: *person, Bottom: child.Child, RelationType: RelationTypeParent, Parentage: child.Parentage})
+	}
+	for _, union := range unions {
+		relations = append(relations, PersonRelation{Top: *person, Bottom: union.Spouse, RelationType: RelationTypeSpouse, Union: union.Union})
+	}
+	if err := useCase.familyTreeRepo.DeletePersonRelations(ctx, tx, *person); err != nil {
+		return nil, err
+	}
+	if err := useCase.familyTreeRepo.DeletePerson(ctx, tx, *person); err != nil {
+		return nil, err
+	}
+	err = recordAudit(ctx, useCase.auditSink, tx, AuditOperationDeletePerson, AuditState{People: []Person{*person}, Relations: relations}, AuditState{})
+	if err != nil {
+		return nil, err
+	}
+	return relations, nil
+}
+
+// deleteParentsUnion moves the SPOUSE relations between the parents to the
+// trash, every union of them if they married again. They aren't deleted along
+// with the person, so restoring the person doesn't restore them.
+func (useCase *PersonUseCase) deleteParentsUnion(ctx context.Context, tx Tx, parents []PersonParent) ([]PersonRelation, error) {
+	for _, parent := range parents {
+		unions, err := useCase.familyTreeRepo.GetUnions(ctx, tx, parent.Parent)
+		if err != nil {
+			return nil, err
+		}
+		for _, otherParent := range parents {
+			relations := []PersonRelation{}
+			for _, union := range unions {
+				if union.Spouse.ID == otherParent.Parent.ID {
+					relations = append(relations, PersonRelation{Top: parent.Parent, Bottom: union.Spouse, RelationType: RelationTypeSpouse, Union: union.Union})
+				}
+			}
+			if len(relations) == 0 {
+				continue
+			}
+			deleted, err := useCase.familyTreeRepo.DeleteRelationship(ctx, tx, parent.Parent, otherParent.Parent, RelationTypeSpouse)
+			if err != nil {
+				return nil, err
+			}
+			if !deleted {
+				return nil, ErrRelationNotFound
+			}
+			return relations, nil
+		}
+	}
+	return nil, ErrRelationNotFound
+}
+
+func (useCase *PersonUseCase) GetTrash(ctx context.Context) (*Trash, error) {
+	return runInTx(ctx, useCase.familyTreeRepo, SessionRead, func(ctx context.Context, tx Tx) (*Trash, error) {
+		return useCase.familyTreeRepo.GetTrash(ctx, tx)
+	})
+}
+
+// PurgeTrash deletes for good what has been in the trash for longer than the
+// retention, DefaultTrashRetention when it isn't positive.
+func (useCase *PersonUseCase) PurgeTrash(ctx context.Context, retention time.Duration) (*TrashPurge, error) {
+	if retention <= 0 {
+		retention = DefaultTrashRetention
+	}
+	before := time.Now().Add(-retention)
+	return runInTx(ctx, useCase.familyTreeRepo, SessionWrite, func(ctx context.Context, tx Tx) (*TrashPurge, error) {
+		return useCase.purgeTrash(ctx, tx, before)
+	})
+}
 
+// purgeTrash records an event for each person and relation deleted for good,
+// the trash is read before the purge to know them.
+func (useCase *PersonUseCase) purgeTrash(ctx context.Context, tx Tx, before time.Time) (*TrashPurge, error) {
+	trash, err := useCase.familyTreeRepo.GetTrash(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	purge, err := useCase.familyTreeRepo.PurgeTrash(ctx, tx, before)
+	if err != nil {
+		return nil, err
+	}
+	people, relations := trash.purged(before)
+	for _, person := range people {
+		err := recordAudit(ctx, useCase.auditSink, tx, AuditOperationPurgePerson, AuditState{People: []Person{person}}, AuditState{})
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, relation := range relations {
+		err := recordAudit(ctx, useCase.auditSink, tx, AuditOperationPurgeRelation, AuditState{Relations: []PersonRelation{relation}}, AuditState{})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return purge, nil
+}
+
+// GetAuditEvents pages through the history of the person, the latest event
+// first. It's empty when there is no audit sink.
+func (useCase *PersonUseCase) GetAuditEvents(ctx context.Context, personID uuid.UUID, pagination PaginationDetails) (*AuditEventList, error) {
+	useCase.paginationValidate(&pagination)
+	if useCase.auditSink == nil {
+		return &AuditEventList{Content: []AuditEvent{}, Metadata: ListMetadata{Page: pagination.Page}}, nil
+	}
+	return runInTx(ctx, useCase.familyTreeRepo, SessionRead, func(ctx context.Context, tx Tx) (*AuditEventList, error) {
+		return useCase.auditSink.GetAuditEvents(ctx, tx, personID, pagination)
+	})
 }
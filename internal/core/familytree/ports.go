@@ -2,40 +2,135 @@ package familytree
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type FamilyTreeRepo interface {
-	OpenSession(ctx context.Context, mode SessionMode) (interface{}, error)
-	CloseSession(ctx context.Context)
-	SavePerson(ctx context.Context, person *Person) error
-	GetPerson(ctx context.Context, personID uuid.UUID) (*Person, error)
-	SaveRelation(ctx context.Context, relation PersonRelation) error
-	GetParents(ctx context.Context, personID uuid.UUID) ([]*Person, error)
-	GetLowestCommonAncestor(ctx context.Context, firstPerson Person, secondPerson Person) (*Person, error)
-	GetPeople(ctx context.Context, pagination PaginationDetails) (*PeopleList, error)
-	GetFamilyTree(ctx context.Context, person Person) (*FamilyTree, error)
-	GetShortestPathLength(ctx context.Context, firstPerson Person, secondPerson Person) (int, bool, error)
-	HasCommonChild(ctx context.Context, firstPerson Person, secondPerson Person) (bool, error)
-	GetSpouse(ctx context.Context, person Person) (*Person, error)
-	GetParentMaritalChildCount(ctx context.Context, person Person) (int, error)
-	DeleteRelationship(ctx context.Context, firstPerson Person, secondPerson Person, relationType RelationType) (bool, error)
-	DeletePerson(ctx context.Context, person Person) error
+	// Begin starts a Tx, the people locked by a write Tx stay locked until it
+	// ends
+	Begin(ctx context.Context, mode SessionMode) (Tx, error)
+	// LockPeople holds the people until the Tx ends, so concurrent Txs
+	// checking the relations of the same people wait for each other. The
+	// people are locked in the order of their uuids, so Txs locking them at
+	// once don't deadlock
+	LockPeople(ctx context.Context, tx Tx, peopleIDs ...uuid.UUID) error
+	// IsTransientError tells if a Tx that failed with the error may succeed
+	// when run again
+	IsTransientError(err error) bool
+	SavePerson(ctx context.Context, tx Tx, person *Person) error
+	UpdatePerson(ctx context.Context, tx Tx, person *Person) error
+	GetPerson(ctx context.Context, tx Tx, personID uuid.UUID) (*Person, error)
+	SaveRelation(ctx context.Context, tx Tx, relation PersonRelation) error
+	GetParents(ctx context.Context, tx Tx, personID uuid.UUID) ([]PersonParent, error)
+	// GetLowestCommonAncestor only follows biological PARENT relations
+	GetLowestCommonAncestor(ctx context.Context, tx Tx, firstPerson Person, secondPerson Person) (*Person, error)
+	// GetCommonAncestors returns every biological ancestor shared by the people,
+	// the people themselves included, with the fewest generations to each one
+	GetCommonAncestors(ctx context.Context, tx Tx, firstPerson Person, secondPerson Person) ([]CommonAncestor, error)
+	GetPeople(ctx context.Context, tx Tx, filter PeopleFilter, sort PeopleSort, pagination PaginationDetails) (*PeopleList, error)
+	// SearchPeople returns the people passing the filter whose name matches the
+	// query, ranked by their score
+	SearchPeople(ctx context.Context, tx Tx, query string, filter PeopleFilter, pagination PaginationDetails) (*PeopleMatchList, error)
+	GetFamilyTree(ctx context.Context, tx Tx, person Person) (*FamilyTree, error)
+	// GetDescendants returns every PARENT relation below the person, of any
+	// parentage, whose child is up to depth generations away from the person
+	GetDescendants(ctx context.Context, tx Tx, person Person, depth int) ([]PersonChild, error)
+	// IsDescendant tells if the descendant is below the ancestor through PARENT
+	// relations of any parentage
+	IsDescendant(ctx context.Context, tx Tx, ancestor Person, descendant Person) (bool, error)
+	// GetAncestors returns every biological PARENT relation above the person
+	// whose parent is up to generations away from the person
+	GetAncestors(ctx context.Context, tx Tx, person Person, generations int) ([]ChildParent, error)
+	GetShortestPathLength(ctx context.Context, tx Tx, firstPerson Person, secondPerson Person) (int, bool, error)
+	// GetShortestPaths returns up to filter.Count distinct paths between
+	// different people sorted by length, none longer than filter.MaxLength
+	GetShortestPaths(ctx context.Context, tx Tx, firstPerson Person, secondPerson Person, filter PathFilter) ([]Path, error)
+	HasCommonChild(ctx context.Context, tx Tx, firstPerson Person, secondPerson Person) (bool, error)
+	// GetSpouse returns the spouse of the ongoing union of the person
+	GetSpouse(ctx context.Context, tx Tx, person Person) (*Person, error)
+	GetUnions(ctx context.Context, tx Tx, person Person) ([]PersonUnion, error)
+	// UpdateUnion replaces the union starting on startDate of the SPOUSE
+	// relations between the people and returns false when there is no such
+	// relation
+	UpdateUnion(ctx context.Context, tx Tx, firstPerson Person, secondPerson Person, startDate Date, union Union) (bool, error)
+	GetParentMaritalChildCount(ctx context.Context, tx Tx, person Person) (int, error)
+	// DeleteRelationship moves the relation to the trash, deleted people and
+	// relations are hidden from every other query
+	DeleteRelationship(ctx context.Context, tx Tx, firstPerson Person, secondPerson Person, relationType RelationType) (bool, error)
+	// DeleteUnion moves the SPOUSE relation between the people whose union
+	// starts on startDate to the trash, their other unions are kept
+	DeleteUnion(ctx context.Context, tx Tx, firstPerson Person, secondPerson Person, startDate Date) (bool, error)
+	// DeletePersonRelations moves every relation of the person to the trash
+	// along with it
+	DeletePersonRelations(ctx context.Context, tx Tx, person Person) error
+	// DeletePerson moves the person to the trash, it must have no relations left
+	DeletePerson(ctx context.Context, tx Tx, person Person) error
+	// GetTrash returns the trashed people and relations, the latest deleted
+	// first
+	GetTrash(ctx context.Context, tx Tx) (*Trash, error)
+	// RestorePerson takes the person out of the trash, its relations stay there
+	RestorePerson(ctx context.Context, tx Tx, personID uuid.UUID) (bool, error)
+	// RestoreRelationship takes the latest deleted relation between the people
+	// out of the trash
+	RestoreRelationship(ctx context.Context, tx Tx, firstPerson Person, secondPerson Person, relationType RelationType) (bool, error)
+	// PurgeTrash deletes for good the people and relations trashed before the
+	// time, along with every relation of the purged people
+	PurgeTrash(ctx context.Context, tx Tx, before time.Time) (*TrashPurge, error)
+	// GetDuplicateCandidates returns every pair of people sharing the blocking
+	// key of their names, a parent or a spouse, each pair once
+	GetDuplicateCandidates(ctx context.Context, tx Tx) ([]DuplicateCandidate, error)
+	// SaveRedirect records that the person oldID was merged into newID, the
+	// redirects to oldID are moved to newID as well
+	SaveRedirect(ctx context.Context, tx Tx, oldID uuid.UUID, newID uuid.UUID) error
+	// GetRedirect returns the person a merged person was merged into
+	GetRedirect(ctx context.Context, tx Tx, personID uuid.UUID) (uuid.UUID, bool, error)
+}
+
+// AuditSink keeps the audit events of the mutations, it never changes nor
+// deletes them.
+type AuditSink interface {
+	// AppendAuditEvents runs on the Tx of the mutations before its commit, so
+	// the events kept by the repo are dropped along with a rollback. Sinks out
+	// of the repo keep the events until the Tx is committed, with OnCommit
+	AppendAuditEvents(ctx context.Context, tx Tx, events ...AuditEvent) error
+	// GetAuditEvents returns the history of the person, the latest event first
+	GetAuditEvents(ctx context.Context, tx Tx, personID uuid.UUID, pagination PaginationDetails) (*AuditEventList, error)
 }
 
 type PersonUseCasePort interface {
 	CreatePerson(ctx context.Context, person *Person) error
-	GetPeople(ctx context.Context, pagination PaginationDetails) (*PeopleList, error)
+	UpdatePerson(ctx context.Context, personID uuid.UUID, update PersonUpdate) (*Person, error)
+	GetPeople(ctx context.Context, filter PeopleFilter, sort PeopleSort, pagination PaginationDetails) (*PeopleList, error)
+	SearchPeople(ctx context.Context, query string, filter PeopleFilter, pagination PaginationDetails) (*PeopleMatchList, error)
 	GetPerson(ctx context.Context, personID uuid.UUID) (*Person, error)
 	GetBaconsNumber(ctx context.Context, firstPersonID uuid.UUID, secondPersonID uuid.UUID) (int, bool, error)
+	GetPaths(ctx context.Context, firstPersonID uuid.UUID, secondPersonID uuid.UUID, filter PathFilter) ([]Path, error)
 	DeletePerson(ctx context.Context, personID uuid.UUID) error
+	DeletePersonCascade(ctx context.Context, personID uuid.UUID, deleteParentsUnion bool) ([]PersonRelation, error)
+	GetRedirect(ctx context.Context, personID uuid.UUID) (uuid.UUID, bool, error)
+	GetDuplicates(ctx context.Context, minScore float64, pagination PaginationDetails) (*DuplicateList, error)
+	GetTrash(ctx context.Context) (*Trash, error)
+	PurgeTrash(ctx context.Context, retention time.Duration) (*TrashPurge, error)
+	GetAuditEvents(ctx context.Context, personID uuid.UUID, pagination PaginationDetails) (*AuditEventList, error)
 }
 
 type RelationshipUseCasePort interface {
-	CreateParentRelation(ctx context.Context, parentID uuid.UUID, childID uuid.UUID) error
-	CreateSpouseRelation(ctx context.Context, firstSpouseID uuid.UUID, secondSpouseID uuid.UUID) error
+	CreateParentRelation(ctx context.Context, parentID uuid.UUID, childID uuid.UUID, parentage Parentage) ([]RuleViolation, error)
+	CreateSpouseRelation(ctx context.Context, firstSpouseID uuid.UUID, secondSpouseID uuid.UUID, union Union) ([]RuleViolation, error)
+	UpdateSpouseRelation(ctx context.Context, firstSpouseID uuid.UUID, secondSpouseID uuid.UUID, startDate *Date, update UnionUpdate) (*Union, error)
+	GetUnions(ctx context.Context, personID uuid.UUID) ([]PersonUnion, error)
+	GetKinship(ctx context.Context, firstPersonID uuid.UUID, secondPersonID uuid.UUID) (*Kinship, error)
 	GetFamilyTree(ctx context.Context, personID uuid.UUID) (*FamilyTree, error)
-	DeleteSpouseRelation(ctx context.Context, firstSpouseID uuid.UUID, secondSpouseID uuid.UUID) error
+	GetDescendants(ctx context.Context, personID uuid.UUID, depth int, includeSpouses bool) (*Descendants, error)
+	GetPedigree(ctx context.Context, personID uuid.UUID, generations int) (*Pedigree, error)
+	GetRelatedness(ctx context.Context, firstPersonID uuid.UUID, secondPersonID uuid.UUID) (*Relatedness, error)
+	GetInbreeding(ctx context.Context, personID uuid.UUID) (*Inbreeding, error)
+	DeleteSpouseRelation(ctx context.Context, firstSpouseID uuid.UUID, secondSpouseID uuid.UUID, startDate *Date) error
 	DeleteParentRelation(ctx context.Context, parentID uuid.UUID, childID uuid.UUID) error
+	MergePeople(ctx context.Context, survivorID uuid.UUID, duplicateID uuid.UUID) (*MergeResult, error)
+	RestorePerson(ctx context.Context, personID uuid.UUID) (*TrashedPerson, error)
+	RestoreParentRelation(ctx context.Context, parentID uuid.UUID, childID uuid.UUID) error
+	RestoreSpouseRelation(ctx context.Context, firstSpouseID uuid.UUID, secondSpouseID uuid.UUID) error
 }
@@ -0,0 +1,198 @@
+package familytree
+
+import (
+	"math"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// RelatednessGenerations bounds the ancestors searched for shared-ancestor
+// paths, relatives sharing only farther ancestors count as unrelated.
+const RelatednessGenerations = 12
+
+// AncestorContribution is the part of a coefficient coming from the paths
+// through one common ancestor. FirstGenerations and SecondGenerations are the
+// generations of its shortest path from each person.
+type AncestorContribution struct {
+	Ancestor          Person
+	Paths             int
+	FirstGenerations  int
+	SecondGenerations int
+	Inbreeding        float64
+	Contribution      float64
+}
+
+// Relatedness holds Wright's coefficient of relationship between two people,
+// the inbreeding coefficient of each of them and the common ancestors it was
+// built from. Only the biological lineage is followed.
+type Relatedness struct {
+	Coefficient      float64
+	FirstInbreeding  float64
+	SecondInbreeding float64
+	Generations      int
+	CommonAncestors  []AncestorContribution
+}
+
+// Inbreeding holds the inbreeding coefficient of a person, built from the
+// common ancestors of its biological parents.
+type Inbreeding struct {
+	Coefficient     float64
+	Generations     int
+	CommonAncestors []AncestorContribution
+}
+
+// relatednessGraph is the biological pedigree used by Wright's path method.
+// A path goes up from one person to a common ancestor and down to the other
+// one without going through anyone twice, each path adds (1/2)^n (1 + Fa),
+// where n is its number of generations and Fa the inbreeding of the ancestor.
+type relatednessGraph struct {
+	people     map[uuid.UUID]Person
+	parents    map[uuid.UUID][]uuid.UUID
+	inbreeding map[uuid.UUID]float64
+}
+
+func newRelatednessGraph(people []Person, parents []ChildParent) *relatednessGraph {
+	graph := &relatednessGraph{
+		people:     map[uuid.UUID]Person{},
+		parents:    map[uuid.UUID][]uuid.UUID{},
+		inbreeding: map[uuid.UUID]float64{},
+	}
+	for _, person := range people {
+		graph.people[person.ID] = person
+	}
+	for _, parent := range parents {
+		if _, ok := graph.people[parent.Parent.ID]; !ok {
+			graph.people[parent.Parent.ID] = parent.Parent
+		}
+		if containsUUID(graph.parents[parent.ChildID], parent.Parent.ID) {
+			continue
+		}
+		graph.parents[parent.ChildID] = append(graph.parents[parent.ChildID], parent.Parent.ID)
+	}
+	return graph
+}
+
+func containsUUID(ids []uuid.UUID, id uuid.UUID) bool {
+	for _, currentID := range ids {
+		if currentID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// upwardPaths returns every path from the person to each of its ancestors,
+// the person itself included, as the people from the person to the ancestor.
+func (graph *relatednessGraph) upwardPaths(personID uuid.UUID) map[uuid.UUID][][]uuid.UUID {
+	paths := map[uuid.UUID][][]uuid.UUID{}
+	var walk func(path []uuid.UUID)
+	walk = func(path []uuid.UUID) {
+		last := path[len(path)-1]
+		paths[last] = append(paths[last], path)
+		for _, parentID := range graph.parents[last] {
+			walk(append(append([]uuid.UUID{}, path...), parentID))
+		}
+	}
+	walk([]uuid.UUID{personID})
+	return paths
+}
+
+// pathSum adds the paths between the people through every common ancestor.
+func (graph *relatednessGraph) pathSum(firstID uuid.UUID, secondID uuid.UUID) (float64, []AncestorContribution) {
+	firstPaths := graph.upwardPaths(firstID)
+	secondPaths := graph.upwardPaths(secondID)
+	total := 0.0
+	contributions := []AncestorContribution{}
+	for ancestorID, firstAncestorPaths := range firstPaths {
+		secondAncestorPaths, ok := secondPaths[ancestorID]
+		if !ok {
+			continue
+		}
+		contribution := AncestorContribution{
+			Ancestor:   graph.people[ancestorID],
+			Inbreeding: graph.inbreedingOf(ancestorID),
+		}
+		for _, firstPath := range firstAncestorPaths {
+			for _, secondPath := range secondAncestorPaths {
+				if !disjointPaths(firstPath, secondPath) {
+					continue
+				}
+				firstGenerations, secondGenerations := len(firstPath)-1, len(secondPath)-1
+				if contribution.Paths == 0 || firstGenerations+secondGenerations < contribution.FirstGenerations+contribution.SecondGenerations {
+					contribution.FirstGenerations, contribution.SecondGenerations = firstGenerations, secondGenerations
+				}
+				contribution.Paths++
+				contribution.Contribution += math.Pow(0.5, float64(firstGenerations+secondGenerations)) * (1 + contribution.Inbreeding)
+			}
+		}
+		if contribution.Paths == 0 {
+			continue
+		}
+		total += contribution.Contribution
+		contributions = append(contributions, contribution)
+	}
+	sort.SliceStable(contributions, func(i, j int) bool {
+		if contributions[i].Contribution != contributions[j].Contribution {
+			return contributions[i].Contribution > contributions[j].Contribution
+		}
+		return contributions[i].Ancestor.Name < contributions[j].Ancestor.Name
+	})
+	return total, contributions
+}
+
+// disjointPaths tells if the paths only meet at the common ancestor, the last
+// person of both.
+func disjointPaths(firstPath []uuid.UUID, secondPath []uuid.UUID) bool {
+	people := map[uuid.UUID]bool{}
+	for _, personID := range firstPath[:len(firstPath)-1] {
+		people[personID] = true
+	}
+	for _, personID := range secondPath[:len(secondPath)-1] {
+		if people[personID] {
+			return false
+		}
+	}
+	return true
+}
+
+// inbreedingOf is half the path sum between the parents of the person, people
+// without two known parents aren't inbred.
+func (graph *relatednessGraph) inbreedingOf(personID uuid.UUID) float64 {
+	if inbreeding, ok := graph.inbreeding[personID]; ok {
+		return inbreeding
+	}
+	inbreeding := graph.inbreedingFor(personID).Coefficient
+	graph.inbreeding[personID] = inbreeding
+	return inbreeding
+}
+
+func (graph *relatednessGraph) inbreedingFor(personID uuid.UUID) *Inbreeding {
+	inbreeding := &Inbreeding{
+		Generations:     RelatednessGenerations,
+		CommonAncestors: []AncestorContribution{},
+	}
+	parents := graph.parents[personID]
+	if len(parents) != 2 {
+		return inbreeding
+	}
+	sum, contributions := graph.pathSum(parents[0], parents[1])
+	inbreeding.Coefficient = sum / 2
+	for _, contribution := range contributions {
+		contribution.Contribution /= 2
+		inbreeding.CommonAncestors = append(inbreeding.CommonAncestors, contribution)
+	}
+	return inbreeding
+}
+
+func (graph *relatednessGraph) relatedness(firstID uuid.UUID, secondID uuid.UUID) *Relatedness {
+	sum, contributions := graph.pathSum(firstID, secondID)
+	relatedness := &Relatedness{
+		FirstInbreeding:  graph.inbreedingOf(firstID),
+		SecondInbreeding: graph.inbreedingOf(secondID),
+		Generations:      RelatednessGenerations,
+		CommonAncestors:  contributions,
+	}
+	relatedness.Coefficient = sum / math.Sqrt((1+relatedness.FirstInbreeding)*(1+relatedness.SecondInbreeding))
+	return relatedness
+}
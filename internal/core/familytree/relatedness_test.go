@@ -0,0 +1,129 @@
+package familytree
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// testPedigree builds a relatedness graph from "child: parents" entries,
+// naming every person by its key.
+func testPedigree(pedigree map[string][]string) (*relatednessGraph, map[string]uuid.UUID) {
+	ids := map[string]uuid.UUID{}
+	people := []Person{}
+	person := func(name string) Person {
+		if _, ok := ids[name]; !ok {
+			ids[name] = uuid.New()
+			people = append(people, Person{ID: ids[name], Name: name})
+		}
+		return Person{ID: ids[name], Name: name}
+	}
+	parents := []ChildParent{}
+	for child, childParents := range pedigree {
+		childID := person(child).ID
+		for _, parent := range childParents {
+			parents = append(parents, ChildParent{ChildID: childID, Parent: person(parent)})
+		}
+	}
+	return newRelatednessGraph(people, parents), ids
+}
+
+func TestRelatedness(t *testing.T) {
+	graph, ids := testPedigree(map[string][]string{
+		"Father":       {"Grandpa", "Grandma"},
+		"Uncle":        {"Grandpa", "Grandma"},
+		"HalfUncle":    {"Grandpa", "Stepgrandma"},
+		"Child":        {"Father", "Mother"},
+		"Sibling":      {"Father", "Mother"},
+		"HalfSibling":  {"Father", "Stepmother"},
+		"Cousin":       {"Uncle", "Aunt"},
+		"HalfCousin":   {"HalfUncle", "Aunt"},
+		"Inbred":       {"Child", "Sibling"},
+		"CousinsChild": {"Child", "Cousin"},
+	})
+	tests := []struct {
+		first            string
+		second           string
+		coefficient      float64
+		commonAncestors  int
+		firstInbreeding  float64
+		secondInbreeding float64
+	}{
+		{first: "Child", second: "Child", coefficient: 1, commonAncestors: 1},
+		{first: "Child", second: "Father", coefficient: 0.5, commonAncestors: 1},
+		{first: "Child", second: "Grandpa", coefficient: 0.25, commonAncestors: 1},
+		{first: "Child", second: "Sibling", coefficient: 0.5, commonAncestors: 2},
+		{first: "Child", second: "HalfSibling", coefficient: 0.25, commonAncestors: 1},
+		{first: "Child", second: "Uncle", coefficient: 0.25, commonAncestors: 2},
+		{first: "Child", second: "Cousin", coefficient: 0.125, commonAncestors: 2},
+		{first: "Child", second: "HalfCousin", coefficient: 0.0625, commonAncestors: 1},
+		{first: "Child", second: "Mother", coefficient: 0.5, commonAncestors: 1},
+		{first: "Father", second: "Mother", coefficient: 0, commonAncestors: 0},
+		// The child of siblings is related to them above 0.5
+		{first: "Inbred", second: "Child", coefficient: 0.75 / math.Sqrt(1.25), commonAncestors: 3, firstInbreeding: 0.25},
+		{first: "Father", second: "CousinsChild", coefficient: 0.375 / math.Sqrt(1.0625), commonAncestors: 3, secondInbreeding: 0.0625},
+	}
+	for _, test := range tests {
+		t.Run(test.first+" "+test.second, func(t *testing.T) {
+			relatedness := graph.relatedness(ids[test.first], ids[test.second])
+			if math.Abs(relatedness.Coefficient-test.coefficient) > 1e-9 {
+				t.Errorf("the coefficient is %g, expected %g", relatedness.Coefficient, test.coefficient)
+			}
+			if len(relatedness.CommonAncestors) != test.commonAncestors {
+				t.Errorf("the coefficient was built from %d common ancestors, expected %d: %+v", len(relatedness.CommonAncestors), test.commonAncestors, relatedness.CommonAncestors)
+			}
+			if relatedness.FirstInbreeding != test.firstInbreeding || relatedness.SecondInbreeding != test.secondInbreeding {
+				t.Errorf("the inbreeding coefficients are %g and %g, expected %g and %g", relatedness.FirstInbreeding, relatedness.SecondInbreeding, test.firstInbreeding, test.secondInbreeding)
+			}
+			for i := 1; i < len(relatedness.CommonAncestors); i++ {
+				if relatedness.CommonAncestors[i].Contribution > relatedness.CommonAncestors[i-1].Contribution {
+					t.Errorf("the common ancestors aren't sorted by contribution: %+v", relatedness.CommonAncestors)
+				}
+			}
+		})
+	}
+}
+
+func TestInbreeding(t *testing.T) {
+	graph, ids := testPedigree(map[string][]string{
+		"Father":         {"Grandpa", "Grandma"},
+		"Aunt":           {"Grandpa", "Grandma"},
+		"Cousin":         {"Aunt", "Uncle"},
+		"SiblingsChild":  {"Father", "Aunt"},
+		"CousinsChild":   {"Father", "Cousin"},
+		"HalfNephewSon":  {"Father", "HalfNiece"},
+		"HalfNiece":      {"HalfAunt", "Other"},
+		"HalfAunt":       {"Grandpa", "Stepgrandma"},
+		"SingleParented": {"Father"},
+	})
+	tests := []struct {
+		name            string
+		coefficient     float64
+		commonAncestors int
+	}{
+		{name: "Father", coefficient: 0, commonAncestors: 0},
+		{name: "SingleParented", coefficient: 0, commonAncestors: 0},
+		{name: "SiblingsChild", coefficient: 0.25, commonAncestors: 2},
+		{name: "CousinsChild", coefficient: 0.125, commonAncestors: 2},
+		{name: "HalfNephewSon", coefficient: 0.0625, commonAncestors: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			inbreeding := graph.inbreedingFor(ids[test.name])
+			if math.Abs(inbreeding.Coefficient-test.coefficient) > 1e-9 {
+				t.Errorf("the inbreeding coefficient is %g, expected %g", inbreeding.Coefficient, test.coefficient)
+			}
+			if len(inbreeding.CommonAncestors) != test.commonAncestors {
+				t.Errorf("the inbreeding was built from %d common ancestors, expected %d: %+v", len(inbreeding.CommonAncestors), test.commonAncestors, inbreeding.CommonAncestors)
+			}
+			sum := 0.0
+			for _, contribution := range inbreeding.CommonAncestors {
+				sum += contribution.Contribution
+			}
+			if math.Abs(sum-inbreeding.Coefficient) > 1e-9 {
+				t.Errorf("the contributions add up to %g, expected %g", sum, inbreeding.Coefficient)
+			}
+		})
+	}
+}
@@ -2,242 +2,1025 @@ package familytree
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
 
 	"github.com/google/uuid"
 )
 
+// RelationshipUseCase records an audit event of each mutation on the
+// auditSink, nothing is recorded when it's nil.
 type RelationshipUseCase struct {
 	familyTreeRepo FamilyTreeRepo
+	rules          RelationRules
+	auditSink      AuditSink
 }
 
-func NewRelationshipUseCase(familyTreeRepo FamilyTreeRepo) *RelationshipUseCase {
+func NewRelationshipUseCase(familyTreeRepo FamilyTreeRepo, rules RelationRules, auditSink AuditSink) *RelationshipUseCase {
 
 	return &RelationshipUseCase{
 		familyTreeRepo: familyTreeRepo,
+		rules:          rules,
+		auditSink:      auditSink,
 	}
 }
 
-func (useCase *RelationshipUseCase) openSession(ctx context.Context, sessionMode SessionMode) (context.Context, error) {
-	session, err := useCase.familyTreeRepo.OpenSession(ctx, sessionMode)
-	if err != nil {
-		return ctx, err
-	}
-	return context.WithValue(ctx, SessionKey, session), nil
-}
-
-func (useCase *RelationshipUseCase) validateCreateChildRelation(ctx context.Context, parent *Person, child *Person) error {
-	parents, err := useCase.familyTreeRepo.GetParents(ctx, child.ID)
+// validateCreateChildRelation limits the child to MaxParents parents of each
+// parentage. Only biological parents are checked for an existing kinship, by
+// the incest rule, other parents, like a grandparent adopting a grandchild,
+// may already be relatives as long as the parent isn't a descendant of the
+// child through any parentage, or the relation would close a cycle.
+func (useCase *RelationshipUseCase) validateCreateChildRelation(ctx context.Context, tx Tx, parent *Person, child *Person, parentage Parentage) error {
+	parents, err := useCase.familyTreeRepo.GetParents(ctx, tx, child.ID)
 	if err != nil {
 		return err
 	}
-	if len(parents) == MaxParents {
-		return ErrMaxParents
+	sameParentage := 0
+	for _, currentParent := range parents {
+		if currentParent.Parentage == parentage {
+			sameParentage++
+		}
+	}
+	if sameParentage == MaxParents {
+		return fmt.Errorf("%w: %s", ErrMaxParents, parentage)
 	}
 	if parent.ID == child.ID {
 		return ErrSameParentChildID
 	}
 	for _, currentParent := range parents {
-		if currentParent.ID == parent.ID {
+		if currentParent.Parent.ID == parent.ID {
 			return ErrDuplicateRelation
 		}
 	}
 
-	//Check incestuous relationship
-	ancestor, err := useCase.familyTreeRepo.GetLowestCommonAncestor(ctx, *parent, *child)
+	isDescendant, err := useCase.familyTreeRepo.IsDescendant(ctx, tx, *child, *parent)
 	if err != nil {
 		return err
 	}
-	if ancestor != nil {
+	if isDescendant {
 		return ErrIncestuousRelation
 	}
+	if !parentage.IsBiological() {
+		return nil
+	}
+
+	//Check incestuous relationship
+	ancestor, err := useCase.familyTreeRepo.GetLowestCommonAncestor(ctx, tx, *parent, *child)
+	if err != nil {
+		return err
+	}
+	if ancestor == nil {
+		return nil
+	}
+	graph, err := useCase.getRelatednessGraph(ctx, tx, *parent, *child)
+	if err != nil {
+		return err
+	}
+	return useCase.rules.Incest.Validate(*graph.relatedness(parent.ID, child.ID))
+}
+
+// getRelatednessGraph loads the biological ancestors of the people up to
+// RelatednessGenerations generations.
+func (useCase *RelationshipUseCase) getRelatednessGraph(ctx context.Context, tx Tx, people ...Person) (*relatednessGraph, error) {
+	parents := []ChildParent{}
+	for _, person := range people {
+		personParents, err := useCase.familyTreeRepo.GetAncestors(ctx, tx, person, RelatednessGenerations)
+		if err != nil {
+			return nil, err
+		}
+		parents = append(parents, personParents...)
+	}
+	return newRelatednessGraph(people, parents), nil
+}
 
+// validateOngoingUnions checks that the new union doesn't overlap a union of
+// the person with the new spouse, a couple may marry again once divorced, and
+// that, when the new union is ongoing, the person has no other ongoing union.
+// The updated union of the person with the new spouse is skipped, it's the one
+// being replaced.
+func (useCase *RelationshipUseCase) validateOngoingUnions(ctx context.Context, tx Tx, person *Person, newSpouse *Person, union Union, updated *Union) error {
+	unions, err := useCase.familyTreeRepo.GetUnions(ctx, tx, *person)
+	if err != nil {
+		return err
+	}
+	for _, personUnion := range unions {
+		if personUnion.Spouse.ID == newSpouse.ID {
+			if updated != nil && personUnion.Union.StartDate == updated.StartDate {
+				continue
+			}
+			if union.Overlaps(personUnion.Union) {
+				return ErrDuplicateRelation
+			}
+		}
+		if union.Ongoing() && personUnion.Union.Ongoing() {
+			return ErrHasSpouseAlready
+		}
+	}
 	return nil
 }
 
-func (useCase *RelationshipUseCase) validateSpouseExists(ctx context.Context, foundSpouse *Person, newSpouse *Person) error {
-	if foundSpouse == nil {
-		return nil
+// findUnion returns the union between the spouses starting on startDate, or
+// their only union when startDate is nil.
+func (useCase *RelationshipUseCase) findUnion(ctx context.Context, tx Tx, firstSpouse *Person, secondSpouse *Person, startDate *Date) (*Union, error) {
+	if startDate != nil {
+		normalized, err := startDate.Normalize()
+		if err != nil {
+			return nil, err
+		}
+		startDate = &normalized
+	}
+	unions, err := useCase.familyTreeRepo.GetUnions(ctx, tx, *firstSpouse)
+	if err != nil {
+		return nil, err
+	}
+	found := []Union{}
+	for _, personUnion := range unions {
+		if personUnion.Spouse.ID != secondSpouse.ID {
+			continue
+		}
+		if startDate == nil || personUnion.Union.StartDate == *startDate {
+			found = append(found, personUnion.Union)
+		}
 	}
-	if foundSpouse.ID == newSpouse.ID {
-		return ErrDuplicateRelation
+	switch len(found) {
+	case 0:
+		return nil, ErrRelationNotFound
+	case 1:
+		return &found[0], nil
+	default:
+		return nil, ErrAmbiguousUnion
 	}
-	return ErrHasSpouseAlready
 }
 
-func (useCase *RelationshipUseCase) validateCreateSpouseRelation(ctx context.Context, firstSpouse *Person, secondSpouse *Person) error {
-	hasChild, err := useCase.familyTreeRepo.HasCommonChild(ctx, *firstSpouse, *secondSpouse)
+func (useCase *RelationshipUseCase) validateCreateSpouseRelation(ctx context.Context, tx Tx, firstSpouse *Person, secondSpouse *Person, union Union) error {
+	hasChild, err := useCase.familyTreeRepo.HasCommonChild(ctx, tx, *firstSpouse, *secondSpouse)
 	if err != nil {
 		return err
 	}
 	if !hasChild {
 		return ErrCoupleHasNoChild
 	}
-	firstSpouseCheck, err := useCase.familyTreeRepo.GetSpouse(ctx, *firstSpouse)
+	err = useCase.validateOngoingUnions(ctx, tx, firstSpouse, secondSpouse, union, nil)
 	if err != nil {
 		return err
 	}
-	err = useCase.validateSpouseExists(ctx, firstSpouseCheck, secondSpouse)
-	if err != nil {
+	return useCase.validateOngoingUnions(ctx, tx, secondSpouse, firstSpouse, union, nil)
+}
+
+// normalizeUnion writes the dates on their canonical form and ends the union
+// by death when it has no end and one of the spouses has died.
+func (useCase *RelationshipUseCase) normalizeUnion(union *Union, firstSpouse *Person, secondSpouse *Person) error {
+	var err error
+	if union.StartDate, err = union.StartDate.Normalize(); err != nil {
 		return err
 	}
-	secondSpouseCheck, err := useCase.familyTreeRepo.GetSpouse(ctx, *secondSpouse)
-	if err != nil {
+	if union.EndDate, err = union.EndDate.Normalize(); err != nil {
 		return err
 	}
-	return useCase.validateSpouseExists(ctx, secondSpouseCheck, firstSpouse)
+	if union.EndReason, err = union.EndReason.Normalize(); err != nil {
+		return err
+	}
+	if union.Ongoing() {
+		if deathDate := firstDeath(firstSpouse, secondSpouse); !deathDate.IsZero() {
+			union.EndDate = deathDate
+			union.EndReason = UnionEndDeath
+		}
+	}
+	startEarliest, _ := union.StartDate.Bounds()
+	_, endLatest := union.EndDate.Bounds()
+	if !startEarliest.IsZero() && !endLatest.IsZero() && endLatest.Before(startEarliest) {
+		return ErrUnionEndsBeforeStart
+	}
+	return nil
+}
+
+// firstDeath returns the death date of the spouse that surely died first, or
+// any of them when it can't be told.
+func firstDeath(firstSpouse *Person, secondSpouse *Person) Date {
+	if firstSpouse.DeathDate.IsZero() {
+		return secondSpouse.DeathDate
+	}
+	if secondSpouse.DeathDate.IsZero() {
+		return firstSpouse.DeathDate
+	}
+	_, firstLatest := firstSpouse.DeathDate.Bounds()
+	secondEarliest, _ := secondSpouse.DeathDate.Bounds()
+	if !firstLatest.IsZero() && !secondEarliest.IsZero() && secondEarliest.After(firstLatest) {
+		return firstSpouse.DeathDate
+	}
+	_, secondLatest := secondSpouse.DeathDate.Bounds()
+	firstEarliest, _ := firstSpouse.DeathDate.Bounds()
+	if !secondLatest.IsZero() && !firstEarliest.IsZero() && firstEarliest.After(secondLatest) {
+		return secondSpouse.DeathDate
+	}
+	return firstSpouse.DeathDate
 }
 
-func (useCase *RelationshipUseCase) CreateParentRelation(ctx context.Context, parentID uuid.UUID, childID uuid.UUID) error {
+// CreateParentRelation returns the warnings of the relation rules, the ones
+// with ERROR severity are returned as error. The rules are only checked for
+// biological parents.
+func (useCase *RelationshipUseCase) CreateParentRelation(ctx context.Context, parentID uuid.UUID, childID uuid.UUID, parentage Parentage) ([]RuleViolation, error) {
+	return runInTx(ctx, useCase.familyTreeRepo, SessionWrite, func(ctx context.Context, tx Tx) ([]RuleViolation, error) {
+		return useCase.createParentRelation(ctx, tx, parentID, childID, parentage)
+	})
+}
 
-	newCtx, err := useCase.openSession(ctx, SessionWrite)
-	if err != nil {
-		return err
+func (useCase *RelationshipUseCase) createParentRelation(ctx context.Context, tx Tx, parentID uuid.UUID, childID uuid.UUID, parentage Parentage) ([]RuleViolation, error) {
+	if err := useCase.familyTreeRepo.LockPeople(ctx, tx, parentID, childID); err != nil {
+		return nil, err
 	}
-	ctx = newCtx
-	defer useCase.familyTreeRepo.CloseSession(ctx)
-	parent, err := useCase.familyTreeRepo.GetPerson(ctx, parentID)
+	parent, err := useCase.familyTreeRepo.GetPerson(ctx, tx, parentID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if parent == nil {
-		return ErrPersonNotFound
+		return nil, ErrPersonNotFound
 	}
-	child, err := useCase.familyTreeRepo.GetPerson(ctx, childID)
+	child, err := useCase.familyTreeRepo.GetPerson(ctx, tx, childID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if child == nil {
-		return ErrPersonNotFound
+		return nil, ErrPersonNotFound
 	}
 
-	if err := useCase.validateCreateChildRelation(ctx, parent, child); err != nil {
-		return err
+	parentage, err = parentage.Normalize()
+	if err != nil {
+		return nil, err
+	}
+	if err := useCase.validateCreateChildRelation(ctx, tx, parent, child, parentage); err != nil {
+		return nil, err
+	}
+	var warnings []RuleViolation
+	if parentage.IsBiological() {
+		warnings, err = useCase.rules.ValidateParent(*parent, *child)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	err = useCase.familyTreeRepo.SaveRelation(ctx, PersonRelation{
+	relation := PersonRelation{
 		Top:          *parent,
 		Bottom:       *child,
 		RelationType: RelationTypeParent,
-	})
-	return err
+		Parentage:    parentage,
+	}
+	if err := useCase.familyTreeRepo.SaveRelation(ctx, tx, relation); err != nil {
+		return nil, err
+	}
+	err = recordAudit(ctx, useCase.auditSink, tx, AuditOperationCreateParentRelation, AuditState{}, AuditState{Relations: []PersonRelation{relation}})
+	if err != nil {
+		return nil, err
+	}
+	return warnings, nil
 }
 
-func (useCase *RelationshipUseCase) CreateSpouseRelation(ctx context.Context, firstSpouseID uuid.UUID, secondSpouseID uuid.UUID) error {
+// CreateSpouseRelation returns the warnings of the relation rules, as
+// CreateParentRelation does. A person may have any number of ended unions but
+// only one ongoing union.
+func (useCase *RelationshipUseCase) CreateSpouseRelation(ctx context.Context, firstSpouseID uuid.UUID, secondSpouseID uuid.UUID, union Union) ([]RuleViolation, error) {
+	return runInTx(ctx, useCase.familyTreeRepo, SessionWrite, func(ctx context.Context, tx Tx) ([]RuleViolation, error) {
+		return useCase.createSpouseRelation(ctx, tx, firstSpouseID, secondSpouseID, union)
+	})
+}
 
-	newCtx, err := useCase.openSession(ctx, SessionWrite)
-	if err != nil {
-		return err
+func (useCase *RelationshipUseCase) createSpouseRelation(ctx context.Context, tx Tx, firstSpouseID uuid.UUID, secondSpouseID uuid.UUID, union Union) ([]RuleViolation, error) {
+	if err := useCase.familyTreeRepo.LockPeople(ctx, tx, firstSpouseID, secondSpouseID); err != nil {
+		return nil, err
 	}
-	ctx = newCtx
-	defer useCase.familyTreeRepo.CloseSession(ctx)
-	firstSpouse, err := useCase.familyTreeRepo.GetPerson(ctx, firstSpouseID)
+	firstSpouse, err := useCase.familyTreeRepo.GetPerson(ctx, tx, firstSpouseID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if firstSpouse == nil {
-		return ErrPersonNotFound
+		return nil, ErrPersonNotFound
 	}
-	secondSpouse, err := useCase.familyTreeRepo.GetPerson(ctx, secondSpouseID)
+	secondSpouse, err := useCase.familyTreeRepo.GetPerson(ctx, tx, secondSpouseID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if secondSpouse == nil {
-		return ErrPersonNotFound
+		return nil, ErrPersonNotFound
 	}
 
-	if err := useCase.validateCreateSpouseRelation(ctx, firstSpouse, secondSpouse); err != nil {
-		return err
+	if err := useCase.normalizeUnion(&union, firstSpouse, secondSpouse); err != nil {
+		return nil, err
+	}
+	if err := useCase.validateCreateSpouseRelation(ctx, tx, firstSpouse, secondSpouse, union); err != nil {
+		return nil, err
+	}
+	warnings, err := useCase.rules.ValidateSpouse(*firstSpouse, *secondSpouse)
+	if err != nil {
+		return nil, err
 	}
 
-	err = useCase.familyTreeRepo.SaveRelation(ctx, PersonRelation{
+	relation := PersonRelation{
 		Top:          *firstSpouse,
 		Bottom:       *secondSpouse,
 		RelationType: RelationTypeSpouse,
+		Union:        union,
+	}
+	if err := useCase.familyTreeRepo.SaveRelation(ctx, tx, relation); err != nil {
+		return nil, err
+	}
+	err = recordAudit(ctx, useCase.auditSink, tx, AuditOperationCreateSpouseRelation, AuditState{}, AuditState{Relations: []PersonRelation{relation}})
+	if err != nil {
+		return nil, err
+	}
+	return warnings, nil
+}
+
+// UpdateSpouseRelation updates the union of the spouses starting on startDate,
+// which may be nil when they only have one union.
+func (useCase *RelationshipUseCase) UpdateSpouseRelation(ctx context.Context, firstSpouseID uuid.UUID, secondSpouseID uuid.UUID, startDate *Date, update UnionUpdate) (*Union, error) {
+	return runInTx(ctx, useCase.familyTreeRepo, SessionWrite, func(ctx context.Context, tx Tx) (*Union, error) {
+		return useCase.updateSpouseRelation(ctx, tx, firstSpouseID, secondSpouseID, startDate, update)
 	})
-	return err
 }
 
-func (useCase *RelationshipUseCase) GetFamilyTree(ctx context.Context, personID uuid.UUID) (*FamilyTree, error) {
-	newCtx, err := useCase.openSession(ctx, SessionRead)
+func (useCase *RelationshipUseCase) updateSpouseRelation(ctx context.Context, tx Tx, firstSpouseID uuid.UUID, secondSpouseID uuid.UUID, startDate *Date, update UnionUpdate) (*Union, error) {
+	if err := useCase.familyTreeRepo.LockPeople(ctx, tx, firstSpouseID, secondSpouseID); err != nil {
+		return nil, err
+	}
+	firstSpouse, err := useCase.familyTreeRepo.GetPerson(ctx, tx, firstSpouseID)
 	if err != nil {
 		return nil, err
 	}
-	ctx = newCtx
-	defer useCase.familyTreeRepo.CloseSession(ctx)
-
-	person, err := useCase.familyTreeRepo.GetPerson(ctx, personID)
+	if firstSpouse == nil {
+		return nil, ErrPersonNotFound
+	}
+	secondSpouse, err := useCase.familyTreeRepo.GetPerson(ctx, tx, secondSpouseID)
 	if err != nil {
 		return nil, err
 	}
-	if person == nil {
+	if secondSpouse == nil {
 		return nil, ErrPersonNotFound
 	}
+	current, err := useCase.findUnion(ctx, tx, firstSpouse, secondSpouse, startDate)
+	if err != nil {
+		return nil, err
+	}
+
+	before := PersonRelation{Top: *firstSpouse, Bottom: *secondSpouse, RelationType: RelationTypeSpouse, Union: *current}
+	union := *current
+	update.Apply(&union)
+	if err := useCase.normalizeUnion(&union, firstSpouse, secondSpouse); err != nil {
+		return nil, err
+	}
+	if err := useCase.validateOngoingUnions(ctx, tx, firstSpouse, secondSpouse, union, current); err != nil {
+		return nil, err
+	}
+	if err := useCase.validateOngoingUnions(ctx, tx, secondSpouse, firstSpouse, union, current); err != nil {
+		return nil, err
+	}
+	ok, err := useCase.familyTreeRepo.UpdateUnion(ctx, tx, *firstSpouse, *secondSpouse, current.StartDate, union)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrRelationNotFound
+	}
+	after := before
+	after.Union = union
+	err = recordAudit(ctx, useCase.auditSink, tx, AuditOperationUpdateSpouseRelation, AuditState{Relations: []PersonRelation{before}}, AuditState{Relations: []PersonRelation{after}})
+	if err != nil {
+		return nil, err
+	}
+	return &union, nil
+}
 
-	return useCase.familyTreeRepo.GetFamilyTree(ctx, *person)
+// GetUnions returns every union of the person sorted by start date, unions
+// with unknown start come last.
+func (useCase *RelationshipUseCase) GetUnions(ctx context.Context, personID uuid.UUID) ([]PersonUnion, error) {
+	return runInTx(ctx, useCase.familyTreeRepo, SessionRead, func(ctx context.Context, tx Tx) ([]PersonUnion, error) {
+		person, err := useCase.familyTreeRepo.GetPerson(ctx, tx, personID)
+		if err != nil {
+			return nil, err
+		}
+		if person == nil {
+			return nil, ErrPersonNotFound
+		}
+		unions, err := useCase.familyTreeRepo.GetUnions(ctx, tx, *person)
+		if err != nil {
+			return nil, err
+		}
+		sort.SliceStable(unions, func(i, j int) bool {
+			first, _ := unions[i].Union.StartDate.Bounds()
+			second, _ := unions[j].Union.StartDate.Bounds()
+			if first.IsZero() || second.IsZero() {
+				return !first.IsZero() && second.IsZero()
+			}
+			if !first.Equal(second) {
+				return first.Before(second)
+			}
+			return unions[i].Spouse.Name < unions[j].Spouse.Name
+		})
+		return unions, nil
+	})
 }
 
-func (useCase *RelationshipUseCase) DeleteParentRelation(ctx context.Context, parentID uuid.UUID, childID uuid.UUID) error {
-	newCtx, err := useCase.openSession(ctx, SessionWrite)
+// GetKinship names what the second person is to the first one. Blood kinships
+// come first, then unions and then the in-laws through ongoing unions of
+// either person.
+func (useCase *RelationshipUseCase) GetKinship(ctx context.Context, firstPersonID uuid.UUID, secondPersonID uuid.UUID) (*Kinship, error) {
+	return runInTx(ctx, useCase.familyTreeRepo, SessionRead, func(ctx context.Context, tx Tx) (*Kinship, error) {
+		firstPerson, err := useCase.familyTreeRepo.GetPerson(ctx, tx, firstPersonID)
+		if err != nil {
+			return nil, err
+		}
+		if firstPerson == nil {
+			return nil, ErrPersonNotFound
+		}
+		secondPerson, err := useCase.familyTreeRepo.GetPerson(ctx, tx, secondPersonID)
+		if err != nil {
+			return nil, err
+		}
+		if secondPerson == nil {
+			return nil, ErrPersonNotFound
+		}
+		kinship, err := useCase.getKinship(ctx, tx, *firstPerson, *secondPerson)
+		if err != nil {
+			return nil, err
+		}
+		if kinship == nil {
+			return nil, fmt.Errorf("%w: %s and %s", ErrNotRelated, firstPerson.ID, secondPerson.ID)
+		}
+		kinship.setNames(*secondPerson)
+		return kinship, nil
+	})
+}
+
+func (useCase *RelationshipUseCase) getKinship(ctx context.Context, tx Tx, firstPerson Person, secondPerson Person) (*Kinship, error) {
+	kinship, err := useCase.getBloodKinship(ctx, tx, firstPerson, secondPerson)
+	if err != nil || kinship != nil {
+		return kinship, err
+	}
+	firstUnions, err := useCase.familyTreeRepo.GetUnions(ctx, tx, firstPerson)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	// Spouses who married again are only former spouses when every union of
+	// them was dissolved
+	var spouses *Kinship
+	for _, union := range firstUnions {
+		if union.Spouse.ID != secondPerson.ID {
+			continue
+		}
+		if spouses == nil {
+			spouses = &Kinship{Type: KinshipSpouse, CommonAncestors: []Person{}, Former: true}
+		}
+		spouses.Former = spouses.Former && formerUnion(union.Union)
+	}
+	if spouses != nil {
+		return spouses, nil
+	}
+	secondUnions, err := useCase.familyTreeRepo.GetUnions(ctx, tx, secondPerson)
+	if err != nil {
+		return nil, err
+	}
+	for _, union := range firstUnions {
+		if formerUnion(union.Union) {
+			continue
+		}
+		inLaw, err := useCase.getBloodKinship(ctx, tx, union.Spouse, secondPerson)
+		if err != nil {
+			return nil, err
+		}
+		if inLaw.closer(kinship) {
+			inLaw.Type = KinshipInLaw
+			inLaw.InLaw = &InLaw{Side: InLawSpouseRelative, Link: union.Spouse}
+			kinship = inLaw
+		}
+	}
+	for _, union := range secondUnions {
+		if formerUnion(union.Union) {
+			continue
+		}
+		inLaw, err := useCase.getBloodKinship(ctx, tx, firstPerson, union.Spouse)
+		if err != nil {
+			return nil, err
+		}
+		if inLaw.closer(kinship) {
+			inLaw.Type = KinshipInLaw
+			inLaw.InLaw = &InLaw{Side: InLawRelativeSpouse, Link: union.Spouse}
+			kinship = inLaw
+		}
 	}
-	ctx = newCtx
-	defer useCase.familyTreeRepo.CloseSession(ctx)
+	return kinship, nil
+}
 
-	parent, err := useCase.familyTreeRepo.GetPerson(ctx, parentID)
+func (useCase *RelationshipUseCase) getBloodKinship(ctx context.Context, tx Tx, firstPerson Person, secondPerson Person) (*Kinship, error) {
+	ancestors, err := useCase.familyTreeRepo.GetCommonAncestors(ctx, tx, firstPerson, secondPerson)
+	if err != nil {
+		return nil, err
+	}
+	return closestKinship(ancestors), nil
+}
+
+func (useCase *RelationshipUseCase) GetFamilyTree(ctx context.Context, personID uuid.UUID) (*FamilyTree, error) {
+	return runInTx(ctx, useCase.familyTreeRepo, SessionRead, func(ctx context.Context, tx Tx) (*FamilyTree, error) {
+		person, err := useCase.familyTreeRepo.GetPerson(ctx, tx, personID)
+		if err != nil {
+			return nil, err
+		}
+		if person == nil {
+			return nil, ErrPersonNotFound
+		}
+
+		return useCase.familyTreeRepo.GetFamilyTree(ctx, tx, *person)
+	})
+}
+
+func (useCase *RelationshipUseCase) descendantsDepthValidate(depth int) int {
+	if depth <= 0 {
+		return DescendantsDefaultDepth
+	}
+	if depth > DescendantsMaxDepth {
+		return DescendantsMaxDepth
+	}
+	return depth
+}
+
+// GetDescendants builds the d'Aboville numbered descendants report of the
+// person, with the unions of every descendant when includeSpouses is set.
+func (useCase *RelationshipUseCase) GetDescendants(ctx context.Context, personID uuid.UUID, depth int, includeSpouses bool) (*Descendants, error) {
+	return runInTx(ctx, useCase.familyTreeRepo, SessionRead, func(ctx context.Context, tx Tx) (*Descendants, error) {
+		person, err := useCase.familyTreeRepo.GetPerson(ctx, tx, personID)
+		if err != nil {
+			return nil, err
+		}
+		if person == nil {
+			return nil, ErrPersonNotFound
+		}
+
+		depth = useCase.descendantsDepthValidate(depth)
+		children, err := useCase.familyTreeRepo.GetDescendants(ctx, tx, *person, depth)
+		if err != nil {
+			return nil, err
+		}
+		descendants := numberDescendants(*person, depth, children)
+		if !includeSpouses {
+			return descendants, nil
+		}
+		for i, descendant := range descendants.Descendants {
+			if descendant.SameAs != "" {
+				continue
+			}
+			unions, err := useCase.familyTreeRepo.GetUnions(ctx, tx, descendant.Person)
+			if err != nil {
+				return nil, err
+			}
+			descendants.Descendants[i].Spouses = unions
+		}
+		return descendants, nil
+	})
+}
+
+func (useCase *RelationshipUseCase) pedigreeGenerationsValidate(generations int) int {
+	if generations <= 0 {
+		return PedigreeDefaultGenerations
+	}
+	if generations > PedigreeMaxGenerations {
+		return PedigreeMaxGenerations
+	}
+	return generations
+}
+
+// GetPedigree builds the Ahnentafel numbered pedigree of the person, only the
+// biological lineage is followed.
+func (useCase *RelationshipUseCase) GetPedigree(ctx context.Context, personID uuid.UUID, generations int) (*Pedigree, error) {
+	return runInTx(ctx, useCase.familyTreeRepo, SessionRead, func(ctx context.Context, tx Tx) (*Pedigree, error) {
+		person, err := useCase.familyTreeRepo.GetPerson(ctx, tx, personID)
+		if err != nil {
+			return nil, err
+		}
+		if person == nil {
+			return nil, ErrPersonNotFound
+		}
+
+		generations = useCase.pedigreeGenerationsValidate(generations)
+		parents, err := useCase.familyTreeRepo.GetAncestors(ctx, tx, *person, generations)
+		if err != nil {
+			return nil, err
+		}
+		return numberAncestors(*person, generations, parents), nil
+	})
+}
+
+// GetRelatedness computes Wright's coefficient of relationship between the
+// people from every path through their common biological ancestors.
+func (useCase *RelationshipUseCase) GetRelatedness(ctx context.Context, firstPersonID uuid.UUID, secondPersonID uuid.UUID) (*Relatedness, error) {
+	return runInTx(ctx, useCase.familyTreeRepo, SessionRead, func(ctx context.Context, tx Tx) (*Relatedness, error) {
+		firstPerson, err := useCase.familyTreeRepo.GetPerson(ctx, tx, firstPersonID)
+		if err != nil {
+			return nil, err
+		}
+		if firstPerson == nil {
+			return nil, ErrPersonNotFound
+		}
+		secondPerson, err := useCase.familyTreeRepo.GetPerson(ctx, tx, secondPersonID)
+		if err != nil {
+			return nil, err
+		}
+		if secondPerson == nil {
+			return nil, ErrPersonNotFound
+		}
+		graph, err := useCase.getRelatednessGraph(ctx, tx, *firstPerson, *secondPerson)
+		if err != nil {
+			return nil, err
+		}
+		return graph.relatedness(firstPerson.ID, secondPerson.ID), nil
+	})
+}
+
+// GetInbreeding computes the inbreeding coefficient of the person from the
+// common biological ancestors of its parents.
+func (useCase *RelationshipUseCase) GetInbreeding(ctx context.Context, personID uuid.UUID) (*Inbreeding, error) {
+	return runInTx(ctx, useCase.familyTreeRepo, SessionRead, func(ctx context.Context, tx Tx) (*Inbreeding, error) {
+		person, err := useCase.familyTreeRepo.GetPerson(ctx, tx, personID)
+		if err != nil {
+			return nil, err
+		}
+		if person == nil {
+			return nil, ErrPersonNotFound
+		}
+		graph, err := useCase.getRelatednessGraph(ctx, tx, *person)
+		if err != nil {
+			return nil, err
+		}
+		return graph.inbreedingFor(person.ID), nil
+	})
+}
+
+func (useCase *RelationshipUseCase) DeleteParentRelation(ctx context.Context, parentID uuid.UUID, childID uuid.UUID) error {
+	return RunInTx(ctx, useCase.familyTreeRepo, SessionWrite, func(ctx context.Context, tx Tx) error {
+		return useCase.deleteParentRelation(ctx, tx, parentID, childID)
+	})
+}
+
+func (useCase *RelationshipUseCase) deleteParentRelation(ctx context.Context, tx Tx, parentID uuid.UUID, childID uuid.UUID) error {
+	if err := useCase.familyTreeRepo.LockPeople(ctx, tx, parentID, childID); err != nil {
+		return err
+	}
+	parent, err := useCase.familyTreeRepo.GetPerson(ctx, tx, parentID)
 	if err != nil {
 		return err
 	}
 	if parent == nil {
 		return ErrPersonNotFound
 	}
-	child, err := useCase.familyTreeRepo.GetPerson(ctx, childID)
+	child, err := useCase.familyTreeRepo.GetPerson(ctx, tx, childID)
 	if err != nil {
 		return err
 	}
 	if child == nil {
 		return ErrPersonNotFound
 	}
-	count, err := useCase.familyTreeRepo.GetParentMaritalChildCount(ctx, *child)
+	count, err := useCase.familyTreeRepo.GetParentMaritalChildCount(ctx, tx, *child)
 	if err != nil {
 		return err
 	}
 	if count == 1 {
 		return ErrOnlyChildFromSpouseCouple
 	}
-	ok, err := useCase.familyTreeRepo.DeleteRelationship(ctx, *parent, *child, RelationTypeParent)
+	parents, err := useCase.familyTreeRepo.GetParents(ctx, tx, child.ID)
+	if err != nil {
+		return err
+	}
+	relation := PersonRelation{Top: *parent, Bottom: *child, RelationType: RelationTypeParent}
+	for _, childParent := range parents {
+		if childParent.Parent.ID == parent.ID {
+			relation.Parentage = childParent.Parentage
+		}
+	}
+	ok, err := useCase.familyTreeRepo.DeleteRelationship(ctx, tx, *parent, *child, RelationTypeParent)
 	if err != nil {
 		return err
 	}
 	if !ok {
 		return ErrRelationNotFound
 	}
-	return nil
+	return recordAudit(ctx, useCase.auditSink, tx, AuditOperationDeleteParentRelation, AuditState{Relations: []PersonRelation{relation}}, AuditState{})
 }
 
-func (useCase *RelationshipUseCase) DeleteSpouseRelation(ctx context.Context, firstSpouseID uuid.UUID, secondSpouseID uuid.UUID) error {
-	newCtx, err := useCase.openSession(ctx, SessionWrite)
-	if err != nil {
+// DeleteSpouseRelation deletes the union of the spouses starting on
+// startDate, which may be nil when they only have one union.
+func (useCase *RelationshipUseCase) DeleteSpouseRelation(ctx context.Context, firstSpouseID uuid.UUID, secondSpouseID uuid.UUID, startDate *Date) error {
+	return RunInTx(ctx, useCase.familyTreeRepo, SessionWrite, func(ctx context.Context, tx Tx) error {
+		return useCase.deleteSpouseRelation(ctx, tx, firstSpouseID, secondSpouseID, startDate)
+	})
+}
+
+func (useCase *RelationshipUseCase) deleteSpouseRelation(ctx context.Context, tx Tx, firstSpouseID uuid.UUID, secondSpouseID uuid.UUID, startDate *Date) error {
+	if err := useCase.familyTreeRepo.LockPeople(ctx, tx, firstSpouseID, secondSpouseID); err != nil {
 		return err
 	}
-	ctx = newCtx
-	defer useCase.familyTreeRepo.CloseSession(ctx)
-
-	firstSpouse, err := useCase.familyTreeRepo.GetPerson(ctx, firstSpouseID)
+	firstSpouse, err := useCase.familyTreeRepo.GetPerson(ctx, tx, firstSpouseID)
 	if err != nil {
 		return err
 	}
 	if firstSpouse == nil {
 		return ErrPersonNotFound
 	}
-	secondSpouse, err := useCase.familyTreeRepo.GetPerson(ctx, secondSpouseID)
+	secondSpouse, err := useCase.familyTreeRepo.GetPerson(ctx, tx, secondSpouseID)
 	if err != nil {
 		return err
 	}
 	if secondSpouse == nil {
 		return ErrPersonNotFound
 	}
-	ok, err := useCase.familyTreeRepo.DeleteRelationship(ctx, *firstSpouse, *secondSpouse, RelationTypeSpouse)
+	union, err := useCase.findUnion(ctx, tx, firstSpouse, secondSpouse, startDate)
+	if err != nil {
+		return err
+	}
+	relation := PersonRelation{Top: *firstSpouse, Bottom: *secondSpouse, RelationType: RelationTypeSpouse, Union: *union}
+	ok, err := useCase.familyTreeRepo.DeleteUnion(ctx, tx, *firstSpouse, *secondSpouse, union.StartDate)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrRelationNotFound
+	}
+	return recordAudit(ctx, useCase.auditSink, tx, AuditOperationDeleteSpouseRelation, AuditState{Relations: []PersonRelation{relation}}, AuditState{})
+}
+
+// MergePeople folds the duplicate into the survivor, whose empty attributes
+// are filled with the ones of the duplicate. Every relation of the duplicate
+// is moved to the survivor through the checks of its creation, parents first,
+// then children and then spouses, who need the children moved to have one in
+// common with the survivor. The relation rules then run on every relation of
+// the merged person. When anything is refused nothing changes and the
+// conflicts are returned as MergeConflictError. The duplicate goes to the
+// trash with its former relations and its ID redirects to the survivor.
+func (useCase *RelationshipUseCase) MergePeople(ctx context.Context, survivorID uuid.UUID, duplicateID uuid.UUID) (*MergeResult, error) {
+	return runInTx(ctx, useCase.familyTreeRepo, SessionWrite, func(ctx context.Context, tx Tx) (*MergeResult, error) {
+		return useCase.mergePeople(ctx, tx, survivorID, duplicateID)
+	})
+}
+
+func (useCase *RelationshipUseCase) mergePeople(ctx context.Context, tx Tx, survivorID uuid.UUID, duplicateID uuid.UUID) (*MergeResult, error) {
+	if survivorID == duplicateID {
+		return nil, ErrSameMergeID
+	}
+	if err := useCase.familyTreeRepo.LockPeople(ctx, tx, survivorID, duplicateID); err != nil {
+		return nil, err
+	}
+	survivor, err := useCase.familyTreeRepo.GetPerson(ctx, tx, survivorID)
+	if err != nil {
+		return nil, err
+	}
+	if survivor == nil {
+		return nil, ErrPersonNotFound
+	}
+	duplicate, err := useCase.familyTreeRepo.GetPerson(ctx, tx, duplicateID)
+	if err != nil {
+		return nil, err
+	}
+	if duplicate == nil {
+		return nil, ErrPersonNotFound
+	}
+	parents, err := useCase.familyTreeRepo.GetParents(ctx, tx, duplicate.ID)
+	if err != nil {
+		return nil, err
+	}
+	children, err := useCase.familyTreeRepo.GetDescendants(ctx, tx, *duplicate, 1)
+	if err != nil {
+		return nil, err
+	}
+	unions, err := useCase.familyTreeRepo.GetUnions(ctx, tx, *duplicate)
+	if err != nil {
+		return nil, err
+	}
+	// The relations of the duplicate can't change while it's locked, so its
+	// counterparts are all locked at once before checking the moved relations
+	counterpartIDs := []uuid.UUID{}
+	for _, parent := range parents {
+		counterpartIDs = append(counterpartIDs, parent.Parent.ID)
+	}
+	for _, child := range children {
+		counterpartIDs = append(counterpartIDs, child.Child.ID)
+	}
+	for _, union := range unions {
+		counterpartIDs = append(counterpartIDs, union.Spouse.ID)
+	}
+	if err := useCase.familyTreeRepo.LockPeople(ctx, tx, counterpartIDs...); err != nil {
+		return nil, err
+	}
+	if err := useCase.familyTreeRepo.DeletePersonRelations(ctx, tx, *duplicate); err != nil {
+		return nil, err
+	}
+	before := AuditState{People: []Person{*survivor, *duplicate}, Relations: []PersonRelation{}}
+	for _, parent := range parents {
+		before.Relations = append(before.Relations, PersonRelation{Top: parent.Parent, Bottom: *duplicate, RelationType: RelationTypeParent, Parentage: parent.Parentage})
+	}
+	for _, child := range children {
+		before.Relations = append(before.Relations, PersonRelation{Top: *duplicate, Bottom: child.Child, RelationType: RelationTypeParent, Parentage: child.Parentage})
+	}
+	for _, union := range unions {
+		before.Relations = append(before.Relations, PersonRelation{Top: *duplicate, Bottom: union.Spouse, RelationType: RelationTypeSpouse, Union: union.Union})
+	}
+	mergeAttributes(survivor, *duplicate)
+
+	result := &MergeResult{Warnings: []RuleViolation{}}
+	conflicts := []MergeConflict{}
+	move := func(relationType RelationType, other Person, moveRelation func() (bool, error)) error {
+		if other.ID == survivor.ID {
+			conflicts = append(conflicts, MergeConflict{RelationType: relationType, Person: other, Err: ErrMergedSelfRelation})
+			return nil
+		}
+		moved, err := moveRelation()
+		if isMergeConflict(err) {
+			conflicts = append(conflicts, MergeConflict{RelationType: relationType, Person: other, Err: err})
+			return nil
+		}
+		if moved {
+			result.Moved++
+		}
+		return err
+	}
+	for _, parent := range parents {
+		parent := parent
+		err := move(RelationTypeParent, parent.Parent, func() (bool, error) {
+			return useCase.moveParentRelation(ctx, tx, parent.Parent, *survivor, parent.Parentage)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, child := range children {
+		child := child
+		err := move(RelationTypeParent, child.Child, func() (bool, error) {
+			return useCase.moveParentRelation(ctx, tx, *survivor, child.Child, child.Parentage)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, union := range unions {
+		union := union
+		err := move(RelationTypeSpouse, union.Spouse, func() (bool, error) {
+			return useCase.moveSpouseRelation(ctx, tx, *survivor, union.Spouse, union.Union)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(conflicts) == 0 {
+		result.Warnings, conflicts, err = useCase.validateMergedRules(ctx, tx, *survivor)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(conflicts) > 0 {
+		return nil, &MergeConflictError{Conflicts: conflicts}
+	}
+
+	if err := useCase.familyTreeRepo.UpdatePerson(ctx, tx, survivor); err != nil {
+		return nil, err
+	}
+	if err := useCase.familyTreeRepo.DeletePerson(ctx, tx, *duplicate); err != nil {
+		return nil, err
+	}
+	if err := useCase.familyTreeRepo.SaveRedirect(ctx, tx, duplicate.ID, survivor.ID); err != nil {
+		return nil, err
+	}
+	err = recordAudit(ctx, useCase.auditSink, tx, AuditOperationMergePeople, before, AuditState{People: []Person{*survivor}})
+	if err != nil {
+		return nil, err
+	}
+	result.Person = *survivor
+	return result, nil
+}
+
+// RestorePerson takes the person out of the trash along with the relations
+// deleted with it, through the checks of their creation, parents first, then
+// children and then spouses, who need the children to have one in common.
+// Relations with people still in the trash stay there. It returns the person
+// with the restored relations.
+func (useCase *RelationshipUseCase) RestorePerson(ctx context.Context, personID uuid.UUID) (*TrashedPerson, error) {
+	return runInTx(ctx, useCase.familyTreeRepo, SessionWrite, func(ctx context.Context, tx Tx) (*TrashedPerson, error) {
+		return useCase.restorePerson(ctx, tx, personID)
+	})
+}
+
+func (useCase *RelationshipUseCase) restorePerson(ctx context.Context, tx Tx, personID uuid.UUID) (*TrashedPerson, error) {
+	if err := useCase.familyTreeRepo.LockPeople(ctx, tx, personID); err != nil {
+		return nil, err
+	}
+	trash, err := useCase.familyTreeRepo.GetTrash(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	trashed, ok := trash.person(personID)
+	if !ok {
+		return nil, ErrPersonNotFound
+	}
+	if _, err := useCase.familyTreeRepo.RestorePerson(ctx, tx, personID); err != nil {
+		return nil, err
+	}
+
+	restored := &TrashedPerson{Person: trashed.Person, DeletedAt: trashed.DeletedAt, Relations: []PersonRelation{}}
+	order := func(relation PersonRelation) int {
+		switch {
+		case relation.RelationType == RelationTypeParent && relation.Bottom.ID == personID:
+			return 0
+		case relation.RelationType == RelationTypeParent:
+			return 1
+		default:
+			return 2
+		}
+	}
+	sort.SliceStable(trashed.Relations, func(i, j int) bool {
+		return order(trashed.Relations[i]) < order(trashed.Relations[j])
+	})
+	for _, relation := range trashed.Relations {
+		other := relation.Top
+		if other.ID == personID {
+			other = relation.Bottom
+		}
+		found, err := useCase.familyTreeRepo.GetPerson(ctx, tx, other.ID)
+		if err != nil {
+			return nil, err
+		}
+		if found == nil {
+			continue
+		}
+		if err := useCase.restoreRelation(ctx, tx, relation); err != nil {
+			return nil, err
+		}
+		restored.Relations = append(restored.Relations, relation)
+	}
+	err = recordAudit(ctx, useCase.auditSink, tx, AuditOperationRestorePerson, AuditState{}, AuditState{People: []Person{restored.Person}, Relations: restored.Relations})
+	if err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
+
+func (useCase *RelationshipUseCase) RestoreParentRelation(ctx context.Context, parentID uuid.UUID, childID uuid.UUID) error {
+	return RunInTx(ctx, useCase.familyTreeRepo, SessionWrite, func(ctx context.Context, tx Tx) error {
+		return useCase.restoreTrashedRelation(ctx, tx, RelationTypeParent, parentID, childID)
+	})
+}
+
+func (useCase *RelationshipUseCase) RestoreSpouseRelation(ctx context.Context, firstSpouseID uuid.UUID, secondSpouseID uuid.UUID) error {
+	return RunInTx(ctx, useCase.familyTreeRepo, SessionWrite, func(ctx context.Context, tx Tx) error {
+		return useCase.restoreTrashedRelation(ctx, tx, RelationTypeSpouse, firstSpouseID, secondSpouseID)
+	})
+}
+
+func (useCase *RelationshipUseCase) restoreTrashedRelation(ctx context.Context, tx Tx, relationType RelationType, firstID uuid.UUID, secondID uuid.UUID) error {
+	if err := useCase.familyTreeRepo.LockPeople(ctx, tx, firstID, secondID); err != nil {
+		return err
+	}
+	trash, err := useCase.familyTreeRepo.GetTrash(ctx, tx)
+	if err != nil {
+		return err
+	}
+	relation, ok := trash.relation(relationType, firstID, secondID)
+	if !ok {
+		return ErrRelationNotFound
+	}
+	if err := useCase.restoreRelation(ctx, tx, relation); err != nil {
+		return err
+	}
+	operation := AuditOperationRestoreSpouseRelation
+	if relationType == RelationTypeParent {
+		operation = AuditOperationRestoreParentRelation
+	}
+	return recordAudit(ctx, useCase.auditSink, tx, operation, AuditState{}, AuditState{Relations: []PersonRelation{relation}})
+}
+
+// restoreRelation takes the relation out of the trash through the checks of
+// its creation, on the current state of its people.
+func (useCase *RelationshipUseCase) restoreRelation(ctx context.Context, tx Tx, relation PersonRelation) error {
+	if err := useCase.familyTreeRepo.LockPeople(ctx, tx, relation.Top.ID, relation.Bottom.ID); err != nil {
+		return err
+	}
+	top, err := useCase.familyTreeRepo.GetPerson(ctx, tx, relation.Top.ID)
+	if err != nil {
+		return err
+	}
+	if top == nil {
+		return ErrPersonNotFound
+	}
+	bottom, err := useCase.familyTreeRepo.GetPerson(ctx, tx, relation.Bottom.ID)
+	if err != nil {
+		return err
+	}
+	if bottom == nil {
+		return ErrPersonNotFound
+	}
+	if relation.RelationType == RelationTypeParent {
+		err = useCase.validateCreateChildRelation(ctx, tx, top, bottom, relation.Parentage)
+	} else {
+		err = useCase.validateCreateSpouseRelation(ctx, tx, top, bottom, relation.Union)
+	}
+	if err != nil {
+		return err
+	}
+	ok, err := useCase.familyTreeRepo.RestoreRelationship(ctx, tx, *top, *bottom, relation.RelationType)
 	if err != nil {
 		return err
 	}
@@ -246,3 +1029,120 @@ func (useCase *RelationshipUseCase) DeleteSpouseRelation(ctx context.Context, fi
 	}
 	return nil
 }
+
+// isMergeConflict tells if the error refuses a moved relation, any other error
+// aborts the merge.
+func isMergeConflict(err error) bool {
+	for _, conflict := range []error{ErrMaxParents, ErrSameParentChildID, ErrIncestuousRelation, ErrCoupleHasNoChild, ErrHasSpouseAlready, ErrUnionEndsBeforeStart} {
+		if errors.Is(err, conflict) {
+			return true
+		}
+	}
+	return false
+}
+
+// moveParentRelation saves a PARENT relation of the duplicate on the
+// survivor, it returns false when the survivor has the relation already.
+func (useCase *RelationshipUseCase) moveParentRelation(ctx context.Context, tx Tx, parent Person, child Person, parentage Parentage) (bool, error) {
+	if err := useCase.familyTreeRepo.LockPeople(ctx, tx, parent.ID, child.ID); err != nil {
+		return false, err
+	}
+	parents, err := useCase.familyTreeRepo.GetParents(ctx, tx, child.ID)
+	if err != nil {
+		return false, err
+	}
+	for _, currentParent := range parents {
+		if currentParent.Parent.ID == parent.ID {
+			return false, nil
+		}
+	}
+	parentage, err = parentage.Normalize()
+	if err != nil {
+		return false, err
+	}
+	if err := useCase.validateCreateChildRelation(ctx, tx, &parent, &child, parentage); err != nil {
+		return false, err
+	}
+	err = useCase.familyTreeRepo.SaveRelation(ctx, tx, PersonRelation{
+		Top:          parent,
+		Bottom:       child,
+		RelationType: RelationTypeParent,
+		Parentage:    parentage,
+	})
+	return err == nil, err
+}
+
+// moveSpouseRelation saves a SPOUSE relation of the duplicate on the
+// survivor, as moveParentRelation does. The union of the survivor is kept when
+// it has one with the spouse overlapping the moved one already.
+func (useCase *RelationshipUseCase) moveSpouseRelation(ctx context.Context, tx Tx, survivor Person, spouse Person, union Union) (bool, error) {
+	if err := useCase.familyTreeRepo.LockPeople(ctx, tx, survivor.ID, spouse.ID); err != nil {
+		return false, err
+	}
+	unions, err := useCase.familyTreeRepo.GetUnions(ctx, tx, survivor)
+	if err != nil {
+		return false, err
+	}
+	if err := useCase.normalizeUnion(&union, &survivor, &spouse); err != nil {
+		return false, err
+	}
+	for _, personUnion := range unions {
+		if personUnion.Spouse.ID == spouse.ID && personUnion.Union.Overlaps(union) {
+			return false, nil
+		}
+	}
+	if err := useCase.validateCreateSpouseRelation(ctx, tx, &survivor, &spouse, union); err != nil {
+		return false, err
+	}
+	err = useCase.familyTreeRepo.SaveRelation(ctx, tx, PersonRelation{
+		Top:          survivor,
+		Bottom:       spouse,
+		RelationType: RelationTypeSpouse,
+		Union:        union,
+	})
+	return err == nil, err
+}
+
+// validateMergedRules runs the relation rules on every relation of the merged
+// person, its attributes may have been filled by the duplicate. The rules
+// failing with ERROR severity are returned as conflicts.
+func (useCase *RelationshipUseCase) validateMergedRules(ctx context.Context, tx Tx, person Person) ([]RuleViolation, []MergeConflict, error) {
+	warnings := []RuleViolation{}
+	conflicts := []MergeConflict{}
+	check := func(relationType RelationType, other Person, ruleWarnings []RuleViolation, err error) {
+		if err != nil {
+			conflicts = append(conflicts, MergeConflict{RelationType: relationType, Person: other, Err: err})
+			return
+		}
+		warnings = append(warnings, ruleWarnings...)
+	}
+	parents, err := useCase.familyTreeRepo.GetParents(ctx, tx, person.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, parent := range parents {
+		if parent.Parentage.IsBiological() {
+			ruleWarnings, err := useCase.rules.ValidateParent(parent.Parent, person)
+			check(RelationTypeParent, parent.Parent, ruleWarnings, err)
+		}
+	}
+	children, err := useCase.familyTreeRepo.GetDescendants(ctx, tx, person, 1)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, child := range children {
+		if child.Parentage.IsBiological() {
+			ruleWarnings, err := useCase.rules.ValidateParent(person, child.Child)
+			check(RelationTypeParent, child.Child, ruleWarnings, err)
+		}
+	}
+	unions, err := useCase.familyTreeRepo.GetUnions(ctx, tx, person)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, union := range unions {
+		ruleWarnings, err := useCase.rules.ValidateSpouse(person, union.Spouse)
+		check(RelationTypeSpouse, union.Spouse, ruleWarnings, err)
+	}
+	return warnings, conflicts, nil
+}
@@ -0,0 +1,256 @@
+package familytree
+
+import (
+	"errors"
+	"fmt"
+)
+
+type RuleSeverity string
+
+const (
+	RuleSeverityOff     = RuleSeverity("OFF")
+	RuleSeverityWarning = RuleSeverity("WARNING")
+	RuleSeverityError   = RuleSeverity("ERROR")
+
+	RuleMinParentAge        = "MIN_PARENT_AGE"
+	RuleMaxParentAge        = "MAX_PARENT_AGE"
+	RulePosthumousBirth     = "POSTHUMOUS_BIRTH"
+	RuleContemporarySpouses = "CONTEMPORARY_SPOUSES"
+
+	DefaultMinParentAge          = 12
+	DefaultMaxParentAge          = 80
+	DefaultPosthumousBirthMonths = 9
+)
+
+var (
+	ErrInvalidRuleSeverity    = errors.New("invalid rule severity")
+	ErrParentTooYoung         = errors.New("parent is too young at the child birth")
+	ErrParentTooOld           = errors.New("parent is too old at the child birth")
+	ErrBornAfterParentDeath   = errors.New("child was born too long after the parent death")
+	ErrSpousesNotContemporary = errors.New("spouses weren't alive at the same time")
+)
+
+func (severity RuleSeverity) Validate() error {
+	switch severity {
+	case RuleSeverityOff, RuleSeverityWarning, RuleSeverityError:
+		return nil
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidRuleSeverity, string(severity))
+	}
+}
+
+// ParentRule checks a new PARENT relation. Validate returns nil when the
+// relation is fine or can't be judged, like when dates are unknown.
+type ParentRule struct {
+	Name     string
+	Severity RuleSeverity
+	Validate func(parent Person, child Person) error
+}
+
+// SpouseRule checks a new SPOUSE relation, as ParentRule does.
+type SpouseRule struct {
+	Name     string
+	Severity RuleSeverity
+	Validate func(firstSpouse Person, secondSpouse Person) error
+}
+
+// IncestRule rejects biological PARENT relations between a parent and a child
+// that are already relatives. They are too close when their coefficient of
+// relationship is above MaxCoefficient and, when MaxGenerations is set, they
+// share an ancestor up to MaxGenerations generations away from both. The zero
+// value rejects any kinship.
+type IncestRule struct {
+	MaxCoefficient float64
+	MaxGenerations int
+}
+
+func (rule IncestRule) Validate(relatedness Relatedness) error {
+	if relatedness.Coefficient <= rule.MaxCoefficient {
+		return nil
+	}
+	if rule.MaxGenerations > 0 {
+		closeAncestor := false
+		for _, ancestor := range relatedness.CommonAncestors {
+			if ancestor.FirstGenerations <= rule.MaxGenerations && ancestor.SecondGenerations <= rule.MaxGenerations {
+				closeAncestor = true
+			}
+		}
+		if !closeAncestor {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w, coefficient of relationship is %.4f", ErrIncestuousRelation, relatedness.Coefficient)
+}
+
+// RelationRules are run on every new relation after the structural checks.
+// Rules with ERROR severity reject the relation, the WARNING ones are
+// returned to the caller and the relation is still created. The incest rule
+// always rejects.
+type RelationRules struct {
+	ParentRules []ParentRule
+	SpouseRules []SpouseRule
+	Incest      IncestRule
+}
+
+// RuleViolation is the error of a rule, it unwraps to the typed error
+// returned by the rule.
+type RuleViolation struct {
+	Rule     string
+	Severity RuleSeverity
+	Err      error
+}
+
+func (violation *RuleViolation) Error() string {
+	return violation.Err.Error()
+}
+
+func (violation *RuleViolation) Unwrap() error {
+	return violation.Err
+}
+
+func DefaultRelationRules() RelationRules {
+	return RelationRules{
+		ParentRules: []ParentRule{
+			MinParentAgeRule(DefaultMinParentAge, RuleSeverityError),
+			MaxParentAgeRule(DefaultMaxParentAge, RuleSeverityWarning),
+			PosthumousBirthRule(DefaultPosthumousBirthMonths, RuleSeverityError),
+		},
+		SpouseRules: []SpouseRule{
+			ContemporarySpousesRule(RuleSeverityError),
+		},
+		Incest: IncestRule{},
+	}
+}
+
+// MinParentAgeRule fails when the parent is surely younger than years at the
+// child birth, which includes parents born after the child.
+func MinParentAgeRule(years int, severity RuleSeverity) ParentRule {
+	return ParentRule{
+		Name:     RuleMinParentAge,
+		Severity: severity,
+		Validate: func(parent Person, child Person) error {
+			parentEarliest, _ := parent.BirthDate.Bounds()
+			_, childLatest := child.BirthDate.Bounds()
+			if parentEarliest.IsZero() || childLatest.IsZero() {
+				return nil
+			}
+			if childLatest.Before(parentEarliest.AddDate(years, 0, 0)) {
+				return fmt.Errorf("%w, parent born on %s and child on %s, minimum age is %d", ErrParentTooYoung, parent.BirthDate, child.BirthDate, years)
+			}
+			return nil
+		},
+	}
+}
+
+// MaxParentAgeRule fails when the parent is surely older than years at the
+// child birth.
+func MaxParentAgeRule(years int, severity RuleSeverity) ParentRule {
+	return ParentRule{
+		Name:     RuleMaxParentAge,
+		Severity: severity,
+		Validate: func(parent Person, child Person) error {
+			_, parentLatest := parent.BirthDate.Bounds()
+			childEarliest, _ := child.BirthDate.Bounds()
+			if parentLatest.IsZero() || childEarliest.IsZero() {
+				return nil
+			}
+			if !childEarliest.Before(parentLatest.AddDate(years+1, 0, 0)) {
+				return fmt.Errorf("%w, parent born on %s and child on %s, maximum age is %d", ErrParentTooOld, parent.BirthDate, child.BirthDate, years)
+			}
+			return nil
+		},
+	}
+}
+
+// PosthumousBirthRule fails when the child was surely born after the death of
+// the mother or more than months after the death of the father. Parents with
+// unknown sex are given the father window.
+func PosthumousBirthRule(months int, severity RuleSeverity) ParentRule {
+	return ParentRule{
+		Name:     RulePosthumousBirth,
+		Severity: severity,
+		Validate: func(parent Person, child Person) error {
+			_, parentDeathLatest := parent.DeathDate.Bounds()
+			childEarliest, _ := child.BirthDate.Bounds()
+			if parentDeathLatest.IsZero() || childEarliest.IsZero() {
+				return nil
+			}
+			limit := parentDeathLatest
+			if parent.Sex != SexFemale {
+				limit = limit.AddDate(0, months, 0)
+			}
+			if childEarliest.After(limit) {
+				return fmt.Errorf("%w, parent died on %s and child was born on %s", ErrBornAfterParentDeath, parent.DeathDate, child.BirthDate)
+			}
+			return nil
+		},
+	}
+}
+
+func diedBeforeBirth(died Person, born Person) bool {
+	_, deathLatest := died.DeathDate.Bounds()
+	birthEarliest, _ := born.BirthDate.Bounds()
+	return !deathLatest.IsZero() && !birthEarliest.IsZero() && deathLatest.Before(birthEarliest)
+}
+
+// ContemporarySpousesRule fails when one of the spouses surely died before the
+// other was born.
+func ContemporarySpousesRule(severity RuleSeverity) SpouseRule {
+	return SpouseRule{
+		Name:     RuleContemporarySpouses,
+		Severity: severity,
+		Validate: func(firstSpouse Person, secondSpouse Person) error {
+			if diedBeforeBirth(firstSpouse, secondSpouse) || diedBeforeBirth(secondSpouse, firstSpouse) {
+				return fmt.Errorf("%w, %s lived from %s to %s and %s from %s to %s", ErrSpousesNotContemporary,
+					firstSpouse.Name, firstSpouse.BirthDate, firstSpouse.DeathDate,
+					secondSpouse.Name, secondSpouse.BirthDate, secondSpouse.DeathDate)
+			}
+			return nil
+		},
+	}
+}
+
+type ruleCheck struct {
+	name     string
+	severity RuleSeverity
+	check    func() error
+}
+
+// runChecks runs every enabled check, returning the first ERROR violation as
+// error and the WARNING ones as warnings.
+func runChecks(checks []ruleCheck) ([]RuleViolation, error) {
+	warnings := []RuleViolation{}
+	for _, check := range checks {
+		if check.severity == RuleSeverityOff {
+			continue
+		}
+		err := check.check()
+		if err == nil {
+			continue
+		}
+		violation := RuleViolation{Rule: check.name, Severity: check.severity, Err: err}
+		if violation.Severity == RuleSeverityError {
+			return nil, &violation
+		}
+		warnings = append(warnings, violation)
+	}
+	return warnings, nil
+}
+
+func (rules RelationRules) ValidateParent(parent Person, child Person) ([]RuleViolation, error) {
+	checks := make([]ruleCheck, 0, len(rules.ParentRules))
+	for _, rule := range rules.ParentRules {
+		validate := rule.Validate
+		checks = append(checks, ruleCheck{rule.Name, rule.Severity, func() error { return validate(parent, child) }})
+	}
+	return runChecks(checks)
+}
+
+func (rules RelationRules) ValidateSpouse(firstSpouse Person, secondSpouse Person) ([]RuleViolation, error) {
+	checks := make([]ruleCheck, 0, len(rules.SpouseRules))
+	for _, rule := range rules.SpouseRules {
+		validate := rule.Validate
+		checks = append(checks, ruleCheck{rule.Name, rule.Severity, func() error { return validate(firstSpouse, secondSpouse) }})
+	}
+	return runChecks(checks)
+}
@@ -0,0 +1,250 @@
+package familytree
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// SearchMinSimilarity is the trigram similarity a name needs to match a query
+// it doesn't contain nor sounds like, the same default of pg_trgm.
+const SearchMinSimilarity = 0.3
+
+// NameMatch is how a name matched a search, from the strongest to the
+// weakest.
+type NameMatch string
+
+const (
+	NameMatchSubstring = NameMatch("SUBSTRING")
+	NameMatchPhonetic  = NameMatch("PHONETIC")
+	NameMatchSimilar   = NameMatch("SIMILAR")
+)
+
+// PersonMatch Score is the trigram similarity between the query and the name,
+// from 0 to 1, the search results are ranked by it.
+type PersonMatch struct {
+	Person Person
+	Score  float64
+	Match  NameMatch
+}
+
+type PeopleMatchList struct {
+	Content  []PersonMatch
+	Metadata ListMetadata
+}
+
+var foldedLetters = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'æ': "ae",
+	'ç': "c", 'è': "e", 'é': "e", 'ê': "e", 'ë': "e",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ñ': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'œ': "oe",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ý': "y", 'ÿ': "y", 'ß': "ss",
+}
+
+// NormalizeName lowers the name and drops its accents and punctuation, words
+// are separated by a single space.
+func NormalizeName(name string) string {
+	var builder strings.Builder
+	for _, letter := range strings.ToLower(name) {
+		switch folded, ok := foldedLetters[letter]; {
+		case ok:
+			builder.WriteString(folded)
+		case unicode.IsLetter(letter) || unicode.IsDigit(letter):
+			builder.WriteRune(letter)
+		default:
+			builder.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(builder.String()), " ")
+}
+
+var soundexCodes = map[rune]byte{
+	'b': '1', 'f': '1', 'p': '1', 'v': '1',
+	'c': '2', 'g': '2', 'j': '2', 'k': '2', 'q': '2', 's': '2', 'x': '2', 'z': '2',
+	'd': '3', 't': '3',
+	'l': '4',
+	'm': '5', 'n': '5',
+	'r': '6',
+}
+
+// Soundex returns the American Soundex code of a normalized word, like S530
+// for both Smith and Smyth, or an empty string when it has no latin letters.
+func Soundex(word string) string {
+	code := []byte{}
+	var last byte
+	for _, letter := range word {
+		if letter < 'a' || letter > 'z' {
+			continue
+		}
+		digit, ok := soundexCodes[letter]
+		if len(code) == 0 {
+			code = append(code, byte(unicode.ToUpper(letter)))
+			last = digit
+			continue
+		}
+		switch {
+		case letter == 'h' || letter == 'w':
+			// They don't split letters with the same code
+		case !ok:
+			last = 0
+		case digit != last:
+			code = append(code, digit)
+			last = digit
+		}
+		if len(code) == 4 {
+			break
+		}
+	}
+	if len(code) == 0 {
+		return ""
+	}
+	for len(code) < 4 {
+		code = append(code, '0')
+	}
+	return string(code[:4])
+}
+
+// trigram packs three runes of 21 bits each, so comparing trigrams doesn't
+// compare strings.
+type trigram uint64
+
+// NameKeys are what searches compare, computed once per name.
+type NameKeys struct {
+	Normalized string
+	// Phonetics holds the Soundex code of every word
+	Phonetics []string
+	// trigrams are sorted and unique, every word is padded as pg_trgm does
+	trigrams []trigram
+}
+
+func NewNameKeys(name string) NameKeys {
+	keys := NameKeys{Normalized: NormalizeName(name)}
+	for _, word := range strings.Fields(keys.Normalized) {
+		for _, wordTrigram := range Trigrams(word) {
+			runes := []rune(wordTrigram)
+			keys.trigrams = append(keys.trigrams, trigram(runes[0])<<42|trigram(runes[1])<<21|trigram(runes[2]))
+		}
+		if code := Soundex(word); code != "" {
+			keys.Phonetics = append(keys.Phonetics, code)
+		}
+	}
+	sort.Slice(keys.trigrams, func(i, j int) bool { return keys.trigrams[i] < keys.trigrams[j] })
+	unique := keys.trigrams[:0]
+	for i, current := range keys.trigrams {
+		if i == 0 || current != keys.trigrams[i-1] {
+			unique = append(unique, current)
+		}
+	}
+	keys.trigrams = unique
+	return keys
+}
+
+// Trigrams returns the trigrams of a normalized word padded with two spaces
+// before and one after it.
+func Trigrams(word string) []string {
+	padded := []rune("  " + word + " ")
+	trigrams := make([]string, 0, len(padded)-2)
+	for i := 0; i+3 <= len(padded); i++ {
+		trigrams = append(trigrams, string(padded[i:i+3]))
+	}
+	return trigrams
+}
+
+// Similarity is the share of trigrams both names have among all their
+// trigrams.
+func (keys NameKeys) Similarity(other NameKeys) float64 {
+	shared := 0
+	for i, j := 0, 0; i < len(keys.trigrams) && j < len(other.trigrams); {
+		switch {
+		case keys.trigrams[i] == other.trigrams[j]:
+			shared++
+			i++
+			j++
+		case keys.trigrams[i] < other.trigrams[j]:
+			i++
+		default:
+			j++
+		}
+	}
+	total := len(keys.trigrams) + len(other.trigrams) - shared
+	if total == 0 {
+		return 0
+	}
+	return float64(shared) / float64(total)
+}
+
+// MinSharedTrigrams is the fewest trigrams a name must share with the query to
+// be similar to it, since no name has a higher similarity than the one having
+// only the shared trigrams.
+func (keys NameKeys) MinSharedTrigrams() int {
+	for shared := 1; shared < len(keys.trigrams); shared++ {
+		if float64(shared)/float64(len(keys.trigrams)) >= SearchMinSimilarity {
+			return shared
+		}
+	}
+	return len(keys.trigrams)
+}
+
+// soundsLike tells if every word of the query sounds like a word of the name.
+func (keys NameKeys) soundsLike(name NameKeys) bool {
+	if len(keys.Phonetics) == 0 {
+		return false
+	}
+	for _, code := range keys.Phonetics {
+		found := false
+		for _, nameCode := range name.Phonetics {
+			if code == nameCode {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchName compares the keys of a query with the keys of a name, it returns
+// false when they don't match at all.
+func (keys NameKeys) MatchName(name NameKeys) (float64, NameMatch, bool) {
+	similarity := keys.Similarity(name)
+	switch {
+	case keys.Normalized != "" && strings.Contains(name.Normalized, keys.Normalized):
+		return similarity, NameMatchSubstring, true
+	case keys.soundsLike(name):
+		return similarity, NameMatchPhonetic, true
+	case similarity >= SearchMinSimilarity:
+		return similarity, NameMatchSimilar, true
+	default:
+		return 0, "", false
+	}
+}
+
+// SortMatches ranks the matches by score, ties keep the order of the repo.
+func SortMatches(matches []PersonMatch) {
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+}
+
+// PageMatches returns the page of the ranked matches.
+func PageMatches(matches []PersonMatch, pagination PaginationDetails) *PeopleMatchList {
+	list := &PeopleMatchList{
+		Content: []PersonMatch{},
+		Metadata: ListMetadata{
+			TotalItens: len(matches),
+			Page:       pagination.Page,
+		},
+	}
+	start := pagination.Page * pagination.PageSize
+	if start >= len(matches) || pagination.PageSize <= 0 {
+		return list
+	}
+	end := start + pagination.PageSize
+	if end > len(matches) {
+		end = len(matches)
+	}
+	list.Content = matches[start:end]
+	return list
+}
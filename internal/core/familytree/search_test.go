@@ -0,0 +1,169 @@
+package familytree
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{name: "John Smith", expected: "john smith"},
+		{name: "  José  d'Ávila-Souza ", expected: "jose d avila souza"},
+		{name: "Straße", expected: "strasse"},
+		{name: "Ærø", expected: "aero"},
+		{name: "Henry VIII, 2nd", expected: "henry viii 2nd"},
+		{name: "...", expected: ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if normalized := NormalizeName(test.name); normalized != test.expected {
+				t.Errorf("NormalizeName returned %q, expected %q", normalized, test.expected)
+			}
+		})
+	}
+}
+
+func TestSoundex(t *testing.T) {
+	tests := []struct {
+		word     string
+		expected string
+	}{
+		{word: "smith", expected: "S530"},
+		{word: "smyth", expected: "S530"},
+		{word: "robert", expected: "R163"},
+		{word: "rupert", expected: "R163"},
+		{word: "rubin", expected: "R150"},
+		{word: "ashcraft", expected: "A261"},
+		{word: "tymczak", expected: "T522"},
+		{word: "pfister", expected: "P236"},
+		{word: "lee", expected: "L000"},
+		{word: "2nd", expected: "N300"},
+		{word: "123", expected: ""},
+	}
+	for _, test := range tests {
+		t.Run(test.word, func(t *testing.T) {
+			if code := Soundex(test.word); code != test.expected {
+				t.Errorf("Soundex returned %q, expected %q", code, test.expected)
+			}
+		})
+	}
+}
+
+func TestTrigrams(t *testing.T) {
+	tests := []struct {
+		word     string
+		expected []string
+	}{
+		{word: "ann", expected: []string{"  a", " an", "ann", "nn "}},
+		{word: "jo", expected: []string{"  j", " jo", "jo "}},
+		{word: "zé", expected: []string{"  z", " zé", "zé "}},
+	}
+	for _, test := range tests {
+		t.Run(test.word, func(t *testing.T) {
+			if trigrams := Trigrams(test.word); strings.Join(trigrams, "|") != strings.Join(test.expected, "|") {
+				t.Errorf("Trigrams returned %q, expected %q", trigrams, test.expected)
+			}
+		})
+	}
+}
+
+func TestMatchName(t *testing.T) {
+	tests := []struct {
+		query      string
+		name       string
+		similarity float64
+		match      NameMatch
+		ok         bool
+	}{
+		{query: "John Smith", name: "john smith", similarity: 1, match: NameMatchSubstring, ok: true},
+		{query: "silva", name: "Maria da Silva", similarity: 6.0 / 15, match: NameMatchSubstring, ok: true},
+		{query: "Smyth", name: "John Smith", similarity: 3.0 / 14, match: NameMatchPhonetic, ok: true},
+		{query: "Mariana", name: "Maria", similarity: 5.0 / 9, match: NameMatchSimilar, ok: true},
+		{query: "Xavier", name: "John Smith", ok: false},
+		{query: "", name: "John Smith", ok: false},
+	}
+	for _, test := range tests {
+		t.Run(test.query+" "+test.name, func(t *testing.T) {
+			query, name := NewNameKeys(test.query), NewNameKeys(test.name)
+			if similarity := name.Similarity(query); similarity != query.Similarity(name) {
+				t.Errorf("Similarity isn't symmetric, %g and %g", similarity, query.Similarity(name))
+			}
+			similarity, match, ok := query.MatchName(name)
+			if ok != test.ok || match != test.match || math.Abs(similarity-test.similarity) > 1e-9 {
+				t.Errorf("MatchName returned %g, %q, %t, expected %g, %q, %t", similarity, match, ok, test.similarity, test.match, test.ok)
+			}
+		})
+	}
+}
+
+func TestMinSharedTrigrams(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected int
+	}{
+		{query: "", expected: 0},
+		{query: "jo", expected: 1},
+		{query: "smith", expected: 2},
+		{query: "John Smith", expected: 4},
+	}
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			keys := NewNameKeys(test.query)
+			if shared := keys.MinSharedTrigrams(); shared != test.expected {
+				t.Errorf("MinSharedTrigrams returned %d, expected %d", shared, test.expected)
+			}
+			if test.expected == 0 {
+				return
+			}
+			// A name having only the shared trigrams is as similar as any can be
+			similar := NameKeys{trigrams: keys.trigrams[:test.expected]}
+			fewer := NameKeys{trigrams: keys.trigrams[:test.expected-1]}
+			if keys.Similarity(similar) < SearchMinSimilarity || (test.expected > 1 && keys.Similarity(fewer) >= SearchMinSimilarity) {
+				t.Errorf("sharing %d trigrams is %g similar and sharing one less %g", test.expected, keys.Similarity(similar), keys.Similarity(fewer))
+			}
+		})
+	}
+}
+
+func TestSortMatches(t *testing.T) {
+	matches := []PersonMatch{
+		{Person: Person{Name: "First"}, Score: 0.4},
+		{Person: Person{Name: "Second"}, Score: 1},
+		{Person: Person{Name: "Third"}, Score: 0.4},
+		{Person: Person{Name: "Fourth"}, Score: 0.5},
+	}
+	SortMatches(matches)
+	names := []string{}
+	for _, match := range matches {
+		names = append(names, match.Person.Name)
+	}
+	if expected := "Second Fourth First Third"; strings.Join(names, " ") != expected {
+		t.Errorf("SortMatches returned %q, expected %q", names, expected)
+	}
+}
+
+func TestPageMatches(t *testing.T) {
+	matches := make([]PersonMatch, 5)
+	tests := []struct {
+		name       string
+		pagination PaginationDetails
+		expected   int
+	}{
+		{name: "first page", pagination: PaginationDetails{Page: 0, PageSize: 2}, expected: 2},
+		{name: "last page", pagination: PaginationDetails{Page: 2, PageSize: 2}, expected: 1},
+		{name: "after the last page", pagination: PaginationDetails{Page: 3, PageSize: 2}, expected: 0},
+		{name: "empty page", pagination: PaginationDetails{Page: 0, PageSize: 0}, expected: 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			list := PageMatches(matches, test.pagination)
+			if len(list.Content) != test.expected || list.Metadata.TotalItens != len(matches) || list.Metadata.Page != test.pagination.Page {
+				t.Errorf("PageMatches returned %d matches with %+v, expected %d of %d", len(list.Content), list.Metadata, test.expected, len(matches))
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package familytree
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultTrashRetention is how long deleted people and relations stay in the
+// trash before they're purged for good.
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
+// TrashedPerson Relations are the relations deleted along with the person,
+// they're restored with it.
+type TrashedPerson struct {
+	Person    Person
+	DeletedAt time.Time
+	Relations []PersonRelation
+}
+
+type TrashedRelation struct {
+	Relation  PersonRelation
+	DeletedAt time.Time
+}
+
+// Trash Relations are the relations deleted on their own, and the ones
+// deleted along with a person that is back already, both ends of them may be
+// restored.
+type Trash struct {
+	People    []TrashedPerson
+	Relations []TrashedRelation
+}
+
+// TrashPurge counts what was deleted for good.
+type TrashPurge struct {
+	People    int
+	Relations int
+}
+
+// relation returns the latest deleted relation between the people, SPOUSE
+// relations in either direction.
+func (trash Trash) relation(relationType RelationType, firstID uuid.UUID, secondID uuid.UUID) (PersonRelation, bool) {
+	for _, trashed := range trash.Relations {
+		relation := trashed.Relation
+		if relation.RelationType != relationType {
+			continue
+		}
+		if relation.Top.ID == firstID && relation.Bottom.ID == secondID {
+			return relation, true
+		}
+		if !relationType.Directional && relation.Top.ID == secondID && relation.Bottom.ID == firstID {
+			return relation, true
+		}
+	}
+	return PersonRelation{}, false
+}
+
+func (trash Trash) person(personID uuid.UUID) (TrashedPerson, bool) {
+	for _, trashed := range trash.People {
+		if trashed.Person.ID == personID {
+			return trashed, true
+		}
+	}
+	return TrashedPerson{}, false
+}
+
+// purged returns what a purge of the trash before the time deletes for good,
+// the people trashed before it and the relations trashed before it or with a
+// purged end.
+func (trash Trash) purged(before time.Time) ([]Person, []PersonRelation) {
+	people := []Person{}
+	purgedIDs := map[uuid.UUID]bool{}
+	for _, trashed := range trash.People {
+		if trashed.DeletedAt.Before(before) {
+			people = append(people, trashed.Person)
+			purgedIDs[trashed.Person.ID] = true
+		}
+	}
+	relations := []PersonRelation{}
+	purge := func(relation PersonRelation, deletedAt time.Time) {
+		if deletedAt.Before(before) || purgedIDs[relation.Top.ID] || purgedIDs[relation.Bottom.ID] {
+			relations = append(relations, relation)
+		}
+	}
+	for _, trashed := range trash.People {
+		for _, relation := range trashed.Relations {
+			purge(relation, trashed.DeletedAt)
+		}
+	}
+	for _, trashed := range trash.Relations {
+		purge(trashed.Relation, trashed.DeletedAt)
+	}
+	return people, relations
+}
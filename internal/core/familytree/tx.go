@@ -0,0 +1,103 @@
+package familytree
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	// TransactionMaxAttempts is how many times a write use case runs while its
+	// transaction fails with transient errors, like deadlocks between
+	// concurrent requests.
+	TransactionMaxAttempts = 3
+	transactionRetryDelay  = 50 * time.Millisecond
+)
+
+var (
+	ErrReadOnlyTx = errors.New("can't write on a read transaction")
+	ErrTxDone     = errors.New("transaction has already ended")
+)
+
+// Tx is a unit of work of the repo, every repo method runs on the Tx it's
+// given. Queries of a write Tx see its own changes, the others only see them
+// once it's committed.
+type Tx interface {
+	Mode() SessionMode
+	Commit(ctx context.Context) error
+	// Rollback drops the changes of the Tx and does nothing once it has ended,
+	// so it may always be deferred
+	Rollback(ctx context.Context) error
+	// OnCommit keeps a hook to run right after the Tx is committed, the hooks
+	// of Txs rolled back never run
+	OnCommit(hook func())
+}
+
+type txContextKey struct{}
+
+// outerTx returns the Tx of the use case running this one, when there is one
+// fit for the mode.
+func outerTx(ctx context.Context, mode SessionMode) (Tx, error) {
+	outer, ok := ctx.Value(txContextKey{}).(Tx)
+	if !ok {
+		return nil, nil
+	}
+	if mode == SessionWrite && outer.Mode() != SessionWrite {
+		return nil, ErrReadOnlyTx
+	}
+	return outer, nil
+}
+
+// RunInTx runs the work on one Tx of the mode, committed only when the work
+// succeeds. The whole work runs again on transient errors, so it must not
+// keep state between attempts. The context given to the work carries the Tx,
+// so the use cases run by the work join it instead of beginning their own
+// one, and only the outermost work retries, commits or rolls it back.
+func RunInTx(ctx context.Context, familyTreeRepo FamilyTreeRepo, mode SessionMode, work func(ctx context.Context, tx Tx) error) error {
+	outer, err := outerTx(ctx, mode)
+	if err != nil {
+		return err
+	}
+	if outer != nil {
+		return work(ctx, outer)
+	}
+	for attempt := 1; attempt <= TransactionMaxAttempts; attempt++ {
+		err = runTx(ctx, familyTreeRepo, mode, work)
+		if err == nil || !familyTreeRepo.IsTransientError(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Duration(attempt) * transactionRetryDelay):
+		}
+	}
+	return err
+}
+
+func runTx(ctx context.Context, familyTreeRepo FamilyTreeRepo, mode SessionMode, work func(ctx context.Context, tx Tx) error) error {
+	tx, err := familyTreeRepo.Begin(ctx, mode)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	if err := work(context.WithValue(ctx, txContextKey{}, tx), tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// runInTx is RunInTx for the use cases returning a value.
+func runInTx[T any](ctx context.Context, familyTreeRepo FamilyTreeRepo, mode SessionMode, work func(ctx context.Context, tx Tx) (T, error)) (T, error) {
+	var result T
+	err := RunInTx(ctx, familyTreeRepo, mode, func(ctx context.Context, tx Tx) error {
+		var err error
+		result, err = work(ctx, tx)
+		return err
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return result, nil
+}
@@ -0,0 +1,201 @@
+package gedcom
+
+import (
+	"family-tree/internal/core/familytree"
+	"io"
+	"strings"
+)
+
+// Event holds the raw DATE and PLAC values of a BIRT or DEAT structure.
+type Event struct {
+	Date  string
+	Place string
+}
+
+type Individual struct {
+	Xref      string
+	Name      string
+	GivenName string
+	Surname   string
+	Sex       string
+	Birth     Event
+	Death     Event
+	// Pedigrees holds the PEDI, or _PEDI, value of each FAMC link by family
+	// xref, links without it are missing
+	Pedigrees map[string]string
+	Line      int
+}
+
+// Family Marriage, Divorce and Annulment are nil when the FAM record doesn't
+// have the event. Only the first of each event is kept, so a couple who married
+// again is read with its first union.
+type Family struct {
+	Xref      string
+	Husband   string
+	Wife      string
+	Children  []string
+	Marriage  *Event
+	Divorce   *Event
+	Annulment *Event
+	Line      int
+}
+
+// Document holds the records this service understands. Every other top
+// level record, besides HEAD, SUBM and TRLR, is kept on Others so importers can
+// report them.
+type Document struct {
+	Individuals []Individual
+	Families    []Family
+	Others      []*Node
+}
+
+// FormatName turns a GEDCOM personal name, like "John /Smith/ Jr.", into the
+// plain name stored on a person.
+func FormatName(name string) string {
+	return strings.Join(strings.Fields(strings.ReplaceAll(name, "/", " ")), " ")
+}
+
+// SplitName returns the given name and the surname of a GEDCOM personal name,
+// the surname being the part between slashes, like "John /Smith/ Jr.".
+func SplitName(name string) (string, string) {
+	start := strings.Index(name, "/")
+	if start < 0 {
+		return FormatName(name), ""
+	}
+	surname := name[start+1:]
+	if end := strings.Index(surname, "/"); end >= 0 {
+		surname = surname[:end]
+	}
+	return FormatName(name[:start]), FormatName(surname)
+}
+
+// pedigreeParentages maps the PEDI values, and the _PEDI values written for
+// the parentages PEDI has no value for, to the parentage of the PARENT
+// relations.
+var pedigreeParentages = map[string]familytree.Parentage{
+	"BIRTH":    familytree.ParentageBiological,
+	"ADOPTED":  familytree.ParentageAdoptive,
+	"FOSTER":   familytree.ParentageFoster,
+	"STEP":     familytree.ParentageStep,
+	"GUARDIAN": familytree.ParentageGuardian,
+}
+
+// PedigreeParentage returns the parentage of a PEDI or _PEDI value on any case,
+// a missing value is biological.
+func PedigreeParentage(pedigree string) (familytree.Parentage, bool) {
+	if pedigree == "" {
+		return familytree.ParentageBiological, true
+	}
+	parentage, ok := pedigreeParentages[strings.ToUpper(pedigree)]
+	return parentage, ok
+}
+
+// ParentagePedigree returns the tag and value of the FAMC link of a child with
+// the parentage, biological links need none and have an empty tag.
+func ParentagePedigree(parentage familytree.Parentage) (string, string) {
+	switch parentage {
+	case familytree.ParentageAdoptive:
+		return TagPedigree, "adopted"
+	case familytree.ParentageFoster:
+		return TagPedigree, "foster"
+	case familytree.ParentageStep, familytree.ParentageGuardian:
+		return TagUserPedigree, strings.ToLower(string(parentage))
+	default:
+		return "", ""
+	}
+}
+
+func decodeEvent(node *Node) Event {
+	if node == nil {
+		return Event{}
+	}
+	return Event{
+		Date:  strings.TrimSpace(node.ChildValue(TagDate)),
+		Place: strings.TrimSpace(node.ChildValue(TagPlace)),
+	}
+}
+
+func decodeIndividual(node *Node) Individual {
+	individual := Individual{
+		Xref:      node.Xref,
+		Sex:       strings.TrimSpace(node.ChildValue(TagSex)),
+		Birth:     decodeEvent(node.Child(TagBirth)),
+		Death:     decodeEvent(node.Child(TagDeath)),
+		Pedigrees: map[string]string{},
+		Line:      node.Line,
+	}
+	for _, link := range node.ChildrenWithTag(TagFamilyChild) {
+		pedigree := strings.TrimSpace(link.ChildValue(TagPedigree))
+		if userPedigree := strings.TrimSpace(link.ChildValue(TagUserPedigree)); userPedigree != "" {
+			pedigree = userPedigree
+		}
+		if pedigree != "" {
+			individual.Pedigrees[link.Value] = pedigree
+		}
+	}
+	nameNode := node.Child(TagName)
+	if nameNode == nil {
+		return individual
+	}
+	individual.GivenName, individual.Surname = SplitName(nameNode.Value)
+	if givenName := nameNode.ChildValue(TagGivenName); givenName != "" {
+		individual.GivenName = FormatName(givenName)
+	}
+	if surname := nameNode.ChildValue(TagSurname); surname != "" {
+		individual.Surname = FormatName(surname)
+	}
+	individual.Name = FormatName(nameNode.Value)
+	if individual.Name == "" {
+		individual.Name = FormatName(individual.GivenName + " " + individual.Surname)
+	}
+	return individual
+}
+
+func decodeOptionalEvent(node *Node) *Event {
+	if node == nil {
+		return nil
+	}
+	event := decodeEvent(node)
+	return &event
+}
+
+func decodeFamily(node *Node) Family {
+	family := Family{
+		Xref:      node.Xref,
+		Husband:   node.ChildValue(TagHusband),
+		Wife:      node.ChildValue(TagWife),
+		Children:  []string{},
+		Marriage:  decodeOptionalEvent(node.Child(TagMarriage)),
+		Divorce:   decodeOptionalEvent(node.Child(TagDivorce)),
+		Annulment: decodeOptionalEvent(node.Child(TagAnnulment)),
+		Line:      node.Line,
+	}
+	for _, child := range node.ChildrenWithTag(TagChild) {
+		family.Children = append(family.Children, child.Value)
+	}
+	return family
+}
+
+func Decode(reader io.Reader) (*Document, error) {
+	records, err := Parse(reader)
+	if err != nil {
+		return nil, err
+	}
+	document := &Document{
+		Individuals: []Individual{},
+		Families:    []Family{},
+		Others:      []*Node{},
+	}
+	for _, record := range records {
+		switch record.Tag {
+		case TagHeader, TagTrailer, TagSubmitter:
+		case TagIndividual:
+			document.Individuals = append(document.Individuals, decodeIndividual(record))
+		case TagFamily:
+			document.Families = append(document.Families, decodeFamily(record))
+		default:
+			document.Others = append(document.Others, record)
+		}
+	}
+	return document, nil
+}
@@ -0,0 +1,144 @@
+package gedcom
+
+import (
+	"family-tree/internal/core/familytree"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitName(t *testing.T) {
+	tests := []struct {
+		name      string
+		formatted string
+		givenName string
+		surname   string
+	}{
+		{name: "John /Smith/", formatted: "John Smith", givenName: "John", surname: "Smith"},
+		{name: "John /Smith/ Jr.", formatted: "John Smith Jr.", givenName: "John", surname: "Smith"},
+		{name: "/Smith/", formatted: "Smith", givenName: "", surname: "Smith"},
+		{name: "Mary  Ann", formatted: "Mary Ann", givenName: "Mary Ann", surname: ""},
+		{name: "Ana /da  Silva", formatted: "Ana da Silva", givenName: "Ana", surname: "da Silva"},
+		{name: "", formatted: "", givenName: "", surname: ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if formatted := FormatName(test.name); formatted != test.formatted {
+				t.Errorf("FormatName returned %q, expected %q", formatted, test.formatted)
+			}
+			givenName, surname := SplitName(test.name)
+			if givenName != test.givenName || surname != test.surname {
+				t.Errorf("SplitName returned %q, %q, expected %q, %q", givenName, surname, test.givenName, test.surname)
+			}
+		})
+	}
+}
+
+func TestPedigreeParentage(t *testing.T) {
+	tests := []struct {
+		pedigree  string
+		parentage familytree.Parentage
+		ok        bool
+	}{
+		{pedigree: "", parentage: familytree.ParentageBiological, ok: true},
+		{pedigree: "birth", parentage: familytree.ParentageBiological, ok: true},
+		{pedigree: "ADOPTED", parentage: familytree.ParentageAdoptive, ok: true},
+		{pedigree: "Foster", parentage: familytree.ParentageFoster, ok: true},
+		{pedigree: "step", parentage: familytree.ParentageStep, ok: true},
+		{pedigree: "guardian", parentage: familytree.ParentageGuardian, ok: true},
+		{pedigree: "sealing", ok: false},
+	}
+	for _, test := range tests {
+		t.Run(test.pedigree, func(t *testing.T) {
+			parentage, ok := PedigreeParentage(test.pedigree)
+			if ok != test.ok || (ok && parentage != test.parentage) {
+				t.Errorf("PedigreeParentage returned %q, %t, expected %q, %t", parentage, ok, test.parentage, test.ok)
+			}
+		})
+	}
+}
+
+// TestParentagePedigree checks that every parentage is read back from the
+// FAMC link written for it.
+func TestParentagePedigree(t *testing.T) {
+	tests := []struct {
+		parentage familytree.Parentage
+		tag       string
+	}{
+		{parentage: familytree.ParentageBiological, tag: ""},
+		{parentage: familytree.ParentageAdoptive, tag: TagPedigree},
+		{parentage: familytree.ParentageFoster, tag: TagPedigree},
+		{parentage: familytree.ParentageStep, tag: TagUserPedigree},
+		{parentage: familytree.ParentageGuardian, tag: TagUserPedigree},
+	}
+	for _, test := range tests {
+		t.Run(string(test.parentage), func(t *testing.T) {
+			tag, pedigree := ParentagePedigree(test.parentage)
+			if tag != test.tag {
+				t.Errorf("ParentagePedigree returned tag %q, expected %q", tag, test.tag)
+			}
+			if parentage, ok := PedigreeParentage(pedigree); !ok || parentage != test.parentage {
+				t.Errorf("PedigreeParentage(%q) returned %q, %t, expected %q", pedigree, parentage, ok, test.parentage)
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	input := `0 HEAD
+1 CHAR UTF-8
+0 @SUBM@ SUBM
+1 NAME Someone
+0 @I1@ INDI
+1 NAME John /Smith/
+1 SEX M
+1 BIRT
+2 DATE 12 MAR 1850
+2 PLAC  Lisbon
+1 FAMS @F1@
+0 @I2@ INDI
+1 NAME Mary /Jones/
+2 GIVN Mary Ann
+2 SURN Jones-Smith
+1 DEAT
+2 DATE 1920
+0 @I3@ INDI
+1 NAME /Smith/
+1 FAMC @F1@
+2 PEDI adopted
+1 FAMC @F2@
+2 PEDI birth
+2 _PEDI guardian
+0 @F1@ FAM
+1 HUSB @I1@
+1 WIFE @I2@
+1 CHIL @I3@
+1 MARR
+2 DATE 1875
+1 DIV Y
+0 @N1@ NOTE A note
+0 TRLR
+`
+	document, err := Decode(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Decode returned error: %v", err)
+	}
+
+	expectedIndividuals := []Individual{
+		{Xref: "@I1@", Name: "John Smith", GivenName: "John", Surname: "Smith", Sex: "M", Birth: Event{Date: "12 MAR 1850", Place: "Lisbon"}, Pedigrees: map[string]string{}, Line: 5},
+		{Xref: "@I2@", Name: "Mary Jones", GivenName: "Mary Ann", Surname: "Jones-Smith", Death: Event{Date: "1920"}, Pedigrees: map[string]string{}, Line: 12},
+		{Xref: "@I3@", Name: "Smith", Surname: "Smith", Pedigrees: map[string]string{"@F1@": "adopted", "@F2@": "guardian"}, Line: 18},
+	}
+	if !reflect.DeepEqual(document.Individuals, expectedIndividuals) {
+		t.Errorf("Decode returned individuals %+v, expected %+v", document.Individuals, expectedIndividuals)
+	}
+	expectedFamilies := []Family{
+		{Xref: "@F1@", Husband: "@I1@", Wife: "@I2@", Children: []string{"@I3@"}, Marriage: &Event{Date: "1875"}, Divorce: &Event{}, Line: 25},
+	}
+	if !reflect.DeepEqual(document.Families, expectedFamilies) {
+		t.Errorf("Decode returned families %+v, expected %+v", document.Families, expectedFamilies)
+	}
+	if len(document.Others) != 1 || document.Others[0].Tag != "NOTE" {
+		t.Errorf("Decode returned other records %v, expected the NOTE", nodeLines(document.Others))
+	}
+}
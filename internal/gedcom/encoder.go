@@ -0,0 +1,407 @@
+package gedcom
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"family-tree/internal/core/familytree"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TagSource         = "SOUR"
+	TagSubmitter      = "SUBM"
+	TagGedcom         = "GEDC"
+	TagVersion        = "VERS"
+	TagForm           = "FORM"
+	TagCharset        = "CHAR"
+	TagFamilySpouse   = "FAMS"
+	TagMarriage       = "MARR"
+	Version           = "5.5.1"
+	Form              = "LINEAGE-LINKED"
+	Charset           = "UTF-8"
+	SourceSystem      = "FAMILY-TREE"
+	submitterXref     = "@SUBM@"
+	maxValueLength    = 200
+	xrefHexLength     = 19
+	individualXrefTag = "I"
+	familyXrefTag     = "F"
+)
+
+// IndividualXref derives a stable cross-reference from the person id. GEDCOM
+// limits the identifier to 20 characters so only the first 76 bits of the
+// UUID are used, which is more than enough to be unique inside a tree.
+func IndividualXref(personID uuid.UUID) string {
+	return fmt.Sprintf("@%s%s@", individualXrefTag, strings.ToUpper(hex.EncodeToString(personID[:]))[:xrefHexLength])
+}
+
+// FamilyXref derives a stable cross-reference from the ids of the parents or
+// spouses of the family, in any order.
+func FamilyXref(peopleIDs ...uuid.UUID) string {
+	ids := make([]string, 0, len(peopleIDs))
+	for _, personID := range peopleIDs {
+		ids = append(ids, personID.String())
+	}
+	sort.Strings(ids)
+	sum := sha1.Sum([]byte(strings.Join(ids, ",")))
+	return fmt.Sprintf("@%s%s@", familyXrefTag, strings.ToUpper(hex.EncodeToString(sum[:]))[:xrefHexLength])
+}
+
+func isPointer(value string) bool {
+	return len(value) > 2 && strings.HasPrefix(value, "@") && strings.HasSuffix(value, "@") && !strings.Contains(value[1:len(value)-1], "@")
+}
+
+// splitValue breaks the value on CONT lines for every new line and CONC lines
+// for every piece longer than the maximum line value.
+func splitValue(value string) []*Node {
+	nodes := []*Node{}
+	for i, line := range strings.Split(value, "\n") {
+		tag := TagContinued
+		for {
+			piece := line
+			if len(piece) > maxValueLength {
+				piece = line[:maxValueLength]
+				// Never leave a space at the edge, some readers trim CONC values
+				for len(piece) > 1 && (piece[len(piece)-1] == ' ' || line[len(piece)] == ' ') {
+					piece = piece[:len(piece)-1]
+				}
+			}
+			nodes = append(nodes, &Node{Tag: tag, Value: piece})
+			line = line[len(piece):]
+			tag = TagConcatenated
+			if line == "" {
+				break
+			}
+		}
+		if i == 0 {
+			nodes[0].Tag = ""
+		}
+	}
+	return nodes
+}
+
+func writeNode(writer *bufio.Writer, node *Node, level int) error {
+	value := node.Value
+	if !isPointer(value) {
+		value = strings.ReplaceAll(value, "@", "@@")
+	}
+	pieces := splitValue(value)
+	for i, piece := range pieces {
+		currentLevel, xref, tag := level+1, "", piece.Tag
+		if i == 0 {
+			currentLevel, xref, tag = level, node.Xref, node.Tag
+		}
+		line := fmt.Sprint(currentLevel)
+		if xref != "" {
+			line += " " + xref
+		}
+		line += " " + tag
+		if piece.Value != "" {
+			line += " " + piece.Value
+		}
+		if _, err := writer.WriteString(line + "\n"); err != nil {
+			return err
+		}
+	}
+	for _, child := range node.Children {
+		if err := writeNode(writer, child, level+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Write writes the records as GEDCOM lines. Levels are taken from the nesting
+// of the nodes, the Level field is ignored.
+func Write(output io.Writer, records []*Node) error {
+	writer := bufio.NewWriter(output)
+	for _, record := range records {
+		if err := writeNode(writer, record, 0); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+func header() *Node {
+	return &Node{Tag: TagHeader, Children: []*Node{
+		{Tag: TagSource, Value: SourceSystem},
+		{Tag: TagSubmitter, Value: submitterXref},
+		{Tag: TagGedcom, Children: []*Node{
+			{Tag: TagVersion, Value: Version},
+			{Tag: TagForm, Value: Form},
+		}},
+		{Tag: TagCharset, Value: Charset},
+	}}
+}
+
+type treeFamily struct {
+	xref     string
+	parents  []uuid.UUID
+	children []uuid.UUID
+	// parentages holds the children that aren't biological children of the
+	// parents
+	parentages map[uuid.UUID]familytree.Parentage
+	// unions holds every union of the couple, sorted by start date, a couple
+	// that married again has one MARR event for each union
+	unions []familytree.Union
+}
+
+type treeChild struct {
+	id        uuid.UUID
+	parentage familytree.Parentage
+}
+
+type treeCouple struct {
+	spouses []uuid.UUID
+	union   familytree.Union
+}
+
+func sortIDsByXref(ids []uuid.UUID) {
+	sort.Slice(ids, func(i, j int) bool {
+		return IndividualXref(ids[i]) < IndividualXref(ids[j])
+	})
+}
+
+// groupFamilies builds one family for each distinct set of parents of the same
+// parentage found on the PARENT relations and one for each SPOUSE relation, a
+// married couple with children is a single family. An adopted child is then a
+// child of both the birth and the adoptive families.
+func groupFamilies(tree *familytree.FamilyTree) []*treeFamily {
+	parents := map[treeChild][]uuid.UUID{}
+	childrenOrder := []treeChild{}
+	spouses := []treeCouple{}
+	for _, node := range tree.People {
+		for _, relation := range node.Relations {
+			switch relation.RelationType {
+			case familytree.RelationTypeParent:
+				child := treeChild{id: relation.PersonID, parentage: familytree.ParentageBiological}
+				if !relation.Parentage.IsBiological() {
+					child.parentage = relation.Parentage
+				}
+				if _, ok := parents[child]; !ok {
+					childrenOrder = append(childrenOrder, child)
+				}
+				parents[child] = append(parents[child], node.Person.ID)
+			case familytree.RelationTypeSpouse:
+				couple := treeCouple{spouses: []uuid.UUID{node.Person.ID, relation.PersonID}}
+				if relation.Union != nil {
+					couple.union = *relation.Union
+				}
+				spouses = append(spouses, couple)
+			}
+		}
+	}
+
+	families := map[string]*treeFamily{}
+	getFamily := func(familyParents []uuid.UUID) *treeFamily {
+		xref := FamilyXref(familyParents...)
+		family, ok := families[xref]
+		if !ok {
+			sortIDsByXref(familyParents)
+			family = &treeFamily{xref: xref, parents: familyParents, parentages: map[uuid.UUID]familytree.Parentage{}}
+			families[xref] = family
+		}
+		return family
+	}
+	for _, child := range childrenOrder {
+		family := getFamily(append([]uuid.UUID{}, parents[child]...))
+		family.children = append(family.children, child.id)
+		if !child.parentage.IsBiological() {
+			family.parentages[child.id] = child.parentage
+		}
+	}
+	for _, couple := range spouses {
+		family := getFamily(couple.spouses)
+		if !hasUnion(family.unions, couple.union) {
+			family.unions = append(family.unions, couple.union)
+		}
+	}
+
+	sortedFamilies := make([]*treeFamily, 0, len(families))
+	for _, family := range families {
+		sortIDsByXref(family.children)
+		sort.SliceStable(family.unions, func(i, j int) bool {
+			first, _ := family.unions[i].StartDate.Bounds()
+			second, _ := family.unions[j].StartDate.Bounds()
+			return first.Before(second)
+		})
+		sortedFamilies = append(sortedFamilies, family)
+	}
+	sort.Slice(sortedFamilies, func(i, j int) bool {
+		return sortedFamilies[i].xref < sortedFamilies[j].xref
+	})
+	return sortedFamilies
+}
+
+// hasUnion tells if the union was already found on the relation of the other
+// spouse, the start date identifies a union of the couple.
+func hasUnion(unions []familytree.Union, union familytree.Union) bool {
+	for _, other := range unions {
+		if other.StartDate == union.StartDate {
+			return true
+		}
+	}
+	return false
+}
+
+// nameNode writes the surname between slashes when the name is made of the
+// given name and surname, otherwise the name is kept and GIVN and SURN carry
+// the parts.
+func nameNode(person familytree.Person) *Node {
+	node := &Node{Tag: TagName, Value: person.Name}
+	if person.GivenName == "" && person.Surname == "" {
+		return node
+	}
+	if person.Surname != "" && strings.TrimSpace(person.GivenName+" "+person.Surname) == person.Name {
+		node.Value = strings.TrimSpace(person.GivenName + " /" + person.Surname + "/")
+	}
+	if person.GivenName != "" {
+		node.Children = append(node.Children, &Node{Tag: TagGivenName, Value: person.GivenName})
+	}
+	if person.Surname != "" {
+		node.Children = append(node.Children, &Node{Tag: TagSurname, Value: person.Surname})
+	}
+	return node
+}
+
+func eventNode(tag string, date familytree.Date, place string) *Node {
+	if date == "" && place == "" {
+		return nil
+	}
+	node := &Node{Tag: tag}
+	if parsed, err := date.Parse(); err == nil {
+		node.Children = append(node.Children, &Node{Tag: TagDate, Value: parsed.Gedcom()})
+	}
+	if place != "" {
+		node.Children = append(node.Children, &Node{Tag: TagPlace, Value: place})
+	}
+	return node
+}
+
+func individualRecord(person familytree.Person, families []*treeFamily) *Node {
+	record := &Node{Xref: IndividualXref(person.ID), Tag: TagIndividual, Children: []*Node{
+		nameNode(person),
+	}}
+	if person.Sex != familytree.SexUnknown {
+		record.Children = append(record.Children, &Node{Tag: TagSex, Value: string(person.Sex)})
+	}
+	for _, event := range []*Node{
+		eventNode(TagBirth, person.BirthDate, person.BirthPlace),
+		eventNode(TagDeath, person.DeathDate, person.DeathPlace),
+	} {
+		if event != nil {
+			record.Children = append(record.Children, event)
+		}
+	}
+	for _, family := range families {
+		for _, childID := range family.children {
+			if childID != person.ID {
+				continue
+			}
+			link := &Node{Tag: TagFamilyChild, Value: family.xref}
+			if tag, pedigree := ParentagePedigree(family.parentages[childID]); tag != "" {
+				link.Children = append(link.Children, &Node{Tag: tag, Value: pedigree})
+			}
+			record.Children = append(record.Children, link)
+		}
+	}
+	for _, family := range families {
+		for _, parentID := range family.parents {
+			if parentID == person.ID {
+				record.Children = append(record.Children, &Node{Tag: TagFamilySpouse, Value: family.xref})
+			}
+		}
+	}
+	return record
+}
+
+// husbandRank puts men first and women last, so a woman is only written as
+// HUSB when both parents are women.
+func husbandRank(sex familytree.Sex) int {
+	switch sex {
+	case familytree.SexMale:
+		return 0
+	case familytree.SexFemale:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// familyRecord lists the first parent as HUSB and the second as WIFE, choosing
+// by sex and falling back on the xrefs order.
+func familyRecord(family *treeFamily, people map[uuid.UUID]familytree.Person) *Node {
+	record := &Node{Xref: family.xref, Tag: TagFamily, Children: []*Node{}}
+	parents := append([]uuid.UUID{}, family.parents...)
+	sort.SliceStable(parents, func(i, j int) bool {
+		return husbandRank(people[parents[i]].Sex) < husbandRank(people[parents[j]].Sex)
+	})
+	for i, parentID := range parents {
+		tag := TagHusband
+		if i > 0 {
+			tag = TagWife
+		}
+		record.Children = append(record.Children, &Node{Tag: tag, Value: IndividualXref(parentID)})
+	}
+	for _, childID := range family.children {
+		record.Children = append(record.Children, &Node{Tag: TagChild, Value: IndividualXref(childID)})
+	}
+	for _, union := range family.unions {
+		record.Children = append(record.Children, unionEventNode(TagMarriage, union.StartDate))
+		switch union.EndReason {
+		case familytree.UnionEndDivorce:
+			record.Children = append(record.Children, unionEventNode(TagDivorce, union.EndDate))
+		case familytree.UnionEndAnnulment:
+			record.Children = append(record.Children, unionEventNode(TagAnnulment, union.EndDate))
+		}
+	}
+	return record
+}
+
+// unionEventNode writes the event with its DATE, or with the Y value that
+// tells the event happened on an unknown date.
+func unionEventNode(tag string, date familytree.Date) *Node {
+	if event := eventNode(tag, date, ""); event != nil && len(event.Children) > 0 {
+		return event
+	}
+	return &Node{Tag: tag, Value: "Y"}
+}
+
+// FamilyTreeRecords turns the tree into a lineage-linked GEDCOM 5.5.1
+// document, with records sorted by xref so the same tree is always written
+// the same way.
+func FamilyTreeRecords(tree *familytree.FamilyTree) []*Node {
+	families := groupFamilies(tree)
+	people := make([]familytree.Person, 0, len(tree.People))
+	peopleByID := make(map[uuid.UUID]familytree.Person, len(tree.People))
+	for _, node := range tree.People {
+		people = append(people, node.Person)
+		peopleByID[node.Person.ID] = node.Person
+	}
+	sort.Slice(people, func(i, j int) bool {
+		return IndividualXref(people[i].ID) < IndividualXref(people[j].ID)
+	})
+
+	records := []*Node{header()}
+	for _, person := range people {
+		records = append(records, individualRecord(person, families))
+	}
+	for _, family := range families {
+		records = append(records, familyRecord(family, peopleByID))
+	}
+	records = append(records,
+		&Node{Xref: submitterXref, Tag: TagSubmitter, Children: []*Node{{Tag: TagName, Value: SourceSystem}}},
+		&Node{Tag: TagTrailer},
+	)
+	return records
+}
+
+func EncodeFamilyTree(output io.Writer, tree *familytree.FamilyTree) error {
+	return Write(output, FamilyTreeRecords(tree))
+}
@@ -0,0 +1,220 @@
+package gedcom
+
+import (
+	"bytes"
+	"context"
+	"family-tree/internal/adapters/memoryrepo"
+	"family-tree/internal/core/familytree"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestWrite(t *testing.T) {
+	longValue := strings.Repeat("word ", 60)
+	tests := []struct {
+		name     string
+		record   *Node
+		expected string
+	}{
+		{
+			name:     "nested record",
+			record:   &Node{Xref: "@I1@", Tag: TagIndividual, Children: []*Node{{Tag: TagName, Value: "John /Smith/"}, {Tag: TagFamilySpouse, Value: "@F1@"}}},
+			expected: "0 @I1@ INDI\n1 NAME John /Smith/\n1 FAMS @F1@\n",
+		},
+		{
+			name:     "escaped at signs",
+			record:   &Node{Tag: "NOTE", Value: "john@example.com"},
+			expected: "0 NOTE john@@example.com\n",
+		},
+		{
+			name:     "new lines on CONT",
+			record:   &Node{Tag: "NOTE", Value: "First\nSecond"},
+			expected: "0 NOTE First\n1 CONT Second\n",
+		},
+		{
+			name:     "long values on CONC",
+			record:   &Node{Tag: "NOTE", Value: longValue},
+			expected: "",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var output bytes.Buffer
+			if err := Write(&output, []*Node{test.record}); err != nil {
+				t.Fatalf("Write returned error: %v", err)
+			}
+			if test.expected != "" && output.String() != test.expected {
+				t.Errorf("Write returned %q, expected %q", output.String(), test.expected)
+			}
+			for _, line := range strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n") {
+				if len(line) > maxValueLength+10 {
+					t.Errorf("Write returned the line %q longer than %d", line, maxValueLength+10)
+				}
+			}
+			records, err := Parse(&output)
+			if err != nil {
+				t.Fatalf("Parse of the written record returned error: %v", err)
+			}
+			if len(records) != 1 || records[0].Value != test.record.Value {
+				t.Errorf("Parse of the written record returned %q, expected %q", nodeLines(records), test.record.Value)
+			}
+		})
+	}
+}
+
+func TestXrefs(t *testing.T) {
+	first, second := uuid.New(), uuid.New()
+	tests := []struct {
+		name  string
+		xref  string
+		equal string
+	}{
+		{name: "individual", xref: IndividualXref(first), equal: IndividualXref(first)},
+		{name: "family in any order", xref: FamilyXref(first, second), equal: FamilyXref(second, first)},
+		{name: "single parent family", xref: FamilyXref(first), equal: FamilyXref(first)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.xref != test.equal {
+				t.Errorf("the xrefs %s and %s are different", test.xref, test.equal)
+			}
+			if !isPointer(test.xref) || len(test.xref) > 22 {
+				t.Errorf("the xref %s isn't a pointer of up to 20 characters", test.xref)
+			}
+		})
+	}
+	if IndividualXref(first) == IndividualXref(second) || FamilyXref(first, second) == FamilyXref(first) {
+		t.Errorf("different people or families have the same xref")
+	}
+}
+
+// TestEncodeFamilyTree writes a tree as GEDCOM and imports it back, the
+// imported people and relations must be the ones of the tree.
+func TestEncodeFamilyTree(t *testing.T) {
+	john := familytree.Person{ID: uuid.New(), Name: "John Smith", GivenName: "John", Surname: "Smith", Sex: familytree.SexMale, BirthDate: "1850-03-12", BirthPlace: "Lisbon"}
+	mary := familytree.Person{ID: uuid.New(), Name: "Mary Jones", GivenName: "Mary", Surname: "Jones", Sex: familytree.SexFemale, DeathDate: "ABT 1920"}
+	son := familytree.Person{ID: uuid.New(), Name: "Son", Sex: familytree.SexMale}
+	adopted := familytree.Person{ID: uuid.New(), Name: "Adopted @ Home"}
+	union := familytree.Union{StartDate: "1875", EndDate: "1890-05", EndReason: familytree.UnionEndDivorce}
+	parentRelations := []familytree.FamilyTreeRelation{
+		{PersonID: son.ID, RelationType: familytree.RelationTypeParent, Parentage: familytree.ParentageBiological},
+		{PersonID: adopted.ID, RelationType: familytree.RelationTypeParent, Parentage: familytree.ParentageAdoptive},
+	}
+	tree := &familytree.FamilyTree{People: []familytree.FamilyTreeNode{
+		{Person: john, Relations: append([]familytree.FamilyTreeRelation{{PersonID: mary.ID, RelationType: familytree.RelationTypeSpouse, Union: &union}}, parentRelations...)},
+		{Person: mary, Relations: parentRelations},
+		{Person: son},
+		{Person: adopted},
+	}}
+
+	var output bytes.Buffer
+	if err := EncodeFamilyTree(&output, tree); err != nil {
+		t.Fatalf("EncodeFamilyTree returned error: %v", err)
+	}
+	var again bytes.Buffer
+	if err := EncodeFamilyTree(&again, tree); err != nil || again.String() != output.String() {
+		t.Errorf("EncodeFamilyTree wrote the same tree differently, %v", err)
+	}
+
+	repo := memoryrepo.NewFamilyTreeRepo()
+	personUseCase := familytree.NewPersonUseCase(repo, nil)
+	relationshipUseCase := familytree.NewRelationshipUseCase(repo, familytree.DefaultRelationRules(), nil)
+	ctx := context.Background()
+	report, err := NewImporter(repo, personUseCase, relationshipUseCase).ImportReader(ctx, &output)
+	if err != nil {
+		t.Fatalf("ImportReader returned error: %v", err)
+	}
+	if !report.Committed || report.Imported != 9 || report.Skipped != 0 || report.Rejected != 0 {
+		t.Fatalf("ImportReader returned %+v, expected 4 people and 5 relations imported", report)
+	}
+	imported := map[string]uuid.UUID{}
+	for _, entry := range report.Entries {
+		if entry.Tag == TagIndividual {
+			imported[entry.Xref] = entry.PersonID
+		}
+	}
+
+	for _, original := range []familytree.Person{john, mary, son, adopted} {
+		t.Run(original.Name, func(t *testing.T) {
+			person, err := personUseCase.GetPerson(ctx, imported[IndividualXref(original.ID)])
+			if err != nil || person == nil {
+				t.Fatalf("GetPerson returned %v, %v", person, err)
+			}
+			original.ID = person.ID
+			// A name without surname is read back as the given name
+			if original.GivenName == "" && original.Surname == "" {
+				original.GivenName = original.Name
+			}
+			if *person != original {
+				t.Errorf("GetPerson returned %+v, expected %+v", *person, original)
+			}
+		})
+	}
+	unions, err := relationshipUseCase.GetUnions(ctx, imported[IndividualXref(john.ID)])
+	if err != nil || len(unions) != 1 || unions[0].Union != union {
+		t.Errorf("GetUnions(John) returned %+v, %v, expected %+v", unions, err, union)
+	}
+	for _, test := range []struct {
+		child     familytree.Person
+		parentage familytree.Parentage
+	}{
+		{child: son, parentage: familytree.ParentageBiological},
+		{child: adopted, parentage: familytree.ParentageAdoptive},
+	} {
+		var parents []familytree.PersonParent
+		err := familytree.RunInTx(ctx, repo, familytree.SessionRead, func(ctx context.Context, tx familytree.Tx) error {
+			var err error
+			parents, err = repo.GetParents(ctx, tx, imported[IndividualXref(test.child.ID)])
+			return err
+		})
+		if err != nil {
+			t.Fatalf("GetParents(%s) returned error: %v", test.child.Name, err)
+		}
+		names := []string{}
+		for _, parent := range parents {
+			names = append(names, parent.Parent.Name+" "+string(parent.Parentage))
+		}
+		sort.Strings(names)
+		expected := "John Smith " + string(test.parentage) + "|Mary Jones " + string(test.parentage)
+		if strings.Join(names, "|") != expected {
+			t.Errorf("GetParents(%s) returned %q, expected %q", test.child.Name, names, expected)
+		}
+	}
+}
+
+// TestFamilyTreeRecordsRemarriage checks that a couple who married again is a
+// single family with one MARR event for each union, in the order they started.
+func TestFamilyTreeRecordsRemarriage(t *testing.T) {
+	husband := familytree.Person{ID: uuid.New(), Name: "Husband", Sex: familytree.SexMale}
+	wife := familytree.Person{ID: uuid.New(), Name: "Wife", Sex: familytree.SexFemale}
+	first := familytree.Union{StartDate: "1900", EndDate: "1910", EndReason: familytree.UnionEndDivorce}
+	second := familytree.Union{StartDate: "1920"}
+	tree := &familytree.FamilyTree{People: []familytree.FamilyTreeNode{
+		{Person: husband, Relations: []familytree.FamilyTreeRelation{
+			{PersonID: wife.ID, RelationType: familytree.RelationTypeSpouse, Union: &second},
+			{PersonID: wife.ID, RelationType: familytree.RelationTypeSpouse, Union: &first},
+		}},
+		{Person: wife, Relations: []familytree.FamilyTreeRelation{
+			{PersonID: husband.ID, RelationType: familytree.RelationTypeSpouse, Union: &first},
+		}},
+	}}
+
+	var events []string
+	for _, record := range FamilyTreeRecords(tree) {
+		if record.Tag != TagFamily {
+			continue
+		}
+		for _, child := range record.Children {
+			if child.Tag == TagMarriage || child.Tag == TagDivorce {
+				events = append(events, child.Tag+" "+child.ChildValue(TagDate))
+			}
+		}
+	}
+	expected := "MARR 1900|DIV 1910|MARR 1920"
+	if strings.Join(events, "|") != expected {
+		t.Errorf("FamilyTreeRecords wrote the events %q, expected %q", events, expected)
+	}
+}
@@ -0,0 +1,286 @@
+package gedcom
+
+import (
+	"context"
+	"errors"
+	"family-tree/internal/core/familytree"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+type ImportStatus string
+
+const (
+	ImportStatusImported = ImportStatus("IMPORTED")
+	ImportStatusSkipped  = ImportStatus("SKIPPED")
+	ImportStatusRejected = ImportStatus("REJECTED")
+)
+
+var errImportRejected = errors.New("import has rejected records")
+
+// ImportEntry describes what happened to an INDI record or to one of the
+// relations of a FAM record. People holds the xrefs of the individuals
+// involved and PersonID is only set for imported INDI records of a committed
+// import.
+type ImportEntry struct {
+	Xref         string
+	Tag          string
+	RelationType string
+	People       []string
+	PersonID     uuid.UUID
+	Status       ImportStatus
+	Reason       string
+}
+
+// ImportReport Committed is false when some record was rejected, the whole
+// import is rolled back then and the entries only tell what would happen.
+type ImportReport struct {
+	Entries   []ImportEntry
+	Imported  int
+	Skipped   int
+	Rejected  int
+	Committed bool
+}
+
+func (report *ImportReport) add(entry ImportEntry) {
+	switch entry.Status {
+	case ImportStatusImported:
+		report.Imported++
+	case ImportStatusSkipped:
+		report.Skipped++
+	case ImportStatusRejected:
+		report.Rejected++
+	}
+	report.Entries = append(report.Entries, entry)
+}
+
+// Importer creates the people and relations of a GEDCOM document through the
+// use cases, so every relation goes through the same validations as the API.
+// The use cases join the Tx of the import, so it's kept or dropped as a whole.
+type Importer struct {
+	familyTreeRepo      familytree.FamilyTreeRepo
+	personUseCase       familytree.PersonUseCasePort
+	relationshipUseCase familytree.RelationshipUseCasePort
+}
+
+func NewImporter(familyTreeRepo familytree.FamilyTreeRepo, personUseCase familytree.PersonUseCasePort, relationshipUseCase familytree.RelationshipUseCasePort) *Importer {
+	return &Importer{
+		familyTreeRepo:      familyTreeRepo,
+		personUseCase:       personUseCase,
+		relationshipUseCase: relationshipUseCase,
+	}
+}
+
+func (importer *Importer) ImportReader(ctx context.Context, reader io.Reader) (*ImportReport, error) {
+	document, err := Decode(reader)
+	if err != nil {
+		return nil, err
+	}
+	return importer.Import(ctx, document)
+}
+
+// Import runs the whole document on one write Tx, committed only when no
+// record is rejected. A rejected record rolls back every other one, so a bad
+// FAM never leaves the individuals of the file half imported.
+func (importer *Importer) Import(ctx context.Context, document *Document) (*ImportReport, error) {
+	var report *ImportReport
+	err := familytree.RunInTx(ctx, importer.familyTreeRepo, familytree.SessionWrite, func(ctx context.Context, tx familytree.Tx) error {
+		report = importer.importDocument(ctx, document)
+		if report.Rejected > 0 {
+			return errImportRejected
+		}
+		return nil
+	})
+	if errors.Is(err, errImportRejected) {
+		for i := range report.Entries {
+			report.Entries[i].PersonID = uuid.Nil
+		}
+		return report, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	report.Committed = true
+	return report, nil
+}
+
+// importDocument creates every individual first, then the PARENT relations of
+// every family and only then the SPOUSE relations, since a couple must already
+// have a common child to be married.
+func (importer *Importer) importDocument(ctx context.Context, document *Document) *ImportReport {
+	report := &ImportReport{Entries: []ImportEntry{}}
+	people := map[string]uuid.UUID{}
+	individuals := map[string]Individual{}
+
+	for _, individual := range document.Individuals {
+		entry := importer.importIndividual(ctx, individual, people)
+		if entry.Status == ImportStatusImported {
+			individuals[individual.Xref] = individual
+		}
+		report.add(entry)
+	}
+	for _, family := range document.Families {
+		for _, child := range family.Children {
+			for _, parent := range []string{family.Husband, family.Wife} {
+				if parent == "" {
+					continue
+				}
+				pedigree := individuals[child].Pedigrees[family.Xref]
+				report.add(importer.importRelation(ctx, family, familytree.RelationTypeParent, parent, child, pedigree, people))
+			}
+		}
+	}
+	for _, family := range document.Families {
+		if family.Husband == "" || family.Wife == "" {
+			continue
+		}
+		report.add(importer.importRelation(ctx, family, familytree.RelationTypeSpouse, family.Husband, family.Wife, "", people))
+	}
+	for _, record := range document.Others {
+		report.add(ImportEntry{
+			Xref:   record.Xref,
+			Tag:    record.Tag,
+			Status: ImportStatusSkipped,
+			Reason: fmt.Sprintf("%s records aren't supported", record.Tag),
+		})
+	}
+	return report
+}
+
+func (importer *Importer) importIndividual(ctx context.Context, individual Individual, people map[string]uuid.UUID) ImportEntry {
+	entry := ImportEntry{
+		Xref:   individual.Xref,
+		Tag:    TagIndividual,
+		People: []string{individual.Xref},
+	}
+	if _, ok := people[individual.Xref]; ok {
+		entry.Status = ImportStatusSkipped
+		entry.Reason = fmt.Sprintf("duplicated record %s", individual.Xref)
+		return entry
+	}
+	person, ignored := individualPerson(individual)
+	if err := importer.personUseCase.CreatePerson(ctx, person); err != nil {
+		entry.Status = ImportStatusRejected
+		entry.Reason = err.Error()
+		return entry
+	}
+	people[individual.Xref] = person.ID
+	entry.PersonID = person.ID
+	entry.Status = ImportStatusImported
+	if len(ignored) > 0 {
+		entry.Reason = "ignored " + strings.Join(ignored, ", ")
+	}
+	return entry
+}
+
+// individualPerson maps the individual to a person, values the service can't
+// store, like date phrases or GEDCOM 7 sexes, are left out and listed on
+// ignored so the individual is still imported.
+func individualPerson(individual Individual) (*familytree.Person, []string) {
+	ignored := []string{}
+	person := &familytree.Person{
+		Name:       individual.Name,
+		GivenName:  individual.GivenName,
+		Surname:    individual.Surname,
+		BirthPlace: individual.Birth.Place,
+		DeathPlace: individual.Death.Place,
+	}
+	if sex, err := familytree.Sex(individual.Sex).Normalize(); err == nil {
+		person.Sex = sex
+	} else {
+		ignored = append(ignored, fmt.Sprintf("sex %q", individual.Sex))
+	}
+	if date, err := familytree.Date(individual.Birth.Date).Normalize(); err == nil {
+		person.BirthDate = date
+	} else {
+		ignored = append(ignored, fmt.Sprintf("birth date %q", individual.Birth.Date))
+	}
+	if date, err := familytree.Date(individual.Death.Date).Normalize(); err == nil {
+		person.DeathDate = date
+	} else {
+		ignored = append(ignored, fmt.Sprintf("death date %q", individual.Death.Date))
+	}
+	return person, ignored
+}
+
+// importRelation creates the relation between the individuals, pedigree is the
+// PEDI of the child FAMC link and is only used by PARENT relations.
+func (importer *Importer) importRelation(ctx context.Context, family Family, relationType familytree.RelationType, top string, bottom string, pedigree string, people map[string]uuid.UUID) ImportEntry {
+	entry := ImportEntry{
+		Xref:         family.Xref,
+		Tag:          TagFamily,
+		RelationType: relationType.String(),
+		People:       []string{top, bottom},
+	}
+	for _, xref := range entry.People {
+		if _, ok := people[xref]; !ok {
+			entry.Status = ImportStatusSkipped
+			entry.Reason = fmt.Sprintf("individual %s wasn't imported", xref)
+			return entry
+		}
+	}
+	var warnings []familytree.RuleViolation
+	var ignored []string
+	var err error
+	switch relationType {
+	case familytree.RelationTypeParent:
+		parentage, ok := PedigreeParentage(pedigree)
+		if !ok {
+			ignored = append(ignored, fmt.Sprintf("pedigree %q", pedigree))
+		}
+		warnings, err = importer.relationshipUseCase.CreateParentRelation(ctx, people[top], people[bottom], parentage)
+	case familytree.RelationTypeSpouse:
+		var union familytree.Union
+		union, ignored = familyUnion(family)
+		warnings, err = importer.relationshipUseCase.CreateSpouseRelation(ctx, people[top], people[bottom], union)
+	}
+	if err != nil {
+		entry.Status = ImportStatusRejected
+		entry.Reason = err.Error()
+		return entry
+	}
+	entry.Status = ImportStatusImported
+	reasons := []string{}
+	if len(ignored) > 0 {
+		reasons = append(reasons, "ignored "+strings.Join(ignored, ", "))
+	}
+	if len(warnings) > 0 {
+		messages := make([]string, 0, len(warnings))
+		for _, warning := range warnings {
+			messages = append(messages, warning.Error())
+		}
+		reasons = append(reasons, "warning: "+strings.Join(messages, "; "))
+	}
+	entry.Reason = strings.Join(reasons, "; ")
+	return entry
+}
+
+// familyUnion maps the MARR date to the union start and the DIV or ANUL date to
+// the union end, invalid dates are left out and listed on ignored.
+func familyUnion(family Family) (familytree.Union, []string) {
+	ignored := []string{}
+	union := familytree.Union{}
+	eventDate := func(event *Event, name string) familytree.Date {
+		if event == nil {
+			return ""
+		}
+		date, err := familytree.Date(event.Date).Normalize()
+		if err != nil {
+			ignored = append(ignored, fmt.Sprintf("%s date %q", name, event.Date))
+		}
+		return date
+	}
+	union.StartDate = eventDate(family.Marriage, "marriage")
+	if family.Divorce != nil {
+		union.EndReason = familytree.UnionEndDivorce
+		union.EndDate = eventDate(family.Divorce, "divorce")
+	}
+	if family.Annulment != nil {
+		union.EndReason = familytree.UnionEndAnnulment
+		union.EndDate = eventDate(family.Annulment, "annulment")
+	}
+	return union, ignored
+}
@@ -0,0 +1,180 @@
+package gedcom
+
+import (
+	"context"
+	"family-tree/internal/adapters/memoryrepo"
+	"family-tree/internal/core/familytree"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func newTestImporter() (*Importer, familytree.PersonUseCasePort, familytree.RelationshipUseCasePort) {
+	repo := memoryrepo.NewFamilyTreeRepo()
+	personUseCase := familytree.NewPersonUseCase(repo, nil)
+	relationshipUseCase := familytree.NewRelationshipUseCase(repo, familytree.DefaultRelationRules(), nil)
+	return NewImporter(repo, personUseCase, relationshipUseCase), personUseCase, relationshipUseCase
+}
+
+const validImport = `0 HEAD
+0 @I1@ INDI
+1 NAME John /Smith/
+1 SEX M
+1 BIRT
+2 DATE sometime
+0 @I2@ INDI
+1 NAME Mary /Jones/
+1 SEX F
+0 @I3@ INDI
+1 NAME Son /Smith/
+1 FAMC @F1@
+0 @I1@ INDI
+1 NAME John Again
+0 @I4@ INDI
+1 NAME Other
+1 SEX X
+0 @F1@ FAM
+1 HUSB @I1@
+1 WIFE @I2@
+1 CHIL @I3@
+1 MARR
+2 DATE 1875
+`
+
+// TestImport imports the individuals with F1 and the rejected families F2 and
+// F3, nothing is kept then, and imports them again only with F1.
+func TestImport(t *testing.T) {
+	input := validImport + `0 @F2@ FAM
+1 HUSB @I1@
+1 WIFE @I9@
+1 CHIL @I3@
+0 @F3@ FAM
+1 HUSB @I4@
+1 WIFE @I2@
+0 @N1@ NOTE A note
+0 TRLR
+`
+	importer, personUseCase, relationshipUseCase := newTestImporter()
+	ctx := context.Background()
+	report, err := importer.ImportReader(ctx, strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ImportReader returned error: %v", err)
+	}
+
+	expected := []struct {
+		xref         string
+		tag          string
+		relationType string
+		people       string
+		status       ImportStatus
+		reason       string
+	}{
+		{xref: "@I1@", tag: TagIndividual, people: "@I1@", status: ImportStatusImported, reason: `ignored birth date "sometime"`},
+		{xref: "@I2@", tag: TagIndividual, people: "@I2@", status: ImportStatusImported},
+		{xref: "@I3@", tag: TagIndividual, people: "@I3@", status: ImportStatusImported},
+		{xref: "@I1@", tag: TagIndividual, people: "@I1@", status: ImportStatusSkipped, reason: "duplicated record @I1@"},
+		{xref: "@I4@", tag: TagIndividual, people: "@I4@", status: ImportStatusImported, reason: `ignored sex "X"`},
+		{xref: "@F1@", tag: TagFamily, relationType: "PARENT", people: "@I1@ @I3@", status: ImportStatusImported},
+		{xref: "@F1@", tag: TagFamily, relationType: "PARENT", people: "@I2@ @I3@", status: ImportStatusImported},
+		{xref: "@F2@", tag: TagFamily, relationType: "PARENT", people: "@I1@ @I3@", status: ImportStatusRejected},
+		{xref: "@F2@", tag: TagFamily, relationType: "PARENT", people: "@I9@ @I3@", status: ImportStatusSkipped, reason: "individual @I9@ wasn't imported"},
+		{xref: "@F1@", tag: TagFamily, relationType: "SPOUSE", people: "@I1@ @I2@", status: ImportStatusImported},
+		{xref: "@F2@", tag: TagFamily, relationType: "SPOUSE", people: "@I1@ @I9@", status: ImportStatusSkipped, reason: "individual @I9@ wasn't imported"},
+		{xref: "@F3@", tag: TagFamily, relationType: "SPOUSE", people: "@I4@ @I2@", status: ImportStatusRejected},
+		{xref: "@N1@", tag: "NOTE", people: "", status: ImportStatusSkipped, reason: "NOTE records aren't supported"},
+	}
+	if len(report.Entries) != len(expected) {
+		t.Fatalf("ImportReader returned %d entries, expected %d: %+v", len(report.Entries), len(expected), report.Entries)
+	}
+	for i, entry := range report.Entries {
+		test := expected[i]
+		people := strings.Join(entry.People, " ")
+		if entry.Xref != test.xref || entry.Tag != test.tag || entry.RelationType != test.relationType || people != test.people || entry.Status != test.status {
+			t.Errorf("entry %d is %s %s %s [%s] %s, expected %s %s %s [%s] %s", i, entry.Xref, entry.Tag, entry.RelationType, people, entry.Status, test.xref, test.tag, test.relationType, test.people, test.status)
+		}
+		if test.status != ImportStatusRejected && entry.Reason != test.reason {
+			t.Errorf("entry %d has reason %q, expected %q", i, entry.Reason, test.reason)
+		}
+		if test.status == ImportStatusRejected && entry.Reason == "" {
+			t.Errorf("entry %d was rejected without a reason", i)
+		}
+	}
+	if report.Imported != 7 || report.Skipped != 4 || report.Rejected != 2 || report.Committed {
+		t.Errorf("ImportReader counted %d imported, %d skipped and %d rejected, committed %v, expected 7, 4 and 2 rolled back", report.Imported, report.Skipped, report.Rejected, report.Committed)
+	}
+	for i, entry := range report.Entries {
+		if entry.PersonID != uuid.Nil {
+			t.Errorf("entry %d of a rolled back import has the person %s", i, entry.PersonID)
+		}
+	}
+	people, err := personUseCase.GetPeople(ctx, familytree.PeopleFilter{}, familytree.PeopleSort{}, familytree.PaginationDetails{PageSize: 10})
+	if err != nil || people.Metadata.TotalItens != 0 {
+		t.Fatalf("GetPeople after a rolled back import returned %+v, %v, expected nobody", people, err)
+	}
+
+	report, err = importer.ImportReader(ctx, strings.NewReader(validImport+"0 TRLR\n"))
+	if err != nil {
+		t.Fatalf("ImportReader returned error: %v", err)
+	}
+	if report.Imported != 7 || report.Skipped != 1 || report.Rejected != 0 || !report.Committed {
+		t.Fatalf("ImportReader counted %d imported, %d skipped and %d rejected, committed %v, expected 7, 1 and 0 committed", report.Imported, report.Skipped, report.Rejected, report.Committed)
+	}
+
+	john, err := personUseCase.GetPerson(ctx, report.Entries[0].PersonID)
+	if err != nil || john == nil {
+		t.Fatalf("GetPerson(@I1@) returned %v, %v", john, err)
+	}
+	if john.Name != "John Smith" || john.GivenName != "John" || john.Surname != "Smith" || john.Sex != familytree.SexMale || john.BirthDate != "" {
+		t.Errorf("GetPerson(@I1@) returned %+v, expected John Smith without a birth date", john)
+	}
+	unions, err := relationshipUseCase.GetUnions(ctx, john.ID)
+	if err != nil || len(unions) != 1 || unions[0].Union.StartDate != "1875" {
+		t.Errorf("GetUnions(@I1@) returned %+v, %v, expected the union started in 1875", unions, err)
+	}
+}
+
+func TestFamilyUnion(t *testing.T) {
+	tests := []struct {
+		name    string
+		family  Family
+		union   familytree.Union
+		ignored []string
+	}{
+		{
+			name:    "no events",
+			family:  Family{},
+			union:   familytree.Union{},
+			ignored: []string{},
+		},
+		{
+			name:    "marriage and divorce",
+			family:  Family{Marriage: &Event{Date: "12 MAR 1875"}, Divorce: &Event{Date: "1890"}},
+			union:   familytree.Union{StartDate: "1875-03-12", EndDate: "1890", EndReason: familytree.UnionEndDivorce},
+			ignored: []string{},
+		},
+		{
+			name:    "annulment on an unknown date",
+			family:  Family{Marriage: &Event{}, Annulment: &Event{}},
+			union:   familytree.Union{EndReason: familytree.UnionEndAnnulment},
+			ignored: []string{},
+		},
+		{
+			name:    "invalid dates",
+			family:  Family{Marriage: &Event{Date: "spring"}, Divorce: &Event{Date: "later"}},
+			union:   familytree.Union{EndReason: familytree.UnionEndDivorce},
+			ignored: []string{`marriage date "spring"`, `divorce date "later"`},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			union, ignored := familyUnion(test.family)
+			if union != test.union {
+				t.Errorf("familyUnion returned %+v, expected %+v", union, test.union)
+			}
+			if strings.Join(ignored, "|") != strings.Join(test.ignored, "|") {
+				t.Errorf("familyUnion ignored %q, expected %q", ignored, test.ignored)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package gedcom
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	TagHeader      = "HEAD"
+	TagTrailer     = "TRLR"
+	TagIndividual  = "INDI"
+	TagFamily      = "FAM"
+	TagName        = "NAME"
+	TagGivenName   = "GIVN"
+	TagSurname     = "SURN"
+	TagHusband     = "HUSB"
+	TagWife        = "WIFE"
+	TagChild       = "CHIL"
+	TagSex         = "SEX"
+	TagBirth       = "BIRT"
+	TagDeath       = "DEAT"
+	TagDate        = "DATE"
+	TagPlace       = "PLAC"
+	TagDivorce     = "DIV"
+	TagAnnulment   = "ANUL"
+	TagFamilyChild = "FAMC"
+	TagPedigree    = "PEDI"
+	// TagUserPedigree carries the parentages PEDI has no value for
+	TagUserPedigree = "_PEDI"
+	TagContinued    = "CONT"
+	TagConcatenated = "CONC"
+
+	maxLineLength = 1 << 20
+)
+
+// Node is a GEDCOM line with the lines nested below it. Xref is the
+// cross-reference of records, like @I1@, and CONT/CONC lines are already
+// merged into the Value.
+type Node struct {
+	Level    int
+	Xref     string
+	Tag      string
+	Value    string
+	Line     int
+	Children []*Node
+}
+
+type SyntaxError struct {
+	Line    int
+	Message string
+}
+
+func (err *SyntaxError) Error() string {
+	return fmt.Sprintf("gedcom: line %d: %s", err.Line, err.Message)
+}
+
+// Child returns the first nested node with the tag, or nil.
+func (node *Node) Child(tag string) *Node {
+	for _, child := range node.Children {
+		if child.Tag == tag {
+			return child
+		}
+	}
+	return nil
+}
+
+// ChildValue returns the value of the first nested node with the tag.
+func (node *Node) ChildValue(tag string) string {
+	child := node.Child(tag)
+	if child == nil {
+		return ""
+	}
+	return child.Value
+}
+
+// ChildrenWithTag returns every nested node with the tag in file order.
+func (node *Node) ChildrenWithTag(tag string) []*Node {
+	children := []*Node{}
+	for _, child := range node.Children {
+		if child.Tag == tag {
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+// scanLines splits on CR, LF, CRLF and LFCR, which are all valid GEDCOM
+// terminators.
+func scanLines(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if i+1 < len(data) {
+			if (data[i] == '\r' && data[i+1] == '\n') || (data[i] == '\n' && data[i+1] == '\r') {
+				return i + 2, data[:i], nil
+			}
+			return i + 1, data[:i], nil
+		}
+		if atEOF {
+			return i + 1, data[:i], nil
+		}
+		// The terminator may be the first half of a CRLF on the next read
+		return 0, nil, nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+func parseLine(text string, lineNumber int) (*Node, error) {
+	fields := strings.SplitN(strings.TrimLeft(text, " \t"), " ", 2)
+	level, err := strconv.Atoi(fields[0])
+	if err != nil || level < 0 {
+		return nil, &SyntaxError{Line: lineNumber, Message: fmt.Sprintf("invalid level %q", fields[0])}
+	}
+	if len(fields) < 2 || fields[1] == "" {
+		return nil, &SyntaxError{Line: lineNumber, Message: "missing tag"}
+	}
+	node := &Node{Level: level, Line: lineNumber}
+	rest := fields[1]
+	if strings.HasPrefix(rest, "@") {
+		xrefFields := strings.SplitN(rest, " ", 2)
+		node.Xref = xrefFields[0]
+		if len(node.Xref) < 3 || !strings.HasSuffix(node.Xref, "@") {
+			return nil, &SyntaxError{Line: lineNumber, Message: fmt.Sprintf("invalid cross-reference %q", node.Xref)}
+		}
+		if len(xrefFields) < 2 || xrefFields[1] == "" {
+			return nil, &SyntaxError{Line: lineNumber, Message: "missing tag"}
+		}
+		rest = xrefFields[1]
+	}
+	tagFields := strings.SplitN(rest, " ", 2)
+	node.Tag = strings.ToUpper(tagFields[0])
+	if len(tagFields) == 2 {
+		node.Value = strings.ReplaceAll(tagFields[1], "@@", "@")
+	}
+	return node, nil
+}
+
+// Parse reads every line of a GEDCOM stream and returns the top level
+// records. Only UTF-8 (and so ASCII) streams are supported.
+func Parse(reader io.Reader) ([]*Node, error) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineLength)
+	scanner.Split(scanLines)
+
+	records := []*Node{}
+	stack := []*Node{}
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		text := scanner.Text()
+		if lineNumber == 1 {
+			text = strings.TrimPrefix(text, "\uFEFF")
+		}
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		node, err := parseLine(text, lineNumber)
+		if err != nil {
+			return nil, err
+		}
+		if node.Level > len(stack) {
+			return nil, &SyntaxError{Line: lineNumber, Message: fmt.Sprintf("level %d can't follow level %d", node.Level, len(stack)-1)}
+		}
+		stack = stack[:node.Level]
+		if node.Level == 0 {
+			records = append(records, node)
+			stack = append(stack, node)
+			continue
+		}
+		parent := stack[len(stack)-1]
+		switch node.Tag {
+		case TagContinued:
+			parent.Value += "\n" + node.Value
+			// CONT and CONC never have nested lines, keep the stack pointing to the parent
+			stack = append(stack, parent)
+			continue
+		case TagConcatenated:
+			parent.Value += node.Value
+			stack = append(stack, parent)
+			continue
+		}
+		parent.Children = append(parent.Children, node)
+		stack = append(stack, node)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
@@ -0,0 +1,103 @@
+package gedcom
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// nodeLines flattens the nodes as "level xref tag value" lines, the xref only
+// when there is one.
+func nodeLines(nodes []*Node) []string {
+	lines := []string{}
+	var walk func(node *Node)
+	walk = func(node *Node) {
+		fields := []string{}
+		for _, field := range []string{node.Xref, node.Tag, node.Value} {
+			if field != "" {
+				fields = append(fields, field)
+			}
+		}
+		lines = append(lines, strings.Repeat(" ", node.Level)+strings.Join(fields, " "))
+		for _, child := range node.Children {
+			walk(child)
+		}
+	}
+	for _, node := range nodes {
+		walk(node)
+	}
+	return lines
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			name:     "nested records",
+			input:    "0 HEAD\n1 CHAR UTF-8\n0 @I1@ INDI\n1 NAME John /Smith/\n2 GIVN John\n1 BIRT\n2 DATE 1850\n0 TRLR\n",
+			expected: []string{"HEAD", " CHAR UTF-8", "@I1@ INDI", " NAME John /Smith/", "  GIVN John", " BIRT", "  DATE 1850", "TRLR"},
+		},
+		{
+			name:     "every terminator",
+			input:    "0 HEAD\r\n0 @I1@ INDI\r1 SEX M\n\r1 NAME Ann\n0 TRLR",
+			expected: []string{"HEAD", "@I1@ INDI", " SEX M", " NAME Ann", "TRLR"},
+		},
+		{
+			name:     "byte order mark and blank lines",
+			input:    "\uFEFF0 HEAD\n\n   \n0 TRLR\n",
+			expected: []string{"HEAD", "TRLR"},
+		},
+		{
+			name:     "CONT and CONC merged into the value",
+			input:    "0 @N1@ NOTE First\n1 CONT Second\n1 CONC  half\n1 CONT\n0 TRLR\n",
+			expected: []string{"@N1@ NOTE First\nSecond half\n", "TRLR"},
+		},
+		{
+			name:     "escaped at signs and lower case tags",
+			input:    "0 @I1@ indi\n1 name john@@example.com\n",
+			expected: []string{"@I1@ INDI", " NAME john@example.com"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			records, err := Parse(strings.NewReader(test.input))
+			if err != nil {
+				t.Fatalf("Parse returned error: %v", err)
+			}
+			lines := nodeLines(records)
+			if strings.Join(lines, "|") != strings.Join(test.expected, "|") {
+				t.Errorf("Parse returned %q, expected %q", lines, test.expected)
+			}
+		})
+	}
+}
+
+func TestParseSyntaxError(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		line  int
+	}{
+		{name: "invalid level", input: "0 HEAD\nX NAME Ann\n", line: 2},
+		{name: "negative level", input: "-1 HEAD\n", line: 1},
+		{name: "missing tag", input: "0 HEAD\n1\n", line: 2},
+		{name: "missing tag after xref", input: "0 @I1@\n", line: 1},
+		{name: "invalid xref", input: "0 HEAD\n0 @I1 INDI\n", line: 2},
+		{name: "skipped level", input: "0 HEAD\n2 VERS 5.5.1\n", line: 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(test.input))
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("Parse returned %v, expected a SyntaxError", err)
+			}
+			if syntaxErr.Line != test.line {
+				t.Errorf("Parse failed on line %d, expected line %d", syntaxErr.Line, test.line)
+			}
+		})
+	}
+}
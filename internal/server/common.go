@@ -63,6 +63,53 @@ func WriteGOBBody(w http.ResponseWriter, r *http.Request, status int, body inter
 	return nil
 }
 
+type GedcomMarshaler interface {
+	MarshalGEDCOM() ([]byte, error)
+}
+
+type DotMarshaler interface {
+	MarshalDOT() ([]byte, error)
+}
+
+type SVGMarshaler interface {
+	MarshalSVG() ([]byte, error)
+}
+
+func writeMarshaledBody(w http.ResponseWriter, r *http.Request, status int, marshal func() ([]byte, error)) error {
+	bodyResponse, err := marshal()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return err
+	}
+	w.WriteHeader(status)
+	w.Write(bodyResponse)
+	return nil
+}
+
+func WriteGedcomBody(w http.ResponseWriter, r *http.Request, status int, body interface{}) error {
+	marshaler, ok := body.(GedcomMarshaler)
+	if !ok {
+		return WriteErrorMessage(w, r, http.StatusNotAcceptable, ErrNotAcceptable)
+	}
+	return writeMarshaledBody(w, r, status, marshaler.MarshalGEDCOM)
+}
+
+func WriteDotBody(w http.ResponseWriter, r *http.Request, status int, body interface{}) error {
+	marshaler, ok := body.(DotMarshaler)
+	if !ok {
+		return WriteErrorMessage(w, r, http.StatusNotAcceptable, ErrNotAcceptable)
+	}
+	return writeMarshaledBody(w, r, status, marshaler.MarshalDOT)
+}
+
+func WriteSVGBody(w http.ResponseWriter, r *http.Request, status int, body interface{}) error {
+	marshaler, ok := body.(SVGMarshaler)
+	if !ok {
+		return WriteErrorMessage(w, r, http.StatusNotAcceptable, ErrNotAcceptable)
+	}
+	return writeMarshaledBody(w, r, status, marshaler.MarshalSVG)
+}
+
 func WriteErrorValidation(w http.ResponseWriter, r *http.Request, err error) error {
 
 	for mapError, status := range ErrorStatusResponseMap {
@@ -85,6 +132,18 @@ func ResponseStrategy(acceptHeader []string, status int) func(w http.ResponseWri
 			return func(w http.ResponseWriter, r *http.Request, body any) error {
 				return WriteXMLBody(w, r, status, body)
 			}
+		case AcceptGedcom:
+			return func(w http.ResponseWriter, r *http.Request, body any) error {
+				return WriteGedcomBody(w, r, status, body)
+			}
+		case AcceptGraphviz:
+			return func(w http.ResponseWriter, r *http.Request, body any) error {
+				return WriteDotBody(w, r, status, body)
+			}
+		case AcceptSVG:
+			return func(w http.ResponseWriter, r *http.Request, body any) error {
+				return WriteSVGBody(w, r, status, body)
+			}
 		case AcceptApplicationJson:
 			return func(w http.ResponseWriter, r *http.Request, body any) error {
 				return WriteJsonBody(w, r, status, body)
@@ -1,9 +1,26 @@
 package server
 
+import "time"
+
+const (
+	RepositoryNeo4j  = "neo4j"
+	RepositoryMemory = "memory"
+)
+
+const (
+	AuditSinkRepository = "repository"
+	AuditSinkJSONL      = "jsonl"
+	AuditSinkNone       = "none"
+)
+
 type ServerConfig struct {
 	Environment string `env:"SERVER_ENVIRONMENT" envDefault:"local"`
+	Repository  string `env:"SERVER_REPOSITORY" envDefault:"neo4j"`
 	GogmConfig  GogmConfig
 	WebConfig   WebConfig
+	RulesConfig RulesConfig
+	TrashConfig TrashConfig
+	AuditConfig AuditConfig
 }
 
 type GogmConfig struct {
@@ -14,7 +31,43 @@ type GogmConfig struct {
 	Password string `env:"GOGM_PASSWORD,required"`
 }
 
+// WebConfig CursorSecret signs the cursors of people lists, a random one is
+// used when it's empty.
 type WebConfig struct {
-	Timeout int `env:"WEB_TIMEOUT" envDefault:"60"`
-	Port    int `env:"WEB_PORT" envDefault:"8080"`
+	Timeout      int    `env:"WEB_TIMEOUT" envDefault:"60"`
+	Port         int    `env:"WEB_PORT" envDefault:"8080"`
+	CursorSecret string `env:"WEB_CURSOR_SECRET"`
+}
+
+// RulesConfig sets the relation rules, severities are OFF, WARNING or ERROR.
+// The incest rule rejects biological parents whose coefficient of relationship
+// with the child is above IncestMaxCoefficient, only counting relatives sharing
+// an ancestor up to IncestMaxGenerations generations away when it's set.
+type RulesConfig struct {
+	MinParentAge                int     `env:"RULES_MIN_PARENT_AGE" envDefault:"12"`
+	MinParentAgeSeverity        string  `env:"RULES_MIN_PARENT_AGE_SEVERITY" envDefault:"ERROR"`
+	MaxParentAge                int     `env:"RULES_MAX_PARENT_AGE" envDefault:"80"`
+	MaxParentAgeSeverity        string  `env:"RULES_MAX_PARENT_AGE_SEVERITY" envDefault:"WARNING"`
+	PosthumousBirthMonths       int     `env:"RULES_POSTHUMOUS_BIRTH_MONTHS" envDefault:"9"`
+	PosthumousBirthSeverity     string  `env:"RULES_POSTHUMOUS_BIRTH_SEVERITY" envDefault:"ERROR"`
+	ContemporarySpousesSeverity string  `env:"RULES_CONTEMPORARY_SPOUSES_SEVERITY" envDefault:"ERROR"`
+	IncestMaxCoefficient        float64 `env:"RULES_INCEST_MAX_COEFFICIENT" envDefault:"0"`
+	IncestMaxGenerations        int     `env:"RULES_INCEST_MAX_GENERATIONS" envDefault:"0"`
+}
+
+// TrashConfig deleted people and relations are purged for good once they've
+// been in the trash for longer than Retention, checked every PurgeInterval.
+type TrashConfig struct {
+	Retention     time.Duration `env:"TRASH_RETENTION" envDefault:"720h"`
+	PurgeInterval time.Duration `env:"TRASH_PURGE_INTERVAL" envDefault:"1h"`
+}
+
+// AuditConfig Sink is where the audit events of the mutations go, repository
+// keeps them along with the graph, on Neo4j or in memory, jsonl appends them
+// to File once their Tx is committed and none turns the audit off.
+// The actor of the events comes from the X-Actor header, which isn't
+// authenticated, so it's only what the clients declare themselves.
+type AuditConfig struct {
+	Sink string `env:"AUDIT_SINK" envDefault:"repository"`
+	File string `env:"AUDIT_FILE" envDefault:"audit.jsonl"`
 }
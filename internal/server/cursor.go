@@ -0,0 +1,88 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"family-tree/internal/core/familytree"
+	"strings"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// PeopleCursor is what the next and prev tokens of people lists carry. The
+// filter and the sort travel with the key, so following a token only needs
+// the page size.
+type PeopleCursor struct {
+	Filter   familytree.PeopleFilter  `json:"f"`
+	Sort     familytree.PeopleSort    `json:"s"`
+	Key      familytree.PeopleSortKey `json:"k"`
+	Backward bool                     `json:"b,omitempty"`
+}
+
+// CursorSigner writes cursors as base64 JSON followed by its HMAC-SHA256, so
+// clients can't forge keys nor filters.
+type CursorSigner struct {
+	secret []byte
+}
+
+// NewCursorSigner uses a random secret when none is given, tokens are then
+// only valid until the server restarts.
+func NewCursorSigner(secret string) *CursorSigner {
+	if secret != "" {
+		return &CursorSigner{secret: []byte(secret)}
+	}
+	randomSecret := make([]byte, 32)
+	if _, err := rand.Read(randomSecret); err != nil {
+		panic(err)
+	}
+	return &CursorSigner{secret: randomSecret}
+}
+
+func (signer *CursorSigner) signature(payload string) string {
+	mac := hmac.New(sha256.New, signer.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (signer *CursorSigner) Sign(cursor PeopleCursor) (string, error) {
+	body, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(body)
+	return payload + "." + signer.signature(payload), nil
+}
+
+// SignKey returns the token of the page around the key, or an empty token
+// when there is no key.
+func (signer *CursorSigner) SignKey(filter familytree.PeopleFilter, peopleSort familytree.PeopleSort, key *familytree.PeopleSortKey, backward bool) (string, error) {
+	if key == nil {
+		return "", nil
+	}
+	return signer.Sign(PeopleCursor{
+		Filter:   filter,
+		Sort:     peopleSort,
+		Key:      *key,
+		Backward: backward,
+	})
+}
+
+func (signer *CursorSigner) Parse(token string) (*PeopleCursor, error) {
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(signer.signature(payload))) {
+		return nil, ErrInvalidCursor
+	}
+	body, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	cursor := &PeopleCursor{}
+	if err := json.Unmarshal(body, cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return cursor, nil
+}
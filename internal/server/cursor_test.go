@@ -0,0 +1,91 @@
+package server
+
+import (
+	"encoding/base64"
+	"errors"
+	"family-tree/internal/core/familytree"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func mustDecode(t *testing.T, payload string) string {
+	t.Helper()
+	body, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		t.Fatalf("the payload %q isn't base64: %v", payload, err)
+	}
+	return string(body)
+}
+
+func TestCursorSigner(t *testing.T) {
+	hasParents := true
+	cursor := PeopleCursor{
+		Filter:   familytree.PeopleFilter{HasParents: &hasParents, BornAfter: "1850", Surname: "Smith"},
+		Sort:     familytree.PeopleSort{Field: familytree.PeopleSortName, Descending: true},
+		Key:      familytree.PeopleSortKey{Text: "john smith", Name: "John Smith", ID: uuid.New()},
+		Backward: true,
+	}
+	signer := NewCursorSigner("secret")
+	token, err := signer.Sign(cursor)
+	if err != nil {
+		t.Fatalf("Sign returned error: %v", err)
+	}
+	parsed, err := signer.Parse(token)
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if !reflect.DeepEqual(*parsed, cursor) {
+		t.Errorf("Parse returned %+v, expected %+v", *parsed, cursor)
+	}
+	if again, err := NewCursorSigner("secret").Parse(token); err != nil || !reflect.DeepEqual(*again, cursor) {
+		t.Errorf("Parse with the same secret returned %+v, %v", again, err)
+	}
+
+	payload, signature, _ := strings.Cut(token, ".")
+	forged := strings.Replace(mustDecode(t, payload), "Smith", "Jones", 1)
+	forgedPayload := base64.RawURLEncoding.EncodeToString([]byte(forged))
+	tests := []struct {
+		name   string
+		signer *CursorSigner
+		token  string
+	}{
+		{name: "empty token", signer: signer, token: ""},
+		{name: "garbage", signer: signer, token: "not a cursor"},
+		{name: "missing signature", signer: signer, token: payload},
+		{name: "empty signature", signer: signer, token: payload + "."},
+		{name: "tampered payload", signer: signer, token: forgedPayload + "." + signature},
+		{name: "tampered signature", signer: signer, token: payload + "." + strings.ToUpper(signature)},
+		{name: "other secret", signer: NewCursorSigner("other"), token: token},
+		{name: "random secret", signer: NewCursorSigner(""), token: token},
+		{name: "signed invalid base64", signer: signer, token: "e30=." + signer.signature("e30=")},
+		{name: "signed invalid JSON", signer: signer, token: "bm90IGpzb24." + signer.signature("bm90IGpzb24")},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cursor, err := test.signer.Parse(test.token)
+			if !errors.Is(err, ErrInvalidCursor) || cursor != nil {
+				t.Errorf("Parse returned %+v, %v, expected %v", cursor, err, ErrInvalidCursor)
+			}
+		})
+	}
+}
+
+func TestSignKey(t *testing.T) {
+	signer := NewCursorSigner("")
+	token, err := signer.SignKey(familytree.PeopleFilter{}, familytree.PeopleSort{}, nil, false)
+	if err != nil || token != "" {
+		t.Errorf("SignKey without key returned %q, %v, expected no token", token, err)
+	}
+	key := familytree.PeopleSortKey{Number: 42, Name: "Ann", ID: uuid.New()}
+	token, err = signer.SignKey(familytree.PeopleFilter{Surname: "Lee"}, familytree.PeopleSort{}, &key, false)
+	if err != nil {
+		t.Fatalf("SignKey returned error: %v", err)
+	}
+	cursor, err := signer.Parse(token)
+	if err != nil || cursor.Key != key || cursor.Filter.Surname != "Lee" || cursor.Backward {
+		t.Errorf("Parse of the key token returned %+v, %v", cursor, err)
+	}
+}
@@ -1,26 +1,61 @@
 package server
 
 import (
+	"bytes"
 	"encoding/xml"
 	"errors"
 	"family-tree/internal/core/familytree"
+	"family-tree/internal/gedcom"
+	"family-tree/internal/treeview"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 const (
-	PaginationPageParam = "page"
-	PaginationSizeParam = "size"
+	PaginationPageParam      = "page"
+	PaginationSizeParam      = "size"
+	PaginationCursorParam    = "cursor"
+	PaginationTotalParam     = "includeTotal"
+	PeopleSearchParam        = "q"
+	PeopleSortParam          = "sort"
+	PeopleHasParentsParam    = "hasParents"
+	PeopleIsLivingParam      = "isLiving"
+	PeopleBornAfterParam     = "bornAfter"
+	PeopleBornBeforeParam    = "bornBefore"
+	PeopleSurnameParam       = "surname"
+	PathBloodOnlyParam       = "bloodOnly"
+	PathNoSpousesParam       = "excludeSpouses"
+	PathMaxLengthParam       = "maxLength"
+	PathCountParam           = "count"
+	DescendantsDepthParam    = "depth"
+	DescendantsSpousesParam  = "includeSpouses"
+	PedigreeGenerationsParam = "generations"
+	DuplicatesMinScoreParam  = "minScore"
+	DeleteCascadeParam       = "cascade"
+	DeleteCascadeRelations   = "relations"
+	DeleteCascadeUnions      = "unions"
+	AuditPersonIDParam       = "personID"
+	AuditActorHeader         = "X-Actor"
+	AuditAnonymousActor      = "anonymous"
 )
 
 var (
 	ErrNotUUID              = errors.New("invalid uuid")
 	ErrNoPathFound          = errors.New("no path found between people")
+	ErrNotAcceptable        = errors.New("response can't be written on the requested format")
+	ErrInvalidCascade       = errors.New("invalid cascade")
+	ErrSearchNotSortable    = errors.New("search results can't be sorted nor paged by cursor")
 	AcceptApplicationJson   = "application/json"
 	AcceptApplicationXML    = "application/xml"
 	AcceptApplicationBinary = "binary"
 	AcceptOctetStream       = "application/octet-stream"
+	AcceptGedcom            = "text/x-gedcom"
+	AcceptGraphviz          = "text/vnd.graphviz"
+	AcceptSVG               = "image/svg+xml"
 	ErrorStatusResponseMap  = map[error]int{
 		familytree.ErrCreateNilPerson:           http.StatusBadRequest,
 		familytree.ErrEmptyPersonName:           http.StatusBadRequest,
@@ -34,12 +69,35 @@ var (
 		familytree.ErrRelationNotFound:          http.StatusNotFound,
 		familytree.ErrOnlyChildFromSpouseCouple: http.StatusBadRequest,
 		familytree.ErrPersonStillHasRelations:   http.StatusBadRequest,
+		familytree.ErrInvalidDate:               http.StatusBadRequest,
+		familytree.ErrInvalidSex:                http.StatusBadRequest,
+		familytree.ErrParentTooYoung:            http.StatusBadRequest,
+		familytree.ErrParentTooOld:              http.StatusBadRequest,
+		familytree.ErrBornAfterParentDeath:      http.StatusBadRequest,
+		familytree.ErrSpousesNotContemporary:    http.StatusBadRequest,
+		familytree.ErrInvalidUnionEndReason:     http.StatusBadRequest,
+		familytree.ErrUnionEndsBeforeStart:      http.StatusBadRequest,
+		familytree.ErrAmbiguousUnion:            http.StatusBadRequest,
+		familytree.ErrInvalidParentage:          http.StatusBadRequest,
+		familytree.ErrNotRelated:                http.StatusNotFound,
+		familytree.ErrInvalidPeopleSort:         http.StatusBadRequest,
+		familytree.ErrSameMergeID:               http.StatusBadRequest,
+		familytree.ErrMergeConflict:             http.StatusConflict,
 	}
 )
 
+// Person dates are partial or approximate, like "1850", "1850-03-12",
+// "ABT 1850" or "BET 1850 AND 1855". Sex is M, F or U.
 type Person struct {
-	ID   uuid.UUID `json:"id"`
-	Name string    `json:"name"`
+	ID         uuid.UUID       `json:"id"`
+	Name       string          `json:"name"`
+	GivenName  string          `json:"givenName,omitempty" xml:",omitempty"`
+	Surname    string          `json:"surname,omitempty" xml:",omitempty"`
+	Sex        familytree.Sex  `json:"sex,omitempty" xml:",omitempty" swaggertype:"string" enums:"M,F,U"`
+	BirthDate  familytree.Date `json:"birthDate,omitempty" xml:",omitempty" swaggertype:"string" example:"ABT 1850"`
+	BirthPlace string          `json:"birthPlace,omitempty" xml:",omitempty"`
+	DeathDate  familytree.Date `json:"deathDate,omitempty" xml:",omitempty" swaggertype:"string" example:"1920-03-12"`
+	DeathPlace string          `json:"deathPlace,omitempty" xml:",omitempty"`
 }
 
 type Error struct {
@@ -47,11 +105,78 @@ type Error struct {
 }
 
 type PostPersonRequest struct {
-	Name string `json:"name"`
+	Name       string          `json:"name"`
+	GivenName  string          `json:"givenName"`
+	Surname    string          `json:"surname"`
+	Sex        familytree.Sex  `json:"sex" swaggertype:"string" enums:"M,F,U"`
+	BirthDate  familytree.Date `json:"birthDate" swaggertype:"string" example:"ABT 1850"`
+	BirthPlace string          `json:"birthPlace"`
+	DeathDate  familytree.Date `json:"deathDate" swaggertype:"string" example:"1920-03-12"`
+	DeathPlace string          `json:"deathPlace"`
 }
+
+func (r PostPersonRequest) ToPerson() *familytree.Person {
+	return &familytree.Person{
+		Name:       r.Name,
+		GivenName:  r.GivenName,
+		Surname:    r.Surname,
+		Sex:        r.Sex,
+		BirthDate:  r.BirthDate,
+		BirthPlace: r.BirthPlace,
+		DeathDate:  r.DeathDate,
+		DeathPlace: r.DeathPlace,
+	}
+}
+
+type UpdatePersonRequest interface {
+	ToUpdate() familytree.PersonUpdate
+}
+
+type PatchPersonRequest struct {
+	Name       *string          `json:"name"`
+	GivenName  *string          `json:"givenName"`
+	Surname    *string          `json:"surname"`
+	Sex        *familytree.Sex  `json:"sex" swaggertype:"string" enums:"M,F,U"`
+	BirthDate  *familytree.Date `json:"birthDate" swaggertype:"string" example:"ABT 1850"`
+	BirthPlace *string          `json:"birthPlace"`
+	DeathDate  *familytree.Date `json:"deathDate" swaggertype:"string" example:"1920-03-12"`
+	DeathPlace *string          `json:"deathPlace"`
+}
+
+func (r PatchPersonRequest) ToUpdate() familytree.PersonUpdate {
+	return familytree.PersonUpdate{
+		Name:       r.Name,
+		GivenName:  r.GivenName,
+		Surname:    r.Surname,
+		Sex:        r.Sex,
+		BirthDate:  r.BirthDate,
+		BirthPlace: r.BirthPlace,
+		DeathDate:  r.DeathDate,
+		DeathPlace: r.DeathPlace,
+	}
+}
+
+// PutPersonRequest replaces every field, the absent ones are cleared.
+type PutPersonRequest PostPersonRequest
+
+func (r PutPersonRequest) ToUpdate() familytree.PersonUpdate {
+	return familytree.PersonUpdate{
+		Name:       &r.Name,
+		GivenName:  &r.GivenName,
+		Surname:    &r.Surname,
+		Sex:        &r.Sex,
+		BirthDate:  &r.BirthDate,
+		BirthPlace: &r.BirthPlace,
+		DeathDate:  &r.DeathDate,
+		DeathPlace: &r.DeathPlace,
+	}
+}
+
+// PostCreateParentRelationshipRequest Parentage defaults to BIOLOGICAL.
 type PostCreateParentRelationshipRequest struct {
-	ParentID uuid.UUID `json:"parentID"`
-	ChildID  uuid.UUID `json:"childID"`
+	ParentID  uuid.UUID            `json:"parentID"`
+	ChildID   uuid.UUID            `json:"childID"`
+	Parentage familytree.Parentage `json:"parentage" swaggertype:"string" enums:"BIOLOGICAL,ADOPTIVE,FOSTER,STEP,GUARDIAN"`
 }
 
 func (r PostCreateParentRelationshipRequest) Validate() error {
@@ -79,9 +204,28 @@ func (r DeleteParentRelationshipRequest) Validate() error {
 	return nil
 }
 
+// Union is the period of a SPOUSE relation, without end date nor end reason
+// the union is ongoing.
+type Union struct {
+	StartDate familytree.Date           `json:"startDate,omitempty" xml:"startDate,omitempty" swaggertype:"string" example:"1850-06"`
+	EndDate   familytree.Date           `json:"endDate,omitempty" xml:"endDate,omitempty" swaggertype:"string" example:"ABT 1870"`
+	EndReason familytree.UnionEndReason `json:"endReason,omitempty" xml:"endReason,omitempty" swaggertype:"string" enums:"DIVORCE,DEATH,ANNULMENT"`
+}
+
 type PostCreateSpouseRelationshipRequest struct {
-	FirstSpouseID  uuid.UUID `json:"firstSpouseID"`
-	SecondSpouseID uuid.UUID `json:"secondSpouseID"`
+	FirstSpouseID  uuid.UUID                 `json:"firstSpouseID"`
+	SecondSpouseID uuid.UUID                 `json:"secondSpouseID"`
+	StartDate      familytree.Date           `json:"startDate" swaggertype:"string" example:"1850-06"`
+	EndDate        familytree.Date           `json:"endDate" swaggertype:"string" example:"ABT 1870"`
+	EndReason      familytree.UnionEndReason `json:"endReason" swaggertype:"string" enums:"DIVORCE,DEATH,ANNULMENT"`
+}
+
+func (r PostCreateSpouseRelationshipRequest) ToUnion() familytree.Union {
+	return familytree.Union{
+		StartDate: r.StartDate,
+		EndDate:   r.EndDate,
+		EndReason: r.EndReason,
+	}
 }
 
 func (r PostCreateSpouseRelationshipRequest) Validate() error {
@@ -94,9 +238,41 @@ func (r PostCreateSpouseRelationshipRequest) Validate() error {
 	return nil
 }
 
+// PatchSpouseRelationshipRequest UnionStartDate is the current start date of
+// the union to change, only needed when the spouses have several unions.
+type PatchSpouseRelationshipRequest struct {
+	FirstSpouseID  uuid.UUID                  `json:"firstSpouseID"`
+	SecondSpouseID uuid.UUID                  `json:"secondSpouseID"`
+	UnionStartDate *familytree.Date           `json:"unionStartDate" swaggertype:"string" example:"1850"`
+	StartDate      *familytree.Date           `json:"startDate" swaggertype:"string" example:"1850-06"`
+	EndDate        *familytree.Date           `json:"endDate" swaggertype:"string" example:"ABT 1870"`
+	EndReason      *familytree.UnionEndReason `json:"endReason" swaggertype:"string" enums:"DIVORCE,DEATH,ANNULMENT"`
+}
+
+func (r PatchSpouseRelationshipRequest) Validate() error {
+	if r.FirstSpouseID == uuid.Nil {
+		return ErrNotUUID
+	}
+	if r.SecondSpouseID == uuid.Nil {
+		return ErrNotUUID
+	}
+	return nil
+}
+
+func (r PatchSpouseRelationshipRequest) ToUpdate() familytree.UnionUpdate {
+	return familytree.UnionUpdate{
+		StartDate: r.StartDate,
+		EndDate:   r.EndDate,
+		EndReason: r.EndReason,
+	}
+}
+
+// DeleteSpouseRelationshipRequest StartDate is the start date of the union to
+// delete, only needed when the spouses have several unions.
 type DeleteSpouseRelationshipRequest struct {
-	FirstSpouseID  uuid.UUID `json:"firstSpouseID"`
-	SecondSpouseID uuid.UUID `json:"secondSpouseID"`
+	FirstSpouseID  uuid.UUID        `json:"firstSpouseID"`
+	SecondSpouseID uuid.UUID        `json:"secondSpouseID"`
+	StartDate      *familytree.Date `json:"startDate" swaggertype:"string" example:"1850"`
 }
 
 func (r DeleteSpouseRelationshipRequest) Validate() error {
@@ -109,22 +285,478 @@ func (r DeleteSpouseRelationshipRequest) Validate() error {
 	return nil
 }
 
+type RuleWarning struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+type PostCreateRelationshipResponse struct {
+	Warnings []RuleWarning `json:"warnings"`
+}
+
+func CreateRelationshipMapper(warnings []familytree.RuleViolation) PostCreateRelationshipResponse {
+	response := PostCreateRelationshipResponse{Warnings: make([]RuleWarning, 0, len(warnings))}
+	for _, warning := range warnings {
+		response.Warnings = append(response.Warnings, RuleWarning{
+			Rule:    warning.Rule,
+			Message: warning.Error(),
+		})
+	}
+	return response
+}
+
+type PostMergePersonRequest struct {
+	DuplicateID uuid.UUID `json:"duplicateID"`
+}
+
+func (r PostMergePersonRequest) Validate() error {
+	if r.DuplicateID == uuid.Nil {
+		return ErrNotUUID
+	}
+	return nil
+}
+
+type PostMergePersonResponse struct {
+	Person   Person        `json:"person"`
+	Moved    int           `json:"moved"`
+	Warnings []RuleWarning `json:"warnings"`
+}
+
+func MergeMapper(result *familytree.MergeResult) PostMergePersonResponse {
+	return PostMergePersonResponse{
+		Person:   PersonMapper(result.Person),
+		Moved:    result.Moved,
+		Warnings: CreateRelationshipMapper(result.Warnings).Warnings,
+	}
+}
+
+// MergeConflict is a relation of the duplicate the survivor can't take,
+// personID is the other person of the relation.
+type MergeConflict struct {
+	RelationType string    `json:"relationType" enums:"PARENT,SPOUSE"`
+	PersonID     uuid.UUID `json:"personID"`
+	Message      string    `json:"message"`
+}
+
+type MergeConflictResponse struct {
+	Message   string          `json:"message"`
+	Conflicts []MergeConflict `json:"conflicts"`
+}
+
+func MergeConflictMapper(mergeErr *familytree.MergeConflictError) MergeConflictResponse {
+	response := MergeConflictResponse{
+		Message:   familytree.ErrMergeConflict.Error(),
+		Conflicts: make([]MergeConflict, 0, len(mergeErr.Conflicts)),
+	}
+	for _, conflict := range mergeErr.Conflicts {
+		response.Conflicts = append(response.Conflicts, MergeConflict{
+			RelationType: conflict.RelationType.String(),
+			PersonID:     conflict.Person.ID,
+			Message:      conflict.Err.Error(),
+		})
+	}
+	return response
+}
+
+type PersonUnion struct {
+	Spouse  Person `json:"spouse"`
+	Union   Union  `json:"union"`
+	Current bool   `json:"current"`
+}
+
+type GetUnionsResponse struct {
+	Content []PersonUnion `json:"content"`
+}
+
+func UnionsMapper(unions []familytree.PersonUnion) GetUnionsResponse {
+	response := GetUnionsResponse{Content: make([]PersonUnion, 0, len(unions))}
+	for _, union := range unions {
+		response.Content = append(response.Content, PersonUnion{
+			Spouse:  PersonMapper(union.Spouse),
+			Union:   Union(union.Union),
+			Current: union.Union.Ongoing(),
+		})
+	}
+	return response
+}
+
 type GetBaconsNumberResponse struct {
 	PathLength int `json:"pathLength"`
 }
 
+// PersonPath Edges[i] is what People[i] is of People[i+1].
+type PersonPath struct {
+	Length int                   `json:"length"`
+	People []Person              `json:"people"`
+	Edges  []familytree.PathEdge `json:"edges" swaggertype:"array,string" enums:"PARENT_OF,CHILD_OF,SPOUSE_OF"`
+}
+
+type GetPathsResponse struct {
+	Content []PersonPath `json:"content"`
+}
+
+func PathsMapper(paths []familytree.Path) GetPathsResponse {
+	response := GetPathsResponse{Content: make([]PersonPath, 0, len(paths))}
+	for _, path := range paths {
+		personPath := PersonPath{
+			Length: path.Length(),
+			People: make([]Person, 0, len(path.People)),
+			Edges:  path.Edges,
+		}
+		for _, person := range path.People {
+			personPath.People = append(personPath.People, PersonMapper(person))
+		}
+		response.Content = append(response.Content, personPath)
+	}
+	return response
+}
+
+// AncestorContribution FirstGenerations and SecondGenerations are the
+// generations of the shortest path through the ancestor, Inbreeding is the
+// inbreeding coefficient of the ancestor itself.
+type AncestorContribution struct {
+	Ancestor          Person  `json:"ancestor"`
+	Paths             int     `json:"paths"`
+	FirstGenerations  int     `json:"firstGenerations"`
+	SecondGenerations int     `json:"secondGenerations"`
+	Inbreeding        float64 `json:"inbreeding"`
+	Contribution      float64 `json:"contribution"`
+}
+
+func AncestorContributionsMapper(contributions []familytree.AncestorContribution) []AncestorContribution {
+	mappedContributions := make([]AncestorContribution, 0, len(contributions))
+	for _, contribution := range contributions {
+		mappedContributions = append(mappedContributions, AncestorContribution{
+			Ancestor:          PersonMapper(contribution.Ancestor),
+			Paths:             contribution.Paths,
+			FirstGenerations:  contribution.FirstGenerations,
+			SecondGenerations: contribution.SecondGenerations,
+			Inbreeding:        contribution.Inbreeding,
+			Contribution:      contribution.Contribution,
+		})
+	}
+	return mappedContributions
+}
+
+type GetRelatednessResponse struct {
+	Coefficient      float64                `json:"coefficient" example:"0.125"`
+	FirstInbreeding  float64                `json:"firstInbreeding"`
+	SecondInbreeding float64                `json:"secondInbreeding"`
+	Generations      int                    `json:"generations"`
+	CommonAncestors  []AncestorContribution `json:"commonAncestors"`
+}
+
+func RelatednessMapper(relatedness familytree.Relatedness) GetRelatednessResponse {
+	return GetRelatednessResponse{
+		Coefficient:      relatedness.Coefficient,
+		FirstInbreeding:  relatedness.FirstInbreeding,
+		SecondInbreeding: relatedness.SecondInbreeding,
+		Generations:      relatedness.Generations,
+		CommonAncestors:  AncestorContributionsMapper(relatedness.CommonAncestors),
+	}
+}
+
+type GetInbreedingResponse struct {
+	Coefficient     float64                `json:"coefficient" example:"0.0625"`
+	Generations     int                    `json:"generations"`
+	CommonAncestors []AncestorContribution `json:"commonAncestors"`
+}
+
+func InbreedingMapper(inbreeding familytree.Inbreeding) GetInbreedingResponse {
+	return GetInbreedingResponse{
+		Coefficient:     inbreeding.Coefficient,
+		Generations:     inbreeding.Generations,
+		CommonAncestors: AncestorContributionsMapper(inbreeding.CommonAncestors),
+	}
+}
+
+type KinshipNames struct {
+	English    string `json:"en" example:"first cousin once removed"`
+	Portuguese string `json:"pt" example:"primo de primeiro grau, uma geração acima"`
+}
+
+// InLaw Link is the spouse of the person on SPOUSE_RELATIVE kinships and the
+// relative married to the target person on RELATIVE_SPOUSE kinships.
+type InLaw struct {
+	Side familytree.InLawSide `json:"side" swaggertype:"string" enums:"SPOUSE_RELATIVE,RELATIVE_SPOUSE"`
+	Link Person               `json:"link"`
+}
+
+// GetKinshipResponse names what the target person is to the person.
+// FirstGenerations and SecondGenerations are the generations between each of
+// them and the closest common ancestors.
+type GetKinshipResponse struct {
+	Kinship           familytree.KinshipType `json:"kinship" swaggertype:"string" enums:"SELF,ANCESTOR,DESCENDANT,SIBLING,UNCLE,NEPHEW,COUSIN,SPOUSE,IN_LAW"`
+	Names             KinshipNames           `json:"names"`
+	FirstGenerations  int                    `json:"firstGenerations"`
+	SecondGenerations int                    `json:"secondGenerations"`
+	Half              bool                   `json:"half"`
+	CommonAncestors   []Person               `json:"commonAncestors"`
+	Former            bool                   `json:"former,omitempty"`
+	InLaw             *InLaw                 `json:"inLaw,omitempty"`
+}
+
+func KinshipMapper(kinship familytree.Kinship) GetKinshipResponse {
+	response := GetKinshipResponse{
+		Kinship: kinship.Type,
+		Names: KinshipNames{
+			English:    kinship.Names[familytree.LanguageEnglish],
+			Portuguese: kinship.Names[familytree.LanguagePortuguese],
+		},
+		FirstGenerations:  kinship.FirstGenerations,
+		SecondGenerations: kinship.SecondGenerations,
+		Half:              kinship.Half,
+		CommonAncestors:   make([]Person, 0, len(kinship.CommonAncestors)),
+		Former:            kinship.Former,
+	}
+	for _, ancestor := range kinship.CommonAncestors {
+		response.CommonAncestors = append(response.CommonAncestors, PersonMapper(ancestor))
+	}
+	if kinship.InLaw != nil {
+		response.InLaw = &InLaw{
+			Side: kinship.InLaw.Side,
+			Link: PersonMapper(kinship.InLaw.Link),
+		}
+	}
+	return response
+}
+
+// PaginationResponseMetadata Page is only set on offset pages and TotalItens
+// on offset pages or when includeTotal is set. Next and Prev are the cursors
+// of the pages around people lists.
 type PaginationResponseMetadata struct {
-	Page       int `json:"page"`
-	TotalItens int `json:"totalItens"`
+	Page       *int   `json:"page,omitempty"`
+	TotalItens *int   `json:"totalItens,omitempty"`
+	Next       string `json:"next,omitempty"`
+	Prev       string `json:"prev,omitempty"`
+}
+
+func PaginationMetadataMapper(pagination familytree.PaginationDetails, metadata familytree.ListMetadata) PaginationResponseMetadata {
+	response := PaginationResponseMetadata{}
+	if !pagination.IsKeyset() {
+		response.Page = &metadata.Page
+	}
+	if pagination.CountsTotal() {
+		response.TotalItens = &metadata.TotalItens
+	}
+	return response
 }
+
 type GetPeopleResponse struct {
-	Content  []*Person                  `json:"content"`
+	Content  []*PersonListItem          `json:"content"`
 	Metadata PaginationResponseMetadata `json:"metadata"`
 }
 
+// DuplicatePair Score goes from 0 to 1, reasons are the evidences that the
+// people are the same.
+type DuplicatePair struct {
+	First   Person                       `json:"first"`
+	Second  Person                       `json:"second"`
+	Score   float64                      `json:"score" example:"0.8"`
+	Reasons []familytree.DuplicateReason `json:"reasons" swaggertype:"array,string" enums:"SAME_NAME,PHONETIC_NAME,SIMILAR_NAME,SHARED_PARENTS,SHARED_SPOUSE,BIRTH_DATE,DEATH_DATE"`
+}
+
+type GetDuplicatesResponse struct {
+	Content  []DuplicatePair            `json:"content"`
+	Metadata PaginationResponseMetadata `json:"metadata"`
+}
+
+func DuplicatesMapper(pagination familytree.PaginationDetails, list *familytree.DuplicateList) GetDuplicatesResponse {
+	response := GetDuplicatesResponse{
+		Content:  make([]DuplicatePair, 0, len(list.Content)),
+		Metadata: PaginationMetadataMapper(pagination, list.Metadata),
+	}
+	for _, pair := range list.Content {
+		response.Content = append(response.Content, DuplicatePair{
+			First:   PersonMapper(pair.First),
+			Second:  PersonMapper(pair.Second),
+			Score:   pair.Score,
+			Reasons: pair.Reasons,
+		})
+	}
+	return response
+}
+
+// PersonListItem Score and Match are only set on searches, the score is the
+// trigram similarity between the query and the name.
+type PersonListItem struct {
+	Person
+	Score *float64             `json:"score,omitempty" example:"0.5"`
+	Match familytree.NameMatch `json:"match,omitempty" swaggertype:"string" enums:"SUBSTRING,PHONETIC,SIMILAR"`
+}
+
+// DeletedRelation Parentage is only set on PARENT relations and Union on SPOUSE
+// relations. PARENT relations go from the parent on topID to the child on
+// bottomID.
+type DeletedRelation struct {
+	TopID        uuid.UUID            `json:"topID"`
+	BottomID     uuid.UUID            `json:"bottomID"`
+	RelationType string               `json:"relation" enums:"PARENT,SPOUSE"`
+	Parentage    familytree.Parentage `json:"parentage,omitempty" swaggertype:"string" enums:"BIOLOGICAL,ADOPTIVE,FOSTER,STEP,GUARDIAN"`
+	Union        *Union               `json:"union,omitempty"`
+}
+
+type DeletePersonResponse struct {
+	Relations []DeletedRelation `json:"relations"`
+}
+
+func DeletedRelationMapper(relation familytree.PersonRelation) DeletedRelation {
+	deleted := DeletedRelation{
+		TopID:        relation.Top.ID,
+		BottomID:     relation.Bottom.ID,
+		RelationType: relation.RelationType.Name,
+		Parentage:    relation.Parentage,
+	}
+	if relation.RelationType == familytree.RelationTypeSpouse {
+		union := Union(relation.Union)
+		deleted.Union = &union
+	}
+	return deleted
+}
+
+func DeletedRelationsMapper(relations []familytree.PersonRelation) DeletePersonResponse {
+	response := DeletePersonResponse{Relations: make([]DeletedRelation, 0, len(relations))}
+	for _, relation := range relations {
+		response.Relations = append(response.Relations, DeletedRelationMapper(relation))
+	}
+	return response
+}
+
+// TrashedPerson Relations are the relations deleted along with the person,
+// restored with it.
+type TrashedPerson struct {
+	Person    Person            `json:"person"`
+	DeletedAt time.Time         `json:"deletedAt"`
+	Relations []DeletedRelation `json:"relations"`
+}
+
+type TrashedRelation struct {
+	DeletedRelation
+	DeletedAt time.Time `json:"deletedAt"`
+}
+
+type GetTrashResponse struct {
+	People    []TrashedPerson   `json:"people"`
+	Relations []TrashedRelation `json:"relations"`
+}
+
+func TrashedPersonMapper(trashed familytree.TrashedPerson) TrashedPerson {
+	return TrashedPerson{
+		Person:    PersonMapper(trashed.Person),
+		DeletedAt: trashed.DeletedAt,
+		Relations: DeletedRelationsMapper(trashed.Relations).Relations,
+	}
+}
+
+func TrashMapper(trash *familytree.Trash) GetTrashResponse {
+	response := GetTrashResponse{
+		People:    make([]TrashedPerson, 0, len(trash.People)),
+		Relations: make([]TrashedRelation, 0, len(trash.Relations)),
+	}
+	for _, trashed := range trash.People {
+		response.People = append(response.People, TrashedPersonMapper(trashed))
+	}
+	for _, trashed := range trash.Relations {
+		response.Relations = append(response.Relations, TrashedRelation{
+			DeletedRelation: DeletedRelationMapper(trashed.Relation),
+			DeletedAt:       trashed.DeletedAt,
+		})
+	}
+	return response
+}
+
+type RestoreParentRelationshipRequest struct {
+	ParentID uuid.UUID `json:"parentID"`
+	ChildID  uuid.UUID `json:"childID"`
+}
+
+func (r RestoreParentRelationshipRequest) Validate() error {
+	if r.ParentID == uuid.Nil {
+		return ErrNotUUID
+	}
+	if r.ChildID == uuid.Nil {
+		return ErrNotUUID
+	}
+	return nil
+}
+
+type RestoreSpouseRelationshipRequest struct {
+	FirstSpouseID  uuid.UUID `json:"firstSpouseID"`
+	SecondSpouseID uuid.UUID `json:"secondSpouseID"`
+}
+
+func (r RestoreSpouseRelationshipRequest) Validate() error {
+	if r.FirstSpouseID == uuid.Nil {
+		return ErrNotUUID
+	}
+	if r.SecondSpouseID == uuid.Nil {
+		return ErrNotUUID
+	}
+	return nil
+}
+
+// AuditState holds the people and relations changed by a mutation, as they were
+// before or after it.
+type AuditState struct {
+	People    []Person          `json:"people"`
+	Relations []DeletedRelation `json:"relations"`
+}
+
+// AuditEvent PeopleIDs are the people with the event in their history.
+type AuditEvent struct {
+	ID        uuid.UUID   `json:"id"`
+	Timestamp time.Time   `json:"timestamp"`
+	Actor     string      `json:"actor" example:"anonymous"`
+	RequestID string      `json:"requestID"`
+	Operation string      `json:"operation" enums:"CREATE_PERSON,UPDATE_PERSON,DELETE_PERSON,MERGE_PEOPLE,RESTORE_PERSON,PURGE_PERSON,CREATE_PARENT_RELATION,DELETE_PARENT_RELATION,RESTORE_PARENT_RELATION,CREATE_SPOUSE_RELATION,UPDATE_SPOUSE_RELATION,DELETE_SPOUSE_RELATION,RESTORE_SPOUSE_RELATION,PURGE_RELATION"`
+	PeopleIDs []uuid.UUID `json:"peopleIDs"`
+	Before    AuditState  `json:"before"`
+	After     AuditState  `json:"after"`
+}
+
+type GetAuditResponse struct {
+	Content  []AuditEvent               `json:"content"`
+	Metadata PaginationResponseMetadata `json:"metadata"`
+}
+
+func AuditStateMapper(state familytree.AuditState) AuditState {
+	response := AuditState{
+		People:    make([]Person, 0, len(state.People)),
+		Relations: DeletedRelationsMapper(state.Relations).Relations,
+	}
+	for _, person := range state.People {
+		response.People = append(response.People, PersonMapper(person))
+	}
+	return response
+}
+
+func AuditMapper(pagination familytree.PaginationDetails, list *familytree.AuditEventList) GetAuditResponse {
+	response := GetAuditResponse{
+		Content:  make([]AuditEvent, 0, len(list.Content)),
+		Metadata: PaginationMetadataMapper(pagination, list.Metadata),
+	}
+	for _, event := range list.Content {
+		response.Content = append(response.Content, AuditEvent{
+			ID:        event.ID,
+			Timestamp: event.Timestamp,
+			Actor:     event.Actor,
+			RequestID: event.RequestID,
+			Operation: string(event.Operation),
+			PeopleIDs: event.PeopleIDs,
+			Before:    AuditStateMapper(event.Before),
+			After:     AuditStateMapper(event.After),
+		})
+	}
+	return response
+}
+
+// FamilyTreeRelation Parentage is only set on PARENT relations and Union on
+// SPOUSE relations.
 type FamilyTreeRelation struct {
-	PersonID     uuid.UUID `json:"relativeID" xml:"id,attr"`
-	RelationType string    `json:"relation" xml:"relationType"`
+	PersonID     uuid.UUID            `json:"relativeID" xml:"id,attr"`
+	RelationType string               `json:"relation" xml:"relationType"`
+	Parentage    familytree.Parentage `json:"parentage,omitempty" xml:"parentage,omitempty" swaggertype:"string" enums:"BIOLOGICAL,ADOPTIVE,FOSTER,STEP,GUARDIAN"`
+	Union        *Union               `json:"union,omitempty" xml:"union,omitempty"`
 }
 
 type FamilyTreeNode struct {
@@ -135,6 +767,153 @@ type FamilyTreeNode struct {
 type FamilyTree struct {
 	XMLName xml.Name         `json:"-" xml:"familyTree"`
 	People  []FamilyTreeNode `json:"people" xml:"people"`
+	tree    *familytree.FamilyTree
+}
+
+func (tree FamilyTree) MarshalGEDCOM() ([]byte, error) {
+	var body bytes.Buffer
+	if err := gedcom.EncodeFamilyTree(&body, tree.tree); err != nil {
+		return nil, err
+	}
+	return body.Bytes(), nil
+}
+
+func (tree FamilyTree) MarshalDOT() ([]byte, error) {
+	var body bytes.Buffer
+	if err := treeview.EncodeDot(&body, tree.tree); err != nil {
+		return nil, err
+	}
+	return body.Bytes(), nil
+}
+
+func (tree FamilyTree) MarshalSVG() ([]byte, error) {
+	var body bytes.Buffer
+	if err := treeview.EncodeSVG(&body, tree.tree); err != nil {
+		return nil, err
+	}
+	return body.Bytes(), nil
+}
+
+type GedcomImportEntry struct {
+	Xref         string     `json:"xref,omitempty"`
+	Tag          string     `json:"tag"`
+	RelationType string     `json:"relation,omitempty"`
+	People       []string   `json:"people,omitempty"`
+	PersonID     *uuid.UUID `json:"personID,omitempty"`
+	Status       string     `json:"status"`
+	Reason       string     `json:"reason,omitempty"`
+}
+
+type PostGedcomImportResponse struct {
+	Imported  int                 `json:"imported"`
+	Skipped   int                 `json:"skipped"`
+	Rejected  int                 `json:"rejected"`
+	Committed bool                `json:"committed"`
+	Entries   []GedcomImportEntry `json:"entries"`
+}
+
+func GedcomImportMapper(report *gedcom.ImportReport) PostGedcomImportResponse {
+	response := PostGedcomImportResponse{
+		Imported:  report.Imported,
+		Skipped:   report.Skipped,
+		Rejected:  report.Rejected,
+		Committed: report.Committed,
+		Entries:   make([]GedcomImportEntry, 0, len(report.Entries)),
+	}
+	for _, entry := range report.Entries {
+		mappedEntry := GedcomImportEntry{
+			Xref:         entry.Xref,
+			Tag:          entry.Tag,
+			RelationType: entry.RelationType,
+			People:       entry.People,
+			Status:       string(entry.Status),
+			Reason:       entry.Reason,
+		}
+		if entry.PersonID != uuid.Nil {
+			personID := entry.PersonID
+			mappedEntry.PersonID = &personID
+		}
+		response.Entries = append(response.Entries, mappedEntry)
+	}
+	return response
+}
+
+// Descendant Number is the d'Aboville number, like 1.2.1, and SameAs points
+// to the first entry of people descending through more than one line.
+type Descendant struct {
+	Number     string               `json:"number" xml:"number,attr"`
+	Generation int                  `json:"generation" xml:"generation,attr"`
+	Person     Person               `json:"person" xml:"person"`
+	ParentID   *uuid.UUID           `json:"parentID,omitempty" xml:"parentID,omitempty"`
+	Parentage  familytree.Parentage `json:"parentage,omitempty" xml:"parentage,omitempty" swaggertype:"string" enums:"BIOLOGICAL,ADOPTIVE,FOSTER,STEP,GUARDIAN"`
+	SameAs     string               `json:"sameAs,omitempty" xml:"sameAs,omitempty"`
+	Spouses    []PersonUnion        `json:"spouses,omitempty" xml:"spouse,omitempty"`
+}
+
+type Descendants struct {
+	XMLName     xml.Name     `json:"-" xml:"descendants"`
+	Root        Person       `json:"root" xml:"root"`
+	Depth       int          `json:"depth" xml:"depth,attr"`
+	Descendants []Descendant `json:"descendants" xml:"descendant"`
+}
+
+func DescendantsMapper(descendants *familytree.Descendants) *Descendants {
+	response := &Descendants{
+		Root:        PersonMapper(descendants.Root),
+		Depth:       descendants.Depth,
+		Descendants: make([]Descendant, 0, len(descendants.Descendants)),
+	}
+	for _, descendant := range descendants.Descendants {
+		mappedDescendant := Descendant{
+			Number:     descendant.Number,
+			Generation: descendant.Generation,
+			Person:     PersonMapper(descendant.Person),
+			ParentID:   descendant.ParentID,
+			Parentage:  descendant.Parentage,
+			SameAs:     descendant.SameAs,
+		}
+		if descendant.Spouses != nil {
+			mappedDescendant.Spouses = UnionsMapper(descendant.Spouses).Content
+		}
+		response.Descendants = append(response.Descendants, mappedDescendant)
+	}
+	return response
+}
+
+// Ancestor Number is the Ahnentafel number, 2n for the father and 2n+1 for
+// the mother of n. Collapsed marks people reached through more than one line,
+// SameAs points to their lowest number.
+type Ancestor struct {
+	Number     int    `json:"number" xml:"number,attr"`
+	Generation int    `json:"generation" xml:"generation,attr"`
+	Person     Person `json:"person" xml:"person"`
+	SameAs     int    `json:"sameAs,omitempty" xml:"sameAs,omitempty"`
+	Collapsed  bool   `json:"collapsed" xml:"collapsed,attr"`
+}
+
+type Pedigree struct {
+	XMLName     xml.Name   `json:"-" xml:"pedigree"`
+	Root        Person     `json:"root" xml:"root"`
+	Generations int        `json:"generations" xml:"generations,attr"`
+	Ancestors   []Ancestor `json:"ancestors" xml:"ancestor"`
+}
+
+func PedigreeMapper(pedigree *familytree.Pedigree) *Pedigree {
+	response := &Pedigree{
+		Root:        PersonMapper(pedigree.Root),
+		Generations: pedigree.Generations,
+		Ancestors:   make([]Ancestor, 0, len(pedigree.Ancestors)),
+	}
+	for _, ancestor := range pedigree.Ancestors {
+		response.Ancestors = append(response.Ancestors, Ancestor{
+			Number:     ancestor.Number,
+			Generation: ancestor.Generation,
+			Person:     PersonMapper(ancestor.Person),
+			SameAs:     ancestor.SameAs,
+			Collapsed:  ancestor.Collapsed,
+		})
+	}
+	return response
 }
 
 func FamilyTreeMapper(tree *familytree.FamilyTree) *FamilyTree {
@@ -143,6 +922,7 @@ func FamilyTreeMapper(tree *familytree.FamilyTree) *FamilyTree {
 	}
 	newTree := &FamilyTree{
 		People: make([]FamilyTreeNode, 0, len(tree.People)),
+		tree:   tree,
 	}
 	for _, node := range tree.People {
 		convertedNode := &FamilyTreeNode{
@@ -150,10 +930,16 @@ func FamilyTreeMapper(tree *familytree.FamilyTree) *FamilyTree {
 			Relations: make([]FamilyTreeRelation, 0, len(node.Relations)),
 		}
 		for _, relation := range node.Relations {
-			convertedNode.Relations = append(convertedNode.Relations, FamilyTreeRelation{
+			convertedRelation := FamilyTreeRelation{
 				PersonID:     relation.PersonID,
 				RelationType: relation.RelationType.String(),
-			})
+				Parentage:    relation.Parentage,
+			}
+			if relation.Union != nil {
+				union := Union(*relation.Union)
+				convertedRelation.Union = &union
+			}
+			convertedNode.Relations = append(convertedNode.Relations, convertedRelation)
 		}
 		newTree.People = append(newTree.People, *convertedNode)
 
@@ -165,12 +951,42 @@ func PersonMapper(person familytree.Person) Person {
 	return Person(person)
 }
 
-func PeopleMapper(people []*familytree.Person) []*Person {
-	mappedPeople := make([]*Person, 0, len(people))
+// PeopleFilterMapper reads the filter query parameters, booleans that can't be
+// parsed don't filter.
+func PeopleFilterMapper(query url.Values) familytree.PeopleFilter {
+	optional := func(param string) *bool {
+		value, err := strconv.ParseBool(query.Get(param))
+		if err != nil {
+			return nil
+		}
+		return &value
+	}
+	return familytree.PeopleFilter{
+		HasParents: optional(PeopleHasParentsParam),
+		IsLiving:   optional(PeopleIsLivingParam),
+		BornAfter:  familytree.Date(query.Get(PeopleBornAfterParam)),
+		BornBefore: familytree.Date(query.Get(PeopleBornBeforeParam)),
+		Surname:    query.Get(PeopleSurnameParam),
+	}
+}
+
+func PeopleListMapper(people []*familytree.Person) []*PersonListItem {
+	items := make([]*PersonListItem, 0, len(people))
 	for _, person := range people {
-		mappedPerson := Person(*person)
-		mappedPeople = append(mappedPeople, &mappedPerson)
+		items = append(items, &PersonListItem{Person: PersonMapper(*person)})
+	}
+	return items
+}
 
+func PeopleMatchListMapper(matches []familytree.PersonMatch) []*PersonListItem {
+	items := make([]*PersonListItem, 0, len(matches))
+	for _, match := range matches {
+		score := match.Score
+		items = append(items, &PersonListItem{
+			Person: PersonMapper(match.Person),
+			Score:  &score,
+			Match:  match.Match,
+		})
 	}
-	return mappedPeople
+	return items
 }
@@ -2,7 +2,10 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
 	"family-tree/internal/core/familytree"
+	"family-tree/internal/gedcom"
+	"fmt"
 	"net/http"
 	"strconv"
 
@@ -46,14 +49,164 @@ func (server *Server) GetBaconsNumber(w http.ResponseWriter, r *http.Request) {
 	WriteJsonBody(w, r, http.StatusOK, GetBaconsNumberResponse{PathLength: baconsNumber})
 }
 
+// GetRelatednessHandler godoc
+// @Summary Busca o coeficiente de parentesco entre duas pessoas
+// @Description Calcula o coeficiente de parentesco de Wright somando todos os caminhos pelos ancestrais comuns, cada um valendo (1/2)^n (1 + Fa)
+// @Description Traz também o coeficiente de consanguinidade de cada pessoa e a contribuição de cada ancestral comum
+// @Description Apenas a linhagem biológica é seguida, até 12 gerações acima de cada pessoa
+// @Tags relationship
+// @Produce  json
+// @Param personID path string true "ID da pessoa no formato uuid (XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX)"
+// @Param targetPersonID path string true "ID da pessoa alvo no formato uuid (XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX)"
+// @Success 200 {object} GetRelatednessResponse
+// @Router /person/{personID}/relatedness/{targetPersonID} [get]
+func (server *Server) GetRelatednessHandler(w http.ResponseWriter, r *http.Request) {
+	stringUUID := chi.URLParam(r, "personID")
+	personID, err := uuid.Parse(stringUUID)
+	if err != nil {
+		WriteErrorMessage(w, r, http.StatusBadRequest, ErrNotUUID)
+		return
+	}
+	stringUUID = chi.URLParam(r, "targetPersonID")
+	targetPersonID, err := uuid.Parse(stringUUID)
+	if err != nil {
+		WriteErrorMessage(w, r, http.StatusBadRequest, ErrNotUUID)
+		return
+	}
+
+	relatedness, err := server.RelationshipUseCase.GetRelatedness(r.Context(), personID, targetPersonID)
+	if err != nil {
+		WriteErrorValidation(w, r, err)
+		return
+	}
+	WriteJsonBody(w, r, http.StatusOK, RelatednessMapper(*relatedness))
+}
+
+// GetInbreedingHandler godoc
+// @Summary Busca o coeficiente de consanguinidade de uma pessoa
+// @Description Calcula o coeficiente de consanguinidade de uma pessoa, metade da soma dos caminhos entre seus pais biológicos pelos ancestrais comuns
+// @Description Pessoas sem os dois pais biológicos conhecidos têm coeficiente 0
+// @Tags relationship
+// @Produce  json
+// @Param personID path string true "ID da pessoa no formato uuid (XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX)"
+// @Success 200 {object} GetInbreedingResponse
+// @Router /person/{personID}/inbreeding [get]
+func (server *Server) GetInbreedingHandler(w http.ResponseWriter, r *http.Request) {
+	stringUUID := chi.URLParam(r, "personID")
+	personID, err := uuid.Parse(stringUUID)
+	if err != nil {
+		WriteErrorMessage(w, r, http.StatusBadRequest, ErrNotUUID)
+		return
+	}
+
+	inbreeding, err := server.RelationshipUseCase.GetInbreeding(r.Context(), personID)
+	if err != nil {
+		WriteErrorValidation(w, r, err)
+		return
+	}
+	WriteJsonBody(w, r, http.StatusOK, InbreedingMapper(*inbreeding))
+}
+
+// GetPathsHandler godoc
+// @Summary Busca os caminhos mais curtos entre duas pessoas
+// @Description Explica o número de Bacon, listando as pessoas de cada caminho e a relação seguida em cada passo
+// @Description edges[i] é o que people[i] é de people[i+1]: PARENT_OF, CHILD_OF ou SPOUSE_OF
+// @Description Retorna 404 caso não exista caminho
+// @Tags person
+// @Produce  json
+// @Param personID path string true "ID da pessoa no formato uuid (XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX)"
+// @Param targetPersonID path string true "ID da pessoa alvo no formato uuid (XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX)"
+// @Param bloodOnly query bool false "Segue apenas relações de filiação biológica"
+// @Param excludeSpouses query bool false "Não segue relações de esposo"
+// @Param maxLength query int false "Tamanho máximo dos caminhos, padrão 10 e no máximo 20"
+// @Param count query int false "Quantidade de caminhos distintos, padrão 1 e no máximo 10"
+// @Success 200 {object} GetPathsResponse
+// @Router /person/{personID}/path/{targetPersonID} [get]
+func (server *Server) GetPathsHandler(w http.ResponseWriter, r *http.Request) {
+	stringUUID := chi.URLParam(r, "personID")
+	personID, err := uuid.Parse(stringUUID)
+	if err != nil {
+		WriteErrorMessage(w, r, http.StatusBadRequest, ErrNotUUID)
+		return
+	}
+	stringUUID = chi.URLParam(r, "targetPersonID")
+	targetPersonID, err := uuid.Parse(stringUUID)
+	if err != nil {
+		WriteErrorMessage(w, r, http.StatusBadRequest, ErrNotUUID)
+		return
+	}
+	bloodOnly, _ := strconv.ParseBool(r.URL.Query().Get(PathBloodOnlyParam))
+	excludeSpouses, _ := strconv.ParseBool(r.URL.Query().Get(PathNoSpousesParam))
+	maxLength, err := strconv.Atoi(r.URL.Query().Get(PathMaxLengthParam))
+	if err != nil {
+		maxLength = 0
+	}
+	count, err := strconv.Atoi(r.URL.Query().Get(PathCountParam))
+	if err != nil {
+		count = 0
+	}
+
+	paths, err := server.PersonUseCase.GetPaths(r.Context(), personID, targetPersonID, familytree.PathFilter{
+		BloodOnly:      bloodOnly,
+		ExcludeSpouses: excludeSpouses,
+		MaxLength:      maxLength,
+		Count:          count,
+	})
+	if err != nil {
+		WriteErrorValidation(w, r, err)
+		return
+	}
+	if len(paths) == 0 {
+		WriteErrorMessage(w, r, http.StatusNotFound, ErrNoPathFound)
+		return
+	}
+
+	WriteJsonBody(w, r, http.StatusOK, PathsMapper(paths))
+}
+
+// GetKinshipHandler godoc
+// @Summary Busca o parentesco entre duas pessoas
+// @Description Descreve o que a pessoa alvo é da pessoa, em inglês e em português, como "first cousin once removed" ou "tio-avô"
+// @Description Apenas a linhagem biológica e as uniões são seguidas, afins são buscados pelas uniões não dissolvidas
+// @Description Retorna 404 caso as pessoas não sejam parentes
+// @Tags relationship
+// @Produce  json
+// @Param personID path string true "ID da pessoa no formato uuid (XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX)"
+// @Param targetPersonID path string true "ID da pessoa alvo no formato uuid (XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX)"
+// @Success 200 {object} GetKinshipResponse
+// @Router /person/{personID}/kinship/{targetPersonID} [get]
+func (server *Server) GetKinshipHandler(w http.ResponseWriter, r *http.Request) {
+	stringUUID := chi.URLParam(r, "personID")
+	personID, err := uuid.Parse(stringUUID)
+	if err != nil {
+		WriteErrorMessage(w, r, http.StatusBadRequest, ErrNotUUID)
+		return
+	}
+	stringUUID = chi.URLParam(r, "targetPersonID")
+	targetPersonID, err := uuid.Parse(stringUUID)
+	if err != nil {
+		WriteErrorMessage(w, r, http.StatusBadRequest, ErrNotUUID)
+		return
+	}
+
+	kinship, err := server.RelationshipUseCase.GetKinship(r.Context(), personID, targetPersonID)
+	if err != nil {
+		WriteErrorValidation(w, r, err)
+		return
+	}
+	WriteJsonBody(w, r, http.StatusOK, KinshipMapper(*kinship))
+}
+
 // GetPerson godoc
 // @Summary Busca detalhes de uma pessoa pelo seu id
 // @Description Busca detalhes de uma pessoa pelo seu id
 // @Description Retorna 404 caso não existe
+// @Description Pessoas unidas a outra redirecionam com 301 para a pessoa que ficou
 // @Tags person
 // @Produce  json
 // @Param personID path string true "ID da pessoa no formato uuid (XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX)"
 // @Success 200 {object} Person
+// @Success 301
 // @Router /person/{personID} [get]
 func (server *Server) GetPersonHandler(w http.ResponseWriter, r *http.Request) {
 	stringUUID := chi.URLParam(r, "personID")
@@ -69,12 +222,85 @@ func (server *Server) GetPersonHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if person == nil {
-		WriteError(w, r, http.StatusNotFound)
+		server.redirectMergedPerson(w, r, personID)
 		return
 	}
 	WriteJsonBody(w, r, http.StatusOK, PersonMapper(*person))
 }
 
+func (server *Server) redirectMergedPerson(w http.ResponseWriter, r *http.Request, personID uuid.UUID) {
+	redirect, ok, err := server.PersonUseCase.GetRedirect(r.Context(), personID)
+	if err != nil {
+		WriteErrorValidation(w, r, err)
+		return
+	}
+	if !ok {
+		WriteError(w, r, http.StatusNotFound)
+		return
+	}
+	http.Redirect(w, r, "/person/"+redirect.String(), http.StatusMovedPermanently)
+}
+
+// PostMergePersonHandler godoc
+// @Summary Une uma pessoa duplicada a outra
+// @Description Une a pessoa duplicada à pessoa do caminho, que fica, e remove a duplicada
+// @Description Os campos vazios da pessoa que fica são preenchidos com os da duplicada
+// @Description Todas as relações de PARENT e SPOUSE da duplicada passam para a pessoa que fica com as mesmas validações da criação, as que ela já tem são ignoradas
+// @Description Caso alguma relação não possa passar, como um filho que ficaria com três pais, nada é alterado e a lista de conflitos é retornada com 409
+// @Description O ID da duplicada passa a redirecionar para a pessoa que ficou
+// @Tags person
+// @Produce  json
+// @Param personID path string true "ID da pessoa que fica no formato uuid (XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX)"
+// @Param request body PostMergePersonRequest true "Pessoa duplicada"
+// @Success 200 {object} PostMergePersonResponse
+// @Failure 409 {object} MergeConflictResponse
+// @Router /person/{personID}/merge [post]
+func (server *Server) PostMergePersonHandler(w http.ResponseWriter, r *http.Request) {
+	stringUUID := chi.URLParam(r, "personID")
+	personID, err := uuid.Parse(stringUUID)
+	if err != nil {
+		WriteErrorMessage(w, r, http.StatusBadRequest, ErrNotUUID)
+		return
+	}
+	request := &PostMergePersonRequest{}
+	err = json.NewDecoder(r.Body).Decode(request)
+	if err != nil {
+		WriteErrorMessage(w, r, http.StatusBadRequest, err)
+		return
+	}
+	err = request.Validate()
+	if err != nil {
+		WriteErrorMessage(w, r, http.StatusBadRequest, err)
+		return
+	}
+	result, err := server.RelationshipUseCase.MergePeople(r.Context(), personID, request.DuplicateID)
+	if err != nil {
+		var mergeErr *familytree.MergeConflictError
+		if errors.As(err, &mergeErr) {
+			WriteJsonBody(w, r, http.StatusConflict, MergeConflictMapper(mergeErr))
+			return
+		}
+		WriteErrorValidation(w, r, err)
+		return
+	}
+	WriteJsonBody(w, r, http.StatusOK, MergeMapper(result))
+}
+
+// DeletePersonHandler godoc
+// @Summary Remove uma pessoa
+// @Description Sem cascade a pessoa só é removida se não tiver nenhuma relação, e nada é retornado
+// @Description Com cascade=relations a pessoa é removida junto com todas as suas relações de PARENT e SPOUSE em uma única transação, e as relações removidas são retornadas
+// @Description Com cascade=relations a pessoa não é removida se for o único filho de um casal, já que o casal precisa ter um filho em comum
+// @Description Com cascade=unions a pessoa é removida como em cascade=relations, e se for o único filho de um casal a união do casal também é removida e retornada
+// @Description A união do casal não volta ao restaurar a pessoa, ela deve ser restaurada depois da pessoa
+// @Description A pessoa e as relações removidas vão para a lixeira, de onde podem ser restauradas até o fim do período de retenção
+// @Tags person
+// @Produce  json
+// @Param personID path string true "ID da pessoa no formato uuid (XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX)"
+// @Param cascade query string false "Remove também as relações da pessoa, e com unions também a união dos pais de um filho único" Enums(relations, unions)
+// @Success 200 {object} DeletePersonResponse
+// @Success 204
+// @Router /person/{personID} [delete]
 func (server *Server) DeletePersonHandler(w http.ResponseWriter, r *http.Request) {
 	stringUUID := chi.URLParam(r, "personID")
 	personID, err := uuid.Parse(stringUUID)
@@ -83,6 +309,20 @@ func (server *Server) DeletePersonHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	switch cascade := r.URL.Query().Get(DeleteCascadeParam); cascade {
+	case "":
+	case DeleteCascadeRelations, DeleteCascadeUnions:
+		relations, err := server.PersonUseCase.DeletePersonCascade(r.Context(), personID, cascade == DeleteCascadeUnions)
+		if err != nil {
+			WriteErrorValidation(w, r, err)
+			return
+		}
+		WriteJsonBody(w, r, http.StatusOK, DeletedRelationsMapper(relations))
+		return
+	default:
+		WriteErrorMessage(w, r, http.StatusBadRequest, fmt.Errorf("%w: %q", ErrInvalidCascade, cascade))
+		return
+	}
 	err = server.PersonUseCase.DeletePerson(r.Context(), personID)
 	if err != nil {
 		WriteErrorValidation(w, r, err)
@@ -103,10 +343,16 @@ func (server *Server) DeletePersonHandler(w http.ResponseWriter, r *http.Request
 // @Description b) Seus filhos
 // @Description c) Seus sobrinhos
 // @Description d) Relações de esposo entre ancestrais, não incluindo se estiver a relação de esposo com alguém sem relação sanguínea
+// @Description As relações de esposo trazem o período da união
+// @Description Com o header Accept text/x-gedcom a árvore é entregue como GEDCOM 5.5.1, agrupando pais, filhos e esposos em registros FAM
+// @Description Com o header Accept text/vnd.graphviz a árvore é entregue no formato DOT do Graphviz e com image/svg+xml já desenhada em SVG, com uma geração por linha
 // @Tags relationship
 // @Produce  json
 // @Produce  application/xml
 // @Produce  octet-stream
+// @Produce  text/x-gedcom
+// @Produce  text/vnd.graphviz
+// @Produce  image/svg+xml
 // @Param personID path string true "ID da pessoa no formato uuid (XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX)"
 // @Success 200 {object} FamilyTree
 // @Router /person/{personID}/tree [get]
@@ -130,14 +376,100 @@ func (server *Server) GetFamilyTree(w http.ResponseWriter, r *http.Request) {
 	ResponseStrategy(r.Header.Values("accept"), http.StatusOK)(w, r, FamilyTreeMapper(familyTree))
 }
 
+// GetPedigreeHandler godoc
+// @Summary Busca a árvore de costados de uma pessoa
+// @Description Busca os ascendentes diretos de uma pessoa até a geração pedida, numerados no sistema Ahnentafel
+// @Description A pessoa é o número 1, o pai de n é 2n e a mãe é 2n+1, pais sem sexo conhecido seguem uma ordem estável
+// @Description Apenas a linhagem biológica é seguida
+// @Description Ascendentes alcançados por mais de uma linha (implexo) são marcados em collapsed e os números repetidos apontam para o menor em sameAs
+// @Tags relationship
+// @Produce  json
+// @Produce  application/xml
+// @Produce  octet-stream
+// @Param personID path string true "ID da pessoa no formato uuid (XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX)"
+// @Param generations query int false "Quantidade de gerações, padrão 5 e no máximo 10"
+// @Success 200 {object} Pedigree
+// @Router /person/{personID}/pedigree [get]
+func (server *Server) GetPedigreeHandler(w http.ResponseWriter, r *http.Request) {
+	stringUUID := chi.URLParam(r, "personID")
+	personID, err := uuid.Parse(stringUUID)
+	if err != nil {
+		WriteErrorMessage(w, r, http.StatusBadRequest, ErrNotUUID)
+		return
+	}
+	generations, err := strconv.Atoi(r.URL.Query().Get(PedigreeGenerationsParam))
+	if err != nil {
+		generations = 0
+	}
+
+	pedigree, err := server.RelationshipUseCase.GetPedigree(r.Context(), personID, generations)
+	if err != nil {
+		WriteErrorValidation(w, r, err)
+		return
+	}
+	ResponseStrategy(r.Header.Values("accept"), http.StatusOK)(w, r, PedigreeMapper(pedigree))
+}
+
+// GetDescendantsHandler godoc
+// @Summary Busca os descendentes de uma pessoa
+// @Description Busca os descendentes de uma pessoa até a profundidade pedida, numerados no sistema d'Aboville (1, 1.1, 1.2, 1.2.1), com os filhos ordenados pela data de nascimento
+// @Description A pessoa é a entrada 1 e cada entrada traz sua geração, contada a partir dela
+// @Description Quem descende da pessoa por mais de uma linha só é expandido na primeira entrada, as outras apontam para ela em sameAs
+// @Description Com includeSpouses as uniões de cada descendente também são trazidas
+// @Tags relationship
+// @Produce  json
+// @Produce  application/xml
+// @Produce  octet-stream
+// @Param personID path string true "ID da pessoa no formato uuid (XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX)"
+// @Param depth query int false "Quantidade de gerações, padrão 10 e no máximo 20"
+// @Param includeSpouses query bool false "Traz as uniões de cada descendente"
+// @Success 200 {object} Descendants
+// @Router /person/{personID}/descendants [get]
+func (server *Server) GetDescendantsHandler(w http.ResponseWriter, r *http.Request) {
+	stringUUID := chi.URLParam(r, "personID")
+	personID, err := uuid.Parse(stringUUID)
+	if err != nil {
+		WriteErrorMessage(w, r, http.StatusBadRequest, ErrNotUUID)
+		return
+	}
+	depth, err := strconv.Atoi(r.URL.Query().Get(DescendantsDepthParam))
+	if err != nil {
+		depth = 0
+	}
+	includeSpouses, _ := strconv.ParseBool(r.URL.Query().Get(DescendantsSpousesParam))
+
+	descendants, err := server.RelationshipUseCase.GetDescendants(r.Context(), personID, depth, includeSpouses)
+	if err != nil {
+		WriteErrorValidation(w, r, err)
+		return
+	}
+	ResponseStrategy(r.Header.Values("accept"), http.StatusOK)(w, r, DescendantsMapper(descendants))
+}
+
 // GetListPeopleHandler godoc
 // @Summary Busca todas as pessoas salvas no banco
 // @Description Busca todas as pessoas salvas no banco
+// @Description Com o parâmetro q busca as pessoas pelo nome, ignorando maiúsculas e acentos, e aceita nomes que soam iguais (Soundex) ou parecidos (trigramas)
+// @Description Os resultados da busca vêm ordenados pela nota, a similaridade de trigramas entre a busca e o nome, e q não aceita sort nem cursor
+// @Description Sem busca a lista é ordenada por sort, com - na frente para a ordem decrescente, e por padrão pela ordem de criação
+// @Description Os filtros valem para a lista e para a busca, bornAfter e bornBefore só trazem quem com certeza nasceu depois ou antes da data
+// @Description A lista traz em next e prev os cursores das páginas seguinte e anterior, que já levam os filtros e a ordem e substituem page
+// @Description Nas páginas por cursor o total só é contado com includeTotal=true
 // @Tags person
 // @Produce  json
+// @Param q query string false "Nome ou parte do nome buscado"
+// @Param sort query string false "Ordem da lista" Enums(name, -name, birthDate, -birthDate, createdAt, -createdAt)
+// @Param hasParents query bool false "Filtra quem tem ou não pais, false traz os ancestrais mais antigos"
+// @Param isLiving query bool false "Filtra quem não tem ou tem data de falecimento"
+// @Param bornAfter query string false "Data após a qual a pessoa nasceu"
+// @Param bornBefore query string false "Data antes da qual a pessoa nasceu"
+// @Param surname query string false "Sobrenome, ignorando maiúsculas e acentos"
 // @Param page query int false "Página que se deseja buscar onde a página 0 é a primeira página"
 // @Param size query int false "Tamanho da página"
+// @Param cursor query string false "Cursor next ou prev de uma página anterior"
+// @Param includeTotal query bool false "Conta o total de pessoas nas páginas por cursor"
 // @Success 200 {object} GetPeopleResponse
+// @Failure 400 {object} Error
 // @Router /person [get]
 func (server *Server) GetListPeopleHandler(w http.ResponseWriter, r *http.Request) {
 	page, err := strconv.Atoi(r.URL.Query().Get(PaginationPageParam))
@@ -148,30 +480,119 @@ func (server *Server) GetListPeopleHandler(w http.ResponseWriter, r *http.Reques
 	if err != nil {
 		size = 0
 	}
+	includeTotal, _ := strconv.ParseBool(r.URL.Query().Get(PaginationTotalParam))
+	pagination := familytree.PaginationDetails{
+		Page:         page,
+		PageSize:     size,
+		IncludeTotal: includeTotal,
+	}
+	filter := PeopleFilterMapper(r.URL.Query())
 
-	peopleList, err := server.PersonUseCase.GetPeople(r.Context(), familytree.PaginationDetails{
-		Page:     page,
-		PageSize: size,
-	})
+	if query := r.URL.Query().Get(PeopleSearchParam); query != "" {
+		for _, param := range []string{PeopleSortParam, PaginationCursorParam} {
+			if value := r.URL.Query().Get(param); value != "" {
+				WriteErrorMessage(w, r, http.StatusBadRequest, fmt.Errorf("%w: %s=%q", ErrSearchNotSortable, param, value))
+				return
+			}
+		}
+		matchList, err := server.PersonUseCase.SearchPeople(r.Context(), query, filter, pagination)
+		if err != nil {
+			WriteErrorValidation(w, r, err)
+			return
+		}
+		WriteJsonBody(w, r, http.StatusOK, GetPeopleResponse{
+			Content:  PeopleMatchListMapper(matchList.Content),
+			Metadata: PaginationMetadataMapper(pagination, matchList.Metadata),
+		})
+		return
+	}
+
+	peopleSort, err := familytree.ParsePeopleSort(r.URL.Query().Get(PeopleSortParam))
 	if err != nil {
-		WriteErrorMessage(w, r, http.StatusInternalServerError, ErrNotUUID)
+		WriteErrorValidation(w, r, err)
+		return
+	}
+	if token := r.URL.Query().Get(PaginationCursorParam); token != "" {
+		cursor, err := server.CursorSigner.Parse(token)
+		if err != nil {
+			WriteErrorMessage(w, r, http.StatusBadRequest, err)
+			return
+		}
+		filter, peopleSort = cursor.Filter, cursor.Sort
+		if cursor.Backward {
+			pagination.Before = &cursor.Key
+		} else {
+			pagination.After = &cursor.Key
+		}
+	}
+	peopleList, err := server.PersonUseCase.GetPeople(r.Context(), filter, peopleSort, pagination)
+	if err != nil {
+		WriteErrorValidation(w, r, err)
 		return
 	}
 
 	response := GetPeopleResponse{
-		Content:  PeopleMapper(peopleList.Content),
-		Metadata: PaginationResponseMetadata(peopleList.Metadata),
+		Content:  PeopleListMapper(peopleList.Content),
+		Metadata: PaginationMetadataMapper(pagination, peopleList.Metadata),
+	}
+	response.Metadata.Next, err = server.CursorSigner.SignKey(filter, peopleSort, peopleList.Metadata.Next, false)
+	if err != nil {
+		WriteErrorMessage(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	response.Metadata.Prev, err = server.CursorSigner.SignKey(filter, peopleSort, peopleList.Metadata.Prev, true)
+	if err != nil {
+		WriteErrorMessage(w, r, http.StatusInternalServerError, err)
+		return
 	}
 
 	WriteJsonBody(w, r, http.StatusOK, response)
 }
 
+// GetDuplicatesHandler godoc
+// @Summary Busca pares de pessoas que provavelmente são a mesma pessoa
+// @Description Compara apenas as pessoas com o mesmo Soundex no primeiro e no último nome ou que têm um pai, mãe ou esposo em comum
+// @Description Os nomes precisam soar iguais ou ser parecidos, pais e esposos em comum e datas de nascimento e falecimento compatíveis aumentam a nota
+// @Description Pares com sexos diferentes ou datas a mais de 2 anos de distância nunca são duplicados
+// @Description Os pares vêm ordenados pela nota, de 0 a 1, com os motivos de cada um
+// @Tags person
+// @Produce  json
+// @Param minScore query number false "Nota mínima dos pares, padrão 0.5"
+// @Param page query int false "Página que se deseja buscar onde a página 0 é a primeira página"
+// @Param size query int false "Tamanho da página"
+// @Success 200 {object} GetDuplicatesResponse
+// @Router /person/duplicates [get]
+func (server *Server) GetDuplicatesHandler(w http.ResponseWriter, r *http.Request) {
+	page, err := strconv.Atoi(r.URL.Query().Get(PaginationPageParam))
+	if err != nil {
+		page = 0
+	}
+	size, err := strconv.Atoi(r.URL.Query().Get(PaginationSizeParam))
+	if err != nil {
+		size = 0
+	}
+	minScore, err := strconv.ParseFloat(r.URL.Query().Get(DuplicatesMinScoreParam), 64)
+	if err != nil {
+		minScore = 0
+	}
+	pagination := familytree.PaginationDetails{Page: page, PageSize: size}
+
+	duplicates, err := server.PersonUseCase.GetDuplicates(r.Context(), minScore, pagination)
+	if err != nil {
+		WriteErrorValidation(w, r, err)
+		return
+	}
+	WriteJsonBody(w, r, http.StatusOK, DuplicatesMapper(pagination, duplicates))
+}
+
 // PostCreatePersonHandler godoc
 // @Summary Cria uma pessoa dado um body com o nome desejado
 // @Description Cria uma pessoa dado um body com o nome desejado
+// @Description Caso o nome seja vazio ele é montado com o prenome e o sobrenome
+// @Description As datas podem ser parciais ou aproximadas (1850, 1850-03, 1850-03-12, ABT 1850, BEF 1850, BET 1850 AND 1855) e o sexo é M, F ou U
 // @Tags person
 // @Produce  json
-// @Param request body PostPersonRequest true "Nome da pessoa que deseja-se criar"
+// @Param request body PostPersonRequest true "Dados da pessoa que deseja-se criar"
 // @Success 201 {object} Person
 // @Router /person [post]
 func (server *Server) PostCreatePersonHandler(w http.ResponseWriter, r *http.Request) {
@@ -181,9 +602,7 @@ func (server *Server) PostCreatePersonHandler(w http.ResponseWriter, r *http.Req
 		WriteErrorMessage(w, r, http.StatusBadRequest, err)
 		return
 	}
-	createdPerson := &familytree.Person{
-		Name: request.Name,
-	}
+	createdPerson := request.ToPerson()
 	err = server.PersonUseCase.CreatePerson(r.Context(), createdPerson)
 	if err != nil {
 		WriteErrorValidation(w, r, err)
@@ -192,14 +611,67 @@ func (server *Server) PostCreatePersonHandler(w http.ResponseWriter, r *http.Req
 	WriteJsonBody(w, r, http.StatusCreated, PersonMapper(*createdPerson))
 }
 
+// PatchPersonHandler godoc
+// @Summary Altera somente os campos enviados de uma pessoa
+// @Description Altera somente os campos enviados de uma pessoa, os campos ausentes são mantidos
+// @Description O nome passa pelas mesmas validações da criação
+// @Description Retorna 404 caso não existe
+// @Tags person
+// @Produce  json
+// @Param personID path string true "ID da pessoa no formato uuid (XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX)"
+// @Param request body PatchPersonRequest true "Campos que deseja-se alterar"
+// @Success 200 {object} Person
+// @Router /person/{personID} [patch]
+func (server *Server) PatchPersonHandler(w http.ResponseWriter, r *http.Request) {
+	server.updatePerson(w, r, &PatchPersonRequest{})
+}
+
+// PutPersonHandler godoc
+// @Summary Substitui todos os campos de uma pessoa
+// @Description Substitui todos os campos de uma pessoa, os campos ausentes são considerados vazios
+// @Description O nome passa pelas mesmas validações da criação
+// @Description Retorna 404 caso não existe
+// @Tags person
+// @Produce  json
+// @Param personID path string true "ID da pessoa no formato uuid (XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX)"
+// @Param request body PutPersonRequest true "Dados da pessoa"
+// @Success 200 {object} Person
+// @Router /person/{personID} [put]
+func (server *Server) PutPersonHandler(w http.ResponseWriter, r *http.Request) {
+	server.updatePerson(w, r, &PutPersonRequest{})
+}
+
+func (server *Server) updatePerson(w http.ResponseWriter, r *http.Request, request UpdatePersonRequest) {
+	stringUUID := chi.URLParam(r, "personID")
+	personID, err := uuid.Parse(stringUUID)
+	if err != nil {
+		WriteErrorMessage(w, r, http.StatusBadRequest, ErrNotUUID)
+		return
+	}
+	err = json.NewDecoder(r.Body).Decode(request)
+	if err != nil {
+		WriteErrorMessage(w, r, http.StatusBadRequest, err)
+		return
+	}
+	person, err := server.PersonUseCase.UpdatePerson(r.Context(), personID, request.ToUpdate())
+	if err != nil {
+		WriteErrorValidation(w, r, err)
+		return
+	}
+	WriteJsonBody(w, r, http.StatusOK, PersonMapper(*person))
+}
+
 // PostCreateParentRelationshipHandler godoc
 // @Summary Cria uma relação de parentesco entre pai e filho
 // @Description Cria uma relação de parentesco entre pai e filho
-// @Description Não é permitido criação de relação incestuosa
+// @Description Não é permitido criação de relação incestuosa, somente a linhagem biológica é considerada
+// @Description A filiação (parentage) pode ser BIOLOGICAL, ADOPTIVE, FOSTER, STEP ou GUARDIAN, por padrão BIOLOGICAL
+// @Description Cada pessoa pode ter até dois pais ou mães de cada filiação
+// @Description As regras cronológicas (idade mínima e máxima do pai ou mãe, nascimento após a morte do pai ou mãe) recusam a relação ou retornam avisos conforme a configuração, somente para a filiação biológica
 // @Tags relationship
 // @Produce  json
 // @Param request body PostCreateParentRelationshipRequest true "Relação que deseja-se criar"
-// @Success 201
+// @Success 201 {object} PostCreateRelationshipResponse
 // @Router /person/parent [post]
 func (server *Server) PostCreateParentRelationshipHandler(w http.ResponseWriter, r *http.Request) {
 	request := &PostCreateParentRelationshipRequest{}
@@ -213,17 +685,17 @@ func (server *Server) PostCreateParentRelationshipHandler(w http.ResponseWriter,
 		WriteErrorMessage(w, r, http.StatusBadRequest, err)
 		return
 	}
-	err = server.RelationshipUseCase.CreateParentRelation(r.Context(), request.ParentID, request.ChildID)
+	warnings, err := server.RelationshipUseCase.CreateParentRelation(r.Context(), request.ParentID, request.ChildID, request.Parentage)
 	if err != nil {
 		WriteErrorValidation(w, r, err)
 		return
 	}
-	w.WriteHeader(http.StatusCreated)
+	WriteJsonBody(w, r, http.StatusCreated, CreateRelationshipMapper(warnings))
 }
 
 // DeleteParentRelationshipHandler godoc
 // @Summary Remove uma relação de parentesco entre pai e filho
-// @Description Remove uma relação de parentesco entre pai e filho
+// @Description Remove uma relação de parentesco entre pai e filho, a relação vai para a lixeira
 // @Description Não é permitido a remoção da relação se os pais do filho estiverem em uma relação de esposo e este for o único filho do casal
 // @Tags relationship
 // @Produce  json
@@ -254,10 +726,14 @@ func (server *Server) DeleteParentRelationshipHandler(w http.ResponseWriter, r *
 // @Summary Cria uma relação de esposo entre duas pessoas
 // @Description Cria uma relação de esposo entre duas pessoas
 // @Description Só é possível criar relação entre duas pessoas se elas tiverem um filho
+// @Description A regra cronológica de esposos vivos ao mesmo tempo recusa a relação ou retorna um aviso conforme a configuração
+// @Description A união pode ter data de início, data de fim e motivo do fim (DIVORCE, DEATH, ANNULMENT), sem fim ela é a união atual
+// @Description Uma pessoa pode ter várias uniões terminadas mas somente uma união atual, caso um dos esposos já tenha falecido a união termina por DEATH
+// @Description Os mesmos esposos podem se casar de novo depois de uma união terminada, desde que as uniões não se sobreponham
 // @Tags relationship
 // @Produce  json
 // @Param request body PostCreateSpouseRelationshipRequest true "Relação que deseja-se criar"
-// @Success 201
+// @Success 201 {object} PostCreateRelationshipResponse
 // @Router /person/spouse [post]
 func (server *Server) PostCreateSpouseRelationshipHandler(w http.ResponseWriter, r *http.Request) {
 	request := &PostCreateSpouseRelationshipRequest{}
@@ -271,17 +747,73 @@ func (server *Server) PostCreateSpouseRelationshipHandler(w http.ResponseWriter,
 		WriteErrorMessage(w, r, http.StatusBadRequest, err)
 		return
 	}
-	err = server.RelationshipUseCase.CreateSpouseRelation(r.Context(), request.FirstSpouseID, request.SecondSpouseID)
+	warnings, err := server.RelationshipUseCase.CreateSpouseRelation(r.Context(), request.FirstSpouseID, request.SecondSpouseID, request.ToUnion())
+	if err != nil {
+		WriteErrorValidation(w, r, err)
+		return
+	}
+	WriteJsonBody(w, r, http.StatusCreated, CreateRelationshipMapper(warnings))
+}
+
+// PatchSpouseRelationshipHandler godoc
+// @Summary Altera o período da união entre dois esposos
+// @Description Altera somente os campos enviados da união, como a data e o motivo do fim em um divórcio
+// @Description Não é permitido deixar a união sem fim caso um dos esposos já tenha outra união atual
+// @Description Quando os esposos têm mais de uma união, a união é escolhida pela sua data de início atual em unionStartDate
+// @Description Retorna 404 caso a relação de esposo não exista
+// @Tags relationship
+// @Produce  json
+// @Param request body PatchSpouseRelationshipRequest true "Esposos e campos da união que deseja-se alterar"
+// @Success 200 {object} Union
+// @Router /person/spouse [patch]
+func (server *Server) PatchSpouseRelationshipHandler(w http.ResponseWriter, r *http.Request) {
+	request := &PatchSpouseRelationshipRequest{}
+	err := json.NewDecoder(r.Body).Decode(request)
+	if err != nil {
+		WriteErrorMessage(w, r, http.StatusBadRequest, err)
+		return
+	}
+	err = request.Validate()
+	if err != nil {
+		WriteErrorMessage(w, r, http.StatusBadRequest, err)
+		return
+	}
+	union, err := server.RelationshipUseCase.UpdateSpouseRelation(r.Context(), request.FirstSpouseID, request.SecondSpouseID, request.UnionStartDate, request.ToUpdate())
+	if err != nil {
+		WriteErrorValidation(w, r, err)
+		return
+	}
+	WriteJsonBody(w, r, http.StatusOK, Union(*union))
+}
+
+// GetUnionsHandler godoc
+// @Summary Busca todas as uniões de uma pessoa
+// @Description Busca todas as uniões de uma pessoa, terminadas ou não, ordenadas pela data de início
+// @Description A união atual é indicada pelo campo current
+// @Tags relationship
+// @Produce  json
+// @Param personID path string true "ID da pessoa no formato uuid (XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX)"
+// @Success 200 {object} GetUnionsResponse
+// @Router /person/{personID}/unions [get]
+func (server *Server) GetUnionsHandler(w http.ResponseWriter, r *http.Request) {
+	stringUUID := chi.URLParam(r, "personID")
+	personID, err := uuid.Parse(stringUUID)
+	if err != nil {
+		WriteErrorMessage(w, r, http.StatusBadRequest, ErrNotUUID)
+		return
+	}
+	unions, err := server.RelationshipUseCase.GetUnions(r.Context(), personID)
 	if err != nil {
 		WriteErrorValidation(w, r, err)
 		return
 	}
-	w.WriteHeader(http.StatusCreated)
+	WriteJsonBody(w, r, http.StatusOK, UnionsMapper(unions))
 }
 
 // DeleteSpouseRelationshipHandler godoc
 // @Summary Remove uma relação de esposo entre duas pessoas
-// @Description Remove uma relação de esposo entre duas pessoas
+// @Description Remove uma relação de esposo entre duas pessoas, a relação vai para a lixeira
+// @Description Quando os esposos têm mais de uma união, a união é escolhida pela sua data de início em startDate
 // @Tags relationship
 // @Produce  json
 // @Param request body DeleteSpouseRelationshipRequest true "Relação que deseja-se remover"
@@ -299,10 +831,177 @@ func (server *Server) DeleteSpouseRelationshipHandler(w http.ResponseWriter, r *
 		WriteErrorMessage(w, r, http.StatusBadRequest, err)
 		return
 	}
-	err = server.RelationshipUseCase.DeleteSpouseRelation(r.Context(), request.FirstSpouseID, request.SecondSpouseID)
+	err = server.RelationshipUseCase.DeleteSpouseRelation(r.Context(), request.FirstSpouseID, request.SecondSpouseID, request.StartDate)
+	if err != nil {
+		WriteErrorValidation(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetTrashHandler godoc
+// @Summary Lista a lixeira
+// @Description Lista as pessoas removidas, cada uma com as relações removidas junto com ela, e as relações removidas sozinhas, das mais recentes para as mais antigas
+// @Description As relações removidas junto com uma pessoa que já foi restaurada aparecem como relações removidas sozinhas
+// @Description Tudo o que fica na lixeira por mais tempo que o período de retenção é apagado de vez
+// @Tags trash
+// @Produce  json
+// @Success 200 {object} GetTrashResponse
+// @Router /trash [get]
+func (server *Server) GetTrashHandler(w http.ResponseWriter, r *http.Request) {
+	trash, err := server.PersonUseCase.GetTrash(r.Context())
+	if err != nil {
+		WriteErrorValidation(w, r, err)
+		return
+	}
+	WriteJsonBody(w, r, http.StatusOK, TrashMapper(trash))
+}
+
+// PostRestorePersonHandler godoc
+// @Summary Restaura uma pessoa da lixeira
+// @Description Restaura a pessoa junto com as relações removidas com ela, que passam pelas mesmas validações da criação, primeiro as de PARENT e depois as de SPOUSE
+// @Description As relações com pessoas que ainda estão na lixeira continuam lá
+// @Description Caso alguma relação não possa ser restaurada, como um filho que ficaria com três pais, nada é alterado
+// @Tags trash
+// @Produce  json
+// @Param personID path string true "ID da pessoa no formato uuid (XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX)"
+// @Success 200 {object} TrashedPerson
+// @Router /trash/person/{personID}/restore [post]
+func (server *Server) PostRestorePersonHandler(w http.ResponseWriter, r *http.Request) {
+	stringUUID := chi.URLParam(r, "personID")
+	personID, err := uuid.Parse(stringUUID)
+	if err != nil {
+		WriteErrorMessage(w, r, http.StatusBadRequest, ErrNotUUID)
+		return
+	}
+	restored, err := server.RelationshipUseCase.RestorePerson(r.Context(), personID)
+	if err != nil {
+		WriteErrorValidation(w, r, err)
+		return
+	}
+	WriteJsonBody(w, r, http.StatusOK, TrashedPersonMapper(*restored))
+}
+
+// PostRestoreParentRelationshipHandler godoc
+// @Summary Restaura uma relação de pai ou mãe da lixeira
+// @Description Restaura a última relação de PARENT removida entre as pessoas, com as mesmas validações da criação
+// @Tags trash
+// @Produce  json
+// @Param request body RestoreParentRelationshipRequest true "Relação que deseja-se restaurar"
+// @Success 204
+// @Router /trash/parent/restore [post]
+func (server *Server) PostRestoreParentRelationshipHandler(w http.ResponseWriter, r *http.Request) {
+	request := &RestoreParentRelationshipRequest{}
+	err := json.NewDecoder(r.Body).Decode(request)
+	if err != nil {
+		WriteErrorMessage(w, r, http.StatusBadRequest, err)
+		return
+	}
+	err = request.Validate()
+	if err != nil {
+		WriteErrorMessage(w, r, http.StatusBadRequest, err)
+		return
+	}
+	err = server.RelationshipUseCase.RestoreParentRelation(r.Context(), request.ParentID, request.ChildID)
 	if err != nil {
 		WriteErrorValidation(w, r, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// PostRestoreSpouseRelationshipHandler godoc
+// @Summary Restaura uma relação de esposo da lixeira
+// @Description Restaura a última relação de SPOUSE removida entre as pessoas, com a sua união e as mesmas validações da criação
+// @Tags trash
+// @Produce  json
+// @Param request body RestoreSpouseRelationshipRequest true "Relação que deseja-se restaurar"
+// @Success 204
+// @Router /trash/spouse/restore [post]
+func (server *Server) PostRestoreSpouseRelationshipHandler(w http.ResponseWriter, r *http.Request) {
+	request := &RestoreSpouseRelationshipRequest{}
+	err := json.NewDecoder(r.Body).Decode(request)
+	if err != nil {
+		WriteErrorMessage(w, r, http.StatusBadRequest, err)
+		return
+	}
+	err = request.Validate()
+	if err != nil {
+		WriteErrorMessage(w, r, http.StatusBadRequest, err)
+		return
+	}
+	err = server.RelationshipUseCase.RestoreSpouseRelation(r.Context(), request.FirstSpouseID, request.SecondSpouseID)
+	if err != nil {
+		WriteErrorValidation(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetAuditHandler godoc
+// @Summary Lista o histórico de alterações de uma pessoa
+// @Description Lista os eventos de auditoria das alterações que envolveram a pessoa, dos mais recentes para os mais antigos, incluindo as relações criadas ou removidas com ela
+// @Description Cada evento traz quem pediu a alteração, pelo cabeçalho X-Actor, o ID da requisição e os valores antes e depois dela
+// @Description O X-Actor não é autenticado, é o próprio cliente quem o declara, então o histórico não prova quem fez a alteração
+// @Tags audit
+// @Produce  json
+// @Param personID query string true "ID da pessoa no formato uuid (XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX)"
+// @Param page query int false "Página que se deseja buscar onde a página 0 é a primeira página"
+// @Param size query int false "Tamanho da página"
+// @Success 200 {object} GetAuditResponse
+// @Router /audit [get]
+func (server *Server) GetAuditHandler(w http.ResponseWriter, r *http.Request) {
+	personID, err := uuid.Parse(r.URL.Query().Get(AuditPersonIDParam))
+	if err != nil {
+		WriteErrorMessage(w, r, http.StatusBadRequest, ErrNotUUID)
+		return
+	}
+	page, err := strconv.Atoi(r.URL.Query().Get(PaginationPageParam))
+	if err != nil {
+		page = 0
+	}
+	size, err := strconv.Atoi(r.URL.Query().Get(PaginationSizeParam))
+	if err != nil {
+		size = 0
+	}
+	pagination := familytree.PaginationDetails{Page: page, PageSize: size}
+
+	events, err := server.PersonUseCase.GetAuditEvents(r.Context(), personID, pagination)
+	if err != nil {
+		WriteErrorValidation(w, r, err)
+		return
+	}
+	WriteJsonBody(w, r, http.StatusOK, AuditMapper(pagination, events))
+}
+
+// PostImportGedcomHandler godoc
+// @Summary Importa pessoas e relações de um arquivo GEDCOM 5.5.1
+// @Description Importa os registros INDI como pessoas e os registros FAM como relações de PARENT e SPOUSE
+// @Description Todas as relações passam pelas mesmas validações da criação manual, primeiro as de PARENT e depois as de SPOUSE
+// @Description O relatório indica para cada registro se foi importado (IMPORTED), ignorado (SKIPPED) ou recusado (REJECTED) e o motivo
+// @Description A importação é feita numa única transação, caso algum registro seja recusado nada é importado e o relatório é retornado com 422
+// @Description Somente arquivos em UTF-8 ou ASCII são suportados
+// @Tags gedcom
+// @Accept  plain
+// @Produce  json
+// @Param request body string true "Conteúdo do arquivo GEDCOM"
+// @Success 200 {object} PostGedcomImportResponse
+// @Failure 422 {object} PostGedcomImportResponse
+// @Router /gedcom [post]
+func (server *Server) PostImportGedcomHandler(w http.ResponseWriter, r *http.Request) {
+	report, err := server.Importer.ImportReader(r.Context(), r.Body)
+	if err != nil {
+		var syntaxError *gedcom.SyntaxError
+		if errors.As(err, &syntaxError) {
+			WriteErrorMessage(w, r, http.StatusBadRequest, err)
+			return
+		}
+		WriteErrorValidation(w, r, err)
+		return
+	}
+	if !report.Committed {
+		WriteJsonBody(w, r, http.StatusUnprocessableEntity, GedcomImportMapper(report))
+		return
+	}
+	WriteJsonBody(w, r, http.StatusOK, GedcomImportMapper(report))
+}
@@ -2,8 +2,10 @@ package server
 
 import (
 	"family-tree/internal/core/familytree"
+	"family-tree/internal/gedcom"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -11,23 +13,29 @@ import (
 	swag "github.com/swaggo/http-swagger"
 )
 
-func NewServer(config WebConfig, router *chi.Mux, personUseCase familytree.PersonUseCasePort, relationshipUseCasePort familytree.RelationshipUseCasePort) *Server {
+func NewServer(config WebConfig, router *chi.Mux, personUseCase familytree.PersonUseCasePort, relationshipUseCasePort familytree.RelationshipUseCasePort, importer *gedcom.Importer) *Server {
 	return &Server{
 		PersonUseCase:       personUseCase,
 		RelationshipUseCase: relationshipUseCasePort,
+		Importer:            importer,
 		Router:              router,
 		Config:              config,
+		CursorSigner:        NewCursorSigner(config.CursorSecret),
 	}
 }
 
 type Server struct {
 	PersonUseCase       familytree.PersonUseCasePort
 	RelationshipUseCase familytree.RelationshipUseCasePort
+	Importer            *gedcom.Importer
 	Config              WebConfig
 	Router              *chi.Mux
+	CursorSigner        *CursorSigner
 }
 
 func (server *Server) setupMiddleware() {
+	server.Router.Use(middleware.RequestID)
+	server.Router.Use(AuditSourceMiddleware)
 	server.Router.Use(middleware.Logger)
 	server.Router.Use(middleware.Recoverer)
 	server.Router.Use(middleware.Timeout(time.Duration(server.Config.Timeout) * time.Second))
@@ -36,12 +44,30 @@ func (server *Server) setupMiddleware() {
 func (server *Server) setupRoutes() {
 
 	server.Router.Get("/person", server.GetListPeopleHandler)
+	server.Router.Get("/person/duplicates", server.GetDuplicatesHandler)
 	server.Router.Get("/person/{personID}", server.GetPersonHandler)
 	server.Router.Get("/person/{personID}/bacons/{targetPersonID}", server.GetBaconsNumber)
+	server.Router.Get("/person/{personID}/kinship/{targetPersonID}", server.GetKinshipHandler)
+	server.Router.Get("/person/{personID}/path/{targetPersonID}", server.GetPathsHandler)
+	server.Router.Get("/person/{personID}/relatedness/{targetPersonID}", server.GetRelatednessHandler)
+	server.Router.Get("/person/{personID}/inbreeding", server.GetInbreedingHandler)
 	server.Router.Get("/person/{personID}/tree", server.GetFamilyTree)
+	server.Router.Get("/person/{personID}/descendants", server.GetDescendantsHandler)
+	server.Router.Get("/person/{personID}/pedigree", server.GetPedigreeHandler)
+	server.Router.Get("/person/{personID}/unions", server.GetUnionsHandler)
 	server.Router.Post("/person", server.PostCreatePersonHandler)
 	server.Router.Post("/person/parent", server.PostCreateParentRelationshipHandler)
 	server.Router.Post("/person/spouse", server.PostCreateSpouseRelationshipHandler)
+	server.Router.Post("/person/{personID}/merge", server.PostMergePersonHandler)
+	server.Router.Post("/gedcom", server.PostImportGedcomHandler)
+	server.Router.Get("/trash", server.GetTrashHandler)
+	server.Router.Post("/trash/person/{personID}/restore", server.PostRestorePersonHandler)
+	server.Router.Post("/trash/parent/restore", server.PostRestoreParentRelationshipHandler)
+	server.Router.Post("/trash/spouse/restore", server.PostRestoreSpouseRelationshipHandler)
+	server.Router.Get("/audit", server.GetAuditHandler)
+	server.Router.Patch("/person/spouse", server.PatchSpouseRelationshipHandler)
+	server.Router.Patch("/person/{personID}", server.PatchPersonHandler)
+	server.Router.Put("/person/{personID}", server.PutPersonHandler)
 	server.Router.Delete("/person/{personID}", server.DeletePersonHandler)
 	server.Router.Delete("/person/parent", server.DeleteParentRelationshipHandler)
 	server.Router.Delete("/person/spouse", server.DeleteSpouseRelationshipHandler)
@@ -49,6 +75,24 @@ func (server *Server) setupRoutes() {
 
 }
 
+// AuditSourceMiddleware hands the actor of the X-Actor header and the request
+// ID to the use cases, for their audit events. Requests without the header are
+// from the anonymous actor. The server has no authentication, so the actor is
+// declared by the client itself and never checked.
+func AuditSourceMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor := strings.TrimSpace(r.Header.Get(AuditActorHeader))
+		if actor == "" {
+			actor = AuditAnonymousActor
+		}
+		ctx := familytree.WithAuditSource(r.Context(), familytree.AuditSource{
+			Actor:     actor,
+			RequestID: middleware.GetReqID(r.Context()),
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
 func (server *Server) RouteAndServe() {
 	server.setupMiddleware()
 	server.setupRoutes()
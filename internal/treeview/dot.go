@@ -0,0 +1,92 @@
+package treeview
+
+import (
+	"bufio"
+	"family-tree/internal/core/familytree"
+	"fmt"
+	"io"
+	"strings"
+)
+
+func dotQuote(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	value = strings.ReplaceAll(value, "\n", `\n`)
+	return `"` + value + `"`
+}
+
+// UnionLabel describes the period of the union, like "m. 1850 – 1870
+// (DIVORCE)". Ongoing unions without start date have no label.
+func UnionLabel(union *familytree.Union) string {
+	if union == nil || (union.Ongoing() && union.StartDate.IsZero()) {
+		return ""
+	}
+	start := string(union.StartDate)
+	if start == "" {
+		start = "?"
+	}
+	if union.Ongoing() {
+		return "m. " + start
+	}
+	end := string(union.EndDate)
+	if end == "" {
+		end = "?"
+	}
+	label := fmt.Sprintf("m. %s – %s", start, end)
+	if union.EndReason != "" {
+		label += fmt.Sprintf(" (%s)", union.EndReason)
+	}
+	return label
+}
+
+// ParentageLabel names the parentage of the PARENT edges that aren't
+// biological, like "adoptive". Biological edges have no label.
+func ParentageLabel(parentage familytree.Parentage) string {
+	if parentage.IsBiological() {
+		return ""
+	}
+	return strings.ToLower(string(parentage))
+}
+
+// EncodeDot writes the tree as a Graphviz digraph. Every generation is a
+// rank=same subgraph, PARENT edges point from parent to child, dotted when the
+// parent isn't biological, and SPOUSE edges are undirected and don't
+// constrain the ranks.
+func EncodeDot(output io.Writer, tree *familytree.FamilyTree) error {
+	layout := NewLayout(tree)
+	writer := bufio.NewWriter(output)
+
+	fmt.Fprintln(writer, "digraph familyTree {")
+	fmt.Fprintln(writer, "\trankdir=TB;")
+	fmt.Fprintln(writer, "\tnode [shape=box, style=rounded];")
+	for generation, rank := range layout.Ranks {
+		if len(rank) == 0 {
+			continue
+		}
+		fmt.Fprintf(writer, "\tsubgraph generation_%d {\n", generation)
+		fmt.Fprintln(writer, "\t\trank=same;")
+		for _, node := range rank {
+			fmt.Fprintf(writer, "\t\t%s [label=%s];\n", dotQuote(node.Person.ID.String()), dotQuote(node.Person.Name))
+		}
+		fmt.Fprintln(writer, "\t}")
+	}
+	for _, edge := range layout.Edges {
+		from, to := dotQuote(edge.From.String()), dotQuote(edge.To.String())
+		switch edge.RelationType {
+		case familytree.RelationTypeParent:
+			if parentageLabel := ParentageLabel(edge.Parentage); parentageLabel != "" {
+				fmt.Fprintf(writer, "\t%s -> %s [style=dotted, label=%s];\n", from, to, dotQuote(parentageLabel))
+				continue
+			}
+			fmt.Fprintf(writer, "\t%s -> %s;\n", from, to)
+		case familytree.RelationTypeSpouse:
+			label := ""
+			if unionLabel := UnionLabel(edge.Union); unionLabel != "" {
+				label = ", label=" + dotQuote(unionLabel)
+			}
+			fmt.Fprintf(writer, "\t%s -> %s [dir=none, style=dashed, constraint=false%s];\n", from, to, label)
+		}
+	}
+	fmt.Fprintln(writer, "}")
+	return writer.Flush()
+}
@@ -0,0 +1,395 @@
+package treeview
+
+import (
+	"family-tree/internal/core/familytree"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+const (
+	NodeHeight      = 40.0
+	NodeMinWidth    = 80.0
+	NodePadding     = 12.0
+	CharWidth       = 7.0
+	NodeGap         = 30.0
+	SpouseGap       = 24.0
+	RankGap         = 70.0
+	Margin          = 20.0
+	orderingSweeps  = 8
+	placementSweeps = 6
+)
+
+type LayoutNode struct {
+	Person     familytree.Person
+	Generation int
+	Order      int
+	X          float64
+	Y          float64
+	Width      float64
+	Height     float64
+}
+
+func (node *LayoutNode) CenterX() float64 {
+	return node.X + node.Width/2
+}
+
+// LayoutEdge Union is only set on SPOUSE edges and Parentage on PARENT edges.
+type LayoutEdge struct {
+	From         uuid.UUID
+	To           uuid.UUID
+	RelationType familytree.RelationType
+	Union        *familytree.Union
+	Parentage    familytree.Parentage
+}
+
+// Layout places the people of a tree on ranks, one for each generation, with
+// parents above their children and spouses side by side on the same rank.
+// It's a small Sugiyama style layout: rank assignment, barycenter ordering
+// and a greedy coordinate placement, enough for family trees.
+type Layout struct {
+	Nodes  map[uuid.UUID]*LayoutNode
+	Ranks  [][]*LayoutNode
+	Edges  []LayoutEdge
+	Width  float64
+	Height float64
+
+	parents  map[uuid.UUID][]uuid.UUID
+	children map[uuid.UUID][]uuid.UUID
+	spouses  map[uuid.UUID][]uuid.UUID
+	// parentages is keyed by the parent and child ids
+	parentages map[[2]uuid.UUID]familytree.Parentage
+}
+
+// block is a group of people of the same rank that are kept together, the
+// spouses of a couple and anyone else married to them.
+type block struct {
+	members []*LayoutNode
+}
+
+func (b *block) width() float64 {
+	width := 0.0
+	for i, member := range b.members {
+		if i > 0 {
+			width += SpouseGap
+		}
+		width += member.Width
+	}
+	return width
+}
+
+func nodeWidth(name string) float64 {
+	width := float64(utf8.RuneCountInString(name))*CharWidth + 2*NodePadding
+	if width < NodeMinWidth {
+		return NodeMinWidth
+	}
+	return width
+}
+
+func NewLayout(tree *familytree.FamilyTree) *Layout {
+	layout := &Layout{
+		Nodes:      map[uuid.UUID]*LayoutNode{},
+		parents:    map[uuid.UUID][]uuid.UUID{},
+		children:   map[uuid.UUID][]uuid.UUID{},
+		spouses:    map[uuid.UUID][]uuid.UUID{},
+		parentages: map[[2]uuid.UUID]familytree.Parentage{},
+	}
+	people := make([]familytree.Person, 0, len(tree.People))
+	for _, node := range tree.People {
+		if _, ok := layout.Nodes[node.Person.ID]; ok {
+			continue
+		}
+		people = append(people, node.Person)
+		layout.Nodes[node.Person.ID] = &LayoutNode{
+			Person: node.Person,
+			Width:  nodeWidth(node.Person.Name),
+			Height: NodeHeight,
+		}
+	}
+	// The repositories don't promise any order, sort so the drawing is stable
+	sort.SliceStable(people, func(i, j int) bool {
+		if people[i].Name != people[j].Name {
+			return people[i].Name < people[j].Name
+		}
+		return people[i].ID.String() < people[j].ID.String()
+	})
+	for _, node := range tree.People {
+		for _, relation := range node.Relations {
+			if _, ok := layout.Nodes[relation.PersonID]; !ok {
+				continue
+			}
+			layout.Edges = append(layout.Edges, LayoutEdge{From: node.Person.ID, To: relation.PersonID, RelationType: relation.RelationType, Union: relation.Union, Parentage: relation.Parentage})
+			switch relation.RelationType {
+			case familytree.RelationTypeParent:
+				layout.parentages[[2]uuid.UUID{node.Person.ID, relation.PersonID}] = relation.Parentage
+				layout.parents[relation.PersonID] = append(layout.parents[relation.PersonID], node.Person.ID)
+				layout.children[node.Person.ID] = append(layout.children[node.Person.ID], relation.PersonID)
+			case familytree.RelationTypeSpouse:
+				layout.spouses[node.Person.ID] = append(layout.spouses[node.Person.ID], relation.PersonID)
+				layout.spouses[relation.PersonID] = append(layout.spouses[relation.PersonID], node.Person.ID)
+			}
+		}
+	}
+	sort.SliceStable(layout.Edges, func(i, j int) bool {
+		if layout.Edges[i].From != layout.Edges[j].From {
+			return layout.Edges[i].From.String() < layout.Edges[j].From.String()
+		}
+		return layout.Edges[i].To.String() < layout.Edges[j].To.String()
+	})
+
+	layout.assignGenerations(people)
+	blocks := layout.orderRanks(people)
+	layout.placeNodes(blocks)
+	return layout
+}
+
+// assignGenerations puts every child at least one rank below its parents and
+// spouses on the same rank. People without parents are then pulled down so
+// they sit right above their children instead of on the top rank.
+func (layout *Layout) assignGenerations(people []familytree.Person) {
+	settle := func() {
+		for i := 0; i <= 2*len(people); i++ {
+			changed := false
+			for childID, parentIDs := range layout.parents {
+				for _, parentID := range parentIDs {
+					if layout.Nodes[childID].Generation < layout.Nodes[parentID].Generation+1 {
+						layout.Nodes[childID].Generation = layout.Nodes[parentID].Generation + 1
+						changed = true
+					}
+				}
+			}
+			for personID, spouseIDs := range layout.spouses {
+				for _, spouseID := range spouseIDs {
+					if layout.Nodes[personID].Generation < layout.Nodes[spouseID].Generation {
+						layout.Nodes[personID].Generation = layout.Nodes[spouseID].Generation
+						changed = true
+					}
+				}
+			}
+			if !changed {
+				return
+			}
+		}
+	}
+	settle()
+	for _, person := range people {
+		node := layout.Nodes[person.ID]
+		if len(layout.parents[person.ID]) > 0 || len(layout.children[person.ID]) == 0 {
+			continue
+		}
+		target := -1
+		for _, childID := range layout.children[person.ID] {
+			if generation := layout.Nodes[childID].Generation - 1; target < 0 || generation < target {
+				target = generation
+			}
+		}
+		if target > node.Generation {
+			node.Generation = target
+		}
+	}
+	settle()
+
+	maxGeneration := 0
+	for _, node := range layout.Nodes {
+		if node.Generation > maxGeneration {
+			maxGeneration = node.Generation
+		}
+	}
+	layout.Ranks = make([][]*LayoutNode, maxGeneration+1)
+}
+
+// orderRanks builds the spouse blocks of every rank and sorts them with a few
+// barycenter sweeps, so parents and children stay close to each other.
+func (layout *Layout) orderRanks(people []familytree.Person) [][]*block {
+	blocks := make([][]*block, len(layout.Ranks))
+	visited := map[uuid.UUID]bool{}
+	for _, person := range people {
+		if visited[person.ID] {
+			continue
+		}
+		node := layout.Nodes[person.ID]
+		newBlock := &block{}
+		queue := []uuid.UUID{person.ID}
+		visited[person.ID] = true
+		for len(queue) > 0 {
+			current := layout.Nodes[queue[0]]
+			queue = queue[1:]
+			newBlock.members = append(newBlock.members, current)
+			for _, spouseID := range layout.spouses[current.Person.ID] {
+				if visited[spouseID] || layout.Nodes[spouseID].Generation != node.Generation {
+					continue
+				}
+				visited[spouseID] = true
+				queue = append(queue, spouseID)
+			}
+		}
+		blocks[node.Generation] = append(blocks[node.Generation], newBlock)
+	}
+	layout.updateOrder(blocks)
+
+	barycenter := func(b *block, neighbors map[uuid.UUID][]uuid.UUID, generation int) (float64, bool) {
+		sum, count := 0.0, 0
+		for _, member := range b.members {
+			for _, neighborID := range neighbors[member.Person.ID] {
+				neighbor := layout.Nodes[neighborID]
+				if neighbor.Generation != generation {
+					continue
+				}
+				sum += float64(neighbor.Order)
+				count++
+			}
+		}
+		if count == 0 {
+			return 0, false
+		}
+		return sum / float64(count), true
+	}
+	sortRank := func(rank int, neighbors map[uuid.UUID][]uuid.UUID, generation int) {
+		positions := map[*block]float64{}
+		for i, b := range blocks[rank] {
+			position, ok := barycenter(b, neighbors, generation)
+			if !ok {
+				// Keep blocks without neighbors where they are
+				position = float64(i)
+			}
+			positions[b] = position
+		}
+		sort.SliceStable(blocks[rank], func(i, j int) bool {
+			return positions[blocks[rank][i]] < positions[blocks[rank][j]]
+		})
+		layout.updateOrder(blocks)
+	}
+	for sweep := 0; sweep < orderingSweeps; sweep++ {
+		if sweep%2 == 0 {
+			for rank := 1; rank < len(blocks); rank++ {
+				sortRank(rank, layout.parents, rank-1)
+			}
+			continue
+		}
+		for rank := len(blocks) - 2; rank >= 0; rank-- {
+			sortRank(rank, layout.children, rank+1)
+		}
+	}
+	return blocks
+}
+
+func (layout *Layout) updateOrder(blocks [][]*block) {
+	for rank, rankBlocks := range blocks {
+		layout.Ranks[rank] = layout.Ranks[rank][:0]
+		for _, b := range rankBlocks {
+			for _, member := range b.members {
+				member.Order = len(layout.Ranks[rank])
+				layout.Ranks[rank] = append(layout.Ranks[rank], member)
+			}
+		}
+	}
+}
+
+func (layout *Layout) setBlockX(b *block, x float64) {
+	for _, member := range b.members {
+		member.X = x
+		x += member.Width + SpouseGap
+	}
+}
+
+// desiredX returns where the block would like to start so that it's centered
+// below its parents, or above its children, on the given rank.
+func (layout *Layout) desiredX(b *block, neighbors map[uuid.UUID][]uuid.UUID, generation int) (float64, bool) {
+	sum, count := 0.0, 0
+	for _, member := range b.members {
+		for _, neighborID := range neighbors[member.Person.ID] {
+			neighbor := layout.Nodes[neighborID]
+			if neighbor.Generation != generation {
+				continue
+			}
+			sum += neighbor.CenterX()
+			count++
+		}
+	}
+	if count == 0 {
+		return b.members[0].X, false
+	}
+	return sum/float64(count) - b.width()/2, true
+}
+
+// placeRank moves the blocks as close as possible to their desired positions
+// without overlapping, averaging a left to right and a right to left pass.
+func (layout *Layout) placeRank(rankBlocks []*block, desired []float64) {
+	count := len(rankBlocks)
+	if count == 0 {
+		return
+	}
+	forward := make([]float64, count)
+	backward := make([]float64, count)
+	for i := range rankBlocks {
+		forward[i] = desired[i]
+		if i > 0 && forward[i] < forward[i-1]+rankBlocks[i-1].width()+NodeGap {
+			forward[i] = forward[i-1] + rankBlocks[i-1].width() + NodeGap
+		}
+	}
+	for i := count - 1; i >= 0; i-- {
+		backward[i] = desired[i]
+		if i < count-1 && backward[i] > backward[i+1]-rankBlocks[i].width()-NodeGap {
+			backward[i] = backward[i+1] - rankBlocks[i].width() - NodeGap
+		}
+	}
+	previousEnd := 0.0
+	for i, b := range rankBlocks {
+		x := (forward[i] + backward[i]) / 2
+		if i > 0 && x < previousEnd+NodeGap {
+			x = previousEnd + NodeGap
+		}
+		layout.setBlockX(b, x)
+		previousEnd = x + b.width()
+	}
+}
+
+func (layout *Layout) placeNodes(blocks [][]*block) {
+	for _, rankBlocks := range blocks {
+		x := 0.0
+		for _, b := range rankBlocks {
+			layout.setBlockX(b, x)
+			x += b.width() + NodeGap
+		}
+	}
+	placeSweep := func(rank int, neighbors map[uuid.UUID][]uuid.UUID, generation int) {
+		desired := make([]float64, len(blocks[rank]))
+		for i, b := range blocks[rank] {
+			desired[i], _ = layout.desiredX(b, neighbors, generation)
+		}
+		layout.placeRank(blocks[rank], desired)
+	}
+	for sweep := 0; sweep < placementSweeps; sweep++ {
+		if sweep%2 == 0 {
+			for rank := 1; rank < len(blocks); rank++ {
+				placeSweep(rank, layout.parents, rank-1)
+			}
+			continue
+		}
+		for rank := len(blocks) - 2; rank >= 0; rank-- {
+			placeSweep(rank, layout.children, rank+1)
+		}
+	}
+
+	minX, maxX := 0.0, 0.0
+	first := true
+	for _, node := range layout.Nodes {
+		if first || node.X < minX {
+			minX = node.X
+		}
+		if first || node.X+node.Width > maxX {
+			maxX = node.X + node.Width
+		}
+		first = false
+	}
+	for _, node := range layout.Nodes {
+		node.X += Margin - minX
+		node.Y = Margin + float64(node.Generation)*(NodeHeight+RankGap)
+	}
+	layout.Width = maxX - minX + 2*Margin
+	layout.Height = float64(len(layout.Ranks))*(NodeHeight+RankGap) - RankGap + 2*Margin
+	if len(layout.Nodes) == 0 {
+		layout.Width, layout.Height = 2*Margin, 2*Margin
+	}
+}
@@ -0,0 +1,119 @@
+package treeview
+
+import (
+	"family-tree/internal/core/familytree"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// testTree is John and Mary with their son Son and his wife Ann, who have the
+// grandchild Grandchild and the adopted Adopted.
+func testTree() (*familytree.FamilyTree, map[string]uuid.UUID) {
+	ids := map[string]uuid.UUID{}
+	person := func(name string) familytree.Person {
+		ids[name] = uuid.New()
+		return familytree.Person{ID: ids[name], Name: name}
+	}
+	john, mary, son, ann := person("John"), person("Mary"), person("Son"), person("Ann <Smith> & Co")
+	grandchild, adopted := person("Grandchild"), person("Adopted")
+	married := &familytree.Union{StartDate: "1875"}
+	divorced := &familytree.Union{StartDate: "1900", EndDate: "1910", EndReason: familytree.UnionEndDivorce}
+	parent := func(child familytree.Person, parentage familytree.Parentage) familytree.FamilyTreeRelation {
+		return familytree.FamilyTreeRelation{PersonID: child.ID, RelationType: familytree.RelationTypeParent, Parentage: parentage}
+	}
+	spouse := func(other familytree.Person, union *familytree.Union) familytree.FamilyTreeRelation {
+		return familytree.FamilyTreeRelation{PersonID: other.ID, RelationType: familytree.RelationTypeSpouse, Union: union}
+	}
+	tree := &familytree.FamilyTree{People: []familytree.FamilyTreeNode{
+		{Person: grandchild},
+		{Person: john, Relations: []familytree.FamilyTreeRelation{spouse(mary, married), parent(son, familytree.ParentageBiological)}},
+		{Person: mary, Relations: []familytree.FamilyTreeRelation{parent(son, familytree.ParentageBiological)}},
+		{Person: son, Relations: []familytree.FamilyTreeRelation{spouse(ann, divorced), parent(grandchild, familytree.ParentageBiological), parent(adopted, familytree.ParentageAdoptive)}},
+		{Person: ann, Relations: []familytree.FamilyTreeRelation{parent(grandchild, familytree.ParentageBiological), parent(adopted, familytree.ParentageAdoptive)}},
+		{Person: adopted},
+	}}
+	return tree, ids
+}
+
+func TestNodeWidth(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected float64
+	}{
+		{name: "", expected: NodeMinWidth},
+		{name: "Ann", expected: NodeMinWidth},
+		{name: "Maria da Conceição Silva", expected: 24*CharWidth + 2*NodePadding},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if width := nodeWidth(test.name); width != test.expected {
+				t.Errorf("nodeWidth returned %.1f, expected %.1f", width, test.expected)
+			}
+		})
+	}
+}
+
+func TestNewLayout(t *testing.T) {
+	tree, ids := testTree()
+	layout := NewLayout(tree)
+
+	tests := []struct {
+		name       string
+		generation int
+	}{
+		{name: "John", generation: 0},
+		{name: "Mary", generation: 0},
+		{name: "Son", generation: 1},
+		{name: "Ann <Smith> & Co", generation: 1},
+		{name: "Grandchild", generation: 2},
+		{name: "Adopted", generation: 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node := layout.Nodes[ids[test.name]]
+			if node == nil {
+				t.Fatalf("NewLayout didn't place %s", test.name)
+			}
+			if node.Generation != test.generation {
+				t.Errorf("NewLayout placed %s on generation %d, expected %d", test.name, node.Generation, test.generation)
+			}
+			if node.Y != Margin+float64(test.generation)*(NodeHeight+RankGap) || node.Height != NodeHeight {
+				t.Errorf("NewLayout placed %s at y %.1f with height %.1f", test.name, node.Y, node.Height)
+			}
+			if node.X < Margin || node.X+node.Width > layout.Width-Margin || node.Y+node.Height > layout.Height-Margin {
+				t.Errorf("NewLayout placed %s outside of the %.1fx%.1f drawing", test.name, layout.Width, layout.Height)
+			}
+		})
+	}
+
+	if len(layout.Ranks) != 3 || len(layout.Edges) != 8 {
+		t.Errorf("NewLayout returned %d ranks and %d edges, expected 3 and 8", len(layout.Ranks), len(layout.Edges))
+	}
+	if layout.Height != 3*(NodeHeight+RankGap)-RankGap+2*Margin {
+		t.Errorf("NewLayout returned height %.1f for 3 ranks", layout.Height)
+	}
+	for generation, rank := range layout.Ranks {
+		for order, node := range rank {
+			if node.Generation != generation || node.Order != order {
+				t.Errorf("%s is on generation %d order %d of the ranks, but has generation %d order %d", node.Person.Name, generation, order, node.Generation, node.Order)
+			}
+			if order > 0 && node.X < rank[order-1].X+rank[order-1].Width+SpouseGap {
+				t.Errorf("%s overlaps %s on generation %d", node.Person.Name, rank[order-1].Person.Name, generation)
+			}
+		}
+	}
+	for _, couple := range [][2]string{{"John", "Mary"}, {"Son", "Ann <Smith> & Co"}} {
+		first, second := layout.Nodes[ids[couple[0]]], layout.Nodes[ids[couple[1]]]
+		if abs(first.Order-second.Order) != 1 {
+			t.Errorf("the spouses %s and %s aren't side by side", couple[0], couple[1])
+		}
+	}
+}
+
+func TestNewLayoutEmpty(t *testing.T) {
+	layout := NewLayout(&familytree.FamilyTree{})
+	if len(layout.Nodes) != 0 || layout.Width != 2*Margin || layout.Height != 2*Margin {
+		t.Errorf("NewLayout of an empty tree returned %d nodes with %.1fx%.1f", len(layout.Nodes), layout.Width, layout.Height)
+	}
+}
@@ -0,0 +1,143 @@
+package treeview
+
+import (
+	"bufio"
+	"encoding/xml"
+	"family-tree/internal/core/familytree"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const svgStyle = `rect.person{fill:#fff;stroke:#333;stroke-width:1.5}` +
+	`text{font-family:sans-serif;font-size:12px;fill:#111}` +
+	`path.parent{fill:none;stroke:#555;stroke-width:1.2}` +
+	`path.parent.nonbiological{stroke-dasharray:2 3}` +
+	`path.spouse{fill:none;stroke:#a33;stroke-width:1.5;stroke-dasharray:4 3}`
+
+func svgEscape(value string) string {
+	var escaped strings.Builder
+	xml.EscapeText(&escaped, []byte(value))
+	return escaped.String()
+}
+
+// coupleAnchor returns the middle of the SPOUSE line of the child's parents of
+// the given parentage when they are married and drawn side by side, so the
+// PARENT lines of the couple start from the same point.
+func (layout *Layout) coupleAnchor(childID uuid.UUID, parentage familytree.Parentage) (float64, float64, bool) {
+	parents := []uuid.UUID{}
+	for _, parentID := range layout.parents[childID] {
+		if layout.parentages[[2]uuid.UUID{parentID, childID}] == parentage {
+			parents = append(parents, parentID)
+		}
+	}
+	if len(parents) != 2 {
+		return 0, 0, false
+	}
+	first, second := layout.Nodes[parents[0]], layout.Nodes[parents[1]]
+	if first.Generation != second.Generation || abs(first.Order-second.Order) != 1 {
+		return 0, 0, false
+	}
+	married := false
+	for _, spouseID := range layout.spouses[first.Person.ID] {
+		married = married || spouseID == second.Person.ID
+	}
+	if !married {
+		return 0, 0, false
+	}
+	left, right := first, second
+	if right.X < left.X {
+		left, right = right, left
+	}
+	return (left.X + left.Width + right.X) / 2, left.Y + left.Height/2, true
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
+
+// parentAnchor identifies the lines drawn from a couple anchor, a child may
+// have a couple of each parentage.
+type parentAnchor struct {
+	child     uuid.UUID
+	parentage familytree.Parentage
+}
+
+func (layout *Layout) writeParentEdge(writer *bufio.Writer, edge LayoutEdge, drawnAnchors map[parentAnchor]bool) {
+	parent, child := layout.Nodes[edge.From], layout.Nodes[edge.To]
+	endX, endY := child.CenterX(), child.Y
+	startX, startY := parent.CenterX(), parent.Y+parent.Height
+	if anchorX, anchorY, ok := layout.coupleAnchor(edge.To, edge.Parentage); ok {
+		anchor := parentAnchor{child: edge.To, parentage: edge.Parentage}
+		if drawnAnchors[anchor] {
+			return
+		}
+		drawnAnchors[anchor] = true
+		startX, startY = anchorX, anchorY
+	}
+	middleY := endY - RankGap/2
+	if middleY < startY {
+		middleY = (startY + endY) / 2
+	}
+	class, closing := "parent", "/>"
+	if label := ParentageLabel(edge.Parentage); label != "" {
+		class, closing = "parent nonbiological", fmt.Sprintf("><title>%s</title></path>", svgEscape(label))
+	}
+	fmt.Fprintf(writer, "<path class=\"%s\" marker-end=\"url(#arrow)\" d=\"M%.1f %.1f V%.1f H%.1f V%.1f\"%s\n", class, startX, startY, middleY, endX, endY, closing)
+}
+
+func (layout *Layout) writeSpouseEdge(writer *bufio.Writer, edge LayoutEdge) {
+	left, right := layout.Nodes[edge.From], layout.Nodes[edge.To]
+	if right.X < left.X {
+		left, right = right, left
+	}
+	y := left.Y + left.Height/2
+	// The union period is shown as the tooltip of the line
+	closing := "/>"
+	if label := UnionLabel(edge.Union); label != "" {
+		closing = fmt.Sprintf("><title>%s</title></path>", svgEscape(label))
+	}
+	if left.Generation == right.Generation && abs(left.Order-right.Order) == 1 {
+		fmt.Fprintf(writer, "<path class=\"spouse\" d=\"M%.1f %.1f H%.1f\"%s\n", left.X+left.Width, y, right.X, closing)
+		return
+	}
+	// Not side by side, go around the people between them
+	startX, endX := left.CenterX(), right.CenterX()
+	fmt.Fprintf(writer, "<path class=\"spouse\" d=\"M%.1f %.1f Q%.1f %.1f %.1f %.1f\"%s\n", startX, left.Y, (startX+endX)/2, left.Y-RankGap/2, endX, right.Y, closing)
+}
+
+// EncodeSVG draws the tree with the layered layout, without depending on the
+// Graphviz binaries.
+func EncodeSVG(output io.Writer, tree *familytree.FamilyTree) error {
+	layout := NewLayout(tree)
+	writer := bufio.NewWriter(output)
+
+	fmt.Fprintf(writer, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%.0f\" height=\"%.0f\" viewBox=\"0 0 %.0f %.0f\">\n", layout.Width, layout.Height, layout.Width, layout.Height)
+	fmt.Fprintf(writer, "<style>%s</style>\n", svgStyle)
+	fmt.Fprintln(writer, `<defs><marker id="arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="6" markerHeight="6" orient="auto-start-reverse"><path d="M0 0 L10 5 L0 10 z" fill="#555"/></marker></defs>`)
+
+	drawnAnchors := map[parentAnchor]bool{}
+	for _, edge := range layout.Edges {
+		switch edge.RelationType {
+		case familytree.RelationTypeParent:
+			layout.writeParentEdge(writer, edge, drawnAnchors)
+		case familytree.RelationTypeSpouse:
+			layout.writeSpouseEdge(writer, edge)
+		}
+	}
+	for _, rank := range layout.Ranks {
+		for _, node := range rank {
+			fmt.Fprintf(writer, "<g id=\"person-%s\">", node.Person.ID)
+			fmt.Fprintf(writer, "<rect class=\"person\" x=\"%.1f\" y=\"%.1f\" width=\"%.1f\" height=\"%.1f\" rx=\"6\"/>", node.X, node.Y, node.Width, node.Height)
+			fmt.Fprintf(writer, "<text x=\"%.1f\" y=\"%.1f\" text-anchor=\"middle\" dominant-baseline=\"central\">%s</text>", node.CenterX(), node.Y+node.Height/2, svgEscape(node.Person.Name))
+			fmt.Fprintln(writer, "</g>")
+		}
+	}
+	fmt.Fprintln(writer, "</svg>")
+	return writer.Flush()
+}
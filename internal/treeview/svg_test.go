@@ -0,0 +1,124 @@
+package treeview
+
+import (
+	"bytes"
+	"encoding/xml"
+	"family-tree/internal/core/familytree"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestUnionLabel(t *testing.T) {
+	tests := []struct {
+		name     string
+		union    *familytree.Union
+		expected string
+	}{
+		{name: "no union", union: nil, expected: ""},
+		{name: "ongoing without start", union: &familytree.Union{}, expected: ""},
+		{name: "ongoing", union: &familytree.Union{StartDate: "1875"}, expected: "m. 1875"},
+		{name: "ended", union: &familytree.Union{StartDate: "1875", EndDate: "1890-05", EndReason: familytree.UnionEndDivorce}, expected: "m. 1875 – 1890-05 (DIVORCE)"},
+		{name: "ended on unknown dates", union: &familytree.Union{EndReason: familytree.UnionEndAnnulment}, expected: "m. ? – ? (ANNULMENT)"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if label := UnionLabel(test.union); label != test.expected {
+				t.Errorf("UnionLabel returned %q, expected %q", label, test.expected)
+			}
+		})
+	}
+}
+
+func TestParentageLabel(t *testing.T) {
+	tests := []struct {
+		parentage familytree.Parentage
+		expected  string
+	}{
+		{parentage: familytree.ParentageBiological, expected: ""},
+		{parentage: familytree.ParentageAdoptive, expected: "adoptive"},
+		{parentage: familytree.ParentageGuardian, expected: "guardian"},
+	}
+	for _, test := range tests {
+		t.Run(string(test.parentage), func(t *testing.T) {
+			if label := ParentageLabel(test.parentage); label != test.expected {
+				t.Errorf("ParentageLabel returned %q, expected %q", label, test.expected)
+			}
+		})
+	}
+}
+
+func TestEncodeSVG(t *testing.T) {
+	tree, ids := testTree()
+	var output bytes.Buffer
+	if err := EncodeSVG(&output, tree); err != nil {
+		t.Fatalf("EncodeSVG returned error: %v", err)
+	}
+	svg := output.String()
+	decoder := xml.NewDecoder(strings.NewReader(svg))
+	for {
+		if _, err := decoder.Token(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("EncodeSVG wrote invalid XML: %v\n%s", err, svg)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{name: "root element", expected: `<svg xmlns="http://www.w3.org/2000/svg"`},
+		{name: "escaped name", expected: ">Ann &lt;Smith&gt; &amp; Co</text>"},
+		{name: "person group", expected: `<g id="person-` + ids["Grandchild"].String() + `">`},
+		{name: "union tooltip", expected: "<title>m. 1900 – 1910 (DIVORCE)</title>"},
+		{name: "non biological parent", expected: `<path class="parent nonbiological"`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if !strings.Contains(svg, test.expected) {
+				t.Errorf("EncodeSVG didn't write %q:\n%s", test.expected, svg)
+			}
+		})
+	}
+	if count := strings.Count(svg, `<rect class="person"`); count != len(ids) {
+		t.Errorf("EncodeSVG drew %d people, expected %d", count, len(ids))
+	}
+	// The couples are side by side, each child has one line from the biological
+	// couple, and the adopted child one from the adoptive couple
+	if count := strings.Count(svg, `<path class="parent`); count != 3 {
+		t.Errorf("EncodeSVG drew %d parent lines, expected 3", count)
+	}
+}
+
+func TestEncodeDot(t *testing.T) {
+	tree, ids := testTree()
+	var output bytes.Buffer
+	if err := EncodeDot(&output, tree); err != nil {
+		t.Fatalf("EncodeDot returned error: %v", err)
+	}
+	dot := output.String()
+	john, mary, son, adopted := ids["John"].String(), ids["Mary"].String(), ids["Son"].String(), ids["Adopted"].String()
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{name: "digraph", expected: "digraph familyTree {\n"},
+		{name: "generation", expected: "\tsubgraph generation_2 {\n\t\trank=same;\n"},
+		{name: "quoted name", expected: `"` + ids["Ann <Smith> & Co"].String() + `" [label="Ann <Smith> & Co"];`},
+		{name: "parent edge", expected: `"` + mary + `" -> "` + son + `";`},
+		{name: "non biological parent edge", expected: `"` + son + `" -> "` + adopted + `" [style=dotted, label="adoptive"];`},
+		{name: "spouse edge", expected: `"` + john + `" -> "` + mary + `" [dir=none, style=dashed, constraint=false, label="m. 1875"];`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if !strings.Contains(dot, test.expected) {
+				t.Errorf("EncodeDot didn't write %q:\n%s", test.expected, dot)
+			}
+		})
+	}
+	if quoted := dotQuote("say \"hi\"\\\nbye"); quoted != `"say \"hi\"\\\nbye"` {
+		t.Errorf("dotQuote returned %s", quoted)
+	}
+}